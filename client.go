@@ -1,34 +0,0 @@
-package main
-
-import (
-	"os"
-	"path/filepath"
-
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/homedir"
-)
-
-// NewClientFromKubeconfig creates a new Kubernetes clientset using the current-context from the default kubeconfig.
-func NewClientFromKubeconfig() (*kubernetes.Clientset, error) {
-	var kubeconfig string
-	if kubeconfigEnv := os.Getenv("KUBECONFIG"); kubeconfigEnv != "" {
-		kubeconfig = kubeconfigEnv
-	} else if home := homedir.HomeDir(); home != "" {
-		kubeconfig = filepath.Join(home, ".kube", "config")
-	}
-
-	// Load Kubernetes configuration from the kubeconfig file, using the current context.
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create the Kubernetes clientset.
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, err
-	}
-
-	return clientset, nil
-}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+)
+
+// VersionsResponse is the version inventory served at /api/v1/versions.
+type VersionsResponse struct {
+	Cluster           *inspect.ClusterIdentity `json:"cluster"`
+	GeneratedAt       time.Time                `json:"generatedAt"`
+	KubernetesVersion string                   `json:"kubernetesVersion"`
+	Platform          *inspect.PlatformInfo    `json:"platform,omitempty"`
+	Release           *inspect.ReleaseInfo     `json:"release,omitempty"`
+	EtcdVersion       string                   `json:"etcdVersion,omitempty"`
+	NodeVersions      string                   `json:"nodeVersions,omitempty"`
+}
+
+// listResponse wraps the lists the API serves with the cluster and time of the scan they came from.
+type listResponse[T any] struct {
+	Cluster     *inspect.ClusterIdentity `json:"cluster"`
+	GeneratedAt time.Time                `json:"generatedAt"`
+	Items       []T                      `json:"items"`
+}
+
+// registerAPI adds the JSON API:
+//
+//	GET  /api/v1/report                 the latest report
+//	GET  /api/v1/findings?severity=high  findings, optionally filtered by minimum severity and ?check=
+//	GET  /api/v1/endpoints?exposure=public  exposed endpoints, optionally filtered by exposure
+//	GET  /api/v1/versions               Kubernetes, platform, etcd, and node versions
+//	POST /api/v1/scan                   run a scan now and return its report, with the API token
+//
+// The GET endpoints return 503 until the first scan has finished.
+func (s *Server) registerAPI(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/report", s.withReport(func(w http.ResponseWriter, r *http.Request, report *inspect.Report) {
+		writeJSON(w, http.StatusOK, report)
+	}))
+
+	mux.HandleFunc("GET /api/v1/findings", s.withReport(func(w http.ResponseWriter, r *http.Request, report *inspect.Report) {
+		var min inspect.Severity
+		if name := r.URL.Query().Get("severity"); name != "" {
+			var err error
+			if min, err = inspect.ParseSeverity(name); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		check := r.URL.Query().Get("check")
+		items := []inspect.Finding{}
+		for _, f := range report.Findings {
+			if f.Severity.AtLeast(min) && (check == "" || f.CheckID == check) {
+				items = append(items, f)
+			}
+		}
+		writeJSON(w, http.StatusOK, listResponse[inspect.Finding]{report.Cluster, report.GeneratedAt, items})
+	}))
+
+	mux.HandleFunc("GET /api/v1/endpoints", s.withReport(func(w http.ResponseWriter, r *http.Request, report *inspect.Report) {
+		exposure := r.URL.Query().Get("exposure")
+		items := []inspect.ExposedEndpoint{}
+		for _, e := range report.ExposedEndpoints {
+			if exposure == "" || e.Exposure == exposure {
+				items = append(items, e)
+			}
+		}
+		writeJSON(w, http.StatusOK, listResponse[inspect.ExposedEndpoint]{report.Cluster, report.GeneratedAt, items})
+	}))
+
+	mux.HandleFunc("GET /api/v1/versions", s.withReport(func(w http.ResponseWriter, r *http.Request, report *inspect.Report) {
+		writeJSON(w, http.StatusOK, VersionsResponse{
+			Cluster:           report.Cluster,
+			GeneratedAt:       report.GeneratedAt,
+			KubernetesVersion: report.KubernetesVersion,
+			Platform:          report.Platform,
+			Release:           report.Release,
+			EtcdVersion:       report.EtcdVersion,
+			NodeVersions:      report.NodeVersions,
+		})
+	}))
+
+	mux.HandleFunc("POST /api/v1/scan", func(w http.ResponseWriter, r *http.Request) {
+		if !s.allowRescan {
+			http.Error(w, "on-demand scans are disabled (start serve with -allow-rescan)", http.StatusForbidden)
+			return
+		}
+		if !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "a valid bearer token is required", http.StatusUnauthorized)
+			return
+		}
+		if !s.scanning.TryLock() {
+			http.Error(w, "a scan is already running", http.StatusConflict)
+			return
+		}
+		defer s.scanning.Unlock()
+		ctx := s.scanCtx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		report, err := s.scanLocked(ctx)
+		if err != nil {
+			http.Error(w, "scan failed: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	})
+}
+
+// authorized reports whether r carries the API token, when one is set.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.apiToken == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.apiToken)) == 1
+}
+
+// withReport calls handle with the latest report, or responds 503 when there is none yet.
+func (s *Server) withReport(handle func(http.ResponseWriter, *http.Request, *inspect.Report)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := s.Last()
+		if report == nil {
+			http.Error(w, "no scan has completed yet", http.StatusServiceUnavailable)
+			return
+		}
+		handle(w, r, report)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
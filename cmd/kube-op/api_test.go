@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+)
+
+func TestAPI(t *testing.T) {
+	s, err := NewServer(&watcher{}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := s.Handler()
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	if rec := get("/api/v1/findings"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /api/v1/findings before a scan = %d, want 503", rec.Code)
+	}
+
+	s.last = &inspect.Report{
+		GeneratedAt:       time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		KubernetesVersion: "v1.31.2",
+		EtcdVersion:       "3.5.15",
+		Platform:          &inspect.PlatformInfo{Name: inspect.PlatformKubeadm, Version: "v1.31.2"},
+		ExposedEndpoints: []inspect.ExposedEndpoint{
+			{Type: inspect.ExposureLoadBalancer, Name: "public", Exposure: inspect.AddressPublic},
+			{Type: inspect.ExposureLoadBalancer, Name: "internal", Exposure: inspect.AddressPrivate},
+		},
+		Findings: []inspect.Finding{
+			{CheckID: "node-not-ready", Severity: inspect.SeverityHigh, Name: "n1"},
+			{CheckID: "job-no-ttl", Severity: inspect.SeverityLow, Name: "migrate"},
+		},
+	}
+
+	var findings listResponse[inspect.Finding]
+	decode(t, get("/api/v1/findings?severity=medium"), &findings)
+	if len(findings.Items) != 1 || findings.Items[0].Name != "n1" || !findings.GeneratedAt.Equal(s.last.GeneratedAt) {
+		t.Errorf("findings?severity=medium = %+v", findings)
+	}
+	decode(t, get("/api/v1/findings?check=job-no-ttl"), &findings)
+	if len(findings.Items) != 1 || findings.Items[0].Name != "migrate" {
+		t.Errorf("findings?check=job-no-ttl = %+v", findings)
+	}
+	if rec := get("/api/v1/findings?severity=urgent"); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown severity = %d, want 400", rec.Code)
+	}
+
+	var endpoints listResponse[inspect.ExposedEndpoint]
+	decode(t, get("/api/v1/endpoints?exposure=public"), &endpoints)
+	if len(endpoints.Items) != 1 || endpoints.Items[0].Name != "public" {
+		t.Errorf("endpoints?exposure=public = %+v", endpoints)
+	}
+
+	var versions VersionsResponse
+	decode(t, get("/api/v1/versions"), &versions)
+	if versions.KubernetesVersion != "v1.31.2" || versions.EtcdVersion != "3.5.15" || versions.Platform.Name != inspect.PlatformKubeadm {
+		t.Errorf("versions = %+v", versions)
+	}
+
+	var report inspect.Report
+	decode(t, get("/api/v1/report"), &report)
+	if len(report.Findings) != 2 {
+		t.Errorf("report has %d findings, want 2", len(report.Findings))
+	}
+}
+
+func TestAPIRescanGuards(t *testing.T) {
+	s, err := NewServer(&watcher{}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := s.Handler()
+	post := func(token string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/scan", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := post(""); code != http.StatusForbidden {
+		t.Errorf("POST /api/v1/scan with rescans disabled = %d, want 403", code)
+	}
+
+	s.allowRescan, s.apiToken = true, "s3cret"
+	for _, token := range []string{"", "wrong"} {
+		if code := post(token); code != http.StatusUnauthorized {
+			t.Errorf("POST /api/v1/scan with token %q = %d, want 401", token, code)
+		}
+	}
+	s.scanning.Lock()
+	if code := post("s3cret"); code != http.StatusConflict {
+		t.Errorf("POST /api/v1/scan during a scan = %d, want 409", code)
+	}
+	s.scanning.Unlock()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/scan", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /api/v1/scan = %d, want 405", rec.Code)
+	}
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("failed to decode %s: %v", rec.Body, err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+)
+
+func runBaselineCommand(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("baseline", flag.ExitOnError)
+	var overrides inspect.ScanOptions
+	registerScanFlags(fs, &overrides)
+	file := fs.String("file", inspect.DefaultPolicyFile, "policy file to write; entries already in it are kept")
+	reason := fs.String("reason", "", `reason recorded with each newly accepted finding (default "accepted by kube-op baseline on <date>")`)
+	fs.Parse(args)
+
+	if err := overrides.Probe.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if *reason == "" {
+		*reason = "accepted by kube-op baseline on " + time.Now().Format(time.DateOnly)
+	}
+	// The file being written is the policy the scan starts from, unless --policy names another.
+	if overrides.Policy == nil {
+		policy, err := inspect.LoadPolicy(*file)
+		switch {
+		case err == nil:
+			overrides.Policy = policy
+		case errors.Is(err, os.ErrNotExist):
+			overrides.Policy = &inspect.Policy{}
+		default:
+			log.Fatalf("Failed to load policy: %v", err)
+		}
+	}
+
+	clientset, config, opts := connect(ctx, os.Stdout, overrides)
+	report, err := inspect.RunScan(ctx, clientset, config, opts)
+	if err != nil {
+		log.Fatalf("Scan failed: %v", err)
+	}
+	if report.Interrupted {
+		log.Fatalf("Scan interrupted, %s left unchanged", *file)
+	}
+
+	// Entries no finding matches any more are only dropped when the collector that raises their
+	// check ran to completion: a collector that failed, was skipped, or was left out of
+	// --collectors reports nothing, which says nothing about its findings.
+	policy, added, kept := opts.Policy.Baseline(append(report.Findings, report.Suppressed...), report.Covers, *reason)
+	var b bytes.Buffer
+	if err := inspect.WritePolicy(&b, policy); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*file, b.Bytes(), 0o644); err != nil {
+		log.Fatalf("Failed to write policy: %v", err)
+	}
+	fmt.Printf("Accepted %d new finding(s) in %s, which now has %d entries.\n", added, *file, len(policy.Accepted))
+	if kept > 0 {
+		fmt.Printf("Kept %d entries that matched nothing, because the scan did not fully run their checks.\n", kept)
+	}
+	if len(report.Errors) > 0 {
+		fmt.Println("Some sections could not be collected:")
+		for _, section := range slices.Sorted(maps.Keys(report.Errors)) {
+			fmt.Printf("  %s: %s\n", section, report.Errors[section])
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+)
+
+// runBundleCommand implements kube-op bundle: a scan plus the cluster's sanitized manifests in
+// one file, for analysis across an air gap with kube-op report --from-bundle.
+func runBundleCommand(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("bundle", flag.ExitOnError)
+	var overrides inspect.ScanOptions
+	registerScanFlags(fs, &overrides)
+	file := fs.String("file", "", "bundle file to write (default kube-op-bundle-<date>-<time>.tgz)")
+	fs.Parse(args)
+
+	if err := overrides.Probe.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if *file == "" {
+		*file = "kube-op-bundle-" + time.Now().Format("20060102-150405") + ".tgz"
+	}
+	loadDefaultPolicy(&overrides)
+	// The analyst can't go back to the cluster for the objects behind a finding.
+	overrides.WithRaw = true
+
+	clientset, config, opts := connect(ctx, os.Stdout, overrides)
+	report, err := inspect.RunScan(ctx, clientset, config, opts)
+	if err != nil {
+		log.Fatalf("Scan failed: %v", err)
+	}
+	if report.Interrupted {
+		log.Fatal("Scan interrupted, no bundle written")
+	}
+	fmt.Printf("Scanned the cluster: %d finding(s), capturing manifests...\n", len(report.Findings))
+
+	// Write to a temporary file beside the bundle, so an interrupted run leaves no partial one.
+	tmp, err := os.CreateTemp(filepath.Dir(*file), ".kube-op-bundle-*")
+	if err != nil {
+		log.Fatalf("Failed to create bundle: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	info, err := inspect.WriteBundle(ctx, tmp, clientset, config, report, opts, version)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Fatalf("Failed to write bundle: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), *file); err != nil {
+		log.Fatalf("Failed to write bundle: %v", err)
+	}
+
+	inspect.PrintBundleInfo(os.Stdout, *info)
+	fmt.Printf("Wrote %s\n", *file)
+}
+
+// runReportCommand implements kube-op report --from-bundle, which renders the report in a
+// bundle without access to the cluster.
+func runReportCommand(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	from := fs.String("from-bundle", "", "bundle written by kube-op bundle (required)")
+	output := fs.String("output", "text", "output format: text, json, or sarif (security findings only, for GitHub code scanning)")
+	policyFile := fs.String("policy", "", "policy file of accepted findings to apply instead of the one the bundle was scanned with")
+	failOn := fs.String("fail-on", "", "exit non-zero when any finding not accepted in the policy file is at least this severity (info, low, medium, high, critical)")
+	fs.Parse(args)
+
+	if *from == "" {
+		log.Fatal("Pass the bundle to read with --from-bundle")
+	}
+	if *output != "text" && *output != "json" && *output != "sarif" {
+		log.Fatalf("Unknown output format %q (want text, json, or sarif)", *output)
+	}
+	var failSeverity inspect.Severity
+	if *failOn != "" {
+		var err error
+		if failSeverity, err = inspect.ParseSeverity(*failOn); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(*from)
+	if err != nil {
+		log.Fatalf("Failed to open bundle: %v", err)
+	}
+	defer f.Close()
+	bundle, err := inspect.ReadBundle(f)
+	if err != nil {
+		log.Fatalf("Failed to read bundle: %v", err)
+	}
+	report := bundle.Report
+
+	if *policyFile != "" {
+		policy, err := inspect.LoadPolicy(*policyFile)
+		if err != nil {
+			log.Fatalf("Failed to load policy: %v", err)
+		}
+		report.ApplyPolicy(policy)
+	}
+
+	// Keep stdout clean for machine-readable output.
+	status := io.Writer(os.Stdout)
+	if *output != "text" {
+		status = os.Stderr
+	}
+	inspect.PrintBundleInfo(status, bundle.Info)
+	if err := writeReport(os.Stdout, report, *output); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+	if failsOn(report.Findings, failSeverity) {
+		os.Exit(1)
+	}
+}
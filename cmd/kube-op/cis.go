@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+)
+
+func runCISCommand(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("cis", flag.ExitOnError)
+	output := fs.String("output", "text", "output format: text or json")
+	fs.Parse(args)
+
+	if *output != "text" && *output != "json" {
+		log.Fatalf("Unknown output format %q (want text or json)", *output)
+	}
+	status := io.Writer(os.Stdout)
+	if *output == "json" {
+		status = os.Stderr
+	}
+
+	clientset, _, opts := connect(ctx, status, inspect.ScanOptions{})
+	report, err := inspect.GetCISReport(ctx, clientset, opts)
+	if err != nil {
+		log.Fatalf("Failed to assess CIS benchmark controls: %v", err)
+	}
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+	} else {
+		inspect.PrintCISReport(os.Stdout, report, true)
+	}
+	if report.Count(inspect.CISFail) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+)
+
+// runCollectorsCommand implements kube-op collectors list.
+func runCollectorsCommand(args []string) {
+	if len(args) == 0 || args[0] != "list" {
+		log.Fatalf("Unknown collectors subcommand (available: list)")
+	}
+	fs := flag.NewFlagSet("collectors list", flag.ExitOnError)
+	output := fs.String("output", "text", "output format: text or json")
+	fs.Parse(args[1:])
+
+	switch *output {
+	case "text":
+		inspect.PrintCollectors(os.Stdout, inspect.Collectors())
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(inspect.Collectors()); err != nil {
+			log.Fatalf("Failed to write collectors: %v", err)
+		}
+	default:
+		log.Fatalf("Unknown output format %q (want text or json)", *output)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func runDriftCommand(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("drift", flag.ExitOnError)
+	var files stringList
+	fs.Var(&files, "f", "manifest file or directory of rendered manifests, e.g. kustomize build output (repeatable)")
+	release := fs.String("helm-release", "", "compare against the deployed revision of this Helm release instead of files")
+	namespace := fs.String("namespace", "", "namespace of the Helm release and default for objects without one; also limits the check to this namespace")
+	output := fs.String("output", "text", "output format: text or json")
+	fs.Parse(args)
+
+	if *output != "text" && *output != "json" {
+		log.Fatalf("Unknown output format %q (want text or json)", *output)
+	}
+	if (len(files) == 0) == (*release == "") {
+		log.Fatal("Pass either -f with rendered manifests or -helm-release")
+	}
+	status := io.Writer(os.Stdout)
+	if *output == "json" {
+		status = os.Stderr
+	}
+
+	clientset, config, _ := connect(ctx, status, inspect.ScanOptions{})
+
+	var (
+		objects []*unstructured.Unstructured
+		source  string
+		err     error
+	)
+	defaultNamespace := *namespace
+	if defaultNamespace == "" {
+		defaultNamespace = "default"
+	}
+	if *release != "" {
+		source = fmt.Sprintf("helm release %s/%s", defaultNamespace, *release)
+		manifest, err := inspect.GetHelmReleaseManifest(ctx, clientset, defaultNamespace, *release)
+		if err != nil {
+			log.Fatalf("Failed to load helm release: %v", err)
+		}
+		objects, err = inspect.ParseManifests([]byte(manifest))
+		if err != nil {
+			log.Fatalf("Failed to parse helm release manifest: %v", err)
+		}
+	} else {
+		source = strings.Join(files, ", ")
+		objects, err = inspect.LoadManifests(files)
+		if err != nil {
+			log.Fatalf("Failed to load manifests: %v", err)
+		}
+	}
+
+	report, err := inspect.CheckDrift(ctx, config, clientset, objects, defaultNamespace, *namespace)
+	if err != nil {
+		log.Fatalf("Drift check failed: %v", err)
+	}
+	report.Source = source
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatalf("Failed to write drift report: %v", err)
+		}
+	} else {
+		inspect.PrintDriftReport(os.Stdout, report)
+	}
+	if report.Drifted() {
+		os.Exit(1)
+	}
+}
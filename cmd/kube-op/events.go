@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func runEventsCommand(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("events", flag.ExitOnError)
+	window := fs.Duration("window", time.Hour, "how far back to aggregate events")
+	namespace := fs.String("namespace", "", "only aggregate events in this namespace (default all)")
+	top := fs.Int("top", 20, "number of event groups to list in text output (0 = all)")
+	output := fs.String("output", "text", "output format: text or json")
+	fs.Parse(args)
+
+	if *output != "text" && *output != "json" {
+		log.Fatalf("Unknown output format %q (want text or json)", *output)
+	}
+	status := io.Writer(os.Stdout)
+	if *output == "json" {
+		status = os.Stderr
+	}
+
+	clientset, _, opts := connect(ctx, status, inspect.ScanOptions{})
+
+	events, err := inspect.ListEvents(ctx, clientset, opts, *namespace, metav1.ListOptions{})
+	if err != nil {
+		log.Fatalf("Failed to list events: %v", err)
+	}
+	summary := inspect.SummarizeEvents(events, *window, time.Now())
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summary); err != nil {
+			log.Fatalf("Failed to write events: %v", err)
+		}
+		return
+	}
+	inspect.PrintEventSummary(os.Stdout, summary, *top)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+)
+
+func runHelmCommand(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("helm", flag.ExitOnError)
+	namespace := fs.String("namespace", "", "only list releases in this namespace (default all)")
+	var indexes stringList
+	fs.Var(&indexes, "repo-index", "Helm repository index.yaml to compare deployed chart versions with (repeatable)")
+	maxChartAge := fs.Duration("max-chart-age", 365*24*time.Hour, "flag releases whose chart version is older than this (0 = never)")
+	output := fs.String("output", "text", "output format: text or json")
+	fs.Parse(args)
+
+	if *output != "text" && *output != "json" {
+		log.Fatalf("Unknown output format %q (want text or json)", *output)
+	}
+	index, err := inspect.LoadHelmRepoIndexes(indexes)
+	if err != nil {
+		log.Fatalf("Failed to load repository index: %v", err)
+	}
+	status := io.Writer(os.Stdout)
+	if *output == "json" {
+		status = os.Stderr
+	}
+
+	clientset, _, opts := connect(ctx, status, inspect.ScanOptions{})
+	report, err := inspect.GetHelmReport(ctx, clientset, opts, *namespace, inspect.HelmCheckOptions{Index: index, MaxChartAge: *maxChartAge})
+	if err != nil {
+		log.Fatalf("Failed to list Helm releases: %v", err)
+	}
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+	} else {
+		inspect.PrintHelmReport(os.Stdout, report)
+	}
+	for _, f := range report.Findings {
+		if f.CheckID == "helm-release-failed" || f.CheckID == "helm-release-pending" {
+			os.Exit(1)
+		}
+	}
+}
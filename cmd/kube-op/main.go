@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nazufel/kube-op/pkg/client"
+	"github.com/nazufel/kube-op/pkg/inspect"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func main() {
+	args := os.Args[1:]
+	command := "scan"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	if checksForUpdates(command) {
+		if cacheFile, err := updateCheckCacheFile(); err == nil {
+			notifyNewRelease(os.Stderr, newUpdater(updateCheckTimeout), cacheFile, time.Now())
+		}
+	}
+
+	// The first SIGINT or SIGTERM cancels ctx, stopping in-flight API calls so commands can
+	// report what they have. Restoring the default handling lets a second one exit at once.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+		fmt.Fprintln(os.Stderr, "Interrupted, stopping (interrupt again to quit immediately)...")
+	}()
+
+	switch command {
+	case "scan":
+		runScanCommand(ctx, args)
+	case "watch":
+		runWatchCommand(ctx, args)
+	case "serve":
+		runServeCommand(ctx, args)
+	case "tui":
+		runTUICommand(ctx, args)
+	case "probe":
+		runProbeCommand(ctx, args)
+	case "events":
+		runEventsCommand(ctx, args)
+	case "drift":
+		runDriftCommand(ctx, args)
+	case "helm":
+		runHelmCommand(ctx, args)
+	case "baseline":
+		runBaselineCommand(ctx, args)
+	case "bundle":
+		runBundleCommand(ctx, args)
+	case "report":
+		runReportCommand(args)
+	case "cis":
+		runCISCommand(ctx, args)
+	case "scale-down-check":
+		runScaleDownCheckCommand(ctx, args)
+	case "collectors":
+		runCollectorsCommand(args)
+	case "plugins":
+		runPluginsCommand(args)
+	case "rbac-requirements":
+		runRBACRequirementsCommand(ctx, args)
+	case "rbac-diff":
+		runRBACDiffCommand(ctx, args)
+	case "self-update":
+		runSelfUpdateCommand(args)
+	case "version":
+		runVersionCommand(args)
+	default:
+		log.Fatalf("Unknown command %q (available: scan, watch, serve, tui, probe, events, drift, helm, baseline, bundle, report, cis, scale-down-check, collectors, plugins, rbac-requirements, rbac-diff, self-update, version)", command)
+	}
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// registerScanFlags adds the flags shared by every command that scans the cluster.
+func registerScanFlags(fs *flag.FlagSet, overrides *inspect.ScanOptions) {
+	fs.Int64Var(&overrides.PageSize, "page-size", 0, "objects to request per list call (0 = tune to cluster size)")
+	fs.IntVar(&overrides.Concurrency, "concurrency", 0, "collectors to run in parallel (0 = tune to cluster size)")
+	fs.DurationVar(&overrides.Timeout, "timeout", 0, "timeout for each API server request (0 = tune to cluster size)")
+	fs.IntVar(&overrides.ShardWorkers, "shard-namespaces", 0, "list namespaced objects one namespace at a time with this many namespaces in flight, for clusters with thousands of namespaces (0 = cluster-wide lists)")
+	fs.BoolFunc("progress", "report the progress of sharded lists on stderr", func(value string) error {
+		enabled, err := strconv.ParseBool(value)
+		if enabled {
+			overrides.Progress = os.Stderr
+		}
+		return err
+	})
+	fs.BoolVar(&overrides.EtcdDeep, "etcd-deep", false, "exec etcdctl in an etcd pod to report members, leader, DB size, and alarms (needs pods/exec in kube-system)")
+	fs.BoolVar(&overrides.CheckCerts, "check-certs", false, "connect to the API server, etcd, and kubelet ports on control-plane nodes to check certificate expiry")
+	fs.BoolVar(&overrides.ResolveHostnames, "resolve-hostnames", false, "resolve load balancer and Ingress hostnames via DNS to classify them as public or private")
+	fs.IntVar(&overrides.CertWarningDays, "cert-warning-days", 30, "warn about certificates expiring within this many days")
+	fs.StringVar(&overrides.KubeadmCertificateKeyFile, "kubeadm-certificate-key-file", "", "file holding the kubeadm init --upload-certs certificate key, to check the API server's etcd client certificate with --check-certs")
+	fs.BoolVar(&overrides.Probe.Enabled, "probe", false, "actively connect to every exposed endpoint from this machine to verify reachability")
+	fs.DurationVar(&overrides.Probe.Timeout, "probe-timeout", 3*time.Second, "timeout for each reachability probe")
+	fs.BoolVar(&overrides.Probe.Acknowledged, "i-own-these-targets", false, "required with --probe: confirm you are authorized to send traffic to the cluster's exposed endpoints")
+	fs.IntVar(&overrides.Probe.Concurrency, "probe-concurrency", 4, "maximum reachability probes in flight at once")
+	fs.Float64Var(&overrides.Probe.RatePerHost, "probe-rate", 1, "maximum reachability probes per second to any one host")
+	fs.Var((*stringList)(&overrides.Probe.Allow), "probe-allow", "only probe addresses in this CIDR, and with a hostname glob only those Ingress hosts (repeatable)")
+	fs.Var((*stringList)(&overrides.Probe.Deny), "probe-deny", "never probe hosts matching this CIDR or hostname glob (repeatable)")
+	fs.DurationVar(&overrides.DebugMaxAge, "debug-max-age", 4*time.Hour, "flag ephemeral containers and debug pods that have been running longer than this")
+	fs.BoolVar(&overrides.Plugins.Enabled, "plugins", false, "run every kube-op-<name> executable on PATH after the collectors and add the findings they print (see kube-op plugins list)")
+	fs.DurationVar(&overrides.Plugins.Timeout, "plugin-timeout", time.Minute, "timeout for each exec plugin run")
+	fs.StringVar(&overrides.ClusterName, "cluster-name", "", "cluster name to record in the report (default: the cluster of the kubeconfig's current-context)")
+	fs.Func("collectors", "comma-separated collectors to run, plus the ones they require (default all; see kube-op collectors list)", func(value string) error {
+		names, err := inspect.ParseCollectorNames(value)
+		overrides.Collectors = append(overrides.Collectors, names...)
+		return err
+	})
+	fs.Func("skip-collectors", "comma-separated collectors to leave out", func(value string) error {
+		names, err := inspect.ParseCollectorNames(value)
+		overrides.SkipCollectors = append(overrides.SkipCollectors, names...)
+		return err
+	})
+	fs.Func("audit-log", "API server audit log (JSON lines) naming the users who created and last modified exposed endpoints", func(file string) error {
+		audit, err := inspect.LoadAuditLog(file)
+		overrides.AuditLog = audit
+		return err
+	})
+	fs.Func("dns-zone", "BIND zone export to check the cluster's hostnames against for delegation and collisions (repeatable)", func(file string) error {
+		zone, err := inspect.LoadDNSZone(file)
+		if err != nil {
+			return err
+		}
+		overrides.DNS.Zones = append(overrides.DNS.Zones, zone)
+		return nil
+	})
+	fs.BoolVar(&overrides.DNS.Resolve, "dns-resolve", false, "check the cluster's hostnames for delegation and collisions with the system resolver")
+	fs.BoolFunc("cost", "estimate the monthly cost of the nodes with the built-in prices and attribute it to namespaces and workloads", func(value string) error {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil || !enabled || overrides.Prices != nil {
+			return err
+		}
+		overrides.Prices, err = inspect.BuiltinPriceTable()
+		return err
+	})
+	fs.Func("price-table", "YAML or JSON file of hourly prices by instance type for the cost estimate, instead of the built-in prices", func(file string) error {
+		prices, err := inspect.LoadPriceTable(file)
+		if err != nil {
+			return err
+		}
+		overrides.Prices = prices
+		return nil
+	})
+	fs.Func("policy", "policy file of accepted findings to leave out of the results (default .kube-op.yaml, if it exists; empty for none)", func(file string) error {
+		if file == "" {
+			overrides.Policy = &inspect.Policy{}
+			return nil
+		}
+		policy, err := inspect.LoadPolicy(file)
+		overrides.Policy = policy
+		return err
+	})
+	fs.Func("probe-credentials", "YAML file of per-host credentials (basic, bearer, mTLS) to present when probing", func(file string) error {
+		config, err := inspect.LoadProbeCredentials(file)
+		if err != nil {
+			return err
+		}
+		overrides.Probe.Credentials = config
+		return nil
+	})
+}
+
+// loadDefaultPolicy reads .kube-op.yaml from the working directory when --policy was not given
+// and the file exists, exiting if it is invalid.
+func loadDefaultPolicy(overrides *inspect.ScanOptions) {
+	if overrides.Policy != nil {
+		return
+	}
+	policy, err := inspect.LoadPolicy(inspect.DefaultPolicyFile)
+	switch {
+	case err == nil:
+		overrides.Policy = policy
+	case !errors.Is(err, os.ErrNotExist):
+		log.Fatalf("Failed to load policy: %v", err)
+	}
+}
+
+// connect builds a clientset from the kubeconfig, probes the cluster size, and returns a
+// client tuned for it together with its REST config and the effective scan options.
+// Progress is written to status.
+func connect(ctx context.Context, status io.Writer, overrides inspect.ScanOptions) (*kubernetes.Clientset, *rest.Config, inspect.ScanOptions) {
+	fmt.Fprintln(status, "Attempting to connect to Kubernetes cluster...")
+
+	config, err := client.NewRESTConfigFromKubeconfig()
+	if err != nil {
+		log.Fatalf("Failed to create Kubernetes client: %v", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Fatalf("Failed to create Kubernetes client: %v", err)
+	}
+
+	fmt.Fprintln(status, "Successfully connected to Kubernetes cluster!")
+
+	size, err := inspect.GetClusterSize(ctx, clientset)
+	if err != nil {
+		fmt.Fprintf(status, "Could not determine cluster size, assuming a small cluster: %v\n", err)
+	} else {
+		fmt.Fprintf(status, "Detected cluster size: %d node(s), %d namespace(s)\n", size.Nodes, size.Namespaces)
+	}
+	if overrides.ClusterName == "" {
+		// Best effort: in-cluster configs have no kubeconfig to name the cluster.
+		overrides.ClusterName, _ = client.CurrentClusterName()
+	}
+	if overrides.Plugins.Enabled {
+		overrides.Plugins.Env = pluginEnv()
+	}
+	opts := inspect.AutoTuneScanOptions(size).WithOverrides(overrides)
+	fmt.Fprintf(status, "Scan settings: page size %d, concurrency %d, timeout %s\n", opts.PageSize, opts.Concurrency, opts.Timeout)
+	if opts.ShardWorkers > 0 {
+		fmt.Fprintf(status, "Listing namespaced objects per namespace, %d namespace(s) at a time\n", opts.ShardWorkers)
+	}
+	if opts.Plugins.Enabled {
+		fmt.Fprintf(status, "Running %d exec plugin(s) after the collectors\n", len(inspect.FindExecPlugins(os.Getenv("PATH"))))
+	}
+
+	clientset, err = client.NewTunedClient(config, opts.Timeout, opts.Concurrency)
+	if err != nil {
+		log.Fatalf("Failed to create Kubernetes client: %v", err)
+	}
+	return clientset, config, opts
+}
+
+func runScanCommand(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	var overrides inspect.ScanOptions
+	registerScanFlags(fs, &overrides)
+	output := fs.String("output", "text", "output format: text, json, or sarif (security findings only, for GitHub code scanning)")
+	fs.BoolVar(&overrides.WithRaw, "with-raw", false, "embed the raw JSON of flagged objects in json output (secret values are always stripped)")
+	failOn := fs.String("fail-on", "", "exit non-zero when any finding not accepted in the policy file is at least this severity (info, low, medium, high, critical)")
+	fs.Parse(args)
+
+	if *output != "text" && *output != "json" && *output != "sarif" {
+		log.Fatalf("Unknown output format %q (want text, json, or sarif)", *output)
+	}
+	if err := overrides.Probe.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	var failSeverity inspect.Severity
+	if *failOn != "" {
+		var err error
+		if failSeverity, err = inspect.ParseSeverity(*failOn); err != nil {
+			log.Fatal(err)
+		}
+	}
+	loadDefaultPolicy(&overrides)
+
+	// Keep stdout clean for machine-readable output.
+	status := io.Writer(os.Stdout)
+	if *output != "text" {
+		status = os.Stderr
+	}
+
+	clientset, config, opts := connect(ctx, status, overrides)
+
+	report, err := inspect.RunScan(ctx, clientset, config, opts)
+	if err != nil {
+		log.Fatalf("Scan failed: %v", err)
+	}
+
+	if err := writeReport(os.Stdout, report, *output); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+	if report.Interrupted || failsOn(report.Findings, failSeverity) {
+		os.Exit(1)
+	}
+}
+
+// writeReport writes the report in the --output format of scan and report.
+func writeReport(w io.Writer, report *inspect.Report, output string) error {
+	switch output {
+	case "json":
+		return inspect.WriteReportJSON(w, report)
+	case "sarif":
+		return inspect.WriteReportSARIF(w, report, version)
+	default:
+		inspect.PrintReport(w, report)
+		return nil
+	}
+}
+
+// failsOn reports whether any finding is at least the --fail-on severity, if one was given.
+func failsOn(findings []inspect.Finding, severity inspect.Severity) bool {
+	if severity == "" {
+		return false
+	}
+	for _, f := range findings {
+		if f.Severity.AtLeast(severity) {
+			return true
+		}
+	}
+	return false
+}
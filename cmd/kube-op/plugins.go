@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/nazufel/kube-op/pkg/client"
+	"github.com/nazufel/kube-op/pkg/inspect"
+)
+
+// runPluginsCommand implements kube-op plugins list.
+func runPluginsCommand(args []string) {
+	if len(args) == 0 || args[0] != "list" {
+		log.Fatalf("Unknown plugins subcommand (available: list)")
+	}
+	fs := flag.NewFlagSet("plugins list", flag.ExitOnError)
+	output := fs.String("output", "text", "output format: text or json")
+	fs.Parse(args[1:])
+
+	plugins := inspect.FindExecPlugins(os.Getenv("PATH"))
+	switch *output {
+	case "text":
+		inspect.PrintExecPlugins(os.Stdout, plugins)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(plugins); err != nil {
+			log.Fatalf("Failed to write plugins: %v", err)
+		}
+	default:
+		log.Fatalf("Unknown output format %q (want text or json)", *output)
+	}
+}
+
+// pluginEnv is the kubeconfig and context exec plugins should use: the ones kube-op connected with.
+func pluginEnv() []string {
+	env := []string{"KUBECONFIG=" + client.KubeconfigPath()}
+	// Best effort, like the cluster name: in-cluster configs have no kubeconfig context.
+	if name, err := client.CurrentContextName(); err == nil {
+		env = append(env, "KUBE_OP_CONTEXT="+name)
+	}
+	return env
+}
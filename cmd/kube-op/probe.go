@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+)
+
+func runProbeCommand(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("probe", flag.ExitOnError)
+	var config inspect.ProbeConfig
+	var targets stringList
+	fs.StringVar(&config.Namespace, "namespace", "default", "namespace to run the diagnostic pod in")
+	fs.StringVar(&config.Image, "image", "busybox:1.36", "image for the diagnostic pod; must provide sh, nslookup, nc, and awk")
+	fs.StringVar(&config.ClusterDomain, "cluster-domain", "cluster.local", "cluster DNS domain")
+	fs.DurationVar(&config.Wait, "wait", 2*time.Minute, "how long to wait for the diagnostic pod to finish")
+	fs.Var(&targets, "target", "service to check as name.namespace:port (repeatable)")
+	fs.Parse(args)
+
+	for _, t := range targets {
+		target, err := inspect.ParseProbeTarget(t)
+		if err != nil {
+			log.Fatal(err)
+		}
+		config.Targets = append(config.Targets, target)
+	}
+
+	clientset, _, _ := connect(ctx, os.Stdout, inspect.ScanOptions{})
+
+	results, err := inspect.RunProbe(ctx, clientset, config)
+	if err != nil {
+		log.Fatalf("Probe failed: %v", err)
+	}
+	inspect.PrintProbeResults(os.Stdout, results)
+
+	for _, r := range results {
+		if !r.OK {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+func runRBACRequirementsCommand(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("rbac-requirements", flag.ExitOnError)
+	var overrides inspect.ScanOptions
+	registerScanFlags(fs, &overrides)
+	manifest := fs.Bool("manifest", false, "print a ClusterRole/Role manifest granting the required permissions instead of checking them")
+	serviceAccount := fs.String("service-account", "kube-op/kube-op", "namespace/name of the service account the manifest binds")
+	name := fs.String("name", "kube-op", "name of the generated roles and bindings")
+	fs.Parse(args)
+
+	collectors, err := inspect.SelectCollectors(overrides.Collectors, overrides.SkipCollectors)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Both modes need the cluster: some permissions, such as list on each custom resource kind,
+	// depend on what it serves.
+	clientset, config, opts := connect(ctx, os.Stderr, overrides)
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		log.Fatalf("Failed to create dynamic client: %v", err)
+	}
+	required, err := inspect.ResolveRequiredPermissions(ctx, dynamicClient, collectors, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *manifest {
+		ns, sa, ok := strings.Cut(*serviceAccount, "/")
+		if !ok || ns == "" || sa == "" {
+			log.Fatalf("Invalid -service-account %q (want namespace/name)", *serviceAccount)
+		}
+		data, err := inspect.RBACManifest(required, *name, rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: ns, Name: sa})
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Stdout.Write(data)
+		return
+	}
+
+	checks, err := inspect.CheckAccess(ctx, clientset, opts, required)
+	if err != nil {
+		log.Fatal(err)
+	}
+	inspect.PrintAccessChecks(os.Stdout, checks)
+	for _, c := range checks {
+		if !c.Allowed {
+			os.Exit(1)
+		}
+	}
+}
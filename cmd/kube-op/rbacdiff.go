@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+)
+
+func runRBACDiffCommand(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("rbac-diff", flag.ExitOnError)
+	var identities []inspect.Identity
+	identityFlag := func(group bool) func(string) error {
+		return func(value string) error {
+			identity, err := inspect.ParseIdentity(value, group)
+			identities = append(identities, identity)
+			return err
+		}
+	}
+	fs.Func("as", "user, or system:serviceaccount:<namespace>:<name>, to compare (give two, or one with -as-group)", identityFlag(false))
+	fs.Func("as-group", "group to compare", identityFlag(true))
+	output := fs.String("output", "text", "output format: text or json")
+	fs.Parse(args)
+
+	if len(identities) != 2 {
+		log.Fatalf("rbac-diff compares exactly two identities, got %d (e.g. -as alice -as bob)", len(identities))
+	}
+	if *output != "text" && *output != "json" {
+		log.Fatalf("Unknown output format %q (want text or json)", *output)
+	}
+	status := io.Writer(os.Stdout)
+	if *output == "json" {
+		status = os.Stderr
+	}
+
+	clientset, _, opts := connect(ctx, status, inspect.ScanOptions{})
+	policy, err := inspect.GetRBACPolicy(ctx, clientset, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	diff := inspect.DiffRBAC(policy, identities[0], identities[1])
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(diff); err != nil {
+			log.Fatalf("Failed to write diff: %v", err)
+		}
+		return
+	}
+	inspect.PrintRBACDiff(os.Stdout, diff)
+}
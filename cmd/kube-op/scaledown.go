@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func runScaleDownCheckCommand(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("scale-down-check", flag.ExitOnError)
+	group := fs.String("node-group", "", "node group to simulate removing, matched against the EKS, GKE, AKS, Karpenter, and eksctl node group labels")
+	selector := fs.String("selector", "", "label selector for the nodes to remove, instead of -node-group")
+	output := fs.String("output", "text", "output format: text or json")
+	fs.Parse(args)
+
+	if *output != "text" && *output != "json" {
+		log.Fatalf("Unknown output format %q (want text or json)", *output)
+	}
+	if (*group == "") == (*selector == "") {
+		log.Fatal("Pass either -node-group or -selector")
+	}
+	remove := func(n corev1.Node) bool { return inspect.InNodeGroup(n, *group) }
+	name := *group
+	if *selector != "" {
+		sel, err := labels.Parse(*selector)
+		if err != nil {
+			log.Fatalf("Invalid selector: %v", err)
+		}
+		remove = func(n corev1.Node) bool { return sel.Matches(labels.Set(n.Labels)) }
+		name = *selector
+	}
+	status := io.Writer(os.Stdout)
+	if *output == "json" {
+		status = os.Stderr
+	}
+
+	clientset, _, opts := connect(ctx, status, inspect.ScanOptions{})
+	report, err := inspect.CheckScaleDown(ctx, clientset, opts, remove)
+	if err != nil {
+		log.Fatalf("Scale-down check failed: %v", err)
+	}
+	report.NodeGroup = name
+	if len(report.Nodes) == 0 {
+		log.Fatalf("No nodes match %s", name)
+	}
+	if len(report.Unplaceable) > 0 {
+		owners, err := inspect.GetOwnerResolver(ctx, clientset, opts)
+		if err != nil {
+			log.Printf("Could not resolve pod owners: %v", err)
+		}
+		for i := range report.Unplaceable {
+			p := &report.Unplaceable[i]
+			p.Owner = owners.Owner(p.Namespace, p.Name)
+		}
+	}
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+	} else {
+		inspect.PrintScaleDownReport(os.Stdout, report)
+	}
+	if len(report.Unplaceable) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// version is the release kube-op was built from, set with -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
+// releaseSigningKey is the base64 ed25519 public key the release checksums are signed with, set
+// at build time with -ldflags "-X main.releaseSigningKey=...". Builds without it can only verify
+// checksums.
+var releaseSigningKey = ""
+
+const (
+	releasesURL = "https://api.github.com/repos/nazufel/kube-op/releases"
+	// checksumsAsset lists the SHA-256 of every release binary, one "<hex>  <name>" per line, and
+	// checksumsSignatureAsset is its base64 ed25519 signature.
+	checksumsAsset          = "checksums.txt"
+	checksumsSignatureAsset = "checksums.txt.sig"
+	// updateCheckInterval is how often the startup notice asks for the latest release.
+	updateCheckInterval = 24 * time.Hour
+	// updateCheckTimeout keeps the startup notice from holding up commands on offline hosts.
+	updateCheckTimeout = 2 * time.Second
+	// noUpdateCheckEnv disables the startup notice when set to any value.
+	noUpdateCheckEnv = "KUBE_OP_NO_UPDATE_CHECK"
+)
+
+// Release is a published kube-op release.
+type Release struct {
+	Tag    string         `json:"tag_name"`
+	URL    string         `json:"html_url"`
+	Assets []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset is a file attached to a release.
+type ReleaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+func (r *Release) asset(name string) *ReleaseAsset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// updater fetches and verifies releases.
+type updater struct {
+	client     *http.Client
+	baseURL    string
+	goos       string
+	goarch     string
+	signingKey string
+}
+
+func newUpdater(timeout time.Duration) *updater {
+	return &updater{
+		client:     &http.Client{Timeout: timeout},
+		baseURL:    releasesURL,
+		goos:       runtime.GOOS,
+		goarch:     runtime.GOARCH,
+		signingKey: releaseSigningKey,
+	}
+}
+
+// release fetches the release with the given tag, or the latest release when tag is empty.
+func (u *updater) release(tag string) (*Release, error) {
+	url := u.baseURL + "/latest"
+	if tag != "" {
+		url = u.baseURL + "/tags/" + tag
+	}
+	data, err := u.get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release: %w", err)
+	}
+	var release Release
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+	return &release, nil
+}
+
+func (u *updater) get(url string) ([]byte, error) {
+	resp, err := u.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// binaryAssetName is the release asset built for an OS and architecture.
+func binaryAssetName(goos, goarch string) string {
+	name := fmt.Sprintf("kube-op_%s_%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// download fetches this platform's binary from a release and verifies it against the release
+// checksums, and verifies the checksums' signature when the build carries a signing key.
+func (u *updater) download(release *Release) ([]byte, error) {
+	name := binaryAssetName(u.goos, u.goarch)
+	binary := release.asset(name)
+	if binary == nil {
+		return nil, fmt.Errorf("release %s has no binary for %s/%s", release.Tag, u.goos, u.goarch)
+	}
+	checksums := release.asset(checksumsAsset)
+	if checksums == nil {
+		return nil, fmt.Errorf("release %s has no %s", release.Tag, checksumsAsset)
+	}
+
+	checksumData, err := u.get(checksums.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download checksums: %w", err)
+	}
+	if u.signingKey != "" {
+		signature := release.asset(checksumsSignatureAsset)
+		if signature == nil {
+			return nil, fmt.Errorf("release %s has no %s", release.Tag, checksumsSignatureAsset)
+		}
+		signatureData, err := u.get(signature.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download checksum signature: %w", err)
+		}
+		if err := VerifySignature(u.signingKey, checksumData, signatureData); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := u.get(binary.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	if err := VerifyChecksum(checksumData, name, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// VerifyChecksum checks data against the SHA-256 listed for name in a checksums file.
+func VerifyChecksum(checksums []byte, name string, data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		want, err := hex.DecodeString(fields[0])
+		if err != nil {
+			return fmt.Errorf("failed to parse checksum of %s: %w", name, err)
+		}
+		got := sha256.Sum256(data)
+		if !bytes.Equal(got[:], want) {
+			return fmt.Errorf("checksum mismatch for %s: got %x, want %x", name, got, want)
+		}
+		return nil
+	}
+	return fmt.Errorf("no checksum listed for %s", name)
+}
+
+// VerifySignature checks a base64 ed25519 signature of message against a base64 public key.
+func VerifySignature(publicKey string, message, signature []byte) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid release signing key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("failed to parse checksum signature: %w", err)
+	}
+	if !ed25519.Verify(key, message, sig) {
+		return fmt.Errorf("checksum signature does not match the release signing key")
+	}
+	return nil
+}
+
+// compareVersions orders release tags like v1.2.3 numerically, returning -1, 0, or 1. A
+// pre-release (v1.2.3-rc.1) sorts before its release.
+func compareVersions(a, b string) int {
+	parse := func(v string) ([3]int, bool) {
+		v, pre, _ := strings.Cut(strings.TrimPrefix(v, "v"), "-")
+		var parts [3]int
+		for i, p := range strings.SplitN(v, ".", 3) {
+			parts[i], _ = strconv.Atoi(p)
+		}
+		return parts, pre != ""
+	}
+	pa, preA := parse(a)
+	pb, preB := parse(b)
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case preA && !preB:
+		return -1
+	case !preA && preB:
+		return 1
+	}
+	return 0
+}
+
+// replaceExecutable atomically swaps the binary at path for data, keeping its permissions.
+func replaceExecutable(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".kube-op-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write update: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write update: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to set permissions: %w", err)
+	}
+	// Windows can't replace a running executable, but it can rename it out of the way.
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("failed to move %s aside: %w", path, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// updateCheck is the cached result of the last startup check.
+type updateCheck struct {
+	CheckedAt time.Time `json:"checkedAt"`
+	// Latest is empty when the check failed, so offline hosts only retry once per interval.
+	Latest string `json:"latest,omitempty"`
+}
+
+// notifyNewRelease tells the user when a newer release than this build exists. The latest
+// release is looked up at most once per updateCheckInterval and cached in the user cache
+// directory, and every failure is silent, so hosts without internet access are only delayed by
+// updateCheckTimeout once a day. Development builds and KUBE_OP_NO_UPDATE_CHECK skip the check.
+func notifyNewRelease(w io.Writer, u *updater, cacheFile string, now time.Time) {
+	if version == "dev" || os.Getenv(noUpdateCheckEnv) != "" {
+		return
+	}
+
+	var check updateCheck
+	if data, err := os.ReadFile(cacheFile); err == nil {
+		json.Unmarshal(data, &check)
+	}
+	if now.Sub(check.CheckedAt) >= updateCheckInterval {
+		check = updateCheck{CheckedAt: now}
+		if release, err := u.release(""); err == nil {
+			check.Latest = release.Tag
+		}
+		if data, err := json.Marshal(check); err == nil {
+			os.MkdirAll(filepath.Dir(cacheFile), 0o755)
+			os.WriteFile(cacheFile, data, 0o644)
+		}
+	}
+
+	if check.Latest != "" && compareVersions(check.Latest, version) > 0 {
+		fmt.Fprintf(w, "kube-op %s is available (running %s); update with kube-op self-update, or set %s=1 to hide this notice\n",
+			check.Latest, version, noUpdateCheckEnv)
+	}
+}
+
+// checksForUpdates reports whether the command looks up the latest release on startup. serve
+// and watch run unattended, where a notice would go unread, and report reads bundles taken
+// across an air gap, where the lookup must not reach out.
+func checksForUpdates(command string) bool {
+	switch command {
+	case "serve", "watch", "report", "self-update", "version":
+		return false
+	}
+	return true
+}
+
+// updateCheckCacheFile is where notifyNewRelease caches the latest release.
+func updateCheckCacheFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "kube-op", "update-check.json"), nil
+}
+
+func runSelfUpdateCommand(args []string) {
+	fs := flag.NewFlagSet("self-update", flag.ExitOnError)
+	check := fs.Bool("check", false, "only report whether a newer release exists")
+	tag := fs.String("version", "", "install this release tag instead of the latest")
+	fs.Parse(args)
+
+	u := newUpdater(time.Minute)
+	release, err := u.release(*tag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *tag == "" && version != "dev" && compareVersions(release.Tag, version) <= 0 {
+		fmt.Printf("kube-op %s is the latest release\n", version)
+		return
+	}
+	if *check {
+		fmt.Printf("kube-op %s is available (running %s): %s\n", release.Tag, version, release.URL)
+		return
+	}
+
+	if u.signingKey == "" {
+		fmt.Fprintln(os.Stderr, "This build has no release signing key; only the checksum will be verified")
+	}
+	data, err := u.download(release)
+	if err != nil {
+		log.Fatalf("Failed to download kube-op %s: %v", release.Tag, err)
+	}
+	path, err := os.Executable()
+	if err == nil {
+		path, err = filepath.EvalSymlinks(path)
+	}
+	if err != nil {
+		log.Fatalf("Failed to locate the running binary: %v", err)
+	}
+	if err := replaceExecutable(path, data); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			log.Fatalf("Failed to update %s: %v (run with permission to write it)", path, err)
+		}
+		log.Fatalf("Failed to update %s: %v", path, err)
+	}
+	fmt.Printf("Updated kube-op from %s to %s\n", version, release.Tag)
+}
+
+func runVersionCommand(args []string) {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	fs.Parse(args)
+	fmt.Printf("kube-op %s (%s/%s)\n", version, runtime.GOOS, runtime.GOARCH)
+}
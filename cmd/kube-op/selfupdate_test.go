@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// releaseServer serves a release with a binary for linux/amd64, its checksums, and their
+// signature by key.
+func releaseServer(t *testing.T, tag string, binary []byte, key ed25519.PrivateKey) *httptest.Server {
+	t.Helper()
+	sum := sha256.Sum256(binary)
+	checksums := fmt.Sprintf("%x  kube-op_linux_amd64\n%x  kube-op_darwin_arm64\n", sum, sha256.Sum256(nil))
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(checksums)))
+
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Release{Tag: tag, Assets: []ReleaseAsset{
+			{Name: "kube-op_linux_amd64", URL: srv.URL + "/download/binary"},
+			{Name: checksumsAsset, URL: srv.URL + "/download/checksums"},
+			{Name: checksumsSignatureAsset, URL: srv.URL + "/download/signature"},
+		}})
+	})
+	mux.HandleFunc("/download/binary", func(w http.ResponseWriter, r *http.Request) { w.Write(binary) })
+	mux.HandleFunc("/download/checksums", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(checksums)) })
+	mux.HandleFunc("/download/signature", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(signature)) })
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testUpdater(srv *httptest.Server, publicKey ed25519.PublicKey) *updater {
+	return &updater{
+		client: srv.Client(), baseURL: srv.URL + "/releases", goos: "linux", goarch: "amd64",
+		signingKey: base64.StdEncoding.EncodeToString(publicKey),
+	}
+}
+
+func TestUpdaterDownload(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(nil)
+	binary := []byte("new kube-op")
+	u := testUpdater(releaseServer(t, "v1.3.0", binary, private), public)
+
+	release, err := u.release("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := u.download(release)
+	if err != nil || !bytes.Equal(data, binary) {
+		t.Fatalf("download() = %q, %v, want the verified binary", data, err)
+	}
+
+	otherPublic, _, _ := ed25519.GenerateKey(nil)
+	u.signingKey = base64.StdEncoding.EncodeToString(otherPublic)
+	if _, err := u.download(release); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("download() with the wrong key error = %v, want a signature error", err)
+	}
+
+	u.signingKey, u.goos = "", "plan9"
+	if _, err := u.download(release); err == nil || !strings.Contains(err.Error(), "no binary for plan9/amd64") {
+		t.Errorf("download() for plan9 error = %v, want a missing binary error", err)
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("binary")
+	checksums := []byte(fmt.Sprintf("%x *kube-op_linux_amd64\n", sha256.Sum256(data)))
+
+	if err := VerifyChecksum(checksums, "kube-op_linux_amd64", data); err != nil {
+		t.Errorf("VerifyChecksum() = %v, want nil", err)
+	}
+	if err := VerifyChecksum(checksums, "kube-op_linux_amd64", []byte("tampered")); err == nil || !strings.Contains(err.Error(), "mismatch") {
+		t.Errorf("VerifyChecksum(tampered) = %v, want a mismatch", err)
+	}
+	if err := VerifyChecksum(checksums, "kube-op_linux_arm64", data); err == nil {
+		t.Error("VerifyChecksum(kube-op_linux_arm64) = nil, want an error for an unlisted binary")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"v1.10.0", "v1.9.3", 1},
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.2.3-rc.1", "v1.2.3", -1},
+		{"v2.0.0", "v2.0.1", -1},
+	} {
+		if got := compareVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("compareVersions(%s, %s) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestReplaceExecutable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kube-op")
+	if err := os.WriteFile(path, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := replaceExecutable(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	info, _ := os.Stat(path)
+	if string(data) != "new" || info.Mode().Perm() != 0o755 {
+		t.Errorf("replaced file = %q %v, want \"new\" with mode 0755", data, info.Mode())
+	}
+}
+
+func TestNotifyNewRelease(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(Release{Tag: "v1.3.0"})
+	}))
+	defer srv.Close()
+	u := &updater{client: srv.Client(), baseURL: srv.URL + "/releases"}
+	cacheFile := filepath.Join(t.TempDir(), "kube-op", "update-check.json")
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	defer func(v string) { version = v }(version)
+	version = "v1.2.0"
+
+	var out bytes.Buffer
+	notifyNewRelease(&out, u, cacheFile, now)
+	notifyNewRelease(&out, u, cacheFile, now.Add(time.Hour))
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1 with the cached result reused within a day", requests)
+	}
+	if strings.Count(out.String(), "kube-op v1.3.0 is available (running v1.2.0)") != 2 {
+		t.Errorf("notifyNewRelease() wrote %q, want the v1.3.0 notice twice", out.String())
+	}
+
+	out.Reset()
+	t.Setenv(noUpdateCheckEnv, "1")
+	notifyNewRelease(&out, u, cacheFile, now.Add(48*time.Hour))
+	if out.Len() != 0 || requests != 1 {
+		t.Errorf("notifyNewRelease() with %s wrote %q after %d requests, want no check", noUpdateCheckEnv, out.String(), requests)
+	}
+}
+
+func TestNotifyNewReleaseOffline(t *testing.T) {
+	defer func(v string) { version = v }(version)
+	version = "v1.2.0"
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	u := &updater{client: srv.Client(), baseURL: srv.URL + "/releases"}
+	cacheFile := filepath.Join(t.TempDir(), "update-check.json")
+
+	var out bytes.Buffer
+	notifyNewRelease(&out, u, cacheFile, time.Now())
+
+	var check updateCheck
+	data, _ := os.ReadFile(cacheFile)
+	if err := json.Unmarshal(data, &check); err != nil || check.CheckedAt.IsZero() || check.Latest != "" || out.Len() != 0 {
+		t.Errorf("notifyNewRelease() wrote %q and cached %+v (%v), want a silent failure recorded in the cache", out.String(), check, err)
+	}
+}
+
+func TestChecksForUpdates(t *testing.T) {
+	for command, want := range map[string]bool{
+		"scan": true, "baseline": true, "serve": false, "watch": false, "report": false, "version": false,
+	} {
+		if got := checksForUpdates(command); got != want {
+			t.Errorf("checksForUpdates(%q) = %v, want %v", command, got, want)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+)
+
+// serveShutdownTimeout bounds how long serve waits for in-flight requests when it stops.
+const serveShutdownTimeout = 10 * time.Second
+
+// Server runs scans on a schedule and serves the latest report and metrics over HTTP.
+type Server struct {
+	watcher *watcher
+	// next returns when the scan after t should run.
+	next func(t time.Time) time.Time
+	// stateFile, when set, persists the latest report so it survives restarts.
+	stateFile string
+	// allowRescan lets API clients trigger a scan with POST /api/v1/scan.
+	allowRescan bool
+	// apiToken, when set, is the bearer token POST /api/v1/scan requires.
+	apiToken string
+	// scanCtx is the context on-demand scans run with, so a client that disconnects does not
+	// cancel its scan. It defaults to context.Background.
+	scanCtx context.Context
+
+	// scanning is held while a scan runs, so scheduled and on-demand scans never overlap.
+	scanning sync.Mutex
+
+	mu           sync.RWMutex
+	last         *inspect.Report
+	lastDuration time.Duration
+	lastErr      error
+	nextScan     time.Time
+	scans        map[string]int
+}
+
+// NewServer returns a server that scans with w whenever next says to. When stateFile holds a
+// report from an earlier run, it is served until the first scan finishes and becomes the
+// baseline for notifications.
+func NewServer(w *watcher, next func(time.Time) time.Time, stateFile string) (*Server, error) {
+	s := &Server{watcher: w, next: next, stateFile: stateFile, scans: map[string]int{}}
+	if stateFile == "" {
+		return s, nil
+	}
+	report, err := loadReport(stateFile)
+	if err != nil {
+		return nil, err
+	}
+	if report != nil {
+		s.last = report
+		w.previous = report.Findings
+		w.previousReport = report
+		w.baselined = true
+	}
+	return s, nil
+}
+
+// Run scans immediately when there is no earlier report, then on every scheduled time, until
+// ctx is cancelled.
+func (s *Server) Run(ctx context.Context) {
+	if s.Last() == nil {
+		s.scanNow(ctx)
+	}
+	for {
+		next := s.next(time.Now())
+		s.mu.Lock()
+		s.nextScan = next
+		s.mu.Unlock()
+		log.Printf("Next scan at %s", next.Format(time.RFC3339))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+		s.scanNow(ctx)
+	}
+}
+
+// scanNow runs a scan, waiting for one already in progress to finish first.
+func (s *Server) scanNow(ctx context.Context) (*inspect.Report, error) {
+	s.scanning.Lock()
+	defer s.scanning.Unlock()
+	return s.scanLocked(ctx)
+}
+
+// scanLocked runs a scan and records its result. The caller holds s.scanning. Failed and
+// interrupted scans leave the latest report, and the state file, as they were.
+func (s *Server) scanLocked(ctx context.Context) (*inspect.Report, error) {
+	start := time.Now()
+	report, err := s.watcher.scan(ctx)
+
+	s.mu.Lock()
+	s.lastDuration = time.Since(start)
+	s.lastErr = err
+	if err != nil {
+		s.scans["failure"]++
+	} else {
+		s.scans["success"]++
+		s.last = report
+	}
+	s.mu.Unlock()
+
+	if err == nil && s.stateFile != "" {
+		if err := saveReport(s.stateFile, report); err != nil {
+			log.Printf("Failed to persist report: %v", err)
+		}
+	}
+	return report, err
+}
+
+// Last returns the latest successful report, or nil before the first one.
+func (s *Server) Last() *inspect.Report {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.last
+}
+
+// Handler serves /report (JSON, or text with ?format=text), /metrics in the Prometheus text
+// format, /healthz, and the JSON API under /api/v1.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	s.registerAPI(mux)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/report", func(w http.ResponseWriter, r *http.Request) {
+		report := s.Last()
+		if report == nil {
+			http.Error(w, "no scan has completed yet", http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Query().Get("format") == "text" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			inspect.PrintReport(w, report)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := inspect.WriteReportJSON(w, report); err != nil {
+			log.Printf("Failed to write report: %v", err)
+		}
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		s.writeMetrics(w)
+	})
+	return mux
+}
+
+// writeMetrics writes the scan counters and the latest report's findings and collector errors.
+func (s *Server) writeMetrics(w io.Writer) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fmt.Fprintln(w, "# HELP kube_op_scans_total Scans run since kube-op started, by result.")
+	fmt.Fprintln(w, "# TYPE kube_op_scans_total counter")
+	for _, result := range []string{"success", "failure"} {
+		fmt.Fprintf(w, "kube_op_scans_total{result=%q} %d\n", result, s.scans[result])
+	}
+	fmt.Fprintln(w, "# HELP kube_op_last_scan_success Whether the last scan attempt succeeded.")
+	fmt.Fprintln(w, "# TYPE kube_op_last_scan_success gauge")
+	success := 1
+	if s.lastErr != nil {
+		success = 0
+	}
+	fmt.Fprintf(w, "kube_op_last_scan_success %d\n", success)
+	fmt.Fprintln(w, "# HELP kube_op_last_scan_duration_seconds How long the last scan attempt took.")
+	fmt.Fprintln(w, "# TYPE kube_op_last_scan_duration_seconds gauge")
+	fmt.Fprintf(w, "kube_op_last_scan_duration_seconds %g\n", s.lastDuration.Seconds())
+	if !s.nextScan.IsZero() {
+		fmt.Fprintln(w, "# HELP kube_op_next_scan_timestamp_seconds When the next scan is scheduled.")
+		fmt.Fprintln(w, "# TYPE kube_op_next_scan_timestamp_seconds gauge")
+		fmt.Fprintf(w, "kube_op_next_scan_timestamp_seconds %d\n", s.nextScan.Unix())
+	}
+	if s.last == nil {
+		return
+	}
+
+	if c := s.last.Cluster; c != nil {
+		fmt.Fprintln(w, "# HELP kube_op_cluster_info The cluster the latest report was taken from.")
+		fmt.Fprintln(w, "# TYPE kube_op_cluster_info gauge")
+		fmt.Fprintf(w, "kube_op_cluster_info{cluster_id=\"%s\",name=\"%s\",server=\"%s\",provider=\"%s\"} 1\n",
+			metricLabel(c.ID), metricLabel(c.Name), metricLabel(c.Server), metricLabel(c.Provider))
+	}
+	fmt.Fprintln(w, "# HELP kube_op_last_report_timestamp_seconds When the latest report was generated.")
+	fmt.Fprintln(w, "# TYPE kube_op_last_report_timestamp_seconds gauge")
+	fmt.Fprintf(w, "kube_op_last_report_timestamp_seconds %d\n", s.last.GeneratedAt.Unix())
+
+	counts := map[[2]string]int{}
+	for _, f := range s.last.Findings {
+		counts[[2]string{f.CheckID, string(f.Severity)}]++
+	}
+	keys := make([][2]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	fmt.Fprintln(w, "# HELP kube_op_findings Findings in the latest report, by check and severity.")
+	fmt.Fprintln(w, "# TYPE kube_op_findings gauge")
+	for _, k := range keys {
+		fmt.Fprintf(w, "kube_op_findings{check_id=\"%s\",severity=\"%s\"} %d\n", metricLabel(k[0]), metricLabel(k[1]), counts[k])
+	}
+
+	sections := make([]string, 0, len(s.last.Errors))
+	for section := range s.last.Errors {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	fmt.Fprintln(w, "# HELP kube_op_collector_errors Report sections that could not be collected in the latest report.")
+	fmt.Fprintln(w, "# TYPE kube_op_collector_errors gauge")
+	for _, section := range sections {
+		fmt.Fprintf(w, "kube_op_collector_errors{section=\"%s\"} 1\n", metricLabel(section))
+	}
+}
+
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func metricLabel(value string) string {
+	return metricLabelEscaper.Replace(value)
+}
+
+// loadReport reads a report persisted by saveReport. A missing file is not an error.
+func loadReport(path string) (*inspect.Report, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	var report inspect.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	return &report, nil
+}
+
+// saveReport writes the report to path through a temporary file, so a crash never leaves a
+// truncated state file behind.
+func saveReport(path string, report *inspect.Report) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := inspect.WriteReportJSON(tmp, report); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
+
+func runServeCommand(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	var overrides inspect.ScanOptions
+	registerScanFlags(fs, &overrides)
+	schedule := fs.String("schedule", "", `cron expression for scans, such as "0 */6 * * *" (local time; overrides -interval)`)
+	interval := fs.Duration("interval", 10*time.Minute, "time between scans when -schedule is not set")
+	listen := fs.String("listen", ":8080", "address to serve /report, /metrics, and /healthz on")
+	stateFile := fs.String("state-file", "", "file to persist the latest report in, so it survives restarts")
+	notifyConfig := fs.String("notify-config", "", "notification config file (YAML) routing new findings to Slack, Teams, or webhooks")
+	allowRescan := fs.Bool("allow-rescan", false, "let API clients trigger a scan with POST /api/v1/scan (needs -api-token-file)")
+	apiTokenFile := fs.String("api-token-file", "", "file holding the bearer token POST /api/v1/scan requires")
+	fs.Parse(args)
+
+	if err := overrides.Probe.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	var apiToken string
+	if *allowRescan {
+		// Every on-demand scan lists the whole cluster, and probes when --probe is set, so it
+		// is never left open to anyone who can reach -listen.
+		if *apiTokenFile == "" {
+			log.Fatal("-allow-rescan needs -api-token-file")
+		}
+		data, err := os.ReadFile(*apiTokenFile)
+		if err != nil {
+			log.Fatalf("Failed to read API token: %v", err)
+		}
+		if apiToken = strings.TrimSpace(string(data)); apiToken == "" {
+			log.Fatalf("API token file %s is empty", *apiTokenFile)
+		}
+	}
+	loadDefaultPolicy(&overrides)
+	next := func(t time.Time) time.Time { return t.Add(*interval) }
+	if *schedule != "" {
+		cron, err := inspect.ParseCronSchedule(*schedule)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if cron.Next(time.Now()).IsZero() {
+			log.Fatalf("Schedule %q never runs", *schedule)
+		}
+		next = cron.Next
+	}
+	notifiers := loadNotifiers(*notifyConfig)
+
+	clientset, config, opts := connect(ctx, os.Stdout, overrides)
+	server, err := NewServer(&watcher{clientset: clientset, config: config, opts: opts, notifiers: notifiers}, next, *stateFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	server.allowRescan, server.apiToken = *allowRescan, apiToken
+	// On-demand scans run with ctx rather than their request's context, so shutting down
+	// cancels one in progress but a client disconnecting does not.
+	server.scanCtx = ctx
+	httpServer := &http.Server{Addr: *listen, Handler: server.Handler(), BaseContext: func(net.Listener) context.Context { return ctx }}
+	go func() {
+		log.Printf("Serving on %s", *listen)
+		if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+	server.Run(ctx)
+
+	log.Print("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down cleanly: %v", err)
+	}
+}
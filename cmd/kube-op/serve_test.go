@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+)
+
+func TestServerHandler(t *testing.T) {
+	s, err := NewServer(&watcher{}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := s.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/report", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /report before a scan = %d, want 503", rec.Code)
+	}
+
+	s.last = &inspect.Report{
+		Cluster:           &inspect.ClusterIdentity{ID: "1b4e28ba", Name: "prod-east", Server: "https://10.0.0.1:6443", Provider: "EKS"},
+		GeneratedAt:       time.Unix(1700000000, 0),
+		KubernetesVersion: "v1.31.2",
+		Errors:            map[string]string{"images": "forbidden"},
+		Findings: []inspect.Finding{
+			{CheckID: "node-not-ready", Severity: inspect.SeverityHigh},
+			{CheckID: "node-not-ready", Severity: inspect.SeverityHigh},
+			{CheckID: "job-no-ttl", Severity: inspect.SeverityLow},
+		},
+	}
+	s.scans["success"] = 2
+	s.scans["failure"] = 1
+	s.lastErr = errors.New("timeout")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/report", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"kubernetesVersion": "v1.31.2"`) {
+		t.Errorf("GET /report = %d %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/report?format=text", nil))
+	if !strings.HasPrefix(rec.Body.String(), "Cluster: prod-east (ID 1b4e28ba)\n") || !strings.Contains(rec.Body.String(), "Kubernetes API server version: v1.31.2") {
+		t.Errorf("GET /report?format=text = %s", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`kube_op_scans_total{result="success"} 2`,
+		`kube_op_scans_total{result="failure"} 1`,
+		"kube_op_last_scan_success 0",
+		"kube_op_last_report_timestamp_seconds 1700000000",
+		`kube_op_cluster_info{cluster_id="1b4e28ba",name="prod-east",server="https://10.0.0.1:6443",provider="EKS"} 1`,
+		`kube_op_findings{check_id="node-not-ready",severity="high"} 2`,
+		`kube_op_findings{check_id="job-no-ttl",severity="low"} 1`,
+		`kube_op_collector_errors{section="images"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, rec.Body)
+		}
+	}
+}
+
+func TestServerStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	s, err := NewServer(&watcher{}, nil, path)
+	if err != nil {
+		t.Fatalf("NewServer() with missing state file: %v", err)
+	}
+	if s.Last() != nil {
+		t.Fatal("Last() is set without a state file")
+	}
+
+	findings := inspect.FinalizeFindings([]inspect.Finding{{CheckID: "node-not-ready", Kind: "Node", Name: "n1"}})
+	if err := saveReport(path, &inspect.Report{KubernetesVersion: "v1.31.2", Findings: findings}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &watcher{}
+	s, err = NewServer(w, nil, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Last() == nil || s.Last().KubernetesVersion != "v1.31.2" {
+		t.Fatalf("Last() = %+v, want the persisted report", s.Last())
+	}
+	if !w.baselined || w.previousReport != s.Last() {
+		t.Error("persisted report did not become the notification baseline")
+	}
+	if added := inspect.NewFindings(w.previous, findings); len(added) != 0 {
+		t.Errorf("NewFindings() against the persisted baseline = %v, want none", added)
+	}
+}
+
+func TestServerRunStopsOnCancel(t *testing.T) {
+	s, err := NewServer(&watcher{}, func(t time.Time) time.Time { return t.Add(time.Hour) }, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// With a report already loaded, Run goes straight to waiting for the next scan.
+	s.last = &inspect.Report{KubernetesVersion: "v1.31.2"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after its context was cancelled")
+	}
+	if s.Last().KubernetesVersion != "v1.31.2" {
+		t.Errorf("Last() = %+v, want the earlier report kept", s.Last())
+	}
+}
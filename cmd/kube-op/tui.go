@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nazufel/kube-op/pkg/inspect"
+)
+
+// tuiPanels are the panels of the terminal UI, in tab order.
+var tuiPanels = []string{"Versions", "Nodes", "Endpoints", "Findings"}
+
+// tuiChromeLines is the number of screen lines taken by the tab bar, status, and help.
+const tuiChromeLines = 4
+
+type scanDoneMsg struct {
+	report *inspect.Report
+	err    error
+}
+
+type refreshTickMsg struct{}
+
+// tuiModel is the bubbletea model of `kube-op tui`.
+type tuiModel struct {
+	scan    func() (*inspect.Report, error)
+	refresh time.Duration
+
+	report   *inspect.Report
+	scanErr  error
+	scanning bool
+
+	panel  int
+	cursor int
+	offset int
+	height int
+	width  int
+
+	filter    string
+	filtering bool
+}
+
+func newTUIModel(scan func() (*inspect.Report, error), refresh time.Duration) tuiModel {
+	return tuiModel{scan: scan, refresh: refresh, scanning: true, height: 24, width: 80}
+}
+
+func (m tuiModel) Init() tea.Cmd {
+	return m.scanCmd()
+}
+
+func (m tuiModel) scanCmd() tea.Cmd {
+	scan := m.scan
+	return func() tea.Msg {
+		report, err := scan()
+		return scanDoneMsg{report, err}
+	}
+}
+
+func (m tuiModel) tickCmd() tea.Cmd {
+	if m.refresh <= 0 {
+		return nil
+	}
+	return tea.Tick(m.refresh, func(time.Time) tea.Msg { return refreshTickMsg{} })
+}
+
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case scanDoneMsg:
+		m.scanning = false
+		m.scanErr = msg.err
+		if msg.err == nil {
+			m.report = msg.report
+		}
+		m.clampCursor()
+		return m, m.tickCmd()
+	case refreshTickMsg:
+		if m.scanning {
+			return m, nil
+		}
+		m.scanning = true
+		return m, m.scanCmd()
+	case tea.KeyMsg:
+		if m.filtering {
+			return m.updateFilter(msg), nil
+		}
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "tab", "right", "l":
+			m.switchPanel(m.panel + 1)
+		case "shift+tab", "left", "h":
+			m.switchPanel(m.panel - 1)
+		case "1", "2", "3", "4":
+			m.switchPanel(int(msg.String()[0] - '1'))
+		case "down", "j":
+			m.cursor++
+		case "up", "k":
+			m.cursor--
+		case "pgdown", " ":
+			m.cursor += m.bodyHeight()
+		case "pgup":
+			m.cursor -= m.bodyHeight()
+		case "/":
+			m.filtering = true
+		case "esc":
+			m.filter = ""
+		case "r":
+			if !m.scanning {
+				m.scanning = true
+				return m, m.scanCmd()
+			}
+		}
+		m.clampCursor()
+	}
+	return m, nil
+}
+
+func (m tuiModel) updateFilter(msg tea.KeyMsg) tuiModel {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.filtering = false
+	case tea.KeyEsc:
+		m.filtering = false
+		m.filter = ""
+	case tea.KeyBackspace:
+		if len(m.filter) > 0 {
+			m.filter = m.filter[:len(m.filter)-1]
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.filter += string(msg.Runes)
+	}
+	m.cursor, m.offset = 0, 0
+	return m
+}
+
+func (m *tuiModel) switchPanel(panel int) {
+	m.panel = (panel + len(tuiPanels)) % len(tuiPanels)
+	m.cursor, m.offset = 0, 0
+}
+
+func (m tuiModel) bodyHeight() int {
+	return max(m.height-tuiChromeLines, 1)
+}
+
+// clampCursor keeps the cursor on a line and scrolls the view to it.
+func (m *tuiModel) clampCursor() {
+	n := len(m.lines())
+	m.cursor = min(max(m.cursor, 0), max(n-1, 0))
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.bodyHeight() {
+		m.offset = m.cursor - m.bodyHeight() + 1
+	}
+}
+
+// lines returns the current panel's lines that match the filter.
+func (m tuiModel) lines() []string {
+	if m.report == nil {
+		return nil
+	}
+	all := tuiPanelLines(m.report, tuiPanels[m.panel])
+	if m.filter == "" {
+		return all
+	}
+	var matched []string
+	needle := strings.ToLower(m.filter)
+	for _, line := range all {
+		if strings.Contains(strings.ToLower(line), needle) {
+			matched = append(matched, line)
+		}
+	}
+	return matched
+}
+
+func (m tuiModel) View() string {
+	var b strings.Builder
+	for i, name := range tuiPanels {
+		label := fmt.Sprintf(" %d %s ", i+1, name)
+		if i == m.panel {
+			label = "[" + label + "]"
+		} else {
+			label = " " + label + " "
+		}
+		b.WriteString(label)
+	}
+	b.WriteString("\n")
+
+	lines := m.lines()
+	switch {
+	case m.report == nil && m.scanning:
+		b.WriteString("Scanning...\n")
+	case m.report == nil:
+		fmt.Fprintf(&b, "Scan failed: %v\n", m.scanErr)
+	case len(lines) == 0:
+		b.WriteString("Nothing to show.\n")
+	}
+	end := min(m.offset+m.bodyHeight(), len(lines))
+	for i := m.offset; i < end; i++ {
+		prefix := "  "
+		if i == m.cursor {
+			prefix = "> "
+		}
+		b.WriteString(truncate(prefix+lines[i], m.width) + "\n")
+	}
+
+	status := "no scan yet"
+	if m.report != nil {
+		status = fmt.Sprintf("scanned %s, %d finding(s)", m.report.GeneratedAt.Format(time.TimeOnly), len(m.report.Findings))
+	}
+	if m.scanning {
+		status += " - scanning..."
+	} else if m.scanErr != nil && m.report != nil {
+		status += " - last refresh failed: " + m.scanErr.Error()
+	}
+	if m.filter != "" || m.filtering {
+		status += fmt.Sprintf(" - filter: %s", m.filter)
+		if m.filtering {
+			status += "_"
+		}
+	}
+	b.WriteString(truncate(status, m.width) + "\n")
+	b.WriteString("tab/1-4 panel  j/k scroll  / filter  esc clear  r refresh  q quit")
+	return b.String()
+}
+
+func truncate(s string, width int) string {
+	if width <= 0 || len([]rune(s)) <= width {
+		return s
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+// tuiPanelLines renders one panel of the report as lines.
+func tuiPanelLines(report *inspect.Report, panel string) []string {
+	var lines []string
+	switch panel {
+	case "Versions":
+		if c := report.Cluster; c != nil {
+			lines = append(lines, "Cluster: "+c.String())
+			if c.Server != "" {
+				lines = append(lines, "API server URL: "+c.Server)
+			}
+		}
+		lines = append(lines, "Kubernetes API server: "+report.KubernetesVersion)
+		if p := report.Platform; p != nil {
+			lines = append(lines, "Platform: "+p.String())
+		}
+		if r := report.Release; r != nil {
+			lines = append(lines, fmt.Sprintf("Release: %s %s, %s support, standard support ends %s, end of life %s",
+				r.Platform, r.Minor, r.SupportTier, r.StandardSupportEnd.Format(time.DateOnly), r.EndOfLife.Format(time.DateOnly)))
+		}
+		if report.EtcdVersion != "" {
+			lines = append(lines, "etcd: "+report.EtcdVersion)
+		}
+		if report.NodeVersions != "" {
+			lines = append(lines, "Nodes: "+report.NodeVersions)
+		}
+		sections := make([]string, 0, len(report.Errors))
+		for section := range report.Errors {
+			sections = append(sections, section)
+		}
+		sort.Strings(sections)
+		for _, section := range sections {
+			lines = append(lines, fmt.Sprintf("Error (%s): %s", section, report.Errors[section]))
+		}
+	case "Nodes":
+		notReady := map[string]bool{}
+		for _, f := range report.Findings {
+			if f.CheckID == "node-not-ready" {
+				notReady[f.Name] = true
+			}
+		}
+		if report.Utilization != nil {
+			for _, n := range report.Utilization.Nodes {
+				line := n.String()
+				if notReady[n.Name] {
+					line += " - NotReady"
+					delete(notReady, n.Name)
+				}
+				lines = append(lines, line)
+			}
+		}
+		// Nodes missing from the utilization data are still listed when they are not Ready.
+		for _, f := range report.Findings {
+			if f.CheckID == "node-not-ready" && notReady[f.Name] {
+				lines = append(lines, f.Message)
+			}
+		}
+	case "Endpoints":
+		for _, e := range report.ExposedEndpoints {
+			lines = append(lines, e.String())
+		}
+	case "Findings":
+		for _, f := range report.Findings {
+			message := f.Message
+			if f.Owner != nil {
+				message = f.Owner.String() + ": " + message
+			}
+			lines = append(lines, fmt.Sprintf("[%s] %s: %s", f.Severity, f.CheckID, message))
+		}
+	}
+	return lines
+}
+
+func runTUICommand(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	var overrides inspect.ScanOptions
+	registerScanFlags(fs, &overrides)
+	refresh := fs.Duration("refresh", time.Minute, "time between automatic rescans (0 = only on r)")
+	fs.Parse(args)
+
+	if err := overrides.Probe.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	loadDefaultPolicy(&overrides)
+	clientset, config, opts := connect(ctx, os.Stderr, overrides)
+	scan := func() (*inspect.Report, error) { return inspect.RunScan(ctx, clientset, config, opts) }
+
+	_, err := tea.NewProgram(newTUIModel(scan, *refresh), tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Fatalf("Terminal UI failed: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nazufel/kube-op/pkg/inspect"
+)
+
+func testTUIReport() *inspect.Report {
+	return &inspect.Report{
+		GeneratedAt:       time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		KubernetesVersion: "v1.31.2",
+		Platform:          &inspect.PlatformInfo{Name: inspect.PlatformKubeadm},
+		Utilization: &inspect.Utilization{Nodes: []inspect.NodeUsage{
+			{Name: "n1", CPUMillis: 500, CPUAllocatableMillis: 1000, MemoryBytes: 1 << 30, MemoryAllocatableBytes: 2 << 30},
+		}},
+		ExposedEndpoints: []inspect.ExposedEndpoint{{Type: inspect.ExposureNodePort, Namespace: "web", Name: "api"}},
+		Findings: []inspect.Finding{
+			{CheckID: "node-not-ready", Severity: inspect.SeverityHigh, Kind: "Node", Name: "n1", Message: "node n1 is NotReady"},
+			{CheckID: "node-not-ready", Severity: inspect.SeverityHigh, Kind: "Node", Name: "n2", Message: "node n2 is NotReady"},
+			{CheckID: "job-no-ttl", Severity: inspect.SeverityLow, Message: "Job batch/migrate finished"},
+		},
+	}
+}
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func update(m tuiModel, msgs ...tea.Msg) tuiModel {
+	for _, msg := range msgs {
+		next, _ := m.Update(msg)
+		m = next.(tuiModel)
+	}
+	return m
+}
+
+func TestTUIPanelLines(t *testing.T) {
+	report := testTUIReport()
+
+	versions := strings.Join(tuiPanelLines(report, "Versions"), "\n")
+	if !strings.Contains(versions, "Kubernetes API server: v1.31.2") || !strings.Contains(versions, "Platform: kubeadm") {
+		t.Errorf("Versions panel:\n%s", versions)
+	}
+
+	nodes := tuiPanelLines(report, "Nodes")
+	if len(nodes) != 2 || !strings.HasSuffix(nodes[0], "- NotReady") || nodes[1] != "node n2 is NotReady" {
+		t.Errorf("Nodes panel = %q", nodes)
+	}
+
+	if endpoints := tuiPanelLines(report, "Endpoints"); len(endpoints) != 1 || !strings.Contains(endpoints[0], "web/api") {
+		t.Errorf("Endpoints panel = %q", endpoints)
+	}
+	if findings := tuiPanelLines(report, "Findings"); len(findings) != 3 || findings[2] != "[low] job-no-ttl: Job batch/migrate finished" {
+		t.Errorf("Findings panel = %q", findings)
+	}
+}
+
+func TestTUIModel(t *testing.T) {
+	m := newTUIModel(func() (*inspect.Report, error) { return testTUIReport(), nil }, time.Minute)
+	if !strings.Contains(m.View(), "Scanning...") {
+		t.Errorf("initial view:\n%s", m.View())
+	}
+
+	m = update(m, scanDoneMsg{report: testTUIReport()})
+	if m.scanning || m.report == nil {
+		t.Fatal("scan result not applied")
+	}
+
+	m = update(m, key("4"))
+	if tuiPanels[m.panel] != "Findings" || len(m.lines()) != 3 {
+		t.Fatalf("panel %s with %d lines, want Findings with 3", tuiPanels[m.panel], len(m.lines()))
+	}
+
+	m = update(m, key("j"), key("j"), key("j"))
+	if m.cursor != 2 {
+		t.Errorf("cursor = %d after scrolling past the end, want 2", m.cursor)
+	}
+
+	m = update(m, key("/"), key("t"), key("t"), key("l"), key("enter"))
+	if m.filtering || m.filter != "ttl" || len(m.lines()) != 1 || m.cursor != 0 {
+		t.Errorf("filter %q gave %d lines, cursor %d", m.filter, len(m.lines()), m.cursor)
+	}
+	if !strings.Contains(m.View(), "filter: ttl") {
+		t.Errorf("view does not show the filter:\n%s", m.View())
+	}
+
+	m = update(m, key("esc"), key("tab"))
+	if m.filter != "" || tuiPanels[m.panel] != "Versions" {
+		t.Errorf("after esc and tab: filter %q, panel %s", m.filter, tuiPanels[m.panel])
+	}
+
+	m = update(m, refreshTickMsg{})
+	if !m.scanning {
+		t.Error("refresh tick did not start a scan")
+	}
+	m = update(m, scanDoneMsg{err: errors.New("connection refused")})
+	if m.report == nil || !strings.Contains(m.View(), "last refresh failed: connection refused") {
+		t.Errorf("failed refresh should keep the last report and show the error:\n%s", m.View())
+	}
+
+	if _, cmd := m.Update(key("q")); cmd == nil {
+		t.Error("q did not quit")
+	}
+}
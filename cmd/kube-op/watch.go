@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nazufel/kube-op/pkg/inspect"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Watch rescans the cluster every interval and notifies about findings that were not present
+// in the previous scan. The first scan only establishes the baseline. It returns once ctx is
+// cancelled, after logging what the interrupted scan, if any, had collected.
+func Watch(ctx context.Context, clientset *kubernetes.Clientset, config *rest.Config, opts inspect.ScanOptions, interval time.Duration, notifiers []*inspect.Notifier) {
+	w := &watcher{clientset: clientset, config: config, opts: opts, notifiers: notifiers}
+	for {
+		w.scan(ctx)
+		select {
+		case <-ctx.Done():
+			log.Print("Watch stopped")
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// watcher runs scans and notifies about findings that were not present in the previous one,
+// and about exposed endpoints that appeared since.
+type watcher struct {
+	clientset *kubernetes.Clientset
+	config    *rest.Config
+	opts      inspect.ScanOptions
+	notifiers []*inspect.Notifier
+
+	previous       []inspect.Finding
+	previousReport *inspect.Report
+	baselined      bool
+}
+
+// scan runs one scan and sends notifications for its new findings. The first successful scan
+// only establishes the baseline unless previous was seeded from an earlier run. An interrupted
+// scan returns its partial report with an error: the findings it did not get to would otherwise
+// look resolved, so it neither notifies nor becomes the baseline.
+func (w *watcher) scan(ctx context.Context) (*inspect.Report, error) {
+	report, err := inspect.RunScan(ctx, w.clientset, w.config, w.opts)
+	if err != nil {
+		log.Printf("Scan failed: %v", err)
+		return nil, err
+	}
+	if report.Interrupted {
+		log.Printf("Scan interrupted: partial report with %d finding(s) and %d section error(s)", len(report.Findings), len(report.Errors))
+		return report, fmt.Errorf("scan interrupted: %w", ctx.Err())
+	}
+	if w.baselined {
+		added := inspect.NewFindings(w.previous, report.Findings)
+		appeared := inspect.NewEndpoints(w.previousReport, report)
+		for _, e := range appeared {
+			log.Printf("New exposed endpoint: %s", e)
+		}
+		added = append(added, inspect.EndpointChangeFindings(appeared)...)
+		log.Printf("Scan complete: %d finding(s), %d new, %d new endpoint(s)", len(report.Findings), len(added)-len(appeared), len(appeared))
+		if err := inspect.NotifyAll(w.notifiers, added); err != nil {
+			log.Printf("Failed to send notifications: %v", err)
+		}
+	} else {
+		log.Printf("Baseline scan complete: %d finding(s)", len(report.Findings))
+	}
+	w.previous = report.Findings
+	w.previousReport = report
+	w.baselined = true
+	return report, nil
+}
+
+func runWatchCommand(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	var overrides inspect.ScanOptions
+	registerScanFlags(fs, &overrides)
+	interval := fs.Duration("interval", 10*time.Minute, "time between scans")
+	notifyConfig := fs.String("notify-config", "", "notification config file (YAML) routing new findings to Slack, Teams, or webhooks")
+	fs.Parse(args)
+
+	if err := overrides.Probe.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	loadDefaultPolicy(&overrides)
+
+	notifiers := loadNotifiers(*notifyConfig)
+	clientset, config, opts := connect(ctx, os.Stdout, overrides)
+	Watch(ctx, clientset, config, opts, *interval, notifiers)
+}
+
+// loadNotifiers builds the notifiers in the config file, exiting on errors. An empty path means
+// no notifications.
+func loadNotifiers(path string) []*inspect.Notifier {
+	if path == "" {
+		return nil
+	}
+	config, err := inspect.LoadNotificationConfig(path)
+	if err != nil {
+		log.Fatalf("Failed to load notification config: %v", err)
+	}
+	notifiers, err := inspect.NewNotifiers(config)
+	if err != nil {
+		log.Fatalf("Invalid notification config: %v", err)
+	}
+	return notifiers
+}
@@ -0,0 +1,82 @@
+// Package client builds Kubernetes clients from the kubeconfig, tuned for kube-op's
+// concurrent collectors.
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/homedir"
+)
+
+// NewRESTConfigFromKubeconfig loads the REST config for the current-context from the default kubeconfig.
+func NewRESTConfigFromKubeconfig() (*rest.Config, error) {
+	// Load Kubernetes configuration from the kubeconfig file, using the current context.
+	return clientcmd.BuildConfigFromFlags("", KubeconfigPath())
+}
+
+// CurrentClusterName returns the name of the cluster the current-context of the default
+// kubeconfig points at.
+func CurrentClusterName() (string, error) {
+	config, err := clientcmd.LoadFromFile(KubeconfigPath())
+	if err != nil {
+		return "", fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	context, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return "", fmt.Errorf("current-context %q not found in kubeconfig", config.CurrentContext)
+	}
+	return context.Cluster, nil
+}
+
+// CurrentContextName returns the current-context of the default kubeconfig.
+func CurrentContextName() (string, error) {
+	config, err := clientcmd.LoadFromFile(KubeconfigPath())
+	if err != nil {
+		return "", fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	return config.CurrentContext, nil
+}
+
+// KubeconfigPath is the default kubeconfig: $KUBECONFIG, or ~/.kube/config.
+func KubeconfigPath() string {
+	if kubeconfigEnv := os.Getenv("KUBECONFIG"); kubeconfigEnv != "" {
+		return kubeconfigEnv
+	}
+	if home := homedir.HomeDir(); home != "" {
+		return filepath.Join(home, ".kube", "config")
+	}
+	return ""
+}
+
+// NewClientFromKubeconfig creates a new Kubernetes clientset using the current-context from the default kubeconfig.
+func NewClientFromKubeconfig() (*kubernetes.Clientset, error) {
+	config, err := NewRESTConfigFromKubeconfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the Kubernetes clientset.
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return clientset, nil
+}
+
+// NewTunedClient creates a clientset from config whose request timeout and client-side
+// rate limits are sized for the given number of concurrent collectors.
+func NewTunedClient(config *rest.Config, timeout time.Duration, concurrency int) (*kubernetes.Clientset, error) {
+	tuned := rest.CopyConfig(config)
+	tuned.Timeout = timeout
+	// client-go defaults to 5 QPS / 10 burst, which would serialize parallel collectors.
+	tuned.QPS = float32(5 * concurrency)
+	tuned.Burst = 10 * concurrency
+	return kubernetes.NewForConfig(tuned)
+}
@@ -1,4 +1,4 @@
-package main
+package client
 
 import (
 	"os"
@@ -105,3 +105,35 @@ func TestNewClientFromKubeconfig_NoKubeconfigPath(t *testing.T) {
 		t.Errorf("NewClientFromKubeconfig() with no kubeconfig path returned clientset != nil, want nil")
 	}
 }
+
+func TestCurrentClusterName(t *testing.T) {
+	kubeconfigFile := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfigFile, []byte(validKubeconfigContent), 0600); err != nil {
+		t.Fatalf("Failed to write temp kubeconfig: %v", err)
+	}
+	t.Setenv("KUBECONFIG", kubeconfigFile)
+
+	name, err := CurrentClusterName()
+	if err != nil {
+		t.Fatalf("CurrentClusterName() returned error = %v, want nil", err)
+	}
+	if name != "fake-cluster" {
+		t.Errorf("CurrentClusterName() = %q, want %q", name, "fake-cluster")
+	}
+}
+
+func TestCurrentContextName(t *testing.T) {
+	kubeconfigFile := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfigFile, []byte(validKubeconfigContent), 0600); err != nil {
+		t.Fatalf("Failed to write temp kubeconfig: %v", err)
+	}
+	t.Setenv("KUBECONFIG", kubeconfigFile)
+
+	name, err := CurrentContextName()
+	if err != nil {
+		t.Fatalf("CurrentContextName() returned error = %v, want nil", err)
+	}
+	if name != "fake-context" {
+		t.Errorf("CurrentContextName() = %q, want %q", name, "fake-context")
+	}
+}
@@ -0,0 +1,201 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Classes of an exposed address.
+const (
+	AddressPublic  = "public"
+	AddressPrivate = "private"
+	AddressUnknown = "unknown"
+)
+
+// sharedAddressSpace is the RFC 6598 carrier-grade NAT range, which netip does not count as private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// internalLoadBalancerAnnotations are the cloud provider annotations that make a Service's load
+// balancer internal, with the value that enables them. An empty value means any value but "false".
+var internalLoadBalancerAnnotations = map[string]string{
+	"service.beta.kubernetes.io/aws-load-balancer-internal":   "",
+	"service.beta.kubernetes.io/aws-load-balancer-scheme":     "internal",
+	"networking.gke.io/load-balancer-type":                    "Internal",
+	"cloud.google.com/load-balancer-type":                     "Internal",
+	"service.beta.kubernetes.io/azure-load-balancer-internal": "true",
+	"service.beta.kubernetes.io/oci-load-balancer-internal":   "true",
+}
+
+// ClassifiedAddress is an exposed address and whether it is reachable from the internet.
+type ClassifiedAddress struct {
+	Address string `json:"address"`
+	// Class is public, private, or unknown.
+	Class string `json:"class"`
+	// Reason is what the class is based on.
+	Reason string `json:"reason"`
+}
+
+func (a ClassifiedAddress) String() string {
+	return fmt.Sprintf("%s (%s: %s)", a.Address, a.Class, a.Reason)
+}
+
+// lookupFunc resolves a hostname to its addresses.
+type lookupFunc func(host string) ([]netip.Addr, error)
+
+// newLookup returns a DNS lookup bounded by timeout and ctx, or nil when resolve is false.
+func newLookup(ctx context.Context, resolve bool, timeout time.Duration) lookupFunc {
+	if !resolve {
+		return nil
+	}
+	return func(host string) ([]netip.Addr, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	}
+}
+
+// ClassifyIP classifies an IP by its range. Private covers RFC 1918, IPv6 unique local
+// addresses (fc00::/7), loopback, link-local, and the carrier-grade NAT range.
+func ClassifyIP(addr netip.Addr) (class, reason string) {
+	addr = addr.Unmap()
+	switch {
+	case addr.IsPrivate() && addr.Is4():
+		return AddressPrivate, "RFC 1918 range"
+	case addr.IsPrivate():
+		return AddressPrivate, "unique local address"
+	case addr.IsLoopback():
+		return AddressPrivate, "loopback"
+	case addr.IsLinkLocalUnicast():
+		return AddressPrivate, "link-local"
+	case sharedAddressSpace.Contains(addr):
+		return AddressPrivate, "carrier-grade NAT range"
+	case addr.IsGlobalUnicast():
+		return AddressPublic, "globally routable"
+	default:
+		return AddressUnknown, "not a unicast address"
+	}
+}
+
+// ClassifyAddress classifies an IP or hostname. IPs are classified by range. Hostnames are
+// private when internalHint names the annotation that made the load balancer internal or the
+// name is an internal AWS ELB; otherwise they are classified by what lookup resolves them to,
+// and are unknown when lookup is nil.
+func ClassifyAddress(address, internalHint string, lookup lookupFunc) ClassifiedAddress {
+	c := ClassifiedAddress{Address: address}
+	if addr, err := netip.ParseAddr(address); err == nil {
+		c.Class, c.Reason = ClassifyIP(addr)
+		return c
+	}
+
+	switch {
+	case internalHint != "":
+		c.Class, c.Reason = AddressPrivate, "internal load balancer ("+internalHint+")"
+	case strings.HasPrefix(address, "internal-") && strings.HasSuffix(address, ".elb.amazonaws.com"):
+		c.Class, c.Reason = AddressPrivate, "internal AWS load balancer name"
+	case lookup == nil:
+		c.Class, c.Reason = AddressUnknown, "hostname not resolved"
+	default:
+		addrs, err := lookup(address)
+		if err != nil || len(addrs) == 0 {
+			c.Class, c.Reason = AddressUnknown, "hostname did not resolve"
+			return c
+		}
+		c.Class = AddressPrivate
+		for _, addr := range addrs {
+			if class, _ := ClassifyIP(addr); class == AddressPublic {
+				c.Class = AddressPublic
+				break
+			}
+		}
+		c.Reason = "resolves to " + joinAddrs(addrs)
+	}
+	return c
+}
+
+// internalLoadBalancer returns the annotation that makes a Service's load balancer internal.
+func internalLoadBalancer(annotations map[string]string) string {
+	for key, want := range internalLoadBalancerAnnotations {
+		value, ok := annotations[key]
+		if !ok {
+			continue
+		}
+		if (want == "" && value != "false") || strings.EqualFold(value, want) {
+			return key
+		}
+	}
+	return ""
+}
+
+// ClassifyNodeAddresses classifies the distinct InternalIP and ExternalIP addresses of the nodes.
+func ClassifyNodeAddresses(nodes []corev1.Node) []ClassifiedAddress {
+	seen := map[string]bool{}
+	var addresses []ClassifiedAddress
+	for _, node := range nodes {
+		for _, a := range node.Status.Addresses {
+			if (a.Type != corev1.NodeInternalIP && a.Type != corev1.NodeExternalIP) || seen[a.Address] {
+				continue
+			}
+			seen[a.Address] = true
+			addresses = append(addresses, ClassifyAddress(a.Address, "", nil))
+		}
+	}
+	return addresses
+}
+
+// ClassifyEndpoints fills in the address classes of each endpoint. NodePort endpoints take the
+// public node addresses, since the port is open on every node.
+func ClassifyEndpoints(endpoints []ExposedEndpoint, nodeAddresses []ClassifiedAddress, lookup lookupFunc) {
+	var publicNodes []ClassifiedAddress
+	for _, a := range nodeAddresses {
+		if a.Class == AddressPublic {
+			publicNodes = append(publicNodes, a)
+		}
+	}
+	for i := range endpoints {
+		e := &endpoints[i]
+		if e.Type == ExposureNodePort {
+			e.Classified = publicNodes
+			e.Exposure = AddressPrivate
+			if len(publicNodes) > 0 {
+				e.Exposure = AddressPublic
+			}
+			continue
+		}
+		e.Classified = nil
+		for _, address := range e.Addresses {
+			e.Classified = append(e.Classified, ClassifyAddress(address, e.internalHint, lookup))
+		}
+		e.Exposure = overallExposure(e.Classified)
+	}
+}
+
+// overallExposure is public if any address is public, private if all are, and unknown otherwise.
+func overallExposure(addresses []ClassifiedAddress) string {
+	if len(addresses) == 0 {
+		return AddressUnknown
+	}
+	exposure := AddressPrivate
+	for _, a := range addresses {
+		switch a.Class {
+		case AddressPublic:
+			return AddressPublic
+		case AddressUnknown:
+			exposure = AddressUnknown
+		}
+	}
+	return exposure
+}
+
+func joinAddrs(addrs []netip.Addr) string {
+	s := make([]string, len(addrs))
+	for i, a := range addrs {
+		s[i] = a.String()
+	}
+	return strings.Join(s, ", ")
+}
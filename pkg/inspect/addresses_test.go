@@ -0,0 +1,107 @@
+package inspect
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClassifyAddress(t *testing.T) {
+	lookup := func(host string) ([]netip.Addr, error) {
+		switch host {
+		case "public.example.com":
+			return []netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("198.51.100.7")}, nil
+		case "private.example.com":
+			return []netip.Addr{netip.MustParseAddr("10.0.0.1")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	tests := []struct {
+		address string
+		hint    string
+		lookup  lookupFunc
+		want    string
+	}{
+		{"203.0.113.10", "", nil, AddressPublic},
+		{"2001:db8::1", "", nil, AddressPublic},
+		{"10.1.2.3", "", nil, AddressPrivate},
+		{"192.168.0.10", "", nil, AddressPrivate},
+		{"fd00::1", "", nil, AddressPrivate},
+		{"100.64.0.1", "", nil, AddressPrivate},
+		{"169.254.1.1", "", nil, AddressPrivate},
+		{"abc.elb.amazonaws.com", "", nil, AddressUnknown},
+		{"internal-abc.us-east-1.elb.amazonaws.com", "", nil, AddressPrivate},
+		{"lb.example.com", "networking.gke.io/load-balancer-type", nil, AddressPrivate},
+		{"public.example.com", "", lookup, AddressPublic},
+		{"private.example.com", "", lookup, AddressPrivate},
+		{"missing.example.com", "", lookup, AddressUnknown},
+	}
+	for _, tt := range tests {
+		if got := ClassifyAddress(tt.address, tt.hint, tt.lookup); got.Class != tt.want {
+			t.Errorf("ClassifyAddress(%q) = %s, want %s", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestInternalLoadBalancer(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		want        string
+	}{
+		{map[string]string{"service.beta.kubernetes.io/aws-load-balancer-internal": "0.0.0.0/0"}, "service.beta.kubernetes.io/aws-load-balancer-internal"},
+		{map[string]string{"service.beta.kubernetes.io/aws-load-balancer-internal": "false"}, ""},
+		{map[string]string{"service.beta.kubernetes.io/aws-load-balancer-scheme": "internet-facing"}, ""},
+		{map[string]string{"networking.gke.io/load-balancer-type": "Internal"}, "networking.gke.io/load-balancer-type"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := internalLoadBalancer(tt.annotations); got != tt.want {
+			t.Errorf("internalLoadBalancer(%v) = %q, want %q", tt.annotations, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyEndpoints(t *testing.T) {
+	nodes := []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a"}, Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+			{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+			{Type: corev1.NodeExternalIP, Address: "198.51.100.1"},
+			{Type: corev1.NodeHostName, Address: "a"},
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b"}, Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+			{Type: corev1.NodeInternalIP, Address: "10.0.0.2"},
+		}}},
+	}
+	nodeAddresses := ClassifyNodeAddresses(nodes)
+	if len(nodeAddresses) != 3 {
+		t.Fatalf("ClassifyNodeAddresses() = %v, want 3 addresses", nodeAddresses)
+	}
+
+	endpoints := []ExposedEndpoint{
+		{Type: ExposureLoadBalancer, Addresses: []string{"10.0.0.9", "203.0.113.4"}},
+		{Type: ExposureLoadBalancer, Addresses: []string{"10.0.0.9"}},
+		{Type: ExposureIngress, Addresses: []string{"lb.example.com"}},
+		{Type: ExposureIngress},
+		{Type: ExposureNodePort},
+	}
+	ClassifyEndpoints(endpoints, nodeAddresses, nil)
+
+	want := []string{AddressPublic, AddressPrivate, AddressUnknown, AddressUnknown, AddressPublic}
+	for i, e := range endpoints {
+		if e.Exposure != want[i] {
+			t.Errorf("endpoint %d exposure = %s, want %s", i, e.Exposure, want[i])
+		}
+	}
+	if n := endpoints[4].Classified; len(n) != 1 || n[0].Address != "198.51.100.1" {
+		t.Errorf("NodePort classified addresses = %v, want the public node address", n)
+	}
+
+	ClassifyEndpoints(endpoints[4:], ClassifyNodeAddresses(nodes[1:]), nil)
+	if endpoints[4].Exposure != AddressPrivate {
+		t.Errorf("NodePort on private nodes exposure = %s, want private", endpoints[4].Exposure)
+	}
+}
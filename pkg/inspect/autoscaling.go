@@ -0,0 +1,277 @@
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// AutoscalingReport is the HorizontalPodAutoscaler and VerticalPodAutoscaler configuration of the cluster.
+type AutoscalingReport struct {
+	HPAs []HPAStatus `json:"hpas"`
+	// VPAInstalled is false when the autoscaling.k8s.io API is not served.
+	VPAInstalled bool        `json:"vpaInstalled"`
+	VPAs         []VPAStatus `json:"vpas,omitempty"`
+	// Unscaled are Deployments and StatefulSets with no autoscaler and a container without resource requests.
+	Unscaled []WorkloadRef `json:"unscaled,omitempty"`
+}
+
+// WorkloadRef identifies a workload.
+type WorkloadRef struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+func (r WorkloadRef) String() string {
+	return fmt.Sprintf("%s %s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// HPAStatus compares an HPA's bounds with its current and desired replicas.
+type HPAStatus struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Target    string `json:"target"`
+	Min       int32  `json:"min"`
+	Max       int32  `json:"max"`
+	Current   int32  `json:"current"`
+	Desired   int32  `json:"desired"`
+	// TargetMissing is set when the Deployment or StatefulSet the HPA scales does not exist.
+	TargetMissing bool `json:"targetMissing,omitempty"`
+	// MetricsProblem is the reason the HPA cannot compute a desired replica count, usually a missing metric.
+	MetricsProblem string `json:"metricsProblem,omitempty"`
+
+	hpa *autoscalingv2.HorizontalPodAutoscaler
+}
+
+// AtMax reports whether the HPA is running, and wants to run, its maximum replicas.
+func (h HPAStatus) AtMax() bool {
+	return h.Max > 0 && h.Current >= h.Max && h.Desired >= h.Max
+}
+
+// VPAStatus is a VerticalPodAutoscaler and the workload it targets.
+type VPAStatus struct {
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	Target     string `json:"target"`
+	UpdateMode string `json:"updateMode"`
+}
+
+type vpaList struct {
+	Items []struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+		Spec     struct {
+			TargetRef *struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			} `json:"targetRef"`
+			UpdatePolicy *struct {
+				UpdateMode string `json:"updateMode"`
+			} `json:"updatePolicy"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// GetAutoscalingReport collects HPAs, VPAs when the VPA CRDs are installed, and the workloads they target.
+func GetAutoscalingReport(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*AutoscalingReport, error) {
+	hpas, err := listHorizontalPodAutoscalers(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list horizontalpodautoscalers: %w", err)
+	}
+	deployments, err := listDeployments(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	statefulSets, err := listStatefulSets(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+
+	var vpas []VPAStatus
+	vpaInstalled := true
+	data, err := getRaw(ctx, clientset, "/apis/autoscaling.k8s.io/v1/verticalpodautoscalers")
+	switch {
+	case apierrors.IsNotFound(err):
+		vpaInstalled = false
+	case err != nil:
+		return nil, fmt.Errorf("failed to list verticalpodautoscalers: %w", err)
+	default:
+		if vpas, err = ParseVPAList(data); err != nil {
+			return nil, err
+		}
+	}
+
+	report := BuildAutoscalingReport(hpas, vpas, deployments, statefulSets)
+	report.VPAInstalled = vpaInstalled
+	return report, nil
+}
+
+// ParseVPAList parses a VerticalPodAutoscalerList response.
+func ParseVPAList(data []byte) ([]VPAStatus, error) {
+	var list vpaList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse verticalpodautoscalers: %w", err)
+	}
+	var vpas []VPAStatus
+	for _, item := range list.Items {
+		vpa := VPAStatus{Namespace: item.Metadata.Namespace, Name: item.Metadata.Name, UpdateMode: "Auto"}
+		if ref := item.Spec.TargetRef; ref != nil {
+			vpa.Target = ref.Kind + "/" + ref.Name
+		}
+		if p := item.Spec.UpdatePolicy; p != nil && p.UpdateMode != "" {
+			vpa.UpdateMode = p.UpdateMode
+		}
+		vpas = append(vpas, vpa)
+	}
+	return vpas, nil
+}
+
+// BuildAutoscalingReport matches autoscalers with the Deployments and StatefulSets in the cluster.
+func BuildAutoscalingReport(hpas []autoscalingv2.HorizontalPodAutoscaler, vpas []VPAStatus, deployments []appsv1.Deployment, statefulSets []appsv1.StatefulSet) *AutoscalingReport {
+	report := &AutoscalingReport{VPAs: vpas}
+
+	type workload struct {
+		ref  WorkloadRef
+		spec corev1.PodSpec
+	}
+	workloads := map[string]workload{}
+	for _, d := range deployments {
+		workloads[d.Namespace+"/Deployment/"+d.Name] = workload{WorkloadRef{"Deployment", d.Namespace, d.Name}, d.Spec.Template.Spec}
+	}
+	for _, s := range statefulSets {
+		workloads[s.Namespace+"/StatefulSet/"+s.Name] = workload{WorkloadRef{"StatefulSet", s.Namespace, s.Name}, s.Spec.Template.Spec}
+	}
+	scaled := map[string]bool{}
+
+	for i, hpa := range hpas {
+		ref := hpa.Spec.ScaleTargetRef
+		key := hpa.Namespace + "/" + ref.Kind + "/" + ref.Name
+		scaled[key] = true
+
+		status := HPAStatus{
+			Namespace: hpa.Namespace,
+			Name:      hpa.Name,
+			Target:    ref.Kind + "/" + ref.Name,
+			Min:       replicasOrDefault(hpa.Spec.MinReplicas),
+			Max:       hpa.Spec.MaxReplicas,
+			Current:   hpa.Status.CurrentReplicas,
+			Desired:   hpa.Status.DesiredReplicas,
+			hpa:       &hpas[i],
+		}
+		if _, ok := workloads[key]; !ok && (ref.Kind == "Deployment" || ref.Kind == "StatefulSet") {
+			status.TargetMissing = true
+		}
+		for _, c := range hpa.Status.Conditions {
+			if c.Type == autoscalingv2.ScalingActive && c.Status == corev1.ConditionFalse && c.Reason != "ScalingDisabled" {
+				status.MetricsProblem = fmt.Sprintf("%s: %s", c.Reason, c.Message)
+			}
+		}
+		report.HPAs = append(report.HPAs, status)
+	}
+	for _, vpa := range vpas {
+		scaled[vpa.Namespace+"/"+vpa.Target] = true
+	}
+
+	for key, w := range workloads {
+		if scaled[key] || hasAllRequests(w.spec) {
+			continue
+		}
+		report.Unscaled = append(report.Unscaled, w.ref)
+	}
+
+	sort.Slice(report.HPAs, func(i, j int) bool {
+		if report.HPAs[i].Namespace != report.HPAs[j].Namespace {
+			return report.HPAs[i].Namespace < report.HPAs[j].Namespace
+		}
+		return report.HPAs[i].Name < report.HPAs[j].Name
+	})
+	sort.Slice(report.Unscaled, func(i, j int) bool { return report.Unscaled[i].String() < report.Unscaled[j].String() })
+	return report
+}
+
+// hasAllRequests reports whether every container in spec requests CPU and memory.
+func hasAllRequests(spec corev1.PodSpec) bool {
+	for _, c := range spec.Containers {
+		if c.Resources.Requests.Cpu().IsZero() || c.Resources.Requests.Memory().IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckAutoscaling raises findings for HPAs that cannot scale, either because their target
+// is gone or because a metric they scale on is unavailable, for HPAs pinned at their maximum,
+// and for workloads with neither an autoscaler nor resource requests, which the scheduler
+// can neither size nor scale.
+func CheckAutoscaling(report *AutoscalingReport) []Finding {
+	var findings []Finding
+	for _, h := range report.HPAs {
+		finding := Finding{Kind: "HorizontalPodAutoscaler", Namespace: h.Namespace, Name: h.Name, object: h.hpa}
+		switch {
+		case h.TargetMissing:
+			finding.CheckID = "hpa-target-missing"
+			finding.Severity = SeverityHigh
+			finding.Message = fmt.Sprintf("HPA %s/%s scales %s, which does not exist", h.Namespace, h.Name, h.Target)
+		case h.MetricsProblem != "":
+			finding.CheckID = "hpa-missing-metrics"
+			finding.Severity = SeverityHigh
+			finding.Message = fmt.Sprintf("HPA %s/%s cannot scale %s: %s", h.Namespace, h.Name, h.Target, h.MetricsProblem)
+		case h.AtMax():
+			finding.CheckID = "hpa-at-max"
+			finding.Severity = SeverityMedium
+			finding.Message = fmt.Sprintf("HPA %s/%s is pinned at its maximum of %d replicas for %s", h.Namespace, h.Name, h.Max, h.Target)
+		default:
+			continue
+		}
+		findings = append(findings, finding)
+	}
+	for _, w := range report.Unscaled {
+		findings = append(findings, Finding{
+			CheckID:   "no-autoscaling-or-requests",
+			Severity:  SeverityLow,
+			Kind:      w.Kind,
+			Namespace: w.Namespace,
+			Name:      w.Name,
+			Message:   fmt.Sprintf("%s has no HPA or VPA and a container without CPU and memory requests", w),
+		})
+	}
+	return findings
+}
+
+// PrintAutoscaling writes the autoscaling section of the text report.
+func PrintAutoscaling(w io.Writer, report *AutoscalingReport) {
+	fmt.Fprintln(w, "Autoscaling:")
+	if len(report.HPAs) == 0 {
+		fmt.Fprintln(w, "  No HorizontalPodAutoscalers found.")
+	}
+	for _, h := range report.HPAs {
+		line := fmt.Sprintf("HPA %s/%s -> %s: %d current, %d desired (min %d, max %d)", h.Namespace, h.Name, h.Target, h.Current, h.Desired, h.Min, h.Max)
+		switch {
+		case h.TargetMissing:
+			line += " - TARGET MISSING"
+		case h.MetricsProblem != "":
+			line += " - " + h.MetricsProblem
+		case h.AtMax():
+			line += " - AT MAX"
+		}
+		fmt.Fprintf(w, "  - %s\n", line)
+	}
+	if !report.VPAInstalled {
+		fmt.Fprintln(w, "  VerticalPodAutoscaler is not installed.")
+	}
+	for _, v := range report.VPAs {
+		fmt.Fprintf(w, "  - VPA %s/%s -> %s (update mode %s)\n", v.Namespace, v.Name, v.Target, v.UpdateMode)
+	}
+	if len(report.Unscaled) > 0 {
+		fmt.Fprintf(w, "  %d workload(s) have neither an autoscaler nor resource requests\n", len(report.Unscaled))
+	}
+}
@@ -0,0 +1,71 @@
+package inspect
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testHPA(name, target string, max, current, desired int32, conditions ...autoscalingv2.HorizontalPodAutoscalerCondition) autoscalingv2.HorizontalPodAutoscaler {
+	return autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: name},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: target},
+			MaxReplicas:    max,
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: current, DesiredReplicas: desired, Conditions: conditions},
+	}
+}
+
+func TestBuildAutoscalingReport(t *testing.T) {
+	requests := corev1.PodSpec{Containers: []corev1.Container{{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+		corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("128Mi"),
+	}}}}}
+	deployment := func(name string, spec corev1.PodSpec) appsv1.Deployment {
+		return appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: name}, Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: spec}}}
+	}
+	deployments := []appsv1.Deployment{
+		deployment("api", corev1.PodSpec{Containers: []corev1.Container{{}}}),
+		deployment("worker", corev1.PodSpec{Containers: []corev1.Container{{}}}),
+		deployment("batch", corev1.PodSpec{Containers: []corev1.Container{{}}}),
+		deployment("frontend", requests),
+	}
+	hpas := []autoscalingv2.HorizontalPodAutoscaler{
+		testHPA("api", "api", 10, 10, 14),
+		testHPA("worker", "worker", 10, 3, 3, autoscalingv2.HorizontalPodAutoscalerCondition{
+			Type: autoscalingv2.ScalingActive, Status: corev1.ConditionFalse, Reason: "FailedGetResourceMetric",
+			Message: "missing request for cpu in container app",
+		}),
+		testHPA("legacy", "gone", 5, 0, 0),
+	}
+	vpas, err := ParseVPAList([]byte(`{"items":[{"metadata":{"namespace":"web","name":"batch"},"spec":{"targetRef":{"kind":"Deployment","name":"batch"},"updatePolicy":{"updateMode":"Off"}}}]}`))
+	if err != nil {
+		t.Fatalf("ParseVPAList() error = %v", err)
+	}
+	if len(vpas) != 1 || vpas[0].Target != "Deployment/batch" || vpas[0].UpdateMode != "Off" {
+		t.Fatalf("ParseVPAList() = %+v, want one VPA on Deployment/batch in Off mode", vpas)
+	}
+
+	// Only the unscaled deployment without requests is reported as unscaled.
+	unscaled := deployment("cache", corev1.PodSpec{Containers: []corev1.Container{{}}})
+	report := BuildAutoscalingReport(hpas, vpas, append(deployments, unscaled), nil)
+	if len(report.Unscaled) != 1 || report.Unscaled[0].Name != "cache" {
+		t.Errorf("BuildAutoscalingReport() unscaled = %v, want only web/cache", report.Unscaled)
+	}
+
+	var got []string
+	for _, f := range CheckAutoscaling(report) {
+		got = append(got, f.CheckID+" "+f.Name)
+	}
+	sort.Strings(got)
+	want := []string{"hpa-at-max api", "hpa-missing-metrics worker", "hpa-target-missing legacy", "no-autoscaling-or-requests cache"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("CheckAutoscaling() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,163 @@
+package inspect
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// BackendStatus is what backs an exposed Service or Ingress path, from the Service's
+// EndpointSlices.
+type BackendStatus struct {
+	// Service is namespace/name of the backing Service: the exposed Service itself, or the
+	// Service an Ingress path routes to.
+	Service string `json:"service"`
+	// Port is the Service port an Ingress path routes to; empty for exposed Services, which
+	// count the endpoints of every port.
+	Port     string `json:"port,omitempty"`
+	Ready    int    `json:"ready"`
+	NotReady int    `json:"notReady"`
+	// Error is set when the Ingress path's Service or port does not exist.
+	Error string `json:"error,omitempty"`
+}
+
+func (b BackendStatus) String() string {
+	target := b.Service
+	if b.Port != "" {
+		target += ":" + b.Port
+	}
+	if b.Error != "" {
+		return fmt.Sprintf("%s: %s", target, b.Error)
+	}
+	return fmt.Sprintf("%s: %d ready, %d not ready endpoint(s)", target, b.Ready, b.NotReady)
+}
+
+// VerifyBackends fills in the backends of every exposed Service and Ingress path. Ingress paths
+// with a resource backend instead of a Service are left alone.
+func VerifyBackends(endpoints []ExposedEndpoint, services []corev1.Service, slices []discoveryv1.EndpointSlice) {
+	servicesByKey := make(map[string]*corev1.Service, len(services))
+	for i := range services {
+		servicesByKey[services[i].Namespace+"/"+services[i].Name] = &services[i]
+	}
+	slicesByService := map[string][]discoveryv1.EndpointSlice{}
+	for _, s := range slices {
+		if name := s.Labels[discoveryv1.LabelServiceName]; name != "" {
+			slicesByService[s.Namespace+"/"+name] = append(slicesByService[s.Namespace+"/"+name], s)
+		}
+	}
+
+	for i := range endpoints {
+		e := &endpoints[i]
+		if e.Type != ExposureIngress {
+			key := e.Namespace + "/" + e.Name
+			b := &BackendStatus{Service: key}
+			b.Ready, b.NotReady = countBackends(slicesByService[key], nil)
+			e.Backends = b
+			continue
+		}
+		if e.backendService == nil {
+			continue
+		}
+		key := e.Namespace + "/" + e.backendService.Name
+		b := &BackendStatus{Service: key, Port: servicePortString(e.backendService.Port)}
+		e.Backends = b
+		svc := servicesByKey[key]
+		if svc == nil {
+			b.Error = "service does not exist"
+			continue
+		}
+		port := findServicePort(svc, e.backendService.Port)
+		if port == nil {
+			b.Error = "service has no such port"
+			continue
+		}
+		b.Ready, b.NotReady = countBackends(slicesByService[key], &port.Name)
+	}
+}
+
+func servicePortString(port networkingv1.ServiceBackendPort) string {
+	if port.Name != "" {
+		return port.Name
+	}
+	return strconv.Itoa(int(port.Number))
+}
+
+// findServicePort returns the Service port an Ingress backend names by name or number.
+func findServicePort(svc *corev1.Service, port networkingv1.ServiceBackendPort) *corev1.ServicePort {
+	for i, p := range svc.Spec.Ports {
+		if (port.Name != "" && p.Name == port.Name) || (port.Name == "" && p.Port == port.Number) {
+			return &svc.Spec.Ports[i]
+		}
+	}
+	return nil
+}
+
+// countBackends counts the distinct ready and not ready endpoints in a Service's slices. With a
+// port name, only slices serving that port count. Dual-stack Services have a slice per address
+// family, so endpoints are told apart by the pod they point to, or their first address.
+func countBackends(slices []discoveryv1.EndpointSlice, portName *string) (ready, notReady int) {
+	seen := map[string]bool{}
+	for _, s := range slices {
+		if portName != nil && !slicePorts(s, *portName) {
+			continue
+		}
+		for _, e := range s.Endpoints {
+			key := ""
+			if e.TargetRef != nil {
+				key = e.TargetRef.Kind + "/" + e.TargetRef.Namespace + "/" + e.TargetRef.Name
+			} else if len(e.Addresses) > 0 {
+				key = e.Addresses[0]
+			}
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if e.Conditions.Ready == nil || *e.Conditions.Ready {
+				ready++
+			} else {
+				notReady++
+			}
+		}
+	}
+	return ready, notReady
+}
+
+func slicePorts(s discoveryv1.EndpointSlice, name string) bool {
+	for _, p := range s.Ports {
+		if p.Name != nil && *p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckBackends raises a finding for every exposed Service or Ingress path that can't serve
+// traffic: its Service or port doesn't exist, or no endpoint behind it is ready.
+func CheckBackends(endpoints []ExposedEndpoint) []Finding {
+	var findings []Finding
+	for _, e := range endpoints {
+		b := e.Backends
+		if b == nil {
+			continue
+		}
+		f := Finding{Severity: SeverityHigh, Kind: e.Kind(), Namespace: e.Namespace, Name: e.Name, keyFields: []string{e.Host, e.Path}}
+		switch {
+		case b.Error != "":
+			f.CheckID = "ingress-backend-missing"
+			f.Message = fmt.Sprintf("Ingress %s/%s routes %s%s to %s:%s, but the %s", e.Namespace, e.Name, e.Host, e.Path, b.Service, b.Port, b.Error)
+		case b.Ready == 0 && e.Type == ExposureIngress:
+			f.CheckID = "exposure-no-ready-backends"
+			f.Message = fmt.Sprintf("Ingress %s/%s routes %s%s to %s:%s, which has no ready endpoints (%d not ready)", e.Namespace, e.Name, e.Host, e.Path, b.Service, b.Port, b.NotReady)
+		case b.Ready == 0:
+			f.CheckID = "exposure-no-ready-backends"
+			f.Message = fmt.Sprintf("%s service %s/%s is exposed but has no ready endpoints (%d not ready)", e.Type, e.Namespace, e.Name, b.NotReady)
+		default:
+			continue
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
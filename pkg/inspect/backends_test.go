@@ -0,0 +1,111 @@
+package inspect
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func backendSlice(namespace, service string, ports []string, endpoints ...discoveryv1.Endpoint) discoveryv1.EndpointSlice {
+	s := discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: service + "-abc", Labels: map[string]string{discoveryv1.LabelServiceName: service}},
+		Endpoints:  endpoints,
+	}
+	for _, p := range ports {
+		s.Ports = append(s.Ports, discoveryv1.EndpointPort{Name: &p})
+	}
+	return s
+}
+
+func backendEndpoint(pod string, ready bool) discoveryv1.Endpoint {
+	return discoveryv1.Endpoint{
+		Addresses:  []string{"10.0.0.1"},
+		Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+		TargetRef:  &corev1.ObjectReference{Kind: "Pod", Namespace: "web", Name: pod},
+	}
+}
+
+func TestVerifyBackends(t *testing.T) {
+	services := []corev1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "lb"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, Ports: []corev1.ServicePort{{Port: 443}}},
+			Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{
+				{IP: "203.0.113.10"},
+			}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "debug"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeNodePort, Ports: []corev1.ServicePort{{Port: 8080, NodePort: 30080}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "shop"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 80}, {Name: "admin", Port: 9000}}},
+		},
+	}
+	pathType := networkingv1.PathTypePrefix
+	path := func(p, service string, port networkingv1.ServiceBackendPort) networkingv1.HTTPIngressPath {
+		return networkingv1.HTTPIngressPath{Path: p, PathType: &pathType, Backend: networkingv1.IngressBackend{
+			Service: &networkingv1.IngressServiceBackend{Name: service, Port: port},
+		}}
+	}
+	ingresses := []networkingv1.Ingress{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "site"},
+		Spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{
+			Host: "shop.example.com",
+			IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: []networkingv1.HTTPIngressPath{
+				path("/", "shop", networkingv1.ServiceBackendPort{Number: 80}),
+				path("/admin", "shop", networkingv1.ServiceBackendPort{Name: "admin"}),
+				path("/metrics", "shop", networkingv1.ServiceBackendPort{Number: 9090}),
+				path("/old", "legacy", networkingv1.ServiceBackendPort{Name: "http"}),
+			}}},
+		}}},
+	}}
+	slices := []discoveryv1.EndpointSlice{
+		// A dual-stack Service has one slice per address family with the same pods.
+		backendSlice("web", "lb", []string{""}, backendEndpoint("lb-1", true), backendEndpoint("lb-2", false)),
+		backendSlice("web", "lb", []string{""}, backendEndpoint("lb-1", true), backendEndpoint("lb-2", false)),
+		backendSlice("web", "shop", []string{"http"}, backendEndpoint("shop-1", true), backendEndpoint("shop-2", true)),
+		backendSlice("web", "shop", []string{"admin"}, backendEndpoint("admin-1", false)),
+	}
+
+	endpoints := append(ServiceEndpoints(services), IngressEndpoints(ingresses)...)
+	VerifyBackends(endpoints, services, slices)
+	var got []string
+	for _, e := range endpoints {
+		got = append(got, e.Backends.String())
+	}
+	want := []string{
+		"web/lb: 1 ready, 1 not ready endpoint(s)",
+		"web/debug: 0 ready, 0 not ready endpoint(s)",
+		"web/shop:80: 2 ready, 0 not ready endpoint(s)",
+		"web/shop:admin: 0 ready, 1 not ready endpoint(s)",
+		"web/shop:9090: service has no such port",
+		"web/legacy:http: service does not exist",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("backends = %q, want %q", got, want)
+	}
+
+	findings := CheckBackends(endpoints)
+	got = nil
+	for _, f := range findings {
+		got = append(got, f.CheckID+" "+f.Resource()+" "+f.Message)
+	}
+	want = []string{
+		"exposure-no-ready-backends Service/web/debug NodePort service web/debug is exposed but has no ready endpoints (0 not ready)",
+		"exposure-no-ready-backends Ingress/web/site Ingress web/site routes shop.example.com/admin to web/shop:admin, which has no ready endpoints (1 not ready)",
+		"ingress-backend-missing Ingress/web/site Ingress web/site routes shop.example.com/metrics to web/shop:9090, but the service has no such port",
+		"ingress-backend-missing Ingress/web/site Ingress web/site routes shop.example.com/old to web/legacy:http, but the service does not exist",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %q, want %q", got, want)
+	}
+	if fingerprints := FinalizeFindings(findings); fingerprints[1].Fingerprint == fingerprints[2].Fingerprint {
+		t.Error("findings for different paths of one Ingress share a fingerprint")
+	}
+}
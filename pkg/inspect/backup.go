@@ -0,0 +1,495 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	veleroScheduleResource = schema.GroupVersionResource{Group: "velero.io", Version: "v1", Resource: "schedules"}
+	veleroBackupResource   = schema.GroupVersionResource{Group: "velero.io", Version: "v1", Resource: "backups"}
+	snapshotClassResource  = schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshotclasses"}
+)
+
+const (
+	// veleroScheduleLabel names the Schedule a Velero Backup was created by.
+	veleroScheduleLabel = "velero.io/schedule-name"
+	// defaultSnapshotClassAnnotation marks the VolumeSnapshotClass used when a snapshot names none.
+	defaultSnapshotClassAnnotation = "snapshot.storage.kubernetes.io/is-default-class"
+	// backupMissedRuns is how many scheduled runs may pass without a successful backup before
+	// the schedule is reported as stale.
+	backupMissedRuns = 2
+	// backupFallbackMaxAge is the staleness limit for schedules whose interval cannot be parsed.
+	backupFallbackMaxAge = 48 * time.Hour
+)
+
+// backupAnnotations opt a workload's volumes into backups made by a tool other than a Velero
+// Schedule covering its namespace: Velero's file-system backup and k8up.
+var backupAnnotations = []string{"backup.velero.io/backup-volumes", "k8up.io/backup"}
+
+// BackupReport is the cluster's disaster-recovery posture: the backup schedules it runs, whether
+// its volumes can be snapshotted, and the stateful workloads nothing backs up.
+type BackupReport struct {
+	// Velero is set when the Velero CRDs are installed.
+	Velero    bool             `json:"velero"`
+	Schedules []BackupSchedule `json:"schedules"`
+	// EtcdSelfManaged is set when etcd runs as pods, so backing it up is the cluster operator's job.
+	EtcdSelfManaged bool            `json:"etcdSelfManaged"`
+	SnapshotClasses []SnapshotClass `json:"snapshotClasses"`
+	// UnsnapshottableDrivers are the provisioners of bound claims that no VolumeSnapshotClass serves.
+	UnsnapshottableDrivers []string           `json:"unsnapshottableDrivers,omitempty"`
+	Unprotected            []StatefulWorkload `json:"unprotected"`
+	// Gaps summarizes what a restore would be missing, one line per kind of gap.
+	Gaps []string `json:"gaps"`
+}
+
+// BackupSchedule is a Velero Schedule or an etcd backup CronJob.
+type BackupSchedule struct {
+	// Kind is Schedule for Velero schedules and CronJob for etcd backups.
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Schedule  string `json:"schedule"`
+	Etcd      bool   `json:"etcd"`
+	// IncludedNamespaces and ExcludedNamespaces are the namespaces a Velero schedule backs up;
+	// no included namespaces means all of them.
+	IncludedNamespaces []string `json:"includedNamespaces,omitempty"`
+	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty"`
+	// Paused is set for paused Velero schedules and suspended CronJobs.
+	Paused      bool       `json:"paused"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	// LastPhase is the phase of the schedule's most recent Velero Backup.
+	LastPhase string `json:"lastPhase,omitempty"`
+	// Stale is set when backupMissedRuns scheduled runs have passed without a successful backup.
+	Stale bool `json:"stale"`
+
+	created time.Time
+}
+
+// Covers reports whether the schedule backs up the namespace.
+func (s BackupSchedule) Covers(namespace string) bool {
+	if s.Etcd || s.Paused || slices.Contains(s.ExcludedNamespaces, namespace) {
+		return false
+	}
+	return len(s.IncludedNamespaces) == 0 || slices.Contains(s.IncludedNamespaces, "*") || slices.Contains(s.IncludedNamespaces, namespace)
+}
+
+// SnapshotClass is a VolumeSnapshotClass.
+type SnapshotClass struct {
+	Name    string `json:"name"`
+	Driver  string `json:"driver"`
+	Default bool   `json:"default"`
+}
+
+// StatefulWorkload is a StatefulSet or Deployment with persistent volumes.
+type StatefulWorkload struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Claims are the claim templates or PersistentVolumeClaims the workload mounts.
+	Claims []string `json:"claims"`
+
+	object any
+}
+
+// GetBackupReport lists Velero schedules and backups, CronJobs, VolumeSnapshotClasses, claims and
+// storage classes, and stateful workloads. Clusters without Velero or the snapshot CRDs are
+// checked without them. etcdSelfManaged says whether etcd runs as pods.
+func GetBackupReport(ctx context.Context, clientset kubernetes.Interface, client dynamic.Interface, opts ScanOptions, etcdSelfManaged bool, now time.Time) (*BackupReport, error) {
+	veleroInstalled := true
+	schedules, err := listUnstructured(ctx, client.Resource(veleroScheduleResource), opts)
+	if apierrors.IsNotFound(err) {
+		veleroInstalled = false
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to list velero schedules: %w", err)
+	}
+	var backups []unstructured.Unstructured
+	if veleroInstalled {
+		backups, err = listUnstructured(ctx, client.Resource(veleroBackupResource), opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list velero backups: %w", err)
+		}
+	}
+	snapshotClasses, err := listUnstructured(ctx, client.Resource(snapshotClassResource), opts)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to list volumesnapshotclasses: %w", err)
+	}
+	cronJobs, err := listCronJobs(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cronjobs: %w", err)
+	}
+	claims, err := listPersistentVolumeClaims(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistentvolumeclaims: %w", err)
+	}
+	storageClasses, err := listStorageClasses(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storageclasses: %w", err)
+	}
+	statefulSets, err := listStatefulSets(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	deployments, err := listDeployments(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	report := BuildBackupReport(schedules, backups, cronJobs, snapshotClasses, claims, storageClasses, statefulSets, deployments, etcdSelfManaged, now)
+	report.Velero = veleroInstalled
+	return report, nil
+}
+
+// isEtcdBackupCronJob recognizes CronJobs that back up etcd by their name or by a container
+// that mentions etcd together with a snapshot or backup, like etcdctl snapshot save.
+func isEtcdBackupCronJob(cj batchv1.CronJob) bool {
+	mentions := func(s string) bool {
+		s = strings.ToLower(s)
+		return strings.Contains(s, "etcd") && (strings.Contains(s, "snapshot") || strings.Contains(s, "backup"))
+	}
+	if mentions(cj.Name) {
+		return true
+	}
+	for _, c := range cj.Spec.JobTemplate.Spec.Template.Spec.Containers {
+		if mentions(c.Name + " " + c.Image + " " + strings.Join(c.Command, " ") + " " + strings.Join(c.Args, " ")) {
+			return true
+		}
+	}
+	return false
+}
+
+// backupDeadline returns when a schedule that last succeeded (or was created) at since becomes
+// stale: after backupMissedRuns scheduled runs. Velero's @every durations are supported, and
+// unparsable schedules fall back to backupFallbackMaxAge.
+func backupDeadline(schedule string, since time.Time) time.Time {
+	if every, ok := strings.CutPrefix(strings.TrimSpace(schedule), "@every "); ok {
+		if d, err := time.ParseDuration(every); err == nil && d > 0 {
+			return since.Add(backupMissedRuns * d)
+		}
+	}
+	cron, err := ParseCronSchedule(schedule)
+	if err != nil {
+		return since.Add(backupFallbackMaxAge)
+	}
+	deadline := since
+	for range backupMissedRuns {
+		if deadline = cron.Next(deadline.UTC()); deadline.IsZero() {
+			return since.Add(backupFallbackMaxAge)
+		}
+	}
+	return deadline
+}
+
+// BuildBackupReport assesses the backup schedules against now and finds the stateful workloads
+// and volume drivers they leave unprotected.
+func BuildBackupReport(veleroSchedules, veleroBackups []unstructured.Unstructured, cronJobs []batchv1.CronJob, snapshotClasses []unstructured.Unstructured, claims []corev1.PersistentVolumeClaim, storageClasses []storagev1.StorageClass, statefulSets []appsv1.StatefulSet, deployments []appsv1.Deployment, etcdSelfManaged bool, now time.Time) *BackupReport {
+	report := &BackupReport{
+		EtcdSelfManaged: etcdSelfManaged,
+		Schedules:       []BackupSchedule{}, SnapshotClasses: []SnapshotClass{}, Unprotected: []StatefulWorkload{}, Gaps: []string{},
+	}
+
+	// The latest Backup of each Schedule gives its last phase; the latest completed one its last success.
+	type scheduleRuns struct {
+		latest      time.Time
+		phase       string
+		lastSuccess *time.Time
+	}
+	runs := map[string]*scheduleRuns{}
+	for _, b := range veleroBackups {
+		name := b.GetLabels()[veleroScheduleLabel]
+		if name == "" {
+			continue
+		}
+		key := b.GetNamespace() + "/" + name
+		r := runs[key]
+		if r == nil {
+			r = &scheduleRuns{}
+			runs[key] = r
+		}
+		phase, _, _ := unstructured.NestedString(b.Object, "status", "phase")
+		if created := b.GetCreationTimestamp().Time; !created.Before(r.latest) {
+			r.latest, r.phase = created, phase
+		}
+		completed, _, _ := unstructured.NestedString(b.Object, "status", "completionTimestamp")
+		if at, err := time.Parse(time.RFC3339, completed); err == nil && phase == "Completed" && (r.lastSuccess == nil || at.After(*r.lastSuccess)) {
+			r.lastSuccess = &at
+		}
+	}
+
+	for _, s := range veleroSchedules {
+		schedule := BackupSchedule{Kind: "Schedule", Namespace: s.GetNamespace(), Name: s.GetName(), created: s.GetCreationTimestamp().Time}
+		schedule.Schedule, _, _ = unstructured.NestedString(s.Object, "spec", "schedule")
+		schedule.Paused, _, _ = unstructured.NestedBool(s.Object, "spec", "paused")
+		schedule.IncludedNamespaces, _, _ = unstructured.NestedStringSlice(s.Object, "spec", "template", "includedNamespaces")
+		schedule.ExcludedNamespaces, _, _ = unstructured.NestedStringSlice(s.Object, "spec", "template", "excludedNamespaces")
+		if r := runs[schedule.Namespace+"/"+schedule.Name]; r != nil {
+			schedule.LastPhase, schedule.LastSuccess = r.phase, r.lastSuccess
+		}
+		report.Schedules = append(report.Schedules, schedule)
+	}
+	for _, cj := range cronJobs {
+		if !isEtcdBackupCronJob(cj) {
+			continue
+		}
+		schedule := BackupSchedule{
+			Kind: "CronJob", Namespace: cj.Namespace, Name: cj.Name, Schedule: cj.Spec.Schedule, Etcd: true,
+			Paused: cj.Spec.Suspend != nil && *cj.Spec.Suspend, created: cj.CreationTimestamp.Time,
+		}
+		if t := cj.Status.LastSuccessfulTime; t != nil {
+			schedule.LastSuccess = &t.Time
+		}
+		report.Schedules = append(report.Schedules, schedule)
+	}
+	for i := range report.Schedules {
+		s := &report.Schedules[i]
+		since := s.created
+		if s.LastSuccess != nil {
+			since = *s.LastSuccess
+		}
+		s.Stale = !s.Paused && now.After(backupDeadline(s.Schedule, since))
+	}
+	sort.Slice(report.Schedules, func(i, j int) bool {
+		a, b := report.Schedules[i], report.Schedules[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	drivers := map[string]bool{}
+	for _, c := range snapshotClasses {
+		driver, _, _ := unstructured.NestedString(c.Object, "driver")
+		drivers[driver] = true
+		report.SnapshotClasses = append(report.SnapshotClasses, SnapshotClass{
+			Name: c.GetName(), Driver: driver, Default: c.GetAnnotations()[defaultSnapshotClassAnnotation] == "true",
+		})
+	}
+	sort.Slice(report.SnapshotClasses, func(i, j int) bool { return report.SnapshotClasses[i].Name < report.SnapshotClasses[j].Name })
+	provisioners := map[string]string{}
+	for _, sc := range storageClasses {
+		provisioners[sc.Name] = sc.Provisioner
+	}
+	for _, c := range claims {
+		if c.Status.Phase != corev1.ClaimBound || c.Spec.StorageClassName == nil {
+			continue
+		}
+		if p := provisioners[*c.Spec.StorageClassName]; p != "" && !drivers[p] && !slices.Contains(report.UnsnapshottableDrivers, p) {
+			report.UnsnapshottableDrivers = append(report.UnsnapshottableDrivers, p)
+		}
+	}
+	sort.Strings(report.UnsnapshottableDrivers)
+
+	protected := func(namespace string, annotations ...map[string]string) bool {
+		for _, a := range annotations {
+			for _, key := range backupAnnotations {
+				if a[key] != "" {
+					return true
+				}
+			}
+		}
+		return slices.ContainsFunc(report.Schedules, func(s BackupSchedule) bool { return s.Covers(namespace) })
+	}
+	for i, sts := range statefulSets {
+		var names []string
+		for _, t := range sts.Spec.VolumeClaimTemplates {
+			names = append(names, t.Name)
+		}
+		names = append(names, podClaims(sts.Spec.Template.Spec)...)
+		if len(names) > 0 && !protected(sts.Namespace, sts.Annotations, sts.Spec.Template.Annotations) {
+			report.Unprotected = append(report.Unprotected, StatefulWorkload{Kind: "StatefulSet", Namespace: sts.Namespace, Name: sts.Name, Claims: names, object: &statefulSets[i]})
+		}
+	}
+	for i, d := range deployments {
+		if names := podClaims(d.Spec.Template.Spec); len(names) > 0 && !protected(d.Namespace, d.Annotations, d.Spec.Template.Annotations) {
+			report.Unprotected = append(report.Unprotected, StatefulWorkload{Kind: "Deployment", Namespace: d.Namespace, Name: d.Name, Claims: names, object: &deployments[i]})
+		}
+	}
+	sort.Slice(report.Unprotected, func(i, j int) bool {
+		a, b := report.Unprotected[i], report.Unprotected[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	report.Gaps = backupGaps(report)
+	return report
+}
+
+// podClaims returns the PersistentVolumeClaims a pod spec mounts.
+func podClaims(spec corev1.PodSpec) []string {
+	var names []string
+	for _, v := range spec.Volumes {
+		if v.PersistentVolumeClaim != nil {
+			names = append(names, v.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	return names
+}
+
+// etcdUnprotected reports whether etcd runs as pods with no running backup CronJob.
+func (r *BackupReport) etcdUnprotected() bool {
+	return r.EtcdSelfManaged && !slices.ContainsFunc(r.Schedules, func(s BackupSchedule) bool { return s.Etcd && !s.Paused })
+}
+
+// backupGaps summarizes the report's gaps for the text report.
+func backupGaps(report *BackupReport) []string {
+	var gaps []string
+	if report.etcdUnprotected() {
+		gaps = append(gaps, "etcd runs as pods but no etcd backup CronJob is running")
+	}
+	if !slices.ContainsFunc(report.Schedules, func(s BackupSchedule) bool { return !s.Etcd && !s.Paused }) {
+		gaps = append(gaps, "no Velero schedule backs up the cluster's resources")
+	}
+	stale, paused := 0, 0
+	for _, s := range report.Schedules {
+		if s.Stale {
+			stale++
+		}
+		if s.Paused {
+			paused++
+		}
+	}
+	if stale > 0 {
+		gaps = append(gaps, fmt.Sprintf("%d backup schedule(s) have not succeeded for %d scheduled runs", stale, backupMissedRuns))
+	}
+	if paused > 0 {
+		gaps = append(gaps, fmt.Sprintf("%d backup schedule(s) paused or suspended", paused))
+	}
+	if n := len(report.UnsnapshottableDrivers); n > 0 {
+		gaps = append(gaps, fmt.Sprintf("no VolumeSnapshotClass for %d volume provisioner(s) in use: %s", n, strings.Join(report.UnsnapshottableDrivers, ", ")))
+	}
+	if n := len(report.Unprotected); n > 0 {
+		namespaces := map[string]bool{}
+		for _, w := range report.Unprotected {
+			namespaces[w.Namespace] = true
+		}
+		gaps = append(gaps, fmt.Sprintf("%d stateful workload(s) in %d namespace(s) with no backup", n, len(namespaces)))
+	}
+	return gaps
+}
+
+// formatBackupTime renders a last-success time relative to now.
+func formatBackupTime(t *time.Time, now time.Time) string {
+	if t == nil {
+		return "never succeeded"
+	}
+	return "last succeeded " + formatAge(now.Sub(*t)) + " ago"
+}
+
+// CheckBackups reports a missing etcd backup as high; stale schedules and unprotected stateful
+// workloads as medium; and paused schedules and volume provisioners without snapshots as low.
+func CheckBackups(report *BackupReport, now time.Time) []Finding {
+	var findings []Finding
+	if report.etcdUnprotected() {
+		findings = append(findings, Finding{
+			CheckID:  "etcd-backup-missing",
+			Severity: SeverityHigh,
+			Kind:     "Cluster",
+			Name:     "etcd",
+			Message:  "etcd runs as pods but no CronJob backs it up (looked for etcd snapshot or backup CronJobs)",
+		})
+	}
+	for _, s := range report.Schedules {
+		what := "Velero schedule"
+		if s.Etcd {
+			what = "etcd backup CronJob"
+		}
+		switch {
+		case s.Paused:
+			findings = append(findings, Finding{
+				CheckID: "backup-paused", Severity: SeverityLow, Kind: s.Kind, Namespace: s.Namespace, Name: s.Name,
+				Message: fmt.Sprintf("%s %s/%s is paused, %s", what, s.Namespace, s.Name, formatBackupTime(s.LastSuccess, now)),
+			})
+		case s.Stale:
+			msg := fmt.Sprintf("%s %s/%s (%s) %s", what, s.Namespace, s.Name, s.Schedule, formatBackupTime(s.LastSuccess, now))
+			if s.LastPhase != "" && s.LastPhase != "Completed" {
+				msg += "; latest backup is " + s.LastPhase
+			}
+			findings = append(findings, Finding{
+				CheckID: "backup-stale", Severity: SeverityMedium, Kind: s.Kind, Namespace: s.Namespace, Name: s.Name, Message: msg,
+			})
+		}
+	}
+	for _, driver := range report.UnsnapshottableDrivers {
+		findings = append(findings, Finding{
+			CheckID:  "snapshot-class-missing",
+			Severity: SeverityLow,
+			Kind:     "StorageProvisioner",
+			Name:     driver,
+			Message:  fmt.Sprintf("bound volumes use %s but no VolumeSnapshotClass serves it, so they cannot be snapshotted", driver),
+		})
+	}
+	for _, w := range report.Unprotected {
+		findings = append(findings, Finding{
+			CheckID:   "stateful-workload-unprotected",
+			Severity:  SeverityMedium,
+			Kind:      w.Kind,
+			Namespace: w.Namespace,
+			Name:      w.Name,
+			Message: fmt.Sprintf("%s %s/%s stores data in %s but no backup schedule covers its namespace and it has no backup annotation",
+				w.Kind, w.Namespace, w.Name, strings.Join(w.Claims, ", ")),
+			object: w.object,
+		})
+	}
+	return findings
+}
+
+// PrintBackupReport writes the backup section of the text report.
+func PrintBackupReport(w io.Writer, report *BackupReport, now time.Time) {
+	fmt.Fprintln(w, "Backups and DR readiness:")
+	if !report.Velero {
+		fmt.Fprintln(w, "  Velero is not installed.")
+	}
+	for _, s := range report.Schedules {
+		state := formatBackupTime(s.LastSuccess, now)
+		if s.Paused {
+			state = "paused, " + state
+		}
+		what := "Velero"
+		if s.Etcd {
+			what = "etcd"
+		}
+		fmt.Fprintf(w, "  - %s %s/%s (%s): %s\n", what, s.Namespace, s.Name, s.Schedule, state)
+	}
+	if len(report.SnapshotClasses) == 0 {
+		fmt.Fprintln(w, "  No VolumeSnapshotClasses found.")
+	}
+	for _, c := range report.SnapshotClasses {
+		name := c.Name
+		if c.Default {
+			name += " (default)"
+		}
+		fmt.Fprintf(w, "  - VolumeSnapshotClass %s: %s\n", name, c.Driver)
+	}
+	if len(report.Unprotected) > 0 {
+		fmt.Fprintln(w, "  Stateful workloads with no backup:")
+		for _, u := range report.Unprotected {
+			fmt.Fprintf(w, "    - %s %s/%s: %s\n", u.Kind, u.Namespace, u.Name, strings.Join(u.Claims, ", "))
+		}
+	}
+	if len(report.Gaps) == 0 {
+		fmt.Fprintln(w, "  No DR gaps found.")
+		return
+	}
+	fmt.Fprintln(w, "  DR gaps:")
+	for _, g := range report.Gaps {
+		fmt.Fprintf(w, "    - %s\n", g)
+	}
+}
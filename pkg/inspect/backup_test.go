@@ -0,0 +1,143 @@
+package inspect
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func veleroSchedule(name, schedule string, created time.Time, included ...string) unstructured.Unstructured {
+	template := map[string]any{}
+	if len(included) > 0 {
+		namespaces := make([]any, len(included))
+		for i, ns := range included {
+			namespaces[i] = ns
+		}
+		template["includedNamespaces"] = namespaces
+	}
+	u := unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "velero.io/v1", "kind": "Schedule",
+		"spec": map[string]any{"schedule": schedule, "template": template},
+	}}
+	u.SetNamespace("velero")
+	u.SetName(name)
+	u.SetCreationTimestamp(metav1.NewTime(created))
+	return u
+}
+
+func veleroBackup(schedule, phase string, created time.Time) unstructured.Unstructured {
+	status := map[string]any{"phase": phase}
+	if phase == "Completed" {
+		status["completionTimestamp"] = created.Add(10 * time.Minute).Format(time.RFC3339)
+	}
+	u := unstructured.Unstructured{Object: map[string]any{"apiVersion": "velero.io/v1", "kind": "Backup", "status": status}}
+	u.SetNamespace("velero")
+	u.SetName(schedule + "-" + created.Format("20060102150405"))
+	u.SetLabels(map[string]string{veleroScheduleLabel: schedule})
+	u.SetCreationTimestamp(metav1.NewTime(created))
+	return u
+}
+
+func TestBackupDeadline(t *testing.T) {
+	since := time.Date(2026, 10, 14, 2, 30, 0, 0, time.UTC)
+	for schedule, want := range map[string]time.Time{
+		"0 2 * * *":   time.Date(2026, 10, 16, 2, 0, 0, 0, time.UTC),
+		"@every 6h":   since.Add(12 * time.Hour),
+		"not a cron!": since.Add(backupFallbackMaxAge),
+	} {
+		if got := backupDeadline(schedule, since); !got.Equal(want) {
+			t.Errorf("backupDeadline(%q) = %v, want %v", schedule, got, want)
+		}
+	}
+}
+
+func TestBuildBackupReport(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	created := now.Add(-30 * 24 * time.Hour)
+	storageClass := "gp3"
+	claimTemplate := corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data"}}
+	pvcVolume := corev1.Volume{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "uploads"}}}
+
+	report := BuildBackupReport(
+		[]unstructured.Unstructured{
+			veleroSchedule("shop-daily", "0 2 * * *", created, "shop"),
+			veleroSchedule("weekly", "0 3 * * 0", created, "payments"),
+		},
+		[]unstructured.Unstructured{
+			veleroBackup("shop-daily", "Completed", now.Add(-10*time.Hour)),
+			veleroBackup("weekly", "Completed", now.Add(-20*24*time.Hour)),
+			veleroBackup("weekly", "PartiallyFailed", now.Add(-6*24*time.Hour)),
+		},
+		[]batchv1.CronJob{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "snapshots", CreationTimestamp: metav1.NewTime(created)},
+			Spec: batchv1.CronJobSpec{Schedule: "0 */6 * * *", JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "save", Command: []string{"etcdctl", "snapshot", "save", "/backup/db"}}}},
+			}}}},
+			Status: batchv1.CronJobStatus{LastSuccessfulTime: &metav1.Time{Time: now.Add(-2 * time.Hour)}},
+		}},
+		nil,
+		[]corev1.PersistentVolumeClaim{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "data-db-0"},
+			Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &storageClass},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+		}},
+		[]storagev1.StorageClass{{ObjectMeta: metav1.ObjectMeta{Name: "gp3"}, Provisioner: "ebs.csi.aws.com"}},
+		[]appsv1.StatefulSet{
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "db"}, Spec: appsv1.StatefulSetSpec{VolumeClaimTemplates: []corev1.PersistentVolumeClaim{claimTemplate}}},
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "analytics", Name: "warehouse"}, Spec: appsv1.StatefulSetSpec{VolumeClaimTemplates: []corev1.PersistentVolumeClaim{claimTemplate}}},
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "analytics", Name: "cache"}},
+		},
+		[]appsv1.Deployment{
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "cms", Name: "uploads"}, Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Volumes: []corev1.Volume{pvcVolume}}}}},
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "wiki", Name: "files"}, Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"backup.velero.io/backup-volumes": "data"}},
+				Spec:       corev1.PodSpec{Volumes: []corev1.Volume{pvcVolume}},
+			}}},
+		},
+		true, now,
+	)
+
+	stale := map[string]bool{}
+	for _, s := range report.Schedules {
+		stale[s.Name] = s.Stale
+	}
+	if want := map[string]bool{"snapshots": false, "shop-daily": false, "weekly": true}; !reflect.DeepEqual(stale, want) {
+		t.Errorf("stale schedules = %v, want %v", stale, want)
+	}
+	var unprotected []string
+	for _, u := range report.Unprotected {
+		unprotected = append(unprotected, u.Namespace+"/"+u.Name)
+	}
+	if want := []string{"analytics/warehouse", "cms/uploads"}; !reflect.DeepEqual(unprotected, want) {
+		t.Errorf("unprotected = %v, want %v", unprotected, want)
+	}
+	if want := []string{"ebs.csi.aws.com"}; !reflect.DeepEqual(report.UnsnapshottableDrivers, want) {
+		t.Errorf("UnsnapshottableDrivers = %v, want %v", report.UnsnapshottableDrivers, want)
+	}
+
+	findings := CheckBackups(report, now)
+	want := []string{"backup-stale", "snapshot-class-missing", "stateful-workload-unprotected", "stateful-workload-unprotected"}
+	if got := checkIDs(findings); !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckBackups() = %v, want %v", got, want)
+	}
+	if msg := findings[0].Message; msg != "Velero schedule velero/weekly (0 3 * * 0) last succeeded 19d ago; latest backup is PartiallyFailed" {
+		t.Errorf("stale message = %q", msg)
+	}
+}
+
+func TestCheckBackupsEtcdMissing(t *testing.T) {
+	report := BuildBackupReport(nil, nil, nil, nil, nil, nil, nil, nil, true, time.Now())
+	if got := checkIDs(CheckBackups(report, time.Now())); !reflect.DeepEqual(got, []string{"etcd-backup-missing"}) {
+		t.Errorf("CheckBackups() = %v, want etcd-backup-missing", got)
+	}
+	if want := []string{"etcd runs as pods but no etcd backup CronJob is running", "no Velero schedule backs up the cluster's resources"}; !reflect.DeepEqual(report.Gaps, want) {
+		t.Errorf("Gaps = %v, want %v", report.Gaps, want)
+	}
+}
@@ -0,0 +1,282 @@
+package inspect
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+)
+
+// BundleFormatVersion is the layout version of the bundles WriteBundle writes. ReadBundle
+// rejects bundles of a later version.
+const BundleFormatVersion = 1
+
+// Files in a bundle. Manifests are under bundleManifestDir, one YAML List per resource type at
+// <group>/<version>/<resource>.yaml, with "core" as the group of the core API.
+const (
+	bundleInfoFile    = "bundle.json"
+	bundleReportFile  = "report.json"
+	bundleManifestDir = "manifests"
+)
+
+// bundleSkippedResources are left out of bundles: Events churn too fast to be worth capturing,
+// and the report already summarizes them.
+var bundleSkippedResources = []schema.GroupResource{
+	{Resource: "events"},
+	{Group: "events.k8s.io", Resource: "events"},
+}
+
+// BundleInfo describes a bundle and what it holds.
+type BundleInfo struct {
+	FormatVersion int              `json:"formatVersion"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	KubeOpVersion string           `json:"kubeOpVersion"`
+	Resources     []BundleResource `json:"resources"`
+	// DiscoveryError is set when some API groups could not be discovered, so their resources
+	// are missing from the bundle.
+	DiscoveryError string `json:"discoveryError,omitempty"`
+}
+
+// BundleResource is one resource type captured in a bundle.
+type BundleResource struct {
+	Group      string `json:"group,omitempty"`
+	Version    string `json:"version"`
+	Resource   string `json:"resource"`
+	Kind       string `json:"kind"`
+	Namespaced bool   `json:"namespaced"`
+	// File is the path of the manifests in the bundle, unset when the list failed.
+	File    string `json:"file,omitempty"`
+	Objects int    `json:"objects"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Bundle is a bundle read back with ReadBundle.
+type Bundle struct {
+	Info   BundleInfo
+	Report *Report
+	// Files lists the paths in the bundle, such as the manifest files.
+	Files []string
+}
+
+// WriteBundle writes a gzipped tar of the report and the sanitized manifests of every listable
+// resource type in the cluster, for analysis where the cluster can't be reached. Manifests are
+// sanitized like raw findings: managed fields and last-applied annotations are dropped and
+// Secret values are redacted. Resource types that can't be listed are recorded in the bundle
+// info rather than failing the bundle; cancelling ctx does fail it.
+func WriteBundle(ctx context.Context, w io.Writer, clientset kubernetes.Interface, config *rest.Config, report *Report, opts ScanOptions, kubeOpVersion string) (*BundleInfo, error) {
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	return writeBundle(ctx, w, clientset.Discovery(), client, report, opts, kubeOpVersion)
+}
+
+func writeBundle(ctx context.Context, w io.Writer, discoveryClient discovery.DiscoveryInterface, client dynamic.Interface, report *Report, opts ScanOptions, kubeOpVersion string) (*BundleInfo, error) {
+	info := &BundleInfo{FormatVersion: BundleFormatVersion, CreatedAt: time.Now(), KubeOpVersion: kubeOpVersion}
+	lists, err := discovery.ServerPreferredResources(discoveryClient)
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return nil, fmt.Errorf("failed to discover API resources: %w", err)
+		}
+		// The groups that were discovered are still captured.
+		info.DiscoveryError = err.Error()
+	}
+	info.Resources = bundleResources(lists)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	var mu sync.Mutex
+	writeErrs := make([]error, len(info.Resources))
+	tasks := make([]func(), len(info.Resources))
+	for i := range info.Resources {
+		r := &info.Resources[i]
+		tasks[i] = func() {
+			data, objects, err := bundleManifests(ctx, client, *r, opts)
+			if err != nil {
+				r.Error = err.Error()
+				return
+			}
+			file := path.Join(bundleManifestDir, r.groupDir(), r.Version, r.Resource+".yaml")
+			mu.Lock()
+			defer mu.Unlock()
+			if writeErrs[i] = writeBundleFile(tw, file, data, info.CreatedAt); writeErrs[i] == nil {
+				r.File, r.Objects = file, objects
+			}
+		}
+	}
+	runConcurrently(opts.Concurrency, tasks...)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("bundle interrupted: %w", err)
+	}
+	if err := errors.Join(writeErrs...); err != nil {
+		return nil, fmt.Errorf("failed to write manifests: %w", err)
+	}
+
+	for _, f := range []struct {
+		name string
+		v    any
+	}{{bundleReportFile, report}, {bundleInfoFile, info}} {
+		data, err := json.MarshalIndent(f.v, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize %s: %w", f.name, err)
+		}
+		if err := writeBundleFile(tw, f.name, data, info.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return info, nil
+}
+
+// bundleResources returns the resource types worth capturing from discovery: those that can be
+// listed, without subresources or bundleSkippedResources, sorted by group and resource.
+func bundleResources(lists []*metav1.APIResourceList) []BundleResource {
+	var resources []BundleResource
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") || !slices.Contains(r.Verbs, "list") ||
+				slices.Contains(bundleSkippedResources, schema.GroupResource{Group: gv.Group, Resource: r.Name}) {
+				continue
+			}
+			resources = append(resources, BundleResource{Group: gv.Group, Version: gv.Version, Resource: r.Name, Kind: r.Kind, Namespaced: r.Namespaced})
+		}
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].Group != resources[j].Group {
+			return resources[i].Group < resources[j].Group
+		}
+		return resources[i].Resource < resources[j].Resource
+	})
+	return resources
+}
+
+func (r BundleResource) groupDir() string {
+	if r.Group == "" {
+		return "core"
+	}
+	return r.Group
+}
+
+// bundleManifests lists every object of the resource type and returns them sanitized, as a
+// YAML List that kubectl can read.
+func bundleManifests(ctx context.Context, client dynamic.Interface, r BundleResource, opts ScanOptions) ([]byte, int, error) {
+	gvr := schema.GroupVersionResource{Group: r.Group, Version: r.Version, Resource: r.Resource}
+	items, err := listUnstructured(ctx, client.Resource(gvr), opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	objects := make([]any, len(items))
+	for i, item := range items {
+		if item.GetAPIVersion() == "" {
+			item.SetAPIVersion(gvr.GroupVersion().String())
+		}
+		sanitizeFields(r.Kind, item.Object)
+		objects[i] = item.Object
+	}
+	data, err := yaml.Marshal(map[string]any{"apiVersion": "v1", "kind": "List", "items": objects})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to serialize: %w", err)
+	}
+	return data, len(items), nil
+}
+
+func writeBundleFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg}); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// ReadBundle reads the bundle info and report from a bundle written by WriteBundle.
+func ReadBundle(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	defer gz.Close()
+
+	bundle := &Bundle{}
+	var haveInfo bool
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		bundle.Files = append(bundle.Files, header.Name)
+		switch header.Name {
+		case bundleInfoFile:
+			if err := json.NewDecoder(tr).Decode(&bundle.Info); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", bundleInfoFile, err)
+			}
+			haveInfo = true
+		case bundleReportFile:
+			if err := json.NewDecoder(tr).Decode(&bundle.Report); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", bundleReportFile, err)
+			}
+		}
+	}
+	if !haveInfo || bundle.Report == nil {
+		return nil, fmt.Errorf("not a kube-op bundle: missing %s or %s", bundleInfoFile, bundleReportFile)
+	}
+	if bundle.Info.FormatVersion > BundleFormatVersion {
+		return nil, fmt.Errorf("bundle format %d is newer than this kube-op supports (%d); update kube-op", bundle.Info.FormatVersion, BundleFormatVersion)
+	}
+	return bundle, nil
+}
+
+// PrintBundleInfo writes a summary of what a bundle holds.
+func PrintBundleInfo(w io.Writer, info BundleInfo) {
+	objects, failed := 0, 0
+	for _, r := range info.Resources {
+		objects += r.Objects
+		if r.Error != "" {
+			failed++
+		}
+	}
+	fmt.Fprintf(w, "Bundle created %s by kube-op %s: %d object(s) of %d resource type(s)\n",
+		info.CreatedAt.Format(time.RFC3339), info.KubeOpVersion, objects, len(info.Resources)-failed)
+	if info.DiscoveryError != "" {
+		fmt.Fprintf(w, "  Some API groups could not be discovered: %s\n", info.DiscoveryError)
+	}
+	if failed > 0 {
+		fmt.Fprintf(w, "  Could not capture %d resource type(s):\n", failed)
+		for _, r := range info.Resources {
+			if r.Error != "" {
+				fmt.Fprintf(w, "  - %s: %s\n", schema.GroupResource{Group: r.Group, Resource: r.Resource}, r.Error)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestWriteAndReadBundle(t *testing.T) {
+	clientset := fake.NewClientset()
+	clientset.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "secrets", Kind: "Secret", Namespaced: true, Verbs: []string{"get", "list"}},
+			{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: []string{"get", "list"}},
+			{Name: "pods/log", Kind: "Pod", Namespaced: true, Verbs: []string{"get"}},
+			{Name: "events", Kind: "Event", Namespaced: true, Verbs: []string{"get", "list"}},
+			{Name: "bindings", Kind: "Binding", Namespaced: true, Verbs: []string{"create"}},
+		}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{
+			{Name: "deployments", Kind: "Deployment", Namespaced: true, Verbs: []string{"get", "list"}},
+		}},
+	}
+
+	secret := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1", "kind": "Secret",
+		"metadata": map[string]any{"namespace": "shop", "name": "db"},
+	}}
+	pod := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1", "kind": "Pod",
+		"metadata": map[string]any{"namespace": "shop", "name": "web-1"},
+	}}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "secrets"}:                    "SecretList",
+		{Version: "v1", Resource: "pods"}:                       "PodList",
+		{Group: "apps", Version: "v1", Resource: "deployments"}: "DeploymentList",
+	}, secret, pod)
+	dynamicClient.PrependReactor("list", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(action.GetResource().GroupResource(), "", errors.New("denied"))
+	})
+
+	report := &Report{KubernetesVersion: "v1.33.1", Findings: []Finding{{CheckID: "pod-not-ready", Severity: SeverityHigh, Kind: "Pod", Namespace: "shop", Name: "web-1"}}}
+	var buf bytes.Buffer
+	opts := AutoTuneScanOptions(ClusterSize{})
+	if _, err := writeBundle(context.Background(), &buf, clientset.Discovery(), dynamicClient, report, opts, "v1.2.3"); err != nil {
+		t.Fatalf("writeBundle() returned error = %v", err)
+	}
+	bundle, err := ReadBundle(&buf)
+	if err != nil {
+		t.Fatalf("ReadBundle() returned error = %v", err)
+	}
+	if bundle.Report.KubernetesVersion != "v1.33.1" || len(bundle.Report.Findings) != 1 || bundle.Info.KubeOpVersion != "v1.2.3" {
+		t.Errorf("bundle = %+v, want the report and version written", bundle)
+	}
+	var captured []string
+	for _, r := range bundle.Info.Resources {
+		captured = append(captured, r.Resource+":"+r.File+":"+r.Error)
+	}
+	want := []string{
+		"pods:manifests/core/v1/pods.yaml:",
+		"secrets:manifests/core/v1/secrets.yaml:",
+		"deployments::deployments.apps is forbidden: denied",
+	}
+	if !reflect.DeepEqual(captured, want) {
+		t.Errorf("resources = %q, want %q", captured, want)
+	}
+
+	var out bytes.Buffer
+	PrintBundleInfo(&out, bundle.Info)
+	if !strings.Contains(out.String(), "2 object(s) of 2 resource type(s)") || !strings.Contains(out.String(), "- deployments.apps: ") {
+		t.Errorf("PrintBundleInfo() = %q", out.String())
+	}
+}
+
+func TestBundleManifestsRedactSecrets(t *testing.T) {
+	secret := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1", "kind": "Secret",
+		"metadata": map[string]any{"namespace": "shop", "name": "db", "annotations": map[string]any{lastAppliedAnnotation: `{"data":{"password":"aHVudGVyMg=="}}`}},
+		"data":     map[string]any{"password": "aHVudGVyMg=="},
+	}}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "secrets"}: "SecretList",
+	}, secret)
+
+	data, objects, err := bundleManifests(context.Background(), dynamicClient, BundleResource{Version: "v1", Resource: "secrets", Kind: "Secret"}, AutoTuneScanOptions(ClusterSize{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objects != 1 || strings.Contains(string(data), "aHVudGVyMg") || !strings.Contains(string(data), "password: "+redactedValue) {
+		t.Errorf("manifests = %s, want the secret with its value redacted", data)
+	}
+	if !strings.HasPrefix(string(data), "apiVersion: v1\nitems:\n") || !strings.Contains(string(data), "kind: List") {
+		t.Errorf("manifests = %s, want a List", data)
+	}
+}
+
+func TestReadBundleRejectsOtherFiles(t *testing.T) {
+	if _, err := ReadBundle(strings.NewReader("not gzip")); err == nil {
+		t.Error("ReadBundle() returned error = nil for a file that is not a bundle")
+	}
+}
@@ -0,0 +1,336 @@
+package inspect
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// certDialTimeout bounds each TLS handshake used to read a serving certificate.
+const certDialTimeout = 5 * time.Second
+
+// Control-plane components whose certificates are checked.
+const (
+	CertAPIServer           = "kube-apiserver"
+	CertAPIServerEtcdClient = "apiserver-etcd-client"
+	CertEtcdServer          = "etcd-server"
+	CertEtcdPeer            = "etcd-peer"
+	CertKubelet             = "kubelet"
+	CertKubeletClient       = "kubelet-client"
+)
+
+// kubeadmCertsSecret is where kubeadm init --upload-certs stores the control-plane certificates
+// for joining control-plane nodes, each encrypted with the certificate key. kubeadm deletes it
+// after two hours.
+const kubeadmCertsSecret = "kubeadm-certs"
+
+// CertificateStatus is the expiry of one certificate presented by, or issued to, a control-plane component.
+type CertificateStatus struct {
+	Component string    `json:"component"`
+	Target    string    `json:"target"`
+	Subject   string    `json:"subject,omitempty"`
+	NotAfter  time.Time `json:"notAfter,omitempty"`
+	// Error is set when the certificate could not be read, e.g. the port is unreachable from here.
+	Error string `json:"error,omitempty"`
+}
+
+// DaysRemaining returns the whole days left before the certificate expires, negative once expired.
+func (c CertificateStatus) DaysRemaining(now time.Time) int {
+	return int(c.NotAfter.Sub(now).Hours() / 24)
+}
+
+// GetCertificateExpiry reads the certificates served by the API server and, on every control-plane
+// node, by etcd's client and peer ports and the kubelet, plus kubelet client certificates still
+// visible in approved CertificateSigningRequests and the API server's etcd client certificate.
+// Targets that can't be reached are reported with an error rather than failing the whole check,
+// since node ports are often firewalled.
+func GetCertificateExpiry(ctx context.Context, clientset kubernetes.Interface, config *rest.Config, opts ScanOptions) ([]CertificateStatus, error) {
+	targets := []CertificateStatus{{Component: CertAPIServer, Target: apiServerAddress(config)}}
+
+	nodes, err := listControlPlaneNodes(ctx, clientset, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		ip := nodeInternalIP(node)
+		if ip == "" {
+			continue
+		}
+		kubeletPort := int(node.Status.DaemonEndpoints.KubeletEndpoint.Port)
+		if kubeletPort == 0 {
+			kubeletPort = 10250
+		}
+		targets = append(targets,
+			CertificateStatus{Component: CertEtcdServer, Target: net.JoinHostPort(ip, "2379")},
+			CertificateStatus{Component: CertEtcdPeer, Target: net.JoinHostPort(ip, "2380")},
+			CertificateStatus{Component: CertKubelet, Target: net.JoinHostPort(ip, strconv.Itoa(kubeletPort))},
+		)
+	}
+
+	tasks := make([]func(), len(targets))
+	for i := range targets {
+		tasks[i] = func() {
+			cert, err := fetchServingCertificate(ctx, targets[i].Target)
+			if err != nil {
+				targets[i].Error = err.Error()
+				return
+			}
+			targets[i].Subject = cert.Subject.String()
+			targets[i].NotAfter = cert.NotAfter
+		}
+	}
+	runConcurrently(opts.Concurrency, tasks...)
+
+	clientCerts, err := getKubeletClientCertificates(ctx, clientset)
+	if err != nil {
+		return nil, err
+	}
+	targets = append(targets, clientCerts...)
+
+	etcdClient, err := getAPIServerEtcdClientCertificate(ctx, clientset, opts)
+	if err != nil {
+		return nil, err
+	}
+	if etcdClient != nil {
+		targets = append(targets, *etcdClient)
+	}
+	return targets, nil
+}
+
+// CheckCertificateExpiry raises a finding for every certificate expiring within warnDays.
+// Certificates expiring within a week, or already expired, are critical.
+func CheckCertificateExpiry(statuses []CertificateStatus, warnDays int, now time.Time) []Finding {
+	var findings []Finding
+	for _, c := range statuses {
+		if c.Error != "" || c.NotAfter.IsZero() {
+			continue
+		}
+		days := c.DaysRemaining(now)
+		if days > warnDays {
+			continue
+		}
+
+		severity := SeverityHigh
+		message := fmt.Sprintf("%s certificate at %s (%s) expires in %d day(s) on %s",
+			c.Component, c.Target, c.Subject, days, c.NotAfter.Format(time.DateOnly))
+		if days <= 7 {
+			severity = SeverityCritical
+		}
+		if !now.Before(c.NotAfter) {
+			message = fmt.Sprintf("%s certificate at %s (%s) expired on %s",
+				c.Component, c.Target, c.Subject, c.NotAfter.Format(time.DateOnly))
+		}
+		findings = append(findings, Finding{
+			CheckID:  "cert-expiring",
+			Severity: severity,
+			Kind:     "Certificate",
+			Name:     c.Component + "@" + c.Target,
+			Message:  message,
+		})
+	}
+	return findings
+}
+
+// fetchServingCertificate completes enough of a TLS handshake with address to read its leaf
+// certificate. Verification is skipped on purpose: only the expiry is of interest, and peers
+// that demand a client certificate abort the handshake only after presenting their own.
+func fetchServingCertificate(ctx context.Context, address string) (*x509.Certificate, error) {
+	var leaf *x509.Certificate
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return nil
+			}
+			cert, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+			leaf = cert
+			return nil
+		},
+	}
+
+	dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: certDialTimeout}, Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if conn != nil {
+		conn.Close()
+	}
+	if leaf != nil {
+		return leaf, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate from %s: %w", address, err)
+	}
+	return nil, fmt.Errorf("%s presented no certificate", address)
+}
+
+// getKubeletClientCertificates parses the certificates issued through approved kubelet client CSRs.
+// CSRs are garbage collected an hour after approval, so this only covers recent rotations, and it
+// is skipped silently when kube-op may not list CSRs.
+func getKubeletClientCertificates(ctx context.Context, clientset kubernetes.Interface) ([]CertificateStatus, error) {
+	csrs, err := clientset.CertificatesV1().CertificateSigningRequests().List(ctx, metav1.ListOptions{})
+	if apierrors.IsForbidden(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list certificate signing requests: %w", err)
+	}
+
+	var statuses []CertificateStatus
+	for _, csr := range csrs.Items {
+		if csr.Spec.SignerName != certificatesv1.KubeAPIServerClientKubeletSignerName || len(csr.Status.Certificate) == 0 {
+			continue
+		}
+		block, _ := pem.Decode(csr.Status.Certificate)
+		if block == nil {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		statuses = append(statuses, CertificateStatus{
+			Component: CertKubeletClient,
+			Target:    "csr/" + csr.Name,
+			Subject:   cert.Subject.String(),
+			NotAfter:  cert.NotAfter,
+		})
+	}
+	return statuses, nil
+}
+
+// getAPIServerEtcdClientCertificate reads the client certificate the API server authenticates
+// to etcd with. No port serves it, so it is read from the kubeadm-certs Secret, decrypted with
+// the key in opts.KubeadmCertificateKeyFile. It returns nil when no kube-apiserver pod sets
+// --etcd-certfile, as on managed control planes, and a status with an error when the
+// certificate can't be read.
+func getAPIServerEtcdClientCertificate(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*CertificateStatus, error) {
+	pods, err := listPods(ctx, clientset, opts, "kube-system", metav1.ListOptions{LabelSelector: "component=kube-apiserver"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list kube-apiserver pods: %w", err)
+	}
+	var status *CertificateStatus
+	for i := range pods {
+		if file := apiServerFlags(&pods[i])["etcd-certfile"]; file != "" {
+			status = &CertificateStatus{Component: CertAPIServerEtcdClient, Target: pods[i].Spec.NodeName + ":" + file}
+			break
+		}
+	}
+	if status == nil {
+		return nil, nil
+	}
+
+	cert, err := readKubeadmCertificate(ctx, clientset, opts.KubeadmCertificateKeyFile, "apiserver-etcd-client.crt")
+	if err != nil {
+		status.Error = err.Error()
+		return status, nil
+	}
+	status.Subject = cert.Subject.String()
+	status.NotAfter = cert.NotAfter
+	return status, nil
+}
+
+// readKubeadmCertificate decrypts and parses one certificate from the kubeadm-certs Secret, which
+// kubeadm encrypts with AES-GCM under the certificate key, nonce first.
+func readKubeadmCertificate(ctx context.Context, clientset kubernetes.Interface, keyFile, name string) (*x509.Certificate, error) {
+	if keyFile == "" {
+		return nil, fmt.Errorf("pass --kubeadm-certificate-key-file to read it from the %s Secret", kubeadmCertsSecret)
+	}
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the kubeadm certificate key: %w", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeadm certificate key: %w", err)
+	}
+
+	secret, err := clientset.CoreV1().Secrets("kube-system").Get(ctx, kubeadmCertsSecret, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("the %s Secret only exists for two hours after kubeadm init --upload-certs", kubeadmCertsSecret)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the %s Secret: %w", kubeadmCertsSecret, err)
+	}
+	encrypted, ok := secret.Data[name]
+	if !ok {
+		return nil, fmt.Errorf("the %s Secret has no %s", kubeadmCertsSecret, name)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeadm certificate key: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%s in the %s Secret is too short", name, kubeadmCertsSecret)
+	}
+	plain, err := gcm.Open(nil, encrypted[:gcm.NonceSize()], encrypted[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s (wrong certificate key?): %w", name, err)
+	}
+	pemBlock, _ := pem.Decode(plain)
+	if pemBlock == nil {
+		return nil, fmt.Errorf("%s in the %s Secret is not PEM", name, kubeadmCertsSecret)
+	}
+	return x509.ParseCertificate(pemBlock.Bytes)
+}
+
+// listControlPlaneNodes returns the nodes carrying the control-plane role label, or its pre-1.20 name.
+func listControlPlaneNodes(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) ([]corev1.Node, error) {
+	var nodes []corev1.Node
+	for _, role := range []string{"node-role.kubernetes.io/control-plane", "node-role.kubernetes.io/master"} {
+		found, err := listNodes(ctx, clientset, opts, metav1.ListOptions{LabelSelector: role})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list control-plane nodes: %w", err)
+		}
+		if len(found) > 0 {
+			nodes = found
+			break
+		}
+	}
+	return nodes, nil
+}
+
+func nodeInternalIP(node corev1.Node) string {
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == corev1.NodeInternalIP {
+			return addr.Address
+		}
+	}
+	return ""
+}
+
+// apiServerAddress returns the host:port of the API server the client talks to.
+func apiServerAddress(config *rest.Config) string {
+	u, err := url.Parse(config.Host)
+	if err != nil || u.Host == "" {
+		return config.Host
+	}
+	if u.Port() != "" {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), "443")
+}
@@ -0,0 +1,132 @@
+package inspect
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestFetchServingCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(nil)
+	defer server.Close()
+
+	cert, err := fetchServingCertificate(context.Background(), strings.TrimPrefix(server.URL, "https://"))
+	if err != nil {
+		t.Fatalf("fetchServingCertificate() returned error = %v, want nil", err)
+	}
+	if !cert.NotAfter.Equal(server.Certificate().NotAfter) {
+		t.Errorf("fetchServingCertificate() NotAfter = %v, want %v", cert.NotAfter, server.Certificate().NotAfter)
+	}
+}
+
+func TestFetchServingCertificate_Unreachable(t *testing.T) {
+	server := httptest.NewServer(nil)
+	address := strings.TrimPrefix(server.URL, "http://")
+	server.Close()
+
+	if _, err := fetchServingCertificate(context.Background(), address); err == nil {
+		t.Errorf("fetchServingCertificate() on closed port returned error = nil, want non-nil")
+	}
+}
+
+func TestGetAPIServerEtcdClientCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(nil)
+	server.Close()
+	want := server.Certificate()
+
+	// Encrypt the certificate the way kubeadm init --upload-certs does.
+	key := make([]byte, 32)
+	rand.Read(key)
+	block, _ := aes.NewCipher(key)
+	gcm, _ := cipher.NewGCM(block)
+	nonce := make([]byte, gcm.NonceSize())
+	rand.Read(nonce)
+	encrypted := gcm.Seal(nonce, nonce, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: want.Raw}), nil)
+	keyFile := filepath.Join(t.TempDir(), "certificate-key")
+	if err := os.WriteFile(keyFile, []byte(hex.EncodeToString(key)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	apiServer := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "kube-apiserver-cp-1", Labels: map[string]string{"component": "kube-apiserver"}},
+		Spec: corev1.PodSpec{NodeName: "cp-1", Containers: []corev1.Container{{
+			Name: "kube-apiserver", Command: []string{"kube-apiserver", "--etcd-certfile=/etc/kubernetes/pki/apiserver-etcd-client.crt"},
+		}}},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: kubeadmCertsSecret},
+		Data:       map[string][]byte{"apiserver-etcd-client.crt": encrypted},
+	}
+	opts := ScanOptions{KubeadmCertificateKeyFile: keyFile}
+
+	status, err := getAPIServerEtcdClientCertificate(context.Background(), fake.NewSimpleClientset(apiServer, secret), opts)
+	if err != nil {
+		t.Fatalf("getAPIServerEtcdClientCertificate() error = %v, want nil", err)
+	}
+	if status == nil || status.Error != "" || !status.NotAfter.Equal(want.NotAfter) || status.Component != CertAPIServerEtcdClient {
+		t.Fatalf("getAPIServerEtcdClientCertificate() = %+v, want %s expiring %v", status, CertAPIServerEtcdClient, want.NotAfter)
+	}
+	if status.Target != "cp-1:/etc/kubernetes/pki/apiserver-etcd-client.crt" {
+		t.Errorf("Target = %q, want the node and --etcd-certfile path", status.Target)
+	}
+
+	status, err = getAPIServerEtcdClientCertificate(context.Background(), fake.NewSimpleClientset(apiServer), opts)
+	if err != nil || status == nil || !strings.Contains(status.Error, "two hours") {
+		t.Errorf("getAPIServerEtcdClientCertificate() without the Secret = %+v, %v, want an error status", status, err)
+	}
+	status, err = getAPIServerEtcdClientCertificate(context.Background(), fake.NewSimpleClientset(apiServer, secret), ScanOptions{})
+	if err != nil || status == nil || !strings.Contains(status.Error, "--kubeadm-certificate-key-file") {
+		t.Errorf("getAPIServerEtcdClientCertificate() without a key = %+v, %v, want an error status", status, err)
+	}
+	if status, err := getAPIServerEtcdClientCertificate(context.Background(), fake.NewSimpleClientset(), opts); status != nil || err != nil {
+		t.Errorf("getAPIServerEtcdClientCertificate() without kube-apiserver pods = %+v, %v, want nil, nil", status, err)
+	}
+}
+
+func TestCheckCertificateExpiry(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	statuses := []CertificateStatus{
+		{Component: CertAPIServer, Target: "cp:6443", NotAfter: now.AddDate(1, 0, 0)},
+		{Component: CertEtcdPeer, Target: "10.0.0.1:2380", NotAfter: now.AddDate(0, 0, 20)},
+		{Component: CertKubelet, Target: "10.0.0.1:10250", NotAfter: now.AddDate(0, 0, -1)},
+		{Component: CertEtcdServer, Target: "10.0.0.1:2379", Error: "connection refused"},
+	}
+
+	findings := CheckCertificateExpiry(statuses, 30, now)
+	if len(findings) != 2 {
+		t.Fatalf("CheckCertificateExpiry() returned %d findings, want 2", len(findings))
+	}
+	if findings[0].Severity != SeverityHigh || !strings.Contains(findings[0].Name, CertEtcdPeer) {
+		t.Errorf("CheckCertificateExpiry()[0] = %+v, want high finding for etcd-peer", findings[0])
+	}
+	if findings[1].Severity != SeverityCritical || !strings.Contains(findings[1].Message, "expired") {
+		t.Errorf("CheckCertificateExpiry()[1] = %+v, want critical expired finding for kubelet", findings[1])
+	}
+}
+
+func TestAPIServerAddress(t *testing.T) {
+	tests := map[string]string{
+		"https://10.0.0.1:6443":       "10.0.0.1:6443",
+		"https://api.example.com":     "api.example.com:443",
+		"https://[2001:db8::1]:6443/": "[2001:db8::1]:6443",
+	}
+	for host, want := range tests {
+		if got := apiServerAddress(&rest.Config{Host: host}); got != want {
+			t.Errorf("apiServerAddress(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
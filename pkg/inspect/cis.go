@@ -0,0 +1,369 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// cisBenchmark is the CIS Kubernetes Benchmark release the controls are numbered after.
+const cisBenchmark = "CIS Kubernetes Benchmark v1.8.0"
+
+// cisLegacyBenchmark numbers the insecure port controls, which later releases dropped along
+// with the flags.
+const cisLegacyBenchmark = "CIS Kubernetes Benchmark v1.6.0"
+
+// cisListLimit caps how many roles or bindings a control's detail names.
+const cisListLimit = 5
+
+// CISResult is the outcome of one benchmark control.
+type CISResult string
+
+const (
+	CISPass CISResult = "pass"
+	CISFail CISResult = "fail"
+	// CISWarn is used for controls the benchmark leaves to judgement, like who may be
+	// cluster-admin, when the cluster does something the control asks to minimize.
+	CISWarn CISResult = "warn"
+)
+
+// CISReport is the result of the CIS Kubernetes Benchmark controls that can be assessed through
+// the API: API server flags, read from its static pod, and RBAC settings. Node, etcd, and
+// file-permission controls need access to the hosts and are left to kube-bench.
+type CISReport struct {
+	Benchmark string `json:"benchmark"`
+	// APIServerPods are the kube-apiserver pods the API server controls were read from. It is
+	// empty when the control plane is not visible as pods, as on managed clusters, and those
+	// controls are then left out.
+	APIServerPods []string     `json:"apiServerPods"`
+	Controls      []CISControl `json:"controls"`
+}
+
+// CISControl is one benchmark control and its result.
+type CISControl struct {
+	ID     string    `json:"id"`
+	Title  string    `json:"title"`
+	Result CISResult `json:"result"`
+	Detail string    `json:"detail"`
+	// Reference names the benchmark release and control.
+	Reference string `json:"reference"`
+
+	severity Severity
+}
+
+// Count returns how many controls had the result.
+func (r *CISReport) Count(result CISResult) int {
+	n := 0
+	for _, c := range r.Controls {
+		if c.Result == result {
+			n++
+		}
+	}
+	return n
+}
+
+// cisCheck assesses a control from the API server's flags, keyed by name without the dashes.
+type cisCheck func(flags map[string]string) (CISResult, string)
+
+type cisFlagControl struct {
+	id, title string
+	severity  Severity
+	// benchmark is set for controls from an older benchmark release than cisBenchmark.
+	benchmark string
+	check     cisCheck
+}
+
+// cisAPIServerControls are the API server controls, in benchmark order.
+var cisAPIServerControls = []cisFlagControl{
+	{id: "1.2.1", title: "Ensure that the --anonymous-auth argument is set to false", severity: SeverityHigh, check: flagEquals("anonymous-auth", "false", "true")},
+	{id: "1.2.2", title: "Ensure that the --token-auth-file parameter is not set", severity: SeverityMedium, check: flagUnset("token-auth-file")},
+	{id: "1.2.6", title: "Ensure that the --authorization-mode argument is not set to AlwaysAllow", severity: SeverityHigh, check: flagExcludes("authorization-mode", "AlwaysAllow", "AlwaysAllow")},
+	{id: "1.2.7", title: "Ensure that the --authorization-mode argument includes Node", severity: SeverityMedium, check: flagIncludes("authorization-mode", "Node", "AlwaysAllow")},
+	{id: "1.2.8", title: "Ensure that the --authorization-mode argument includes RBAC", severity: SeverityHigh, check: flagIncludes("authorization-mode", "RBAC", "AlwaysAllow")},
+	{id: "1.2.10", title: "Ensure that the admission control plugin AlwaysAdmit is not set", severity: SeverityHigh, check: flagExcludes("enable-admission-plugins", "AlwaysAdmit", "")},
+	{id: "1.2.14", title: "Ensure that the admission control plugin NodeRestriction is set", severity: SeverityMedium, check: flagIncludes("enable-admission-plugins", "NodeRestriction", "")},
+	{id: "1.2.15", title: "Ensure that the --profiling argument is set to false", severity: SeverityLow, check: flagEquals("profiling", "false", "true")},
+	{id: "1.2.16", title: "Ensure that the --audit-log-path argument is set", severity: SeverityMedium, check: flagSet("audit-log-path")},
+	{id: "1.2.18", title: "Ensure that the --insecure-bind-address argument is not set", severity: SeverityHigh, benchmark: cisLegacyBenchmark, check: flagUnset("insecure-bind-address")},
+	// The insecure port defaults to 0 since Kubernetes 1.20, and the flag was removed in 1.24.
+	{id: "1.2.19", title: "Ensure that the --insecure-port argument is set to 0", severity: SeverityHigh, benchmark: cisLegacyBenchmark, check: flagEquals("insecure-port", "0", "0")},
+	{id: "1.2.27", title: "Ensure that the --encryption-provider-config argument is set as appropriate", severity: SeverityMedium, check: flagSet("encryption-provider-config")},
+	{id: "3.2.1", title: "Ensure that a minimal audit policy is created", severity: SeverityMedium, check: flagSet("audit-policy-file")},
+}
+
+// describeFlag renders a flag as it appears on the command line, or says it is unset.
+func describeFlag(flags map[string]string, name, def string) string {
+	value, ok := flags[name]
+	if !ok {
+		if def == "" {
+			return "--" + name + " is not set"
+		}
+		return fmt.Sprintf("--%s is not set (default %s)", name, def)
+	}
+	return "--" + name + "=" + value
+}
+
+// flagValue returns the flag's value, or def when it is not set.
+func flagValue(flags map[string]string, name, def string) string {
+	if value, ok := flags[name]; ok {
+		return value
+	}
+	return def
+}
+
+func flagEquals(name, want, def string) cisCheck {
+	return func(flags map[string]string) (CISResult, string) {
+		if flagValue(flags, name, def) == want {
+			return CISPass, describeFlag(flags, name, def)
+		}
+		return CISFail, describeFlag(flags, name, def)
+	}
+}
+
+func flagSet(name string) cisCheck {
+	return func(flags map[string]string) (CISResult, string) {
+		if flags[name] != "" {
+			return CISPass, describeFlag(flags, name, "")
+		}
+		return CISFail, describeFlag(flags, name, "")
+	}
+}
+
+func flagUnset(name string) cisCheck {
+	return func(flags map[string]string) (CISResult, string) {
+		if _, ok := flags[name]; ok {
+			return CISFail, describeFlag(flags, name, "")
+		}
+		return CISPass, describeFlag(flags, name, "")
+	}
+}
+
+// flagIncludes passes when the comma-separated list flag contains item.
+func flagIncludes(name, item, def string) cisCheck {
+	return func(flags map[string]string) (CISResult, string) {
+		if slices.Contains(strings.Split(flagValue(flags, name, def), ","), item) {
+			return CISPass, describeFlag(flags, name, def)
+		}
+		return CISFail, describeFlag(flags, name, def)
+	}
+}
+
+// flagExcludes passes when the comma-separated list flag does not contain item.
+func flagExcludes(name, item, def string) cisCheck {
+	return func(flags map[string]string) (CISResult, string) {
+		if slices.Contains(strings.Split(flagValue(flags, name, def), ","), item) {
+			return CISFail, describeFlag(flags, name, def)
+		}
+		return CISPass, describeFlag(flags, name, def)
+	}
+}
+
+// apiServerFlags parses the --name=value and bare --name arguments of the API server container.
+func apiServerFlags(pod *corev1.Pod) map[string]string {
+	container := &pod.Spec.Containers[0]
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == "kube-apiserver" {
+			container = &pod.Spec.Containers[i]
+		}
+	}
+	flags := map[string]string{}
+	for _, arg := range append(append([]string{}, container.Command...), container.Args...) {
+		if !strings.HasPrefix(arg, "--") {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if !ok {
+			value = "true"
+		}
+		flags[name] = value
+	}
+	return flags
+}
+
+// cisResultRank orders results from best to worst.
+var cisResultRank = map[CISResult]int{CISPass: 0, CISWarn: 1, CISFail: 2}
+
+// GetCISReport reads the kube-apiserver static pods and the cluster's RBAC objects and assesses
+// the benchmark controls against them.
+func GetCISReport(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*CISReport, error) {
+	pods, err := listPods(ctx, clientset, opts, "kube-system", metav1.ListOptions{LabelSelector: "component=kube-apiserver"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list kube-apiserver pods: %w", err)
+	}
+	policy, err := GetRBACPolicy(ctx, clientset, opts)
+	if err != nil {
+		return nil, err
+	}
+	return BuildCISReport(pods, policy), nil
+}
+
+// BuildCISReport assesses the API server controls against every kube-apiserver pod, keeping the
+// worst result when they disagree, and the RBAC controls against policy.
+func BuildCISReport(apiServers []corev1.Pod, policy *RBACPolicy) *CISReport {
+	report := &CISReport{Benchmark: cisBenchmark, APIServerPods: []string{}, Controls: []CISControl{}}
+	var flags []map[string]string
+	for i := range apiServers {
+		if len(apiServers[i].Spec.Containers) == 0 {
+			continue
+		}
+		report.APIServerPods = append(report.APIServerPods, apiServers[i].Name)
+		flags = append(flags, apiServerFlags(&apiServers[i]))
+	}
+	sort.Strings(report.APIServerPods)
+
+	if len(flags) > 0 {
+		for _, c := range cisAPIServerControls {
+			benchmark := c.benchmark
+			if benchmark == "" {
+				benchmark = cisBenchmark
+			}
+			control := CISControl{ID: c.id, Title: c.title, Reference: benchmark + ", control " + c.id, severity: c.severity}
+			for i, f := range flags {
+				result, detail := c.check(f)
+				if control.Result != "" && cisResultRank[result] <= cisResultRank[control.Result] {
+					continue
+				}
+				if len(flags) > 1 {
+					detail = apiServers[i].Name + ": " + detail
+				}
+				control.Result, control.Detail = result, detail
+			}
+			report.Controls = append(report.Controls, control)
+		}
+	}
+
+	report.Controls = append(report.Controls, cisRBACControls(policy)...)
+	return report
+}
+
+// isDefaultRole reports whether a role is one Kubernetes creates and reconciles itself.
+func isDefaultRole(meta metav1.ObjectMeta) bool {
+	return strings.HasPrefix(meta.Name, "system:") || meta.Labels["kubernetes.io/bootstrapping"] == "rbac-defaults"
+}
+
+// cisNames joins up to cisListLimit names, saying how many more there are.
+func cisNames(names []string) string {
+	sort.Strings(names)
+	if len(names) <= cisListLimit {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s, and %d more", strings.Join(names[:cisListLimit], ", "), len(names)-cisListLimit)
+}
+
+// cisRBACControls assesses the RBAC controls the benchmark leaves to judgement: each warns when
+// the cluster does what the control asks to minimize.
+func cisRBACControls(policy *RBACPolicy) []CISControl {
+	control := func(id, title, warning string, names []string) CISControl {
+		c := CISControl{ID: id, Title: title, Result: CISPass, Detail: "none found", Reference: cisBenchmark + ", control " + id, severity: SeverityLow}
+		if len(names) > 0 {
+			c.Result, c.Detail = CISWarn, fmt.Sprintf(warning, len(names), cisNames(names))
+		}
+		return c
+	}
+
+	// Bindings the cluster creates itself, like cluster-admin to system:masters, are expected.
+	var clusterAdmin, defaultSA []string
+	bindingUses := func(name, namespace string, roleRef rbacv1.RoleRef, subjects []rbacv1.Subject) {
+		if strings.HasPrefix(name, "system:") {
+			return
+		}
+		if namespace != "" {
+			name = namespace + "/" + name
+		}
+		if roleRef.Kind == "ClusterRole" && roleRef.Name == "cluster-admin" {
+			clusterAdmin = append(clusterAdmin, name)
+		}
+		for _, s := range subjects {
+			if s.Kind == rbacv1.ServiceAccountKind && s.Name == "default" {
+				defaultSA = append(defaultSA, name)
+				break
+			}
+		}
+	}
+	for _, b := range policy.ClusterRoleBindings {
+		bindingUses(b.Name, "", b.RoleRef, b.Subjects)
+	}
+	for _, b := range policy.RoleBindings {
+		bindingUses(b.Name, b.Namespace, b.RoleRef, b.Subjects)
+	}
+
+	var wildcards, escalation []string
+	roleGrants := func(name string, rules []rbacv1.PolicyRule) {
+		wildcard, escalates := false, false
+		for _, r := range rules {
+			wildcard = wildcard || slices.Contains(r.Verbs, "*") || slices.Contains(r.Resources, "*") || slices.Contains(r.APIGroups, "*")
+			escalates = escalates || slices.ContainsFunc(r.Verbs, func(v string) bool {
+				return v == "bind" || v == "escalate" || v == "impersonate"
+			})
+		}
+		if wildcard {
+			wildcards = append(wildcards, name)
+		}
+		if escalates {
+			escalation = append(escalation, name)
+		}
+	}
+	for _, r := range policy.ClusterRoles {
+		if !isDefaultRole(r.ObjectMeta) && r.AggregationRule == nil {
+			roleGrants(r.Name, r.Rules)
+		}
+	}
+	for _, r := range policy.Roles {
+		if !isDefaultRole(r.ObjectMeta) {
+			roleGrants(r.Namespace+"/"+r.Name, r.Rules)
+		}
+	}
+
+	return []CISControl{
+		control("5.1.1", "Ensure that the cluster-admin role is only used where required",
+			"%d binding(s) grant cluster-admin: %s", clusterAdmin),
+		control("5.1.3", "Minimize wildcard use in Roles and ClusterRoles",
+			"%d role(s) use wildcards: %s", wildcards),
+		control("5.1.5", "Ensure that default service accounts are not actively used",
+			"%d binding(s) grant roles to a default ServiceAccount: %s", defaultSA),
+		control("5.1.8", "Limit use of the Bind, Impersonate and Escalate permissions in the Kubernetes cluster",
+			"%d role(s) grant bind, escalate, or impersonate: %s", escalation),
+	}
+}
+
+// CheckCIS reports failed controls at their severity and warnings as low.
+func CheckCIS(report *CISReport) []Finding {
+	var findings []Finding
+	for _, c := range report.Controls {
+		f := Finding{Kind: "CISControl", Name: c.ID, Message: fmt.Sprintf("CIS %s %s: %s", c.ID, c.Title, c.Detail)}
+		switch c.Result {
+		case CISFail:
+			f.CheckID, f.Severity = "cis-control-failed", c.severity
+		case CISWarn:
+			f.CheckID, f.Severity = "cis-control-warning", SeverityLow
+		default:
+			continue
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// PrintCISReport writes the CIS section of the text report. Passing controls are only listed
+// with all.
+func PrintCISReport(w io.Writer, report *CISReport, all bool) {
+	fmt.Fprintf(w, "CIS benchmark (%s): %d pass, %d fail, %d warn\n",
+		report.Benchmark, report.Count(CISPass), report.Count(CISFail), report.Count(CISWarn))
+	if len(report.APIServerPods) == 0 {
+		fmt.Fprintln(w, "  No kube-apiserver pods found, so the API server controls were not assessed (managed control plane?).")
+	}
+	for _, c := range report.Controls {
+		if c.Result == CISPass && !all {
+			continue
+		}
+		fmt.Fprintf(w, "  %-4s %-6s %s: %s\n", strings.ToUpper(string(c.Result)), c.ID, c.Title, c.Detail)
+	}
+}
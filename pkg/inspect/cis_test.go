@@ -0,0 +1,102 @@
+package inspect
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func apiServerPod(name string, args ...string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kube-system", Labels: map[string]string{"component": "kube-apiserver"}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "kube-apiserver", Command: append([]string{"kube-apiserver"}, args...)},
+		}},
+	}
+}
+
+func cisResults(report *CISReport) map[string]CISResult {
+	results := map[string]CISResult{}
+	for _, c := range report.Controls {
+		results[c.ID] = c.Result
+	}
+	return results
+}
+
+func TestBuildCISReportAPIServer(t *testing.T) {
+	hardened := []string{
+		"--anonymous-auth=false", "--authorization-mode=Node,RBAC", "--enable-admission-plugins=NodeRestriction",
+		"--profiling=false", "--audit-log-path=/var/log/audit.log", "--audit-policy-file=/etc/kubernetes/audit.yaml",
+		"--encryption-provider-config=/etc/kubernetes/enc.yaml",
+	}
+	report := BuildCISReport([]corev1.Pod{
+		apiServerPod("kube-apiserver-cp-1", hardened...),
+		// A second control-plane node that drifted: the worst result wins.
+		apiServerPod("kube-apiserver-cp-2", append(hardened, "--token-auth-file=/etc/tokens.csv", "--insecure-port=8080")...),
+	}, &RBACPolicy{})
+
+	results := cisResults(report)
+	for id, want := range map[string]CISResult{
+		"1.2.1": CISPass, "1.2.2": CISFail, "1.2.6": CISPass, "1.2.8": CISPass, "1.2.14": CISPass,
+		"1.2.18": CISPass, "1.2.19": CISFail, "3.2.1": CISPass,
+	} {
+		if results[id] != want {
+			t.Errorf("control %s = %q, want %q", id, results[id], want)
+		}
+	}
+	for _, c := range report.Controls {
+		if c.ID == "1.2.2" && c.Detail != "kube-apiserver-cp-2: --token-auth-file=/etc/tokens.csv" {
+			t.Errorf("1.2.2 detail = %q, want it to name the failing pod", c.Detail)
+		}
+	}
+
+	defaults := cisResults(BuildCISReport([]corev1.Pod{apiServerPod("kube-apiserver-cp-1")}, &RBACPolicy{}))
+	for _, id := range []string{"1.2.1", "1.2.6", "1.2.8", "1.2.15", "1.2.16", "3.2.1"} {
+		if defaults[id] != CISFail {
+			t.Errorf("control %s with default flags = %q, want fail", id, defaults[id])
+		}
+	}
+}
+
+func TestBuildCISReportRBAC(t *testing.T) {
+	policy := &RBACPolicy{
+		ClusterRoles: []rbacv1.ClusterRole{
+			{ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin", Labels: map[string]string{"kubernetes.io/bootstrapping": "rbac-defaults"}},
+				Rules: []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "ci-deployer"},
+				Rules: []rbacv1.PolicyRule{{APIGroups: []string{"apps"}, Resources: []string{"*"}, Verbs: []string{"get"}}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "role-manager"},
+				Rules: []rbacv1.PolicyRule{{APIGroups: []string{"rbac.authorization.k8s.io"}, Resources: []string{"clusterroles"}, Verbs: []string{"bind"}}}},
+		},
+		ClusterRoleBindings: []rbacv1.ClusterRoleBinding{
+			{ObjectMeta: metav1.ObjectMeta{Name: "system:masters-admin"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "ci-admin"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"}},
+		},
+		RoleBindings: []rbacv1.RoleBinding{
+			{ObjectMeta: metav1.ObjectMeta{Name: "view", Namespace: "shop"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"},
+				Subjects: []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "default", Namespace: "shop"}}},
+		},
+	}
+
+	report := BuildCISReport(nil, policy)
+	if len(report.APIServerPods) != 0 || len(report.Controls) != 4 {
+		t.Fatalf("got %d API server pods and %d controls, want only the 4 RBAC controls", len(report.APIServerPods), len(report.Controls))
+	}
+	want := map[string]string{
+		"5.1.1": "1 binding(s) grant cluster-admin: ci-admin",
+		"5.1.3": "1 role(s) use wildcards: ci-deployer",
+		"5.1.5": "1 binding(s) grant roles to a default ServiceAccount: shop/view",
+		"5.1.8": "1 role(s) grant bind, escalate, or impersonate: role-manager",
+	}
+	for _, c := range report.Controls {
+		if c.Result != CISWarn || c.Detail != want[c.ID] {
+			t.Errorf("control %s = %s %q, want warn %q", c.ID, c.Result, c.Detail, want[c.ID])
+		}
+	}
+
+	if findings := CheckCIS(report); len(findings) != 4 || findings[0].CheckID != "cis-control-warning" || findings[0].Resource() != "CISControl/5.1.1" {
+		t.Errorf("CheckCIS() = %+v, want a low warning per control", findings)
+	}
+}
@@ -0,0 +1,82 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// ClusterIdentity attributes a report to the cluster it was taken from, so archived reports
+// and fleet stores can tell clusters apart even when they share a name or an API server URL.
+type ClusterIdentity struct {
+	// ID is the UID of the kube-system namespace, which lives as long as the cluster does.
+	ID string `json:"id,omitempty"`
+	// Name is the cluster name from the kubeconfig context the scan used.
+	Name string `json:"name,omitempty"`
+	// Server is the API server URL the scan connected to.
+	Server string `json:"server,omitempty"`
+	// Provider is the detected platform, such as EKS or k3s, or the infrastructure provider
+	// when the platform is unrecognized.
+	Provider string `json:"provider,omitempty"`
+}
+
+// String names the cluster for the text report.
+func (c *ClusterIdentity) String() string {
+	name := c.Name
+	if name == "" {
+		name = "unnamed cluster"
+	}
+	if c.ID != "" {
+		name += " (ID " + c.ID + ")"
+	}
+	return name
+}
+
+// GetClusterIdentity reads the cluster ID from the kube-system namespace. The server comes from
+// config and the name from opts.ClusterName; both are set even when the ID cannot be read.
+func GetClusterIdentity(ctx context.Context, clientset kubernetes.Interface, config *rest.Config, opts ScanOptions) (*ClusterIdentity, error) {
+	identity := &ClusterIdentity{Name: opts.ClusterName}
+	if config != nil {
+		identity.Server = config.Host
+	}
+
+	namespace, err := clientset.CoreV1().Namespaces().Get(ctx, "kube-system", metav1.GetOptions{})
+	if err != nil {
+		return identity, fmt.Errorf("failed to get the kube-system namespace: %w", err)
+	}
+	identity.ID = string(namespace.UID)
+	return identity, nil
+}
+
+// clusterProvider names the provider of a detected platform for ClusterIdentity.
+func clusterProvider(p *PlatformInfo) string {
+	if p == nil {
+		return ""
+	}
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Provider
+}
+
+// printClusterIdentity writes the identity block that heads the text report.
+func printClusterIdentity(w io.Writer, report *Report) {
+	c := report.Cluster
+	if c == nil {
+		return
+	}
+	fmt.Fprintf(w, "Cluster: %s\n", c)
+	if c.Server != "" {
+		fmt.Fprintf(w, "  API server: %s\n", c.Server)
+	}
+	if c.Provider != "" {
+		fmt.Fprintf(w, "  Provider: %s\n", c.Provider)
+	}
+	if msg, ok := report.Errors[sectionCluster]; ok {
+		fmt.Fprintf(w, "  Could not determine the cluster ID: %s\n", msg)
+	}
+}
@@ -0,0 +1,70 @@
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestGetClusterIdentity(t *testing.T) {
+	clientset := fake.NewClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "1b4e28ba"}})
+	config := &rest.Config{Host: "https://10.0.0.1:6443"}
+
+	identity, err := GetClusterIdentity(context.Background(), clientset, config, ScanOptions{ClusterName: "prod-east"})
+	if err != nil {
+		t.Fatalf("GetClusterIdentity() returned error = %v, want nil", err)
+	}
+	want := ClusterIdentity{ID: "1b4e28ba", Name: "prod-east", Server: "https://10.0.0.1:6443"}
+	if *identity != want {
+		t.Errorf("GetClusterIdentity() = %+v, want %+v", *identity, want)
+	}
+
+	// Without kube-system the name and server still identify the cluster.
+	identity, err = GetClusterIdentity(context.Background(), fake.NewClientset(), config, ScanOptions{ClusterName: "prod-east"})
+	if err == nil {
+		t.Error("GetClusterIdentity() without kube-system returned error = nil, want non-nil")
+	}
+	if identity.Name != "prod-east" || identity.Server != "https://10.0.0.1:6443" || identity.ID != "" {
+		t.Errorf("GetClusterIdentity() without kube-system = %+v, want name and server only", *identity)
+	}
+}
+
+func TestClusterProvider(t *testing.T) {
+	tests := []struct {
+		platform *PlatformInfo
+		want     string
+	}{
+		{nil, ""},
+		{&PlatformInfo{Name: PlatformEKS, Provider: "aws"}, PlatformEKS},
+		{&PlatformInfo{Provider: "gce"}, "gce"},
+	}
+	for _, tt := range tests {
+		if got := clusterProvider(tt.platform); got != tt.want {
+			t.Errorf("clusterProvider(%+v) = %q, want %q", tt.platform, got, tt.want)
+		}
+	}
+}
+
+func TestPrintClusterIdentity(t *testing.T) {
+	var b bytes.Buffer
+	printClusterIdentity(&b, &Report{
+		Cluster: &ClusterIdentity{Server: "https://k3s.lan:6443", Provider: PlatformK3s},
+		Errors:  map[string]string{sectionCluster: "forbidden"},
+	})
+	want := "Cluster: unnamed cluster\n  API server: https://k3s.lan:6443\n  Provider: k3s\n  Could not determine the cluster ID: forbidden\n"
+	if got := b.String(); got != want {
+		t.Errorf("printClusterIdentity() = %q, want %q", got, want)
+	}
+
+	b.Reset()
+	printClusterIdentity(&b, &Report{Cluster: &ClusterIdentity{ID: "1b4e28ba", Name: "prod-east"}})
+	if got := b.String(); !strings.HasPrefix(got, "Cluster: prod-east (ID 1b4e28ba)\n") {
+		t.Errorf("printClusterIdentity() = %q, want it to start with the name and ID", got)
+	}
+}
@@ -0,0 +1,686 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Permission is an API access a collector needs.
+type Permission struct {
+	Group string `json:"group"`
+	// Resource is the plural resource name, with a subresource after a slash, like pods/exec.
+	Resource string   `json:"resource"`
+	Verbs    []string `json:"verbs"`
+	// Namespace limits the permission to one namespace; empty means cluster-wide.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+func (p Permission) String() string {
+	resource := p.Resource
+	if p.Group != "" {
+		resource += "." + p.Group
+	}
+	s := strings.Join(p.Verbs, ",") + " " + resource
+	if p.Namespace != "" {
+		s += " in " + p.Namespace
+	}
+	return s
+}
+
+func allow(group, resource string, verbs ...string) Permission {
+	return Permission{Group: group, Resource: resource, Verbs: verbs}
+}
+
+func allowIn(namespace, group, resource string, verbs ...string) Permission {
+	return Permission{Group: group, Resource: resource, Verbs: verbs, Namespace: namespace}
+}
+
+// corePermissions are needed by every scan: the cluster size probe, the API server version, the
+// cluster identity, and platform detection run before any collector.
+var corePermissions = []Permission{
+	allow("", "nodes", "list"),
+	allow("", "namespaces", "get", "list"),
+	allowIn("kube-system", "", "configmaps", "get"),
+}
+
+// scanState is what collectors read and write during one scan.
+type scanState struct {
+	ctx       context.Context
+	clientset kubernetes.Interface
+	dynamic   dynamic.Interface
+	config    *rest.Config
+	opts      ScanOptions
+	report    *Report
+	owners    *OwnerResolver
+}
+
+// Collector is one section of the scan. RunScan runs the registered collectors the user selects
+// with --collectors and --skip-collectors; each fills in its part of the report and returns its
+// findings.
+type Collector struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	RBAC        []Permission `json:"rbac"`
+	// OptIn is the flag that enables a collector that is off by default, even when selected.
+	OptIn string `json:"optIn,omitempty"`
+	// Requires names the collectors whose results this one uses; they are selected along with it.
+	Requires []string `json:"requires,omitempty"`
+	// Checks are the check IDs of the collector's findings.
+	Checks []string `json:"checks,omitempty"`
+
+	// section is the Report.Errors key failures are recorded under.
+	section string
+	// after marks collectors that run once the concurrent ones have finished, because they use
+	// their results or contact the cluster's nodes directly.
+	after bool
+	// enabled reports whether an opt-in collector's flag is set.
+	enabled func(opts ScanOptions) bool
+	// clusterRBAC returns permissions that depend on what the cluster serves, such as list on
+	// each custom resource kind; ResolveRequiredPermissions adds them to RBAC.
+	clusterRBAC func(ctx context.Context, client dynamic.Interface, opts ScanOptions) ([]Permission, error)
+	// applies reports whether the collector can run on this cluster, given the results so far.
+	applies func(s *scanState) bool
+	run     func(s *scanState) ([]Finding, error)
+}
+
+// collectorRegistry holds every collector in the order they run and are listed.
+var collectorRegistry = []*Collector{
+	{
+		Name: "nodes", Description: "Kubernetes versions of the nodes, and OS, kernel, and runtime drift within node pools",
+		Checks:  []string{"node-pool-drift"},
+		RBAC:    []Permission{allow("", "nodes", "list")},
+		section: sectionNodes,
+		run: func(s *scanState) ([]Finding, error) {
+			inventory, err := GetNodeInventory(s.ctx, s.clientset, s.opts)
+			s.report.NodeInventory = inventory
+			if inventory == nil {
+				return nil, err
+			}
+			s.report.NodeVersions = inventory.KubeletVersions()
+			return CheckNodeDrift(inventory), err
+		},
+	},
+	{
+		Name: "endpoints", Description: "LoadBalancer, NodePort, and Ingress endpoints exposed outside the cluster",
+		Checks: []string{"exposure-no-ready-backends", "ingress-backend-missing"},
+		RBAC: []Permission{
+			allow("", "services", "list"), allow("networking.k8s.io", "ingresses", "list"), allow("", "nodes", "list"),
+			allow("discovery.k8s.io", "endpointslices", "list"),
+		},
+		section: sectionEndpoints,
+		run: func(s *scanState) (findings []Finding, err error) {
+			s.report.ExposedEndpoints, err = GetExposedEndpoints(s.ctx, s.clientset, s.opts)
+			return CheckBackends(s.report.ExposedEndpoints), err
+		},
+	},
+	{
+		Name: "ingress-controllers", Description: "Ingress controllers and their versions, IngressClasses, and Ingresses no class serves",
+		Checks: []string{"ingress-class-missing", "ingress-class-multiple-defaults", "ingress-class-unset"},
+		RBAC: []Permission{
+			allow("networking.k8s.io", "ingressclasses", "list"), allow("networking.k8s.io", "ingresses", "list"),
+			allow("apps", "deployments", "list"), allow("apps", "daemonsets", "list"),
+		},
+		section: sectionIngress,
+		run: func(s *scanState) ([]Finding, error) {
+			ingress, err := GetIngressReport(s.ctx, s.clientset, s.opts)
+			s.report.Ingress = ingress
+			if ingress == nil {
+				return nil, err
+			}
+			return CheckIngressClasses(ingress), err
+		},
+	},
+	{
+		Name: "node-health", Description: "nodes whose Ready condition is not True",
+		Checks:  []string{"node-not-ready"},
+		RBAC:    []Permission{allow("", "nodes", "list")},
+		section: sectionFindings,
+		run: func(s *scanState) ([]Finding, error) {
+			return GetNodeFindings(s.ctx, s.clientset, s.opts)
+		},
+	},
+	{
+		Name: "exposure", Description: "LoadBalancer services with a public address",
+		Checks:  []string{"public-loadbalancer"},
+		RBAC:    []Permission{allow("", "services", "list")},
+		section: sectionFindings,
+		run: func(s *scanState) ([]Finding, error) {
+			return GetExposureFindings(s.ctx, s.clientset, s.opts)
+		},
+	},
+	{
+		Name: "dns", Description: "hostnames the cluster claims that DNS sends elsewhere or to other environments too",
+		Checks: []string{"dns-collision", "dns-missing", "dns-not-delegated"},
+		RBAC: []Permission{
+			allow("", "services", "list"), allow("networking.k8s.io", "ingresses", "list"),
+			allow("gateway.networking.k8s.io", "gateways", "list"), allow("gateway.networking.k8s.io", "httproutes", "list"),
+		},
+		OptIn:   "--dns-zone or --dns-resolve",
+		section: sectionDNS,
+		enabled: func(opts ScanOptions) bool { return opts.DNS.Enabled() },
+		run: func(s *scanState) ([]Finding, error) {
+			report, err := GetDNSReport(s.ctx, s.clientset, s.dynamic, s.opts)
+			s.report.DNS = report
+			if report == nil {
+				return nil, err
+			}
+			return CheckDNSReport(report), err
+		},
+	},
+	{
+		Name: "debug", Description: "ephemeral containers, node debug shells, and nsenter pods left running",
+		Checks:  []string{"ephemeral-debug-container", "leftover-node-debugger", "privileged-node-shell"},
+		RBAC:    []Permission{allow("", "pods", "list")},
+		section: sectionFindings,
+		run: func(s *scanState) ([]Finding, error) {
+			return GetDebugFindings(s.ctx, s.clientset, s.opts)
+		},
+	},
+	{
+		Name: "disruption", Description: "PodDisruptionBudgets and workloads that block or break node drains",
+		Checks: []string{"pdb-blocks-drain", "replicas-not-spread", "single-replica-workload", "workload-no-pdb"},
+		RBAC: []Permission{
+			allow("apps", "deployments", "list"), allow("apps", "statefulsets", "list"),
+			allow("policy", "poddisruptionbudgets", "list"),
+		},
+		section: sectionFindings,
+		run: func(s *scanState) ([]Finding, error) {
+			return GetDisruptionFindings(s.ctx, s.clientset, s.opts)
+		},
+	},
+	{
+		Name: "webhooks", Description: "admission webhooks that can block API writes cluster-wide",
+		Checks: []string{"webhook-backend-unavailable", "webhook-fail-closed-broad", "webhook-long-timeout"},
+		RBAC: []Permission{
+			allow("admissionregistration.k8s.io", "validatingwebhookconfigurations", "list"),
+			allow("admissionregistration.k8s.io", "mutatingwebhookconfigurations", "list"),
+			allow("", "services", "get"), allow("discovery.k8s.io", "endpointslices", "list"),
+		},
+		section: sectionFindings,
+		run: func(s *scanState) ([]Finding, error) {
+			return GetWebhookFindings(s.ctx, s.clientset, s.opts)
+		},
+	},
+	{
+		Name: "gc-policy", Description: "Jobs, CronJobs, and Deployments whose cleanup settings keep old objects around",
+		Checks: []string{"cronjob-history-limits-unset", "deployment-default-revision-history", "job-no-ttl"},
+		RBAC: []Permission{
+			allow("batch", "jobs", "list"), allow("batch", "cronjobs", "list"), allow("apps", "deployments", "list"),
+			allow("apps", "replicasets", "list"), allow("", "pods", "list"),
+		},
+		section: sectionFindings,
+		run: func(s *scanState) ([]Finding, error) {
+			return GetGCPolicyFindings(s.ctx, s.clientset, s.opts)
+		},
+	},
+	{
+		Name: "utilization", Description: "CPU and memory usage from metrics-server or the kubelet summary API",
+		RBAC: []Permission{
+			allow("", "nodes", "list"), allow("", "nodes/proxy", "get"),
+			allow("metrics.k8s.io", "nodes", "list"), allow("metrics.k8s.io", "pods", "list"),
+		},
+		section: sectionUtilization,
+		run: func(s *scanState) (findings []Finding, err error) {
+			s.report.Utilization, err = GetUtilization(s.ctx, s.clientset, s.opts)
+			return nil, err
+		},
+	},
+	{
+		Name: "images", Description: "container image versions spread across nodes and workloads",
+		RBAC:    []Permission{allow("", "nodes", "list"), allow("", "pods", "list")},
+		section: sectionImages,
+		run: func(s *scanState) (findings []Finding, err error) {
+			s.report.ImageSpread, err = GetImageSpread(s.ctx, s.clientset, s.opts)
+			return nil, err
+		},
+	},
+	{
+		Name: "ip-families", Description: "IPv4/IPv6 readiness, DNS64, and egress gateways",
+		RBAC: []Permission{
+			allow("", "nodes", "list"), allow("", "pods", "list"), allow("", "services", "list"),
+			allow("apps", "deployments", "list"), allow("apps", "daemonsets", "list"),
+			allowIn("kube-system", "", "configmaps", "get"), allow("cilium.io", "ciliumegressgatewaypolicies", "list"),
+		},
+		section: sectionIPFamilies,
+		run: func(s *scanState) (findings []Finding, err error) {
+			s.report.IPFamilies, err = GetIPFamilyReport(s.ctx, s.clientset, s.opts)
+			return nil, err
+		},
+	},
+	{
+		Name: "custom-resources", Description: "custom resources whose Ready condition is False",
+		Checks:      []string{"custom-resource-not-ready"},
+		RBAC:        []Permission{allow("apiextensions.k8s.io", "customresourcedefinitions", "list")},
+		section:     sectionCustomResources,
+		clusterRBAC: customResourcePermissions,
+		run: func(s *scanState) ([]Finding, error) {
+			health, err := GetCustomResourceHealth(s.ctx, s.dynamic, s.opts)
+			s.report.CustomResources = health
+			if health == nil {
+				return nil, err
+			}
+			return CheckCustomResourceHealth(health), err
+		},
+	},
+	{
+		Name: "trust-bundles", Description: "CA bundle distribution to workloads behind a TLS-intercepting proxy",
+		Checks: []string{"proxy-missing-ca-bundle"},
+		RBAC: []Permission{
+			allow("", "configmaps", "list"), allow("certificates.k8s.io", "clustertrustbundles", "list"),
+			allow("apps", "deployments", "list"), allow("apps", "statefulsets", "list"),
+			allow("apps", "daemonsets", "list"), allow("batch", "cronjobs", "list"),
+		},
+		section: sectionTrustBundles,
+		run: func(s *scanState) ([]Finding, error) {
+			report, err := GetTrustBundleReport(s.ctx, s.clientset, s.opts)
+			s.report.TrustBundles = report
+			if report == nil {
+				return nil, err
+			}
+			return CheckTrustBundles(report), err
+		},
+	},
+	{
+		Name: "workloads", Description: "Deployments, StatefulSets, and DaemonSets short of replicas, and crash-looping pods",
+		Checks: []string{"pod-not-starting", "rollout-stuck", "workload-unavailable"},
+		RBAC: []Permission{
+			allow("apps", "deployments", "list"), allow("apps", "statefulsets", "list"),
+			allow("apps", "daemonsets", "list"), allow("", "pods", "list"),
+		},
+		section: sectionWorkloads,
+		run: func(s *scanState) ([]Finding, error) {
+			health, err := GetWorkloadHealth(s.ctx, s.clientset, s.opts)
+			s.report.Workloads = health
+			if health == nil {
+				return nil, err
+			}
+			return CheckWorkloadHealth(health), err
+		},
+	},
+	{
+		Name: "scheduling", Description: "why Pending pods can't be scheduled, by capacity and configuration cause",
+		Checks:  []string{"pod-unschedulable"},
+		RBAC:    []Permission{allow("", "pods", "list"), allow("", "events", "list")},
+		section: sectionScheduling,
+		run: func(s *scanState) ([]Finding, error) {
+			scheduling, err := GetSchedulingReport(s.ctx, s.clientset, s.opts)
+			s.report.Scheduling = scheduling
+			if scheduling == nil {
+				return nil, err
+			}
+			return CheckScheduling(scheduling, s.report.GeneratedAt), err
+		},
+	},
+	{
+		Name: "service-account-tokens", Description: "long-lived ServiceAccount token Secrets and the pods mounting them",
+		Checks:  []string{"legacy-sa-token"},
+		RBAC:    []Permission{allow("", "secrets", "list"), allow("", "pods", "list")},
+		section: sectionTokens,
+		run: func(s *scanState) ([]Finding, error) {
+			tokens, err := GetTokenReport(s.ctx, s.clientset, s.opts)
+			s.report.ServiceAccountTokens = tokens
+			if tokens == nil {
+				return nil, err
+			}
+			return CheckTokenSecrets(tokens, s.report.GeneratedAt), err
+		},
+	},
+	{
+		Name: "cis", Description: "CIS Kubernetes Benchmark controls assessable through the API: API server flags and RBAC settings",
+		Checks: []string{"cis-control-failed", "cis-control-warning"},
+		RBAC: []Permission{
+			allowIn("kube-system", "", "pods", "list"),
+			allow("rbac.authorization.k8s.io", "clusterroles", "list"), allow("rbac.authorization.k8s.io", "clusterrolebindings", "list"),
+			allow("rbac.authorization.k8s.io", "roles", "list"), allow("rbac.authorization.k8s.io", "rolebindings", "list"),
+		},
+		section: sectionCIS,
+		run: func(s *scanState) ([]Finding, error) {
+			cis, err := GetCISReport(s.ctx, s.clientset, s.opts)
+			s.report.CIS = cis
+			if cis == nil {
+				return nil, err
+			}
+			return CheckCIS(cis), err
+		},
+	},
+	{
+		Name: "autoscaling", Description: "HorizontalPodAutoscalers and VerticalPodAutoscalers",
+		Checks: []string{"hpa-at-max", "hpa-missing-metrics", "hpa-target-missing", "no-autoscaling-or-requests"},
+		RBAC: []Permission{
+			allow("autoscaling", "horizontalpodautoscalers", "list"), allow("autoscaling.k8s.io", "verticalpodautoscalers", "list"),
+			allow("apps", "deployments", "list"), allow("apps", "statefulsets", "list"),
+		},
+		section: sectionAutoscaling,
+		run: func(s *scanState) ([]Finding, error) {
+			autoscaling, err := GetAutoscalingReport(s.ctx, s.clientset, s.opts)
+			s.report.Autoscaling = autoscaling
+			if autoscaling == nil {
+				return nil, err
+			}
+			return CheckAutoscaling(autoscaling), err
+		},
+	},
+	{
+		Name: "quotas", Description: "ResourceQuota usage, namespaces without quotas or LimitRanges, and workloads quota would block",
+		Checks: []string{"namespace-without-quota", "quota-blocks-workload", "quota-exhausted", "quota-near-limit"},
+		RBAC: []Permission{
+			allow("", "namespaces", "list"), allow("", "resourcequotas", "list"), allow("", "limitranges", "list"),
+			allow("apps", "deployments", "list"), allow("apps", "statefulsets", "list"),
+		},
+		section: sectionQuotas,
+		run: func(s *scanState) ([]Finding, error) {
+			quotas, err := GetQuotaReport(s.ctx, s.clientset, s.opts)
+			s.report.Quotas = quotas
+			if quotas == nil {
+				return nil, err
+			}
+			return CheckQuotas(quotas), err
+		},
+	},
+	{
+		Name: "priorities", Description: "PriorityClass usage, namespaces without priorities, and pods likely to be preempted first",
+		Checks: []string{"namespace-without-priority", "pod-preemption-risk", "priority-default-beside-critical"},
+		RBAC: []Permission{
+			allow("scheduling.k8s.io", "priorityclasses", "list"), allow("", "nodes", "list"), allow("", "pods", "list"),
+		},
+		section: sectionPriorities,
+		run: func(s *scanState) ([]Finding, error) {
+			priorities, err := GetPriorityReport(s.ctx, s.clientset, s.opts)
+			s.report.Priorities = priorities
+			if priorities == nil {
+				return nil, err
+			}
+			return CheckPriorities(priorities), err
+		},
+	},
+	{
+		Name: "cost", Description: "monthly cost of the nodes by instance type, attributed to namespaces and workloads by their requests",
+		RBAC: []Permission{
+			allow("", "nodes", "list"), allow("", "pods", "list"), allow("apps", "replicasets", "list"), allow("batch", "jobs", "list"),
+		},
+		OptIn:   "--cost or --price-table",
+		section: sectionCost,
+		enabled: func(opts ScanOptions) bool { return opts.Prices != nil },
+		run: func(s *scanState) (findings []Finding, err error) {
+			s.report.Cost, err = GetCostReport(s.ctx, s.clientset, s.opts)
+			return nil, err
+		},
+	},
+	{
+		Name: "owners", Description: "top-level owner and Helm release of every reported pod",
+		RBAC:    []Permission{allow("", "pods", "list"), allow("apps", "replicasets", "list"), allow("batch", "jobs", "list")},
+		section: sectionOwners,
+		run: func(s *scanState) (findings []Finding, err error) {
+			s.owners, err = GetOwnerResolver(s.ctx, s.clientset, s.opts)
+			return nil, err
+		},
+	},
+	{
+		Name: "etcd", Description: "etcd version, on clusters that run etcd as pods",
+		RBAC:    []Permission{allowIn("kube-system", "", "pods", "list")},
+		section: sectionEtcd,
+		applies: func(s *scanState) bool { return !s.report.Platform.Managed },
+		run: func(s *scanState) (findings []Finding, err error) {
+			s.report.EtcdVersion, err = GetEtcdVersion(s.ctx, s.clientset)
+			return nil, err
+		},
+	},
+	{
+		Name: "etcd-health", Description: "etcd members, leader, DB size, and alarms via etcdctl",
+		Checks:  []string{"etcd-alarm", "etcd-db-near-quota", "etcd-fragmented", "etcd-member-errors", "etcd-member-unreachable", "etcd-no-leader"},
+		RBAC:    []Permission{allowIn("kube-system", "", "pods", "list"), allowIn("kube-system", "", "pods/exec", "create")},
+		OptIn:   "--etcd-deep",
+		section: sectionEtcdDeep,
+		after:   true,
+		enabled: func(opts ScanOptions) bool { return opts.EtcdDeep },
+		applies: func(s *scanState) bool { return !s.report.Platform.Managed },
+		run: func(s *scanState) ([]Finding, error) {
+			health, err := GetEtcdHealth(s.ctx, s.clientset, s.config)
+			s.report.EtcdHealth = health
+			if health == nil {
+				return nil, err
+			}
+			return CheckEtcdHealth(health), err
+		},
+	},
+	{
+		Name: "backups", Description: "Velero and etcd backup schedules and their last successful runs, snapshot classes, and stateful workloads with no backup",
+		Checks: []string{"backup-paused", "backup-stale", "etcd-backup-missing", "snapshot-class-missing", "stateful-workload-unprotected"},
+		RBAC: []Permission{
+			allow("velero.io", "schedules", "list"), allow("velero.io", "backups", "list"), allow("batch", "cronjobs", "list"),
+			allow("snapshot.storage.k8s.io", "volumesnapshotclasses", "list"), allow("", "persistentvolumeclaims", "list"),
+			allow("storage.k8s.io", "storageclasses", "list"), allow("apps", "statefulsets", "list"), allow("apps", "deployments", "list"),
+		},
+		section: sectionBackups,
+		// Running after the etcd collector tells it whether etcd runs as pods, and so needs
+		// backing up by the operator.
+		after: true,
+		run: func(s *scanState) ([]Finding, error) {
+			backups, err := GetBackupReport(s.ctx, s.clientset, s.dynamic, s.opts, s.report.EtcdVersion != "", s.report.GeneratedAt)
+			s.report.Backups = backups
+			if backups == nil {
+				return nil, err
+			}
+			return CheckBackups(backups, s.report.GeneratedAt), err
+		},
+	},
+	{
+		Name: "reachability", Description: "active probes of every exposed endpoint from this machine",
+		Checks:   []string{"endpoint-open-without-auth"},
+		RBAC:     []Permission{allow("", "nodes", "list")},
+		OptIn:    "--probe",
+		Requires: []string{"endpoints"},
+		section:  sectionReachability,
+		after:    true,
+		enabled:  func(opts ScanOptions) bool { return opts.Probe.Enabled },
+		applies: func(s *scanState) bool {
+			_, failed := s.report.Errors[sectionEndpoints]
+			return !failed
+		},
+		run: func(s *scanState) ([]Finding, error) {
+			err := ProbeEndpoints(s.ctx, s.clientset, s.opts, s.report.ExposedEndpoints)
+			return CheckReachability(s.report.ExposedEndpoints), err
+		},
+	},
+	{
+		Name: "certificates", Description: "expiry of API server, etcd, and kubelet certificates",
+		Checks: []string{"cert-expiring"},
+		RBAC: []Permission{
+			allow("", "nodes", "list"), allow("certificates.k8s.io", "certificatesigningrequests", "list"),
+			allowIn("kube-system", "", "pods", "list"), allowIn("kube-system", "", "secrets", "get"),
+		},
+		OptIn:   "--check-certs",
+		section: sectionCerts,
+		after:   true,
+		enabled: func(opts ScanOptions) bool { return opts.CheckCerts },
+		run: func(s *scanState) ([]Finding, error) {
+			certs, err := GetCertificateExpiry(s.ctx, s.clientset, s.config, s.opts)
+			s.report.Certificates = certs
+			return CheckCertificateExpiry(certs, s.opts.CertWarningDays, s.report.GeneratedAt), err
+		},
+	},
+}
+
+// Enabled reports whether the collector runs with these options: opt-in collectors only run
+// when their flag is set.
+func (c *Collector) Enabled(opts ScanOptions) bool {
+	return c.enabled == nil || c.enabled(opts)
+}
+
+// Collectors returns every registered collector, in the order they run.
+func Collectors() []*Collector {
+	return append([]*Collector(nil), collectorRegistry...)
+}
+
+// lookupCollector returns the registered collector with the given name, or nil.
+func lookupCollector(name string) *Collector {
+	for _, c := range collectorRegistry {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// ParseCollectorNames splits a comma-separated list of collector names and rejects unknown ones.
+func ParseCollectorNames(value string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if lookupCollector(name) == nil {
+			return nil, fmt.Errorf("unknown collector %q (see kube-op collectors list)", name)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// SelectCollectors returns the registered collectors to run, in registry order. An empty include
+// list selects every collector; the collectors an included one requires are selected with it.
+// Skipped collectors are removed, along with every collector that requires one of them.
+func SelectCollectors(include, skip []string) ([]*Collector, error) {
+	for _, name := range append(append([]string{}, include...), skip...) {
+		if lookupCollector(name) == nil {
+			return nil, fmt.Errorf("unknown collector %q (see kube-op collectors list)", name)
+		}
+	}
+
+	selected := map[string]bool{}
+	if len(include) == 0 {
+		for _, c := range collectorRegistry {
+			selected[c.Name] = true
+		}
+	}
+	var add func(name string)
+	add = func(name string) {
+		if selected[name] {
+			return
+		}
+		selected[name] = true
+		for _, required := range lookupCollector(name).Requires {
+			add(required)
+		}
+	}
+	for _, name := range include {
+		add(name)
+	}
+	for _, name := range skip {
+		delete(selected, name)
+	}
+
+	var collectors []*Collector
+	for _, c := range collectorRegistry {
+		if !selected[c.Name] {
+			continue
+		}
+		missing := false
+		for _, required := range c.Requires {
+			missing = missing || !selected[required]
+		}
+		if !missing {
+			collectors = append(collectors, c)
+		}
+	}
+	return collectors, nil
+}
+
+// runCollectors runs the applicable collectors: the concurrent ones first, at most
+// opts.Concurrency at a time, then the rest in order. Failures are recorded in the report under
+// each collector's section, and the findings of all collectors are returned. Once s.ctx is
+// cancelled, the collectors that have not started yet fail without calling the API server.
+func runCollectors(s *scanState, collectors []*Collector) []Finding {
+	var findings []Finding
+	succeeded := map[string]bool{}
+	run := func(c *Collector) ([]Finding, error) {
+		if err := s.ctx.Err(); err != nil {
+			return nil, fmt.Errorf("scan interrupted before the %s collector ran: %w", c.Name, err)
+		}
+		return c.run(s)
+	}
+	runPhase := func(after bool) {
+		var phase []*Collector
+		for _, c := range collectors {
+			if c.after == after && c.Enabled(s.opts) && (c.applies == nil || c.applies(s)) {
+				phase = append(phase, c)
+			}
+		}
+		results := make([][]Finding, len(phase))
+		errs := make([]error, len(phase))
+		if after {
+			for i, c := range phase {
+				results[i], errs[i] = run(c)
+			}
+		} else {
+			tasks := make([]func(), len(phase))
+			for i, c := range phase {
+				tasks[i] = func() { results[i], errs[i] = run(c) }
+			}
+			runConcurrently(s.opts.Concurrency, tasks...)
+		}
+		for i, c := range phase {
+			recordError(s.report, c.section, errs[i])
+			findings = append(findings, results[i]...)
+			succeeded[c.Name] = errs[i] == nil
+		}
+	}
+	runPhase(false)
+	runPhase(true)
+
+	// A collector's findings are only complete when the collectors it uses succeeded too.
+	var complete func(c *Collector) bool
+	complete = func(c *Collector) bool {
+		if !succeeded[c.Name] {
+			return false
+		}
+		for _, name := range c.Requires {
+			i := slices.IndexFunc(collectors, func(r *Collector) bool { return r.Name == name })
+			if i < 0 || !complete(collectors[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, c := range collectors {
+		if complete(c) {
+			s.report.CompletedCollectors = append(s.report.CompletedCollectors, c.Name)
+		}
+	}
+	return findings
+}
+
+// PrintCollectors writes the collector list for kube-op collectors list.
+func PrintCollectors(w io.Writer, collectors []*Collector) {
+	permissions := func(rbac []Permission) string {
+		var s []string
+		for _, p := range rbac {
+			s = append(s, p.String())
+		}
+		sort.Strings(s)
+		return strings.Join(s, "; ")
+	}
+	fmt.Fprintf(w, "Always run (version and platform detection): %s\n\n", permissions(corePermissions))
+	for _, c := range collectors {
+		name := c.Name
+		if c.OptIn != "" {
+			name += " (needs " + c.OptIn + ")"
+		}
+		fmt.Fprintf(w, "%s\n  %s\n  RBAC: %s\n", name, c.Description, permissions(c.RBAC))
+		if len(c.Requires) > 0 {
+			fmt.Fprintf(w, "  Requires: %s\n", strings.Join(c.Requires, ", "))
+		}
+		if len(c.Checks) > 0 {
+			fmt.Fprintf(w, "  Checks: %s\n", strings.Join(c.Checks, ", "))
+		}
+	}
+}
@@ -0,0 +1,500 @@
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func collectorNames(collectors []*Collector) []string {
+	var names []string
+	for _, c := range collectors {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestCollectorRegistry(t *testing.T) {
+	seen := map[string]bool{}
+	for _, c := range collectorRegistry {
+		if seen[c.Name] {
+			t.Errorf("collector %s registered twice", c.Name)
+		}
+		seen[c.Name] = true
+		if c.Description == "" || len(c.RBAC) == 0 || c.section == "" || c.run == nil {
+			t.Errorf("collector %s is missing its description, RBAC, section, or run function", c.Name)
+		}
+	}
+	for _, c := range collectorRegistry {
+		for _, required := range c.Requires {
+			if !seen[required] {
+				t.Errorf("collector %s requires unknown collector %s", c.Name, required)
+			}
+		}
+	}
+}
+
+func TestCollectorChecks(t *testing.T) {
+	// Checks raised outside a scan: by the helm command and by watch.
+	owners := map[string]string{
+		"helm-chart-outdated":  "helm",
+		"helm-release-failed":  "helm",
+		"helm-release-pending": "helm",
+		"endpoint-appeared":    "watch",
+	}
+	raised := raisedCheckIDs(t)
+	for _, c := range collectorRegistry {
+		for _, id := range c.Checks {
+			if !raised[id] {
+				t.Errorf("collector %s claims check %s, which the package never raises", c.Name, id)
+			}
+			if owner, ok := owners[id]; ok {
+				t.Errorf("check %s is claimed by both %s and %s", id, owner, c.Name)
+			}
+			owners[id] = c.Name
+		}
+	}
+	for id := range raised {
+		if _, ok := owners[id]; !ok {
+			t.Errorf("check %s is claimed by no collector; add it to the Checks of the collector that raises it", id)
+		}
+	}
+}
+
+func TestSelectCollectors(t *testing.T) {
+	all, err := SelectCollectors(nil, nil)
+	if err != nil || len(all) != len(collectorRegistry) {
+		t.Fatalf("SelectCollectors(nil, nil) = %v, %v, want every collector", collectorNames(all), err)
+	}
+
+	selected, err := SelectCollectors([]string{"reachability", "workloads"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := collectorNames(selected), []string{"endpoints", "workloads", "reachability"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SelectCollectors(reachability, workloads) = %v, want %v", got, want)
+	}
+
+	selected, err = SelectCollectors(nil, []string{"endpoints", "owners"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range collectorNames(selected) {
+		if name == "endpoints" || name == "owners" || name == "reachability" {
+			t.Errorf("SelectCollectors(nil, endpoints, owners) selected %s, want it skipped along with its dependents", name)
+		}
+	}
+
+	if _, err := SelectCollectors([]string{"nope"}, nil); err == nil || !strings.Contains(err.Error(), `"nope"`) {
+		t.Errorf("SelectCollectors(nope) error = %v, want an unknown collector error", err)
+	}
+}
+
+func TestParseCollectorNames(t *testing.T) {
+	names, err := ParseCollectorNames("nodes, workloads,,")
+	if err != nil || !reflect.DeepEqual(names, []string{"nodes", "workloads"}) {
+		t.Errorf("ParseCollectorNames() = %v, %v, want [nodes workloads]", names, err)
+	}
+	if _, err := ParseCollectorNames("nodes,bogus"); err == nil {
+		t.Error("ParseCollectorNames(nodes,bogus) error = nil, want an unknown collector error")
+	}
+}
+
+func TestRunCollectors(t *testing.T) {
+	var order []string
+	collector := func(name string, after bool, applies func(*scanState) bool, err error) *Collector {
+		return &Collector{
+			Name: name, section: name, after: after, applies: applies,
+			run: func(s *scanState) ([]Finding, error) {
+				order = append(order, name)
+				return []Finding{{CheckID: name}}, err
+			},
+		}
+	}
+	s := &scanState{ctx: context.Background(), opts: ScanOptions{Concurrency: 1}, report: &Report{Errors: map[string]string{}}}
+
+	collectors := []*Collector{
+		collector("late", true, nil, nil),
+		collector("failing", false, nil, errors.New("forbidden")),
+		collector("inapplicable", false, func(*scanState) bool { return false }, nil),
+		collector("early", false, nil, nil),
+		collector("dependent", false, nil, nil),
+	}
+	collectors[4].Requires = []string{"failing"}
+	findings := runCollectors(s, collectors)
+
+	if want := []string{"failing", "early", "dependent", "late"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("run order = %v, want %v", order, want)
+	}
+	if len(findings) != 4 {
+		t.Errorf("len(runCollectors()) = %d, want 4", len(findings))
+	}
+	if s.report.Errors["failing"] != "forbidden" || len(s.report.Errors) != 1 {
+		t.Errorf("Errors = %v, want only failing: forbidden", s.report.Errors)
+	}
+	if want := []string{"late", "early"}; !reflect.DeepEqual(s.report.CompletedCollectors, want) {
+		t.Errorf("CompletedCollectors = %v, want %v", s.report.CompletedCollectors, want)
+	}
+}
+
+// newFakeClients returns a fake clientset holding objects and the kube-system namespace, and an
+// empty fake dynamic client that serves CRDs.
+func newFakeClients(objects ...runtime.Object) (*fake.Clientset, *dynamicfake.FakeDynamicClient) {
+	kubeSystem := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "1b4e28ba"}}
+	clientset := fake.NewClientset(append(objects, kubeSystem)...)
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			crdResource:            "CustomResourceDefinitionList",
+			gatewayResource:        "GatewayList",
+			httpRouteResource:      "HTTPRouteList",
+			veleroScheduleResource: "ScheduleList",
+			veleroBackupResource:   "BackupList",
+			snapshotClassResource:  "VolumeSnapshotClassList",
+		})
+	return clientset, dynamicClient
+}
+
+// fakeScan runs the named collectors, plus the ones they require, against fake clients.
+func fakeScan(t *testing.T, clientset kubernetes.Interface, dynamicClient dynamic.Interface, collectors ...string) *Report {
+	t.Helper()
+	opts := AutoTuneScanOptions(ClusterSize{}).WithOverrides(ScanOptions{Collectors: collectors})
+	report, err := runScan(&scanState{ctx: context.Background(), clientset: clientset, dynamic: dynamicClient, opts: opts})
+	if err != nil {
+		t.Fatalf("runScan(%v) returned error = %v", collectors, err)
+	}
+	return report
+}
+
+func checkIDs(findings []Finding) []string {
+	var ids []string
+	for _, f := range findings {
+		ids = append(ids, f.CheckID)
+	}
+	return ids
+}
+
+func TestCollectorsEmptyCluster(t *testing.T) {
+	// An empty cluster has nothing to report version information for, and the fake clientset
+	// serves neither the metrics API nor node proxy, so these collectors fail on it.
+	wantErrors := map[string]string{
+		"nodes":       sectionNodes,
+		"etcd":        sectionEtcd,
+		"utilization": sectionUtilization,
+	}
+	for _, c := range collectorRegistry {
+		t.Run(c.Name, func(t *testing.T) {
+			clientset, dynamicClient := newFakeClients()
+			report := fakeScan(t, clientset, dynamicClient, c.Name)
+			if len(report.Findings) != 0 {
+				t.Errorf("Findings = %+v, want none on an empty cluster", report.Findings)
+			}
+			want := map[string]bool{}
+			if section, ok := wantErrors[c.Name]; ok {
+				want[section] = true
+			}
+			for section := range report.Errors {
+				if !want[section] {
+					t.Errorf("Errors[%s] = %q, want none", section, report.Errors[section])
+				}
+			}
+			for section := range want {
+				if report.Errors[section] == "" {
+					t.Errorf("Errors[%s] is empty, want an error", section)
+				}
+			}
+			if report.Cluster == nil || report.Cluster.ID != "1b4e28ba" {
+				t.Errorf("Cluster = %+v, want the ID from kube-system", report.Cluster)
+			}
+		})
+	}
+}
+
+func TestCollectorsListFailures(t *testing.T) {
+	forbidden := func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(action.GetResource().GroupResource(), "", errors.New("denied"))
+	}
+	for _, c := range collectorRegistry {
+		if c.OptIn != "" {
+			continue
+		}
+		t.Run(c.Name, func(t *testing.T) {
+			clientset, dynamicClient := newFakeClients()
+			clientset.PrependReactor("list", "*", forbidden)
+			dynamicClient.PrependReactor("list", "*", forbidden)
+
+			report := fakeScan(t, clientset, dynamicClient, c.Name)
+			if !strings.Contains(report.Errors[c.section], "forbidden") {
+				t.Errorf("Errors = %v, want the forbidden list in Errors[%s]", report.Errors, c.section)
+			}
+			if !strings.Contains(report.Errors[sectionPlatform], "forbidden") {
+				t.Errorf("Errors = %v, want the platform detection failure in Errors[%s]", report.Errors, sectionPlatform)
+			}
+		})
+	}
+}
+
+func TestCollectorsPartialFailure(t *testing.T) {
+	clientset, dynamicClient := newFakeClients(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n1"}, Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{KubeletVersion: "v1.31.2"}}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "api"}, Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeNodePort, Ports: []corev1.ServicePort{{Port: 443, NodePort: 30443}},
+		}},
+		&discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "api-x7k2p", Labels: map[string]string{discoveryv1.LabelServiceName: "api"}},
+			Endpoints:  []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.5"}}},
+		},
+	)
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(action.GetResource().GroupResource(), "", errors.New("denied"))
+	})
+
+	report := fakeScan(t, clientset, dynamicClient, "nodes", "node-health", "endpoints", "debug", "owners")
+	if report.NodeVersions != "v1.31.2" || len(report.ExposedEndpoints) == 0 {
+		t.Errorf("NodeVersions, ExposedEndpoints = %q, %+v, want both despite the pods failure", report.NodeVersions, report.ExposedEndpoints)
+	}
+	if want := []string{"node-not-ready"}; !reflect.DeepEqual(checkIDs(report.Findings), want) {
+		t.Errorf("Findings = %v, want %v", checkIDs(report.Findings), want)
+	}
+	for _, section := range []string{sectionFindings, sectionOwners} {
+		if !strings.Contains(report.Errors[section], "pods is forbidden") {
+			t.Errorf("Errors = %v, want the pods failure in Errors[%s]", report.Errors, section)
+		}
+	}
+	if _, ok := report.Errors[sectionEndpoints]; ok {
+		t.Errorf("Errors[%s] = %q, want none", sectionEndpoints, report.Errors[sectionEndpoints])
+	}
+}
+
+func TestCollectorEdgeCases(t *testing.T) {
+	hourAgo := metav1.NewTime(time.Now().Add(-time.Hour))
+	controller := true
+	controlledBy := func(kind, name string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}}
+	}
+	node := func(name, kubelet string, conditions ...corev1.NodeCondition) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: corev1.NodeStatus{
+			NodeInfo: corev1.NodeSystemInfo{KubeletVersion: kubelet}, Conditions: conditions,
+		}}
+	}
+	loadBalancer := func(name, ip string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: name},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			Status:     corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: ip}}}},
+		}
+	}
+	deployment := func(name string, replicas int32) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: name}, Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(replicas)}}
+	}
+	finishedJob := func(name string, owners []metav1.OwnerReference) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "batch", Name: name, OwnerReferences: owners},
+			Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: hourAgo},
+			}},
+		}
+	}
+	pendingPod := func(name, nodeName string, owners []metav1.OwnerReference) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: name, OwnerReferences: owners, CreationTimestamp: hourAgo},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+			Status: corev1.PodStatus{Phase: corev1.PodPending, Conditions: []corev1.PodCondition{{
+				Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable,
+				Message: "0/3 nodes are available: 3 Insufficient cpu.", LastTransitionTime: hourAgo,
+			}}},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		collectors []string
+		objects    []runtime.Object
+		check      func(t *testing.T, report *Report)
+	}{
+		{
+			name:       "node versions are sorted and deduplicated",
+			collectors: []string{"nodes"},
+			objects:    []runtime.Object{node("n1", "v1.31.2"), node("n2", "v1.30.5"), node("n3", "v1.31.2")},
+			check: func(t *testing.T, report *Report) {
+				if report.NodeVersions != "v1.30.5, v1.31.2" {
+					t.Errorf("NodeVersions = %q, want %q", report.NodeVersions, "v1.30.5, v1.31.2")
+				}
+			},
+		},
+		{
+			name:       "nodes without a Ready condition are not ready",
+			collectors: []string{"node-health"},
+			objects: []runtime.Object{
+				node("ready", "v1.31.2", corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionTrue}),
+				node("new", "v1.31.2"),
+			},
+			check: func(t *testing.T, report *Report) {
+				if len(report.Findings) != 1 || report.Findings[0].Name != "new" || !strings.Contains(report.Findings[0].Message, "no Ready condition reported") {
+					t.Errorf("Findings = %+v, want only node new", report.Findings)
+				}
+			},
+		},
+		{
+			name:       "private load balancers are not exposed",
+			collectors: []string{"exposure"},
+			objects:    []runtime.Object{loadBalancer("internal", "10.0.0.5"), loadBalancer("public", "34.102.136.180")},
+			check: func(t *testing.T, report *Report) {
+				if len(report.Findings) != 1 || report.Findings[0].Name != "public" || report.Findings[0].Severity != SeverityHigh {
+					t.Errorf("Findings = %+v, want only the public load balancer", report.Findings)
+				}
+			},
+		},
+		{
+			name:       "workloads scaled to zero carry no disruption risk",
+			collectors: []string{"disruption"},
+			objects:    []runtime.Object{deployment("paused", 0), deployment("singleton", 1)},
+			check: func(t *testing.T, report *Report) {
+				if len(report.Findings) != 1 || report.Findings[0].CheckID != "single-replica-workload" || report.Findings[0].Name != "singleton" {
+					t.Errorf("Findings = %+v, want only the single-replica deployment", report.Findings)
+				}
+			},
+		},
+		{
+			name:       "jobs owned by a CronJob are cleaned up by its history limits",
+			collectors: []string{"gc-policy"},
+			objects:    []runtime.Object{finishedJob("manual", nil), finishedJob("nightly-28000000", controlledBy("CronJob", "nightly"))},
+			check: func(t *testing.T, report *Report) {
+				if len(report.Findings) != 1 || report.Findings[0].CheckID != "job-no-ttl" || report.Findings[0].Name != "manual" {
+					t.Errorf("Findings = %+v, want only the standalone Job", report.Findings)
+				}
+			},
+		},
+		{
+			name:       "pending pods already bound to a node are not unschedulable",
+			collectors: []string{"scheduling"},
+			objects:    []runtime.Object{pendingPod("pulling", "n1", nil), pendingPod("stuck", "", nil)},
+			check: func(t *testing.T, report *Report) {
+				if len(report.Findings) != 1 || report.Findings[0].CheckID != "pod-unschedulable" || report.Findings[0].Name != "stuck" {
+					t.Errorf("Findings = %+v, want only the unbound pod", report.Findings)
+				}
+			},
+		},
+		{
+			name:       "flagged pods are attributed to their Deployment",
+			collectors: []string{"scheduling", "owners"},
+			objects: []runtime.Object{
+				pendingPod("api-7d4b9-x2x9k", "", controlledBy("ReplicaSet", "api-7d4b9")),
+				&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "api-7d4b9", OwnerReferences: controlledBy("Deployment", "api")}},
+			},
+			check: func(t *testing.T, report *Report) {
+				want := &Owner{Kind: "Deployment", Namespace: "web", Name: "api"}
+				if len(report.Findings) != 1 || !reflect.DeepEqual(report.Findings[0].Owner, want) {
+					t.Errorf("Findings = %+v, want one owned by %v", report.Findings, want)
+				}
+			},
+		},
+		{
+			name:       "etcd images without a tag have no version",
+			collectors: []string{"etcd"},
+			objects: []runtime.Object{&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "etcd-cp1", Labels: map[string]string{"component": "etcd"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "etcd", Image: "registry.k8s.io/etcd"}}},
+			}},
+			check: func(t *testing.T, report *Report) {
+				if !strings.Contains(report.Errors[sectionEtcd], "does not have a discernible version tag") {
+					t.Errorf("Errors = %v, want an error for the untagged etcd image", report.Errors)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset, dynamicClient := newFakeClients(tt.objects...)
+			report := fakeScan(t, clientset, dynamicClient, tt.collectors...)
+			tt.check(t, report)
+		})
+	}
+}
+
+func TestCustomResourcesCollector(t *testing.T) {
+	crd := testCRD("cert-manager.io", "Certificate", "certificates", "Namespaced", crdVersion("v1", true, true, true))
+	crd.SetAPIVersion("apiextensions.k8s.io/v1")
+	crd.SetKind("CustomResourceDefinition")
+	crd.SetName("certificates.cert-manager.io")
+	certificate := func(name, ready string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]any{
+			"status": map[string]any{"conditions": []any{map[string]any{"type": "Ready", "status": ready, "reason": "Pending"}}},
+		}}
+		u.SetAPIVersion("cert-manager.io/v1")
+		u.SetKind("Certificate")
+		u.SetNamespace("web")
+		u.SetName(name)
+		return u
+	}
+	certificates := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+
+	clientset, _ := newFakeClients()
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{crdResource: "CustomResourceDefinitionList", certificates: "CertificateList"},
+		&crd, certificate("api-tls", "True"), certificate("www-tls", "False"))
+
+	report := fakeScan(t, clientset, dynamicClient, "custom-resources")
+	if len(report.Errors) != 0 {
+		t.Fatalf("Errors = %v, want none", report.Errors)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].CheckID != "custom-resource-not-ready" || report.Findings[0].Name != "www-tls" {
+		t.Errorf("Findings = %+v, want only www-tls", report.Findings)
+	}
+
+	// A kind that cannot be listed is an error, but the kinds that could still be checked are kept.
+	dynamicClient.PrependReactor("list", "certificates", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(certificates.GroupResource(), "", errors.New("denied"))
+	})
+	report = fakeScan(t, clientset, dynamicClient, "custom-resources")
+	if !strings.Contains(report.Errors[sectionCustomResources], "failed to list certificates.cert-manager.io") {
+		t.Errorf("Errors = %v, want the certificates list failure", report.Errors)
+	}
+	if report.CustomResources == nil || len(report.CustomResources.Kinds) != 1 {
+		t.Errorf("CustomResources = %+v, want the Certificate kind still reported", report.CustomResources)
+	}
+}
+
+func TestCollectorsCancelledScan(t *testing.T) {
+	clientset, dynamicClient := newFakeClients()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	opts := AutoTuneScanOptions(ClusterSize{}).WithOverrides(ScanOptions{Collectors: []string{"node-health", "debug"}})
+	report, err := runScan(&scanState{ctx: ctx, clientset: clientset, dynamic: dynamicClient, opts: opts})
+	if err != nil {
+		t.Fatalf("runScan() with a cancelled context returned error = %v, want the partial report", err)
+	}
+	if !report.Interrupted {
+		t.Error("Interrupted = false, want true")
+	}
+	for _, name := range []string{"node-health", "debug"} {
+		if msg := report.Errors[sectionFindings]; !strings.Contains(msg, "before the "+name+" collector ran") {
+			t.Errorf("Errors[%s] = %q, want %s recorded as not run", sectionFindings, msg, name)
+		}
+	}
+
+	var b bytes.Buffer
+	PrintReport(&b, report)
+	if !strings.Contains(b.String(), "Scan interrupted: this report is partial") {
+		t.Errorf("text report does not say it is partial:\n%s", b.String())
+	}
+}
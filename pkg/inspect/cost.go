@@ -0,0 +1,280 @@
+package inspect
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// hoursPerMonth is the average month used to turn hourly prices into monthly estimates.
+const hoursPerMonth = 730
+
+// costEntriesShown is how many of the most expensive namespaces and workloads the text report
+// lists.
+const costEntriesShown = 10
+
+// instanceTypeLabels are the node labels the instance type is read from, newest first.
+var instanceTypeLabels = []string{corev1.LabelInstanceTypeStable, corev1.LabelInstanceType}
+
+//go:embed cost_prices.json
+var builtinPricesJSON []byte
+
+// PriceTable maps node instance types to their hourly price.
+type PriceTable struct {
+	Currency string             `json:"currency"`
+	Hourly   map[string]float64 `json:"hourly"`
+	// Default, when set, is the hourly price of nodes whose instance type is not listed.
+	Default float64 `json:"default,omitempty"`
+	// Updated is when the built-in prices were last refreshed.
+	Updated string `json:"updated,omitempty"`
+
+	// source names the table in the report: the file it was loaded from, or built-in.
+	source string
+}
+
+// BuiltinPriceTable returns the embedded on-demand Linux list prices of common AWS (us-east-1),
+// GCP (us-central1), and Azure (eastus) instance types, in USD.
+func BuiltinPriceTable() (*PriceTable, error) {
+	var table PriceTable
+	if err := json.Unmarshal(builtinPricesJSON, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse embedded price table: %w", err)
+	}
+	table.source = "built-in, updated " + table.Updated
+	return &table, nil
+}
+
+// LoadPriceTable reads a YAML or JSON price table, which replaces the built-in one.
+func LoadPriceTable(file string) (*PriceTable, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price table: %w", err)
+	}
+
+	var table PriceTable
+	if err := yaml.UnmarshalStrict(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse price table %s: %w", file, err)
+	}
+	if len(table.Hourly) == 0 && table.Default == 0 {
+		return nil, fmt.Errorf("price table %s: no hourly prices", file)
+	}
+	for instanceType, price := range table.Hourly {
+		if price < 0 {
+			return nil, fmt.Errorf("price table %s: negative price for %s", file, instanceType)
+		}
+	}
+	if table.Currency == "" {
+		table.Currency = "USD"
+	}
+	table.source = file
+	return &table, nil
+}
+
+// price returns the hourly price of an instance type, falling back to the table's default.
+func (t *PriceTable) price(instanceType string) (float64, bool) {
+	if price, ok := t.Hourly[instanceType]; ok {
+		return price, true
+	}
+	return t.Default, t.Default > 0
+}
+
+// CostReport is the estimated monthly cost of the cluster's nodes, attributed to the
+// namespaces and workloads whose requests reserve them.
+type CostReport struct {
+	Currency string `json:"currency"`
+	// PriceSource is the price table file, or built-in.
+	PriceSource string `json:"priceSource"`
+	// Monthly is the estimated monthly cost of the priced nodes.
+	Monthly float64 `json:"monthly"`
+	// Idle is the part of Monthly that no pod requests.
+	Idle       float64         `json:"idle"`
+	Nodes      []NodeCost      `json:"nodes"`
+	Namespaces []NamespaceCost `json:"namespaces"`
+	Workloads  []WorkloadCost  `json:"workloads"`
+}
+
+// NodeCost is one node's price.
+type NodeCost struct {
+	Name         string `json:"name"`
+	InstanceType string `json:"instanceType,omitempty"`
+	// Priced is false when the instance type is unknown or not in the price table; such nodes
+	// are left out of the estimate.
+	Priced  bool    `json:"priced"`
+	Monthly float64 `json:"monthly,omitempty"`
+}
+
+// NamespaceCost is the monthly cost attributed to one namespace.
+type NamespaceCost struct {
+	Namespace string  `json:"namespace"`
+	Monthly   float64 `json:"monthly"`
+}
+
+// WorkloadCost is the monthly cost attributed to one workload: the top-level owner of its pods,
+// or the pod itself when it has none.
+type WorkloadCost struct {
+	Kind      string  `json:"kind"`
+	Namespace string  `json:"namespace"`
+	Name      string  `json:"name"`
+	Pods      int     `json:"pods"`
+	Monthly   float64 `json:"monthly"`
+}
+
+// GetCostReport lists the nodes and running pods, and the ReplicaSets and Jobs between the pods
+// and their owners, and estimates the monthly cost with the given prices.
+func GetCostReport(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*CostReport, error) {
+	nodes, err := listNodes(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	pods, err := listPods(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	replicaSets, err := listReplicaSets(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	jobs, err := listJobs(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	return EstimateCost(nodes, pods, NewOwnerResolver(pods, replicaSets, jobs), opts.Prices), nil
+}
+
+// EstimateCost prices every node by its instance type and splits the price among the pods on
+// it in proportion to their requests: half the price follows the share of allocatable CPU
+// requested, half the share of allocatable memory. What no pod requests is idle. Nodes without
+// a price, and the pods on them, are left out.
+func EstimateCost(nodes []corev1.Node, pods []corev1.Pod, owners *OwnerResolver, prices *PriceTable) *CostReport {
+	report := &CostReport{Currency: prices.Currency, PriceSource: prices.source}
+	priced := map[string]*corev1.Node{}
+	nodeMonthly := map[string]float64{}
+	for i := range nodes {
+		node := &nodes[i]
+		c := NodeCost{Name: node.Name}
+		for _, label := range instanceTypeLabels {
+			if t := node.Labels[label]; t != "" {
+				c.InstanceType = t
+				break
+			}
+		}
+		if hourly, ok := prices.price(c.InstanceType); ok {
+			c.Priced, c.Monthly = true, hourly*hoursPerMonth
+			priced[node.Name] = node
+			nodeMonthly[node.Name] = c.Monthly
+			report.Monthly += c.Monthly
+		}
+		report.Nodes = append(report.Nodes, c)
+	}
+
+	unrequested := maps.Clone(nodeMonthly)
+	namespaces := map[string]float64{}
+	workloads := map[string]*WorkloadCost{}
+	for _, pod := range pods {
+		node := priced[pod.Spec.NodeName]
+		if node == nil || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		cpu, memory := podRequests(pod)
+		var share float64
+		if allocatable := node.Status.Allocatable.Cpu().MilliValue(); allocatable > 0 {
+			share += float64(cpu) / float64(allocatable) / 2
+		}
+		if allocatable := node.Status.Allocatable.Memory().Value(); allocatable > 0 {
+			share += float64(memory) / float64(allocatable) / 2
+		}
+		monthly := min(share*nodeMonthly[node.Name], unrequested[node.Name])
+		unrequested[node.Name] -= monthly
+
+		namespaces[pod.Namespace] += monthly
+		w := WorkloadCost{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name}
+		if owner := owners.Owner(pod.Namespace, pod.Name); owner != nil {
+			w.Kind, w.Name = owner.Kind, owner.Name
+		}
+		key := objectKey(w.Kind, w.Namespace, w.Name)
+		if workloads[key] == nil {
+			workloads[key] = &w
+		}
+		workloads[key].Pods++
+		workloads[key].Monthly += monthly
+	}
+	for _, idle := range unrequested {
+		report.Idle += idle
+	}
+
+	for ns, monthly := range namespaces {
+		report.Namespaces = append(report.Namespaces, NamespaceCost{Namespace: ns, Monthly: monthly})
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool {
+		a, b := report.Namespaces[i], report.Namespaces[j]
+		if a.Monthly != b.Monthly {
+			return a.Monthly > b.Monthly
+		}
+		return a.Namespace < b.Namespace
+	})
+	for _, w := range workloads {
+		report.Workloads = append(report.Workloads, *w)
+	}
+	sort.Slice(report.Workloads, func(i, j int) bool {
+		a, b := report.Workloads[i], report.Workloads[j]
+		if a.Monthly != b.Monthly {
+			return a.Monthly > b.Monthly
+		}
+		return objectKey(a.Kind, a.Namespace, a.Name) < objectKey(b.Kind, b.Namespace, b.Name)
+	})
+	return report
+}
+
+// Unpriced returns the nodes left out of the estimate.
+func (r *CostReport) Unpriced() []NodeCost {
+	var unpriced []NodeCost
+	for _, n := range r.Nodes {
+		if !n.Priced {
+			unpriced = append(unpriced, n)
+		}
+	}
+	return unpriced
+}
+
+// PrintCostReport writes the cost section of the text report.
+func PrintCostReport(w io.Writer, report *CostReport) {
+	money := func(amount float64) string {
+		return fmt.Sprintf("%.2f %s", amount, report.Currency)
+	}
+	fmt.Fprintf(w, "Estimated monthly cost (prices: %s):\n", report.PriceSource)
+	fmt.Fprintf(w, "  Total: %s, of which idle (not requested by any pod): %s\n", money(report.Monthly), money(report.Idle))
+	if unpriced := report.Unpriced(); len(unpriced) > 0 {
+		types := map[string]bool{}
+		for _, n := range unpriced {
+			instanceType := n.InstanceType
+			if instanceType == "" {
+				instanceType = "no instance type label"
+			}
+			types[instanceType] = true
+		}
+		fmt.Fprintf(w, "  %d node(s) left out, with no price for: %s\n", len(unpriced), strings.Join(slices.Sorted(maps.Keys(types)), ", "))
+	}
+	if len(report.Namespaces) > 0 {
+		fmt.Fprintln(w, "  Top namespaces:")
+		for _, ns := range report.Namespaces[:min(len(report.Namespaces), costEntriesShown)] {
+			fmt.Fprintf(w, "    - %s: %s\n", ns.Namespace, money(ns.Monthly))
+		}
+	}
+	if len(report.Workloads) > 0 {
+		fmt.Fprintln(w, "  Top workloads:")
+		for _, wl := range report.Workloads[:min(len(report.Workloads), costEntriesShown)] {
+			fmt.Fprintf(w, "    - %s %s/%s (%d pod(s)): %s\n", wl.Kind, wl.Namespace, wl.Name, wl.Pods, money(wl.Monthly))
+		}
+	}
+}
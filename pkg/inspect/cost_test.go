@@ -0,0 +1,177 @@
+package inspect
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func costNode(name, instanceType, cpu, memory string) corev1.Node {
+	node := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}},
+	}
+	if instanceType != "" {
+		node.Labels[corev1.LabelInstanceTypeStable] = instanceType
+	}
+	return node
+}
+
+func costPod(namespace, name, node, cpu, memory string, owner *metav1.OwnerReference) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			}},
+		}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if owner != nil {
+		pod.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+	return pod
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 0.001
+}
+
+func TestEstimateCost(t *testing.T) {
+	prices := &PriceTable{Currency: "USD", Hourly: map[string]float64{"m5.xlarge": 0.2}, source: "test"}
+	nodes := []corev1.Node{
+		costNode("a", "m5.xlarge", "4", "16Gi"),
+		costNode("b", "m5.xlarge", "4", "16Gi"),
+		costNode("c", "x9.huge", "4", "16Gi"),
+	}
+	isController := true
+	rs := &metav1.OwnerReference{Kind: "ReplicaSet", Name: "api-7d9", Controller: &isController}
+	pods := []corev1.Pod{
+		// Half the CPU and a quarter of the memory of node a: 37.5% of its price.
+		costPod("shop", "api-1", "a", "2", "4Gi", rs),
+		costPod("shop", "api-2", "b", "2", "4Gi", rs),
+		costPod("batch", "report", "a", "1", "8Gi", nil),
+		// Pods on unpriced nodes are left out, and finished pods request nothing.
+		costPod("shop", "api-3", "c", "2", "4Gi", rs),
+	}
+	finished := costPod("batch", "done", "b", "4", "16Gi", nil)
+	finished.Status.Phase = corev1.PodSucceeded
+	pods = append(pods, finished)
+	replicaSets := []appsv1.ReplicaSet{{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "shop", Name: "api-7d9",
+		OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "api", Controller: &isController}},
+	}}}
+
+	report := EstimateCost(nodes, pods, NewOwnerResolver(pods, replicaSets, nil), prices)
+	nodeMonthly := 0.2 * hoursPerMonth
+	if !closeTo(report.Monthly, 2*nodeMonthly) {
+		t.Errorf("Monthly = %v, want %v", report.Monthly, 2*nodeMonthly)
+	}
+	api := 2 * 0.375 * nodeMonthly
+	report1 := 0.375 * nodeMonthly
+	if !closeTo(report.Idle, 2*nodeMonthly-api-report1) {
+		t.Errorf("Idle = %v, want %v", report.Idle, 2*nodeMonthly-api-report1)
+	}
+	if len(report.Namespaces) != 2 || report.Namespaces[0].Namespace != "shop" || !closeTo(report.Namespaces[0].Monthly, api) {
+		t.Errorf("Namespaces = %+v, want shop first at %v", report.Namespaces, api)
+	}
+	if len(report.Workloads) != 2 {
+		t.Fatalf("Workloads = %+v, want the api Deployment and the report pod", report.Workloads)
+	}
+	if w := report.Workloads[0]; w.Kind != "Deployment" || w.Name != "api" || w.Pods != 2 || !closeTo(w.Monthly, api) {
+		t.Errorf("Workloads[0] = %+v, want Deployment api with 2 pods at %v", w, api)
+	}
+	if w := report.Workloads[1]; w.Kind != "Pod" || w.Name != "report" {
+		t.Errorf("Workloads[1] = %+v, want the bare report pod", w)
+	}
+	if unpriced := report.Unpriced(); len(unpriced) != 1 || unpriced[0].Name != "c" {
+		t.Errorf("Unpriced() = %+v, want node c", unpriced)
+	}
+
+	var b bytes.Buffer
+	PrintCostReport(&b, report)
+	for _, want := range []string{
+		"Estimated monthly cost (prices: test):",
+		"Total: 292.00 USD, of which idle (not requested by any pod): 127.75 USD",
+		"1 node(s) left out, with no price for: x9.huge",
+		"- Deployment shop/api (2 pod(s)): 109.50 USD",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestPriceTables(t *testing.T) {
+	builtin, err := BuiltinPriceTable()
+	if err != nil {
+		t.Fatalf("BuiltinPriceTable() returned error = %v", err)
+	}
+	if builtin.Currency != "USD" || builtin.Hourly["m5.large"] == 0 || builtin.Updated == "" {
+		t.Errorf("built-in table = %+v, want USD prices with an update date", builtin)
+	}
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	table, err := LoadPriceTable(write("prices.yaml", "currency: EUR\nhourly:\n  m6i.xlarge: 0.178\ndefault: 0.15\n"))
+	if err != nil {
+		t.Fatalf("LoadPriceTable() returned error = %v", err)
+	}
+	if price, ok := table.price("m6i.xlarge"); !ok || price != 0.178 {
+		t.Errorf("price(m6i.xlarge) = %v, %v, want 0.178", price, ok)
+	}
+	if price, ok := table.price(""); !ok || price != 0.15 {
+		t.Errorf("price() of an unlabeled node = %v, %v, want the default", price, ok)
+	}
+
+	for name, content := range map[string]string{
+		"empty.yaml":    "currency: USD\n",
+		"negative.yaml": "hourly:\n  m5.large: -1\n",
+		"unknown.yaml":  "hourly:\n  m5.large: 0.1\nspot: true\n",
+	} {
+		if _, err := LoadPriceTable(write(name, content)); err == nil {
+			t.Errorf("LoadPriceTable(%s) returned error = nil, want non-nil", name)
+		}
+	}
+}
+
+func TestCostCollector(t *testing.T) {
+	node := costNode("a", "m5.large", "2", "8Gi")
+	pod := costPod("shop", "api", "a", "1", "2Gi", nil)
+	clientset, dynamicClient := newFakeClients(&node, &pod)
+	prices, err := BuiltinPriceTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := AutoTuneScanOptions(ClusterSize{}).WithOverrides(ScanOptions{Collectors: []string{"cost"}, Prices: prices})
+	report, err := runScan(&scanState{ctx: t.Context(), clientset: clientset, dynamic: dynamicClient, opts: opts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg, ok := report.Errors[sectionCost]; ok {
+		t.Fatalf("cost collector failed: %s", msg)
+	}
+	if report.Cost == nil || len(report.Cost.Workloads) != 1 || report.Cost.Workloads[0].Monthly == 0 {
+		t.Errorf("Cost = %+v, want the api pod priced", report.Cost)
+	}
+}
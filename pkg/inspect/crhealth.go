@@ -0,0 +1,254 @@
+package inspect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var crdResource = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// CustomResourceHealth summarizes the Ready condition of custom resources whose CRDs declare
+// status.conditions.
+type CustomResourceHealth struct {
+	// Kinds are the custom resource kinds that were checked.
+	Kinds     []CustomResourceKind      `json:"kinds"`
+	Unhealthy []UnhealthyCustomResource `json:"unhealthy,omitempty"`
+}
+
+// CustomResourceKind is a custom resource kind and how many of its objects are not Ready.
+type CustomResourceKind struct {
+	Group      string `json:"group"`
+	Version    string `json:"version"`
+	Kind       string `json:"kind"`
+	Resource   string `json:"resource"`
+	Namespaced bool   `json:"namespaced"`
+	Total      int    `json:"total"`
+	NotReady   int    `json:"notReady"`
+}
+
+// UnhealthyCustomResource is a custom resource whose Ready condition is False.
+type UnhealthyCustomResource struct {
+	Group     string    `json:"group"`
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"`
+	Reason    string    `json:"reason,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Since     time.Time `json:"since,omitempty"`
+
+	object *unstructured.Unstructured
+}
+
+// GetCustomResourceHealth lists the CRDs and, for each that declares status.conditions, checks
+// the Ready condition of every object. Kinds that cannot be listed are reported in the returned
+// error alongside the kinds that could.
+func GetCustomResourceHealth(ctx context.Context, client dynamic.Interface, opts ScanOptions) (*CustomResourceHealth, error) {
+	crds, err := listUnstructured(ctx, client.Resource(crdResource), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customresourcedefinitions: %w", err)
+	}
+
+	health := &CustomResourceHealth{Kinds: ConditionKinds(crds)}
+	var errs []error
+	for i := range health.Kinds {
+		kind := &health.Kinds[i]
+		gvr := schema.GroupVersionResource{Group: kind.Group, Version: kind.Version, Resource: kind.Resource}
+		items, err := listUnstructured(ctx, client.Resource(gvr), opts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list %s.%s: %w", kind.Resource, kind.Group, err))
+			continue
+		}
+		health.Unhealthy = append(health.Unhealthy, CheckReadyConditions(kind, items)...)
+	}
+	return health, errors.Join(errs...)
+}
+
+// customResourcePermissions returns list on each custom resource kind GetCustomResourceHealth
+// would check in the cluster, for the custom-resources collector's RBAC.
+func customResourcePermissions(ctx context.Context, client dynamic.Interface, opts ScanOptions) ([]Permission, error) {
+	crds, err := listUnstructured(ctx, client.Resource(crdResource), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customresourcedefinitions: %w", err)
+	}
+	var permissions []Permission
+	for _, kind := range ConditionKinds(crds) {
+		permissions = append(permissions, allow(kind.Group, kind.Resource, "list"))
+	}
+	return permissions, nil
+}
+
+func listUnstructured(ctx context.Context, resource dynamic.ResourceInterface, opts ScanOptions) ([]unstructured.Unstructured, error) {
+	return listPaged(opts.PageSize, metav1.ListOptions{}, func(o metav1.ListOptions) ([]unstructured.Unstructured, string, error) {
+		l, err := resource.List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.GetContinue(), nil
+	})
+}
+
+// ConditionKinds returns the kinds of the CRDs whose served storage version (or, failing that,
+// first served version) has a schema with status.conditions.
+func ConditionKinds(crds []unstructured.Unstructured) []CustomResourceKind {
+	var kinds []CustomResourceKind
+	for _, crd := range crds {
+		versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+		var chosen map[string]any
+		for _, v := range versions {
+			version, ok := v.(map[string]any)
+			if !ok || version["served"] != true {
+				continue
+			}
+			if chosen == nil || version["storage"] == true {
+				chosen = version
+			}
+		}
+		if chosen == nil {
+			continue
+		}
+		if _, ok, _ := unstructured.NestedMap(chosen, "schema", "openAPIV3Schema", "properties", "status", "properties", "conditions"); !ok {
+			continue
+		}
+
+		group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+		plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+		scope, _, _ := unstructured.NestedString(crd.Object, "spec", "scope")
+		name, _, _ := unstructured.NestedString(chosen, "name")
+		kinds = append(kinds, CustomResourceKind{
+			Group: group, Version: name, Kind: kind, Resource: plural, Namespaced: scope == "Namespaced",
+		})
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if kinds[i].Group != kinds[j].Group {
+			return kinds[i].Group < kinds[j].Group
+		}
+		return kinds[i].Kind < kinds[j].Kind
+	})
+	return kinds
+}
+
+// CheckReadyConditions counts the objects of kind and returns those whose Ready condition is
+// False. Objects without a Ready condition are counted but not flagged, since many resources
+// only set conditions once they have something to report.
+func CheckReadyConditions(kind *CustomResourceKind, items []unstructured.Unstructured) []UnhealthyCustomResource {
+	var unhealthy []UnhealthyCustomResource
+	for i := range items {
+		obj := &items[i]
+		kind.Total++
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, c := range conditions {
+			condition, ok := c.(map[string]any)
+			if !ok || condition["type"] != "Ready" || condition["status"] != "False" {
+				continue
+			}
+			u := UnhealthyCustomResource{
+				Group: kind.Group, Kind: kind.Kind, Namespace: obj.GetNamespace(), Name: obj.GetName(), object: obj,
+			}
+			u.Reason, _, _ = unstructured.NestedString(condition, "reason")
+			u.Message, _, _ = unstructured.NestedString(condition, "message")
+			if since, _, _ := unstructured.NestedString(condition, "lastTransitionTime"); since != "" {
+				u.Since, _ = time.Parse(time.RFC3339, since)
+			}
+			unhealthy = append(unhealthy, u)
+			kind.NotReady++
+			break
+		}
+	}
+	return unhealthy
+}
+
+// CheckCustomResourceHealth raises a finding for every custom resource that is not Ready.
+func CheckCustomResourceHealth(health *CustomResourceHealth) []Finding {
+	var findings []Finding
+	for _, u := range health.Unhealthy {
+		name := u.Name
+		if u.Namespace != "" {
+			name = u.Namespace + "/" + u.Name
+		}
+		message := fmt.Sprintf("%s %s (%s) is not Ready", u.Kind, name, u.Group)
+		if u.Reason != "" {
+			message += ": " + u.Reason
+		}
+		if u.Message != "" {
+			message += ": " + u.Message
+		}
+		findings = append(findings, Finding{
+			CheckID:   "custom-resource-not-ready",
+			Severity:  SeverityMedium,
+			Kind:      u.Kind,
+			Namespace: u.Namespace,
+			Name:      u.Name,
+			Message:   message,
+			object:    u.object,
+			keyFields: []string{u.Group},
+		})
+	}
+	return findings
+}
+
+// PrintCustomResourceHealth writes the custom resource section of the text report, with the
+// resources that are not Ready grouped by kind and namespace.
+func PrintCustomResourceHealth(w io.Writer, health *CustomResourceHealth) {
+	fmt.Fprintf(w, "Custom resources (%d kind(s) with conditions):\n", len(health.Kinds))
+	if len(health.Unhealthy) == 0 {
+		fmt.Fprintln(w, "  All custom resources with a Ready condition are Ready.")
+		return
+	}
+
+	type group struct {
+		kind, apiGroup, namespace string
+		count                     int
+		reasons                   map[string]int
+	}
+	var groups []*group
+	byKey := map[string]*group{}
+	for _, u := range health.Unhealthy {
+		key := u.Group + "/" + u.Kind + "/" + u.Namespace
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{kind: u.Kind, apiGroup: u.Group, namespace: u.Namespace, reasons: map[string]int{}}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.count++
+		if u.Reason != "" {
+			g.reasons[u.Reason]++
+		}
+	}
+
+	for _, g := range groups {
+		where := g.namespace
+		if where == "" {
+			where = "cluster scope"
+		}
+		line := fmt.Sprintf("%s (%s) in %s: %d not Ready", g.kind, g.apiGroup, where, g.count)
+		if len(g.reasons) > 0 {
+			reasons := make([]string, 0, len(g.reasons))
+			for r := range g.reasons {
+				reasons = append(reasons, r)
+			}
+			sort.Slice(reasons, func(i, j int) bool {
+				if g.reasons[reasons[i]] != g.reasons[reasons[j]] {
+					return g.reasons[reasons[i]] > g.reasons[reasons[j]]
+				}
+				return reasons[i] < reasons[j]
+			})
+			line += fmt.Sprintf(" (%s", reasons[0])
+			if len(reasons) > 1 {
+				line += fmt.Sprintf(" and %d other reason(s)", len(reasons)-1)
+			}
+			line += ")"
+		}
+		fmt.Fprintf(w, "  - %s\n", line)
+	}
+}
@@ -0,0 +1,107 @@
+package inspect
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func testCRD(group, kind, plural, scope string, versions ...map[string]any) unstructured.Unstructured {
+	vs := make([]any, len(versions))
+	for i, v := range versions {
+		vs[i] = v
+	}
+	return unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"group":    group,
+			"scope":    scope,
+			"names":    map[string]any{"kind": kind, "plural": plural},
+			"versions": vs,
+		},
+	}}
+}
+
+func crdVersion(name string, served, storage, conditions bool) map[string]any {
+	status := map[string]any{"type": "object"}
+	if conditions {
+		status["properties"] = map[string]any{"conditions": map[string]any{"type": "array"}}
+	}
+	return map[string]any{
+		"name": name, "served": served, "storage": storage,
+		"schema": map[string]any{"openAPIV3Schema": map[string]any{"properties": map[string]any{"status": status}}},
+	}
+}
+
+func TestConditionKinds(t *testing.T) {
+	crds := []unstructured.Unstructured{
+		testCRD("cert-manager.io", "Certificate", "certificates", "Namespaced",
+			crdVersion("v1alpha2", true, false, false), crdVersion("v1", true, true, true)),
+		testCRD("example.com", "Widget", "widgets", "Namespaced", crdVersion("v1", true, true, false)),
+		testCRD("example.com", "Retired", "retireds", "Cluster", crdVersion("v1", false, true, true)),
+		testCRD("infra.example.com", "Cluster", "clusters", "Cluster", crdVersion("v1beta1", true, false, true)),
+	}
+	kinds := ConditionKinds(crds)
+	if len(kinds) != 2 {
+		t.Fatalf("ConditionKinds() = %+v, want Certificate and Cluster", kinds)
+	}
+	if k := kinds[0]; k.Kind != "Certificate" || k.Version != "v1" || k.Resource != "certificates" || !k.Namespaced {
+		t.Errorf("kinds[0] = %+v", k)
+	}
+	if k := kinds[1]; k.Kind != "Cluster" || k.Version != "v1beta1" || k.Namespaced {
+		t.Errorf("kinds[1] = %+v", k)
+	}
+}
+
+func TestCheckReadyConditions(t *testing.T) {
+	object := func(namespace, name string, conditions ...map[string]any) unstructured.Unstructured {
+		cs := make([]any, len(conditions))
+		for i, c := range conditions {
+			cs[i] = c
+		}
+		return unstructured.Unstructured{Object: map[string]any{
+			"metadata": map[string]any{"namespace": namespace, "name": name},
+			"status":   map[string]any{"conditions": cs},
+		}}
+	}
+	notReady := func(reason string) map[string]any {
+		return map[string]any{"type": "Ready", "status": "False", "reason": reason, "message": "issuer not found", "lastTransitionTime": "2025-06-01T10:00:00Z"}
+	}
+	items := []unstructured.Unstructured{
+		object("web", "api-tls", map[string]any{"type": "Issuing", "status": "True"}, notReady("IssuerNotFound")),
+		object("web", "www-tls", notReady("IssuerNotFound")),
+		object("shop", "shop-tls", notReady("Expired")),
+		object("web", "ok-tls", map[string]any{"type": "Ready", "status": "True"}),
+		object("web", "new-tls"),
+	}
+
+	kind := &CustomResourceKind{Group: "cert-manager.io", Kind: "Certificate"}
+	unhealthy := CheckReadyConditions(kind, items)
+	if kind.Total != 5 || kind.NotReady != 3 || len(unhealthy) != 3 {
+		t.Fatalf("Total = %d, NotReady = %d, unhealthy = %d; want 5, 3, 3", kind.Total, kind.NotReady, len(unhealthy))
+	}
+	if u := unhealthy[0]; u.Name != "api-tls" || u.Reason != "IssuerNotFound" || u.Since.IsZero() {
+		t.Errorf("unhealthy[0] = %+v", u)
+	}
+
+	health := &CustomResourceHealth{Kinds: []CustomResourceKind{*kind}, Unhealthy: unhealthy}
+	findings := CheckCustomResourceHealth(health)
+	if len(findings) != 3 || findings[0].CheckID != "custom-resource-not-ready" || findings[0].Kind != "Certificate" {
+		t.Fatalf("CheckCustomResourceHealth() = %+v", findings)
+	}
+	if want := "Certificate web/api-tls (cert-manager.io) is not Ready: IssuerNotFound: issuer not found"; findings[0].Message != want {
+		t.Errorf("message = %q, want %q", findings[0].Message, want)
+	}
+
+	var buf bytes.Buffer
+	PrintCustomResourceHealth(&buf, health)
+	for _, want := range []string{
+		"Certificate (cert-manager.io) in web: 2 not Ready (IssuerNotFound)",
+		"Certificate (cert-manager.io) in shop: 1 not Ready (Expired)",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package inspect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronMacros are the shorthand schedules accepted in place of five fields.
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSearchLimit bounds how far ahead Next looks for a matching time, so impossible
+// schedules such as February 30th end instead of looping forever.
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// CronSchedule is a parsed five-field cron expression: minute, hour, day of month, month, and
+// day of week.
+type CronSchedule struct {
+	expr                               string
+	minutes, hours, days, months, dows [64]bool
+	// daysRestricted and dowsRestricted record whether the day fields started with something
+	// other than *, since cron matches either day field when both are restricted; like cron,
+	// a stepped */2 counts as unrestricted.
+	daysRestricted, dowsRestricted bool
+}
+
+func (s *CronSchedule) String() string {
+	return s.expr
+}
+
+// ParseCronSchedule parses a standard cron expression. Each field accepts *, numbers, ranges
+// (1-5), lists (1,15), and steps (*/15, 0-30/10); Sunday is 0 or 7. The @hourly, @daily,
+// @weekly, @monthly, and @yearly macros are also accepted.
+func ParseCronSchedule(expr string) (*CronSchedule, error) {
+	spec := strings.TrimSpace(expr)
+	if macro, ok := cronMacros[spec]; ok {
+		spec = macro
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, not %d", expr, len(fields))
+	}
+
+	s := &CronSchedule{expr: expr}
+	bounds := []struct {
+		name     string
+		set      *[64]bool
+		min, max int
+	}{
+		{"minute", &s.minutes, 0, 59},
+		{"hour", &s.hours, 0, 23},
+		{"day of month", &s.days, 1, 31},
+		{"month", &s.months, 1, 12},
+		{"day of week", &s.dows, 0, 7},
+	}
+	for i, b := range bounds {
+		if err := parseCronField(fields[i], b.set, b.min, b.max); err != nil {
+			return nil, fmt.Errorf("invalid %s field in %q: %w", b.name, expr, err)
+		}
+	}
+	if s.dows[7] {
+		s.dows[0] = true
+	}
+	s.daysRestricted = !strings.HasPrefix(fields[2], "*")
+	s.dowsRestricted = !strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+func parseCronField(field string, set *[64]bool, min, max int) error {
+	for _, part := range strings.Split(field, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step <= 0 {
+				return fmt.Errorf("invalid step %q", stepText)
+			}
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			loText, hiText, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(loText); err != nil {
+				return fmt.Errorf("invalid value %q", loText)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiText); err != nil {
+					return fmt.Errorf("invalid value %q", hiText)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+// Next returns the first time after t that matches the schedule, in t's location, or the zero
+// time when nothing matches within five years.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+	for t.Before(limit) {
+		switch {
+		case !s.months[t.Month()]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !s.hours[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !s.minutes[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *CronSchedule) matchesDay(t time.Time) bool {
+	day, dow := s.days[t.Day()], s.dows[t.Weekday()]
+	if s.daysRestricted && s.dowsRestricted {
+		return day || dow
+	}
+	return day && dow
+}
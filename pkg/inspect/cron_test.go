@@ -0,0 +1,56 @@
+package inspect
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	// 2025-06-04 is a Wednesday.
+	from := time.Date(2025, 6, 4, 10, 17, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"0 */6 * * *", "2025-06-04T12:00:00Z"},
+		{"*/15 * * * *", "2025-06-04T10:30:00Z"},
+		{"30 2 * * *", "2025-06-05T02:30:00Z"},
+		{"0 9 * * 1-5", "2025-06-05T09:00:00Z"},
+		{"0 0 * * 0", "2025-06-08T00:00:00Z"},
+		{"0 0 * * 7", "2025-06-08T00:00:00Z"},
+		{"0 0 1 * *", "2025-07-01T00:00:00Z"},
+		{"0 0 13 * 5", "2025-06-06T00:00:00Z"},
+		{"0 0 */2 * 1", "2025-06-09T00:00:00Z"},
+		{"0 12 29 2 *", "2028-02-29T12:00:00Z"},
+		{"5,10 10 * * *", "2025-06-05T10:05:00Z"},
+		{"@hourly", "2025-06-04T11:00:00Z"},
+		{"@weekly", "2025-06-08T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		s, err := ParseCronSchedule(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCronSchedule(%q): %v", tt.expr, err)
+		}
+		if got := s.Next(from).Format(time.RFC3339); got != tt.want {
+			t.Errorf("Next(%q) = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCronScheduleNever(t *testing.T) {
+	s, err := ParseCronSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := s.Next(time.Now()); !next.IsZero() {
+		t.Errorf("Next() = %s for February 30th, want zero", next)
+	}
+}
+
+func TestParseCronScheduleErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCronSchedule(expr); err == nil {
+			t.Errorf("ParseCronSchedule(%q) succeeded, want error", expr)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// nodeDebuggerPrefix is the name prefix `kubectl debug node/<name>` gives the pods it creates.
+const nodeDebuggerPrefix = "node-debugger-"
+
+// GetDebugFindings flags debugging access left behind in the cluster.
+func GetDebugFindings(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) ([]Finding, error) {
+	pods, err := listPods(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return CheckDebugArtifacts(pods, opts.DebugMaxAge, time.Now()), nil
+}
+
+// CheckDebugArtifacts raises findings for debugging access that has outlived maxAge: ephemeral
+// containers still running in a pod, node shells left by `kubectl debug node`, and bare privileged
+// pods that share the host PID namespace to nsenter into the node. Pods that have finished are
+// ignored, as are pods owned by a controller, which covers CNI and monitoring DaemonSets that
+// legitimately run privileged in the host namespaces.
+func CheckDebugArtifacts(pods []corev1.Pod, maxAge time.Duration, now time.Time) []Finding {
+	var findings []Finding
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		for _, ec := range pod.Spec.EphemeralContainers {
+			started := ephemeralContainerStart(pod, ec.Name)
+			if started.IsZero() || now.Sub(started) < maxAge {
+				continue
+			}
+			severity := SeverityMedium
+			if isPrivileged(ec.SecurityContext) {
+				severity = SeverityHigh
+			}
+			target := ""
+			if ec.TargetContainerName != "" {
+				target = fmt.Sprintf(" targeting container %s", ec.TargetContainerName)
+			}
+			findings = append(findings, Finding{
+				CheckID:   "ephemeral-debug-container",
+				Severity:  severity,
+				Kind:      "Pod",
+				Namespace: pod.Namespace,
+				Name:      pod.Name,
+				Message: fmt.Sprintf("ephemeral container %s%s in pod %s/%s has been running for %s",
+					ec.Name, target, pod.Namespace, pod.Name, formatAge(now.Sub(started))),
+				object:    &pod,
+				keyFields: []string{ec.Name},
+			})
+		}
+
+		if len(pod.OwnerReferences) > 0 || now.Sub(pod.CreationTimestamp.Time) < maxAge {
+			continue
+		}
+		age := formatAge(now.Sub(pod.CreationTimestamp.Time))
+		switch {
+		case isNodeDebugger(pod):
+			findings = append(findings, Finding{
+				CheckID:   "leftover-node-debugger",
+				Severity:  SeverityHigh,
+				Kind:      "Pod",
+				Namespace: pod.Namespace,
+				Name:      pod.Name,
+				Message: fmt.Sprintf("node debug pod %s/%s on node %s has been running for %s with the host filesystem mounted",
+					pod.Namespace, pod.Name, pod.Spec.NodeName, age),
+				object: &pod,
+			})
+		case isNodeShell(pod):
+			findings = append(findings, Finding{
+				CheckID:   "privileged-node-shell",
+				Severity:  SeverityHigh,
+				Kind:      "Pod",
+				Namespace: pod.Namespace,
+				Name:      pod.Name,
+				Message: fmt.Sprintf("privileged pod %s/%s shares the host PID namespace of node %s and has been running for %s without an owner",
+					pod.Namespace, pod.Name, pod.Spec.NodeName, age),
+				object: &pod,
+			})
+		}
+	}
+	return findings
+}
+
+// ephemeralContainerStart returns when the named ephemeral container started running, or the
+// zero time if it is not running.
+func ephemeralContainerStart(pod corev1.Pod, name string) time.Time {
+	for _, cs := range pod.Status.EphemeralContainerStatuses {
+		if cs.Name == name && cs.State.Running != nil {
+			return cs.State.Running.StartedAt.Time
+		}
+	}
+	return time.Time{}
+}
+
+// isNodeDebugger reports whether pod looks like one created by `kubectl debug node/<name>`,
+// which mounts the node's root filesystem at /host.
+func isNodeDebugger(pod corev1.Pod) bool {
+	if strings.HasPrefix(pod.Name, nodeDebuggerPrefix) {
+		return true
+	}
+	for _, v := range pod.Spec.Volumes {
+		if v.HostPath != nil && v.HostPath.Path == "/" && pod.Spec.HostPID {
+			return true
+		}
+	}
+	return false
+}
+
+// isNodeShell reports whether pod is a privileged pod in the host PID namespace, the
+// combination nsenter needs to get a root shell on the node.
+func isNodeShell(pod corev1.Pod) bool {
+	if !pod.Spec.HostPID {
+		return false
+	}
+	for _, c := range pod.Spec.Containers {
+		if isPrivileged(c.SecurityContext) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPrivileged(sc *corev1.SecurityContext) bool {
+	return sc != nil && sc.Privileged != nil && *sc.Privileged
+}
+
+// formatAge renders d in minutes under an hour, in hours under two days, and in days beyond.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return d.Round(time.Minute).String()
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
@@ -0,0 +1,76 @@
+package inspect
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckDebugArtifacts(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	old := metav1.NewTime(now.Add(-6 * time.Hour))
+	recent := metav1.NewTime(now.Add(-30 * time.Minute))
+	privileged := true
+
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "api", CreationTimestamp: old},
+			Spec: corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{
+				{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger-abc"}, TargetContainerName: "app"},
+				{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger-done"}},
+			}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, EphemeralContainerStatuses: []corev1.ContainerStatus{
+				{Name: "debugger-abc", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: old}}},
+				{Name: "debugger-done", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "node-debugger-worker-1-x7k2p", CreationTimestamp: old},
+			Spec:       corev1.PodSpec{NodeName: "worker-1", HostPID: true},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nsenter-worker-2", CreationTimestamp: old},
+			Spec: corev1.PodSpec{NodeName: "worker-2", HostPID: true, Containers: []corev1.Container{{
+				Name: "shell", SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+			}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			// Fresh debug pods are still in use.
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "node-debugger-worker-3-abcde", CreationTimestamp: recent},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			// Controller-owned privileged pods, such as a CNI DaemonSet, are expected.
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "cilium-x", CreationTimestamp: old,
+				OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "cilium"}}},
+			Spec: corev1.PodSpec{HostPID: true, Containers: []corev1.Container{{
+				Name: "agent", SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+			}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "node-debugger-worker-4-done", CreationTimestamp: old},
+			Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+		},
+	}
+
+	findings := CheckDebugArtifacts(pods, 4*time.Hour, now)
+
+	want := map[string]string{
+		"ephemeral-debug-container": "web/api",
+		"leftover-node-debugger":    "default/node-debugger-worker-1-x7k2p",
+		"privileged-node-shell":     "default/nsenter-worker-2",
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("CheckDebugArtifacts() returned %d findings, want %d: %+v", len(findings), len(want), findings)
+	}
+	for _, f := range findings {
+		if got := f.Namespace + "/" + f.Name; want[f.CheckID] != got {
+			t.Errorf("CheckDebugArtifacts() %s flagged %s, want %s", f.CheckID, got, want[f.CheckID])
+		}
+	}
+}
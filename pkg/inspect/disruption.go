@@ -0,0 +1,154 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// disruptionTarget is a replicated workload as far as voluntary disruptions are concerned.
+type disruptionTarget struct {
+	kind     string
+	object   any
+	meta     metav1.ObjectMeta
+	replicas int32
+	template corev1.PodTemplateSpec
+}
+
+// GetDisruptionFindings flags the workloads and PodDisruptionBudgets that make node
+// maintenance risky.
+func GetDisruptionFindings(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) ([]Finding, error) {
+	deployments, err := listDeployments(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	statefulSets, err := listStatefulSets(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	pdbs, err := listPodDisruptionBudgets(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list poddisruptionbudgets: %w", err)
+	}
+	return CheckDisruptionRisk(deployments, statefulSets, pdbs), nil
+}
+
+// CheckDisruptionRisk cross-references Deployments and StatefulSets with PodDisruptionBudgets.
+// It raises findings for PDBs that currently allow no disruptions, which make `kubectl drain`
+// hang; for single-replica workloads, which go down whenever their node is drained whatever
+// their PDB says; and for replicated workloads that have no PDB, or whose replicas may all be
+// scheduled onto the same node because they set neither pod anti-affinity nor topology spread
+// constraints. Workloads scaled to zero are skipped.
+func CheckDisruptionRisk(deployments []appsv1.Deployment, statefulSets []appsv1.StatefulSet, pdbs []policyv1.PodDisruptionBudget) []Finding {
+	var findings []Finding
+
+	for _, pdb := range pdbs {
+		if pdb.Status.ExpectedPods == 0 || pdb.Status.DisruptionsAllowed > 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			CheckID:   "pdb-blocks-drain",
+			Severity:  SeverityMedium,
+			Kind:      "PodDisruptionBudget",
+			Namespace: pdb.Namespace,
+			Name:      pdb.Name,
+			Message: fmt.Sprintf("PodDisruptionBudget %s/%s allows 0 disruptions (%s, %d/%d pods healthy); draining a node that runs its pods will block",
+				pdb.Namespace, pdb.Name, pdbBudget(pdb), pdb.Status.CurrentHealthy, pdb.Status.ExpectedPods),
+			object: &pdb,
+		})
+	}
+
+	var targets []disruptionTarget
+	for i, d := range deployments {
+		targets = append(targets, disruptionTarget{"Deployment", &deployments[i], d.ObjectMeta, replicasOrDefault(d.Spec.Replicas), d.Spec.Template})
+	}
+	for i, s := range statefulSets {
+		targets = append(targets, disruptionTarget{"StatefulSet", &statefulSets[i], s.ObjectMeta, replicasOrDefault(s.Spec.Replicas), s.Spec.Template})
+	}
+
+	for _, t := range targets {
+		if t.replicas == 0 {
+			continue
+		}
+		finding := Finding{Kind: t.kind, Namespace: t.meta.Namespace, Name: t.meta.Name, object: t.object}
+		if t.replicas == 1 {
+			finding.CheckID = "single-replica-workload"
+			finding.Severity = SeverityMedium
+			finding.Message = fmt.Sprintf("%s %s/%s runs a single replica and is unavailable whenever its node is drained",
+				t.kind, t.meta.Namespace, t.meta.Name)
+			findings = append(findings, finding)
+			continue
+		}
+		if len(matchingPDBs(t, pdbs)) == 0 {
+			finding.CheckID = "workload-no-pdb"
+			finding.Severity = SeverityLow
+			finding.Message = fmt.Sprintf("%s %s/%s has %d replicas but no PodDisruptionBudget; a drain may evict them all at once",
+				t.kind, t.meta.Namespace, t.meta.Name, t.replicas)
+			findings = append(findings, finding)
+		}
+		if !spreadsReplicas(t.template.Spec) {
+			finding.CheckID = "replicas-not-spread"
+			finding.Severity = SeverityLow
+			finding.Message = fmt.Sprintf("%s %s/%s sets no pod anti-affinity or topology spread constraints, so its %d replicas may share a node",
+				t.kind, t.meta.Namespace, t.meta.Name, t.replicas)
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// matchingPDBs returns the names of the PDBs in the workload's namespace that select its pods.
+func matchingPDBs(t disruptionTarget, pdbs []policyv1.PodDisruptionBudget) []string {
+	var names []string
+	for _, pdb := range pdbs {
+		if pdb.Namespace != t.meta.Namespace || pdb.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			continue
+		}
+		if selector.Matches(labels.Set(t.template.Labels)) {
+			names = append(names, pdb.Name)
+		}
+	}
+	return names
+}
+
+// spreadsReplicas reports whether a pod spec asks the scheduler to keep its replicas apart.
+func spreadsReplicas(spec corev1.PodSpec) bool {
+	if len(spec.TopologySpreadConstraints) > 0 {
+		return true
+	}
+	a := spec.Affinity
+	if a == nil || a.PodAntiAffinity == nil {
+		return false
+	}
+	return len(a.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) > 0 ||
+		len(a.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) > 0
+}
+
+// pdbBudget describes a PDB's minAvailable or maxUnavailable setting.
+func pdbBudget(pdb policyv1.PodDisruptionBudget) string {
+	switch {
+	case pdb.Spec.MinAvailable != nil:
+		return "minAvailable " + pdb.Spec.MinAvailable.String()
+	case pdb.Spec.MaxUnavailable != nil:
+		return "maxUnavailable " + pdb.Spec.MaxUnavailable.String()
+	}
+	return "no budget set"
+}
+
+// replicasOrDefault returns the replica count of a workload spec, which defaults to 1.
+func replicasOrDefault(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
@@ -0,0 +1,75 @@
+package inspect
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func testDeployment(name string, replicas int32, spec corev1.PodSpec) appsv1.Deployment {
+	return appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: name},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}}, Spec: spec},
+		},
+	}
+}
+
+func TestCheckDisruptionRisk(t *testing.T) {
+	spread := corev1.PodSpec{TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{TopologyKey: "kubernetes.io/hostname"}}}
+	antiAffinity := corev1.PodSpec{Affinity: &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{Weight: 100}},
+	}}}
+	minAvailable := intstr.FromInt32(3)
+
+	deployments := []appsv1.Deployment{
+		testDeployment("api", 3, spread),
+		testDeployment("worker", 3, corev1.PodSpec{}),
+		testDeployment("cron", 1, antiAffinity),
+		testDeployment("paused", 0, corev1.PodSpec{}),
+	}
+	statefulSets := []appsv1.StatefulSet{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "db", Name: "pg"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: int32Ptr(3),
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "pg"}}, Spec: antiAffinity},
+		},
+	}}
+	pdbs := []policyv1.PodDisruptionBudget{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "api"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{MinAvailable: &minAvailable, Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}}},
+			Status:     policyv1.PodDisruptionBudgetStatus{ExpectedPods: 3, CurrentHealthy: 3, DisruptionsAllowed: 0},
+		},
+		{
+			// Same labels in another namespace do not count.
+			ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "pg"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "pg"}}},
+			Status:     policyv1.PodDisruptionBudgetStatus{ExpectedPods: 0},
+		},
+	}
+
+	var got []string
+	for _, f := range CheckDisruptionRisk(deployments, statefulSets, pdbs) {
+		got = append(got, f.CheckID+" "+f.Namespace+"/"+f.Name)
+	}
+	sort.Strings(got)
+
+	want := []string{
+		"pdb-blocks-drain web/api",
+		"replicas-not-spread web/worker",
+		"single-replica-workload web/cron",
+		"workload-no-pdb db/pg",
+		"workload-no-pdb web/worker",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("CheckDisruptionRisk() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,582 @@
+package inspect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Statuses of a claimed hostname in the DNS check.
+const (
+	// DNSDelegated means every answer points at this cluster's load balancers.
+	DNSDelegated = "delegated"
+	// DNSElsewhere means the hostname has answers, but none point at this cluster.
+	DNSElsewhere = "elsewhere"
+	// DNSConflict means some answers point at this cluster and others elsewhere.
+	DNSConflict = "conflict"
+	// DNSMissing means no zone or resolver has an answer for the hostname.
+	DNSMissing = "missing"
+)
+
+// dnsResolverSource names answers that came from live DNS rather than a zone export.
+const dnsResolverSource = "resolver"
+
+// maxCNAMEHops bounds how many CNAMEs are followed through the zone exports.
+const maxCNAMEHops = 8
+
+// dnsClaimsShown is how many hostnames that are not delegated the text report lists.
+const dnsClaimsShown = 20
+
+// External-dns annotations that claim hostnames for Services and Ingresses, and override the
+// address their records point at.
+const (
+	externalDNSHostnameAnnotation = "external-dns.alpha.kubernetes.io/hostname"
+	externalDNSTargetAnnotation   = "external-dns.alpha.kubernetes.io/target"
+)
+
+var (
+	gatewayResource   = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "gateways"}
+	httpRouteResource = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"}
+)
+
+// DNSOptions selects where the DNS check looks up the hostnames the cluster claims.
+type DNSOptions struct {
+	// Zones are zone exports to look hostnames up in.
+	Zones []*DNSZone
+	// Resolve also looks hostnames up with the system resolver.
+	Resolve bool
+}
+
+// Enabled reports whether the DNS check has anywhere to look hostnames up.
+func (o DNSOptions) Enabled() bool {
+	return len(o.Zones) > 0 || o.Resolve
+}
+
+// DNSZone holds the A, AAAA, and CNAME records of a zone export.
+type DNSZone struct {
+	// Name identifies the zone in the report, such as the export's file name.
+	Name    string      `json:"name"`
+	Records []DNSRecord `json:"records"`
+}
+
+// DNSRecord is one A, AAAA, or CNAME record. Names are fully qualified, in lower case, and
+// without the trailing dot.
+type DNSRecord struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// LoadDNSZone reads a zone export in the BIND zone file format, as written by most DNS
+// providers' export tools. The zone is named after the file.
+func LoadDNSZone(file string) (*DNSZone, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DNS zone: %w", err)
+	}
+	zone, err := ParseDNSZone(filepath.Base(file), string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DNS zone %s: %w", file, err)
+	}
+	return zone, nil
+}
+
+// ParseDNSZone parses the A, AAAA, and CNAME records of a BIND zone file. It understands
+// $ORIGIN, relative and @ owner names, records that repeat the previous owner, optional TTLs
+// and classes, and records continued across lines in parentheses. Other record types are
+// skipped.
+func ParseDNSZone(name, data string) (*DNSZone, error) {
+	zone := &DNSZone{Name: name}
+	var origin, owner string
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := stripZoneComment(lines[i])
+		number := i + 1
+		for strings.Count(line, "(") > strings.Count(line, ")") && i+1 < len(lines) {
+			i++
+			line += " " + stripZoneComment(lines[i])
+		}
+		line = strings.NewReplacer("(", " ", ")", " ").Replace(line)
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "$ORIGIN":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: $ORIGIN without a name", number)
+			}
+			origin = qualifyDNSName(fields[1], origin)
+			continue
+		case "$TTL":
+			continue
+		case "$INCLUDE":
+			return nil, fmt.Errorf("line %d: $INCLUDE is not supported", number)
+		}
+
+		// A record that starts with blank space belongs to the previous owner.
+		if line[0] != ' ' && line[0] != '\t' {
+			owner = qualifyDNSName(fields[0], origin)
+			fields = fields[1:]
+		}
+		if owner == "" {
+			return nil, fmt.Errorf("line %d: record without an owner name", number)
+		}
+		for len(fields) > 0 && (isZoneTTL(fields[0]) || isZoneClass(fields[0])) {
+			fields = fields[1:]
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: incomplete record", number)
+		}
+
+		record := DNSRecord{Name: owner, Type: strings.ToUpper(fields[0]), Value: fields[1]}
+		switch record.Type {
+		case "A", "AAAA":
+		case "CNAME":
+			record.Value = qualifyDNSName(record.Value, origin)
+		default:
+			continue
+		}
+		zone.Records = append(zone.Records, record)
+	}
+	return zone, nil
+}
+
+func stripZoneComment(line string) string {
+	if i := strings.IndexByte(line, ';'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+func isZoneTTL(field string) bool {
+	return field[0] >= '0' && field[0] <= '9'
+}
+
+func isZoneClass(field string) bool {
+	switch strings.ToUpper(field) {
+	case "IN", "CH", "HS", "CS":
+		return true
+	}
+	return false
+}
+
+// qualifyDNSName makes name fully qualified against origin, in the form DNSRecord uses.
+func qualifyDNSName(name, origin string) string {
+	switch {
+	case name == "@":
+		return origin
+	case strings.HasSuffix(name, "."):
+		return normalizeDNSName(name)
+	case origin == "":
+		return normalizeDNSName(name)
+	default:
+		return normalizeDNSName(name) + "." + origin
+	}
+}
+
+func normalizeDNSName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
+
+// lookup returns the zone's records for name, or those of the closest wildcard above it.
+func (z *DNSZone) lookup(name string) []DNSRecord {
+	match := func(owner string) []DNSRecord {
+		var records []DNSRecord
+		for _, r := range z.Records {
+			if r.Name == owner {
+				records = append(records, r)
+			}
+		}
+		return records
+	}
+	if records := match(name); len(records) > 0 {
+		return records
+	}
+	for parent := name; ; {
+		_, rest, ok := strings.Cut(parent, ".")
+		if !ok || rest == "" {
+			return nil
+		}
+		if records := match("*." + rest); len(records) > 0 {
+			return records
+		}
+		parent = rest
+	}
+}
+
+// DNSReport compares the hostnames the cluster claims with what DNS says about them.
+type DNSReport struct {
+	// Zones are the names of the zone exports checked.
+	Zones []string `json:"zones,omitempty"`
+	// Resolved is set when the system resolver was also asked.
+	Resolved bool `json:"resolved"`
+	// ClusterAddresses are the load balancer addresses and external-dns targets that count as
+	// this cluster.
+	ClusterAddresses []string   `json:"clusterAddresses"`
+	Claims           []DNSClaim `json:"claims"`
+}
+
+// DNSClaim is a hostname the cluster claims and the answers found for it.
+type DNSClaim struct {
+	Hostname string `json:"hostname"`
+	// Sources are the objects that claim the hostname, such as Ingress web/shop.
+	Sources []string `json:"sources"`
+	// Status is delegated, elsewhere, conflict, or missing.
+	Status  string      `json:"status"`
+	Answers []DNSAnswer `json:"answers,omitempty"`
+	// Error is set when the resolver failed for a reason other than the name not existing.
+	Error string `json:"error,omitempty"`
+}
+
+// DNSAnswer is where one zone or the resolver sends a hostname.
+type DNSAnswer struct {
+	// Source is the zone export the answer came from, or resolver.
+	Source string `json:"source"`
+	// CNAMEs are the aliases followed on the way to Value.
+	CNAMEs []string `json:"cnames,omitempty"`
+	// Value is an address, or the last alias when it leads out of the zone exports.
+	Value string `json:"value"`
+	// Cluster is set when Value or one of the CNAMEs is this cluster's.
+	Cluster bool `json:"cluster"`
+}
+
+func (a DNSAnswer) String() string {
+	path := append(append([]string{}, a.CNAMEs...), a.Value)
+	return fmt.Sprintf("%s (%s)", strings.Join(path, " -> "), a.Source)
+}
+
+// dnsLookupFunc returns the resolver's answers for a hostname.
+type dnsLookupFunc func(host string) ([]DNSAnswer, error)
+
+// newDNSLookup returns a system resolver lookup bounded by timeout and ctx. A name that does not
+// exist has no answers rather than an error.
+func newDNSLookup(ctx context.Context, timeout time.Duration) dnsLookupFunc {
+	return func(host string) ([]DNSAnswer, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		var dnsErr *net.DNSError
+		addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var cnames []string
+		if cname, err := net.DefaultResolver.LookupCNAME(ctx, host); err == nil && normalizeDNSName(cname) != host {
+			cnames = []string{normalizeDNSName(cname)}
+		}
+		answers := make([]DNSAnswer, len(addrs))
+		for i, addr := range addrs {
+			answers[i] = DNSAnswer{Source: dnsResolverSource, CNAMEs: cnames, Value: addr.Unmap().String()}
+		}
+		return answers, nil
+	}
+}
+
+// GetDNSReport collects the hostnames claimed by Ingresses, Gateway API Gateways and
+// HTTPRoutes, and external-dns annotations, and checks them against opts.DNS. Clusters without
+// the Gateway API are checked without it.
+func GetDNSReport(ctx context.Context, clientset kubernetes.Interface, client dynamic.Interface, opts ScanOptions) (*DNSReport, error) {
+	services, err := listServices(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	ingresses, err := listIngresses(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+	gateways, err := listUnstructured(ctx, client.Resource(gatewayResource), opts)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to list gateways: %w", err)
+	}
+	routes, err := listUnstructured(ctx, client.Resource(httpRouteResource), opts)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to list httproutes: %w", err)
+	}
+
+	var lookup dnsLookupFunc
+	if opts.DNS.Resolve {
+		lookup = newDNSLookup(ctx, opts.Timeout)
+	}
+	claims := DNSClaims(services, ingresses, gateways, routes)
+	return CheckDNSClaims(claims, ClusterAddresses(services, ingresses, gateways), opts.DNS.Zones, lookup), nil
+}
+
+// DNSClaims returns the hostnames claimed by Ingress rules and TLS sections, Gateway listeners,
+// HTTPRoutes, and the external-dns hostname annotation on Services and Ingresses, sorted by
+// hostname. Each lists the objects that claim it.
+func DNSClaims(services []corev1.Service, ingresses []networkingv1.Ingress, gateways, routes []unstructured.Unstructured) []DNSClaim {
+	sources := map[string][]string{}
+	claim := func(host, source string) {
+		host = normalizeDNSName(host)
+		if host != "" && !slices.Contains(sources[host], source) {
+			sources[host] = append(sources[host], source)
+		}
+	}
+	annotated := func(annotations map[string]string, source string) {
+		for _, host := range strings.Split(annotations[externalDNSHostnameAnnotation], ",") {
+			claim(host, source)
+		}
+	}
+
+	for _, svc := range services {
+		annotated(svc.Annotations, "Service "+svc.Namespace+"/"+svc.Name)
+	}
+	for _, ing := range ingresses {
+		source := "Ingress " + ing.Namespace + "/" + ing.Name
+		annotated(ing.Annotations, source)
+		for _, rule := range ing.Spec.Rules {
+			claim(rule.Host, source)
+		}
+		for _, t := range ing.Spec.TLS {
+			for _, host := range t.Hosts {
+				claim(host, source)
+			}
+		}
+	}
+	for _, gw := range gateways {
+		listeners, _, _ := unstructured.NestedSlice(gw.Object, "spec", "listeners")
+		for _, l := range listeners {
+			if listener, ok := l.(map[string]any); ok {
+				host, _ := listener["hostname"].(string)
+				claim(host, "Gateway "+gw.GetNamespace()+"/"+gw.GetName())
+			}
+		}
+	}
+	for _, route := range routes {
+		hosts, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+		for _, host := range hosts {
+			claim(host, "HTTPRoute "+route.GetNamespace()+"/"+route.GetName())
+		}
+	}
+
+	claims := make([]DNSClaim, 0, len(sources))
+	for host, from := range sources {
+		claims = append(claims, DNSClaim{Hostname: host, Sources: from})
+	}
+	sort.Slice(claims, func(i, j int) bool { return claims[i].Hostname < claims[j].Hostname })
+	return claims
+}
+
+// ClusterAddresses returns the addresses DNS may point at for a hostname to reach this cluster:
+// the load balancer addresses of Services, Ingresses, and Gateways, and external-dns targets.
+func ClusterAddresses(services []corev1.Service, ingresses []networkingv1.Ingress, gateways []unstructured.Unstructured) []string {
+	seen := map[string]bool{}
+	add := func(addresses ...string) {
+		for _, a := range addresses {
+			if a = normalizeDNSName(a); a != "" {
+				seen[a] = true
+			}
+		}
+	}
+	targets := func(annotations map[string]string) {
+		if target := annotations[externalDNSTargetAnnotation]; target != "" {
+			add(strings.Split(target, ",")...)
+		}
+	}
+
+	for _, svc := range services {
+		add(loadBalancerAddresses(svc.Status.LoadBalancer.Ingress)...)
+		targets(svc.Annotations)
+	}
+	for _, ing := range ingresses {
+		for _, lb := range ing.Status.LoadBalancer.Ingress {
+			add(lb.IP, lb.Hostname)
+		}
+		targets(ing.Annotations)
+	}
+	for _, gw := range gateways {
+		addresses, _, _ := unstructured.NestedSlice(gw.Object, "status", "addresses")
+		for _, a := range addresses {
+			if address, ok := a.(map[string]any); ok {
+				value, _ := address["value"].(string)
+				add(value)
+			}
+		}
+	}
+
+	addresses := make([]string, 0, len(seen))
+	for a := range seen {
+		addresses = append(addresses, a)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// CheckDNSClaims looks every claimed hostname up in the zone exports, following CNAMEs across
+// them, and with lookup when it is not nil, then sets each claim's status. Wildcard hostnames
+// are only looked up in the zones, where they match wildcard records.
+func CheckDNSClaims(claims []DNSClaim, clusterAddresses []string, zones []*DNSZone, lookup dnsLookupFunc) *DNSReport {
+	report := &DNSReport{Resolved: lookup != nil, ClusterAddresses: clusterAddresses, Claims: claims}
+	for _, z := range zones {
+		report.Zones = append(report.Zones, z.Name)
+	}
+	cluster := map[string]bool{}
+	for _, a := range clusterAddresses {
+		cluster[a] = true
+	}
+
+	for i := range report.Claims {
+		c := &report.Claims[i]
+		for _, z := range zones {
+			c.Answers = append(c.Answers, resolveInZones(c.Hostname, z, zones)...)
+		}
+		if lookup != nil && !strings.HasPrefix(c.Hostname, "*.") {
+			answers, err := lookup(c.Hostname)
+			if err != nil {
+				c.Error = err.Error()
+			}
+			c.Answers = append(c.Answers, answers...)
+		}
+
+		var here, elsewhere bool
+		for j := range c.Answers {
+			a := &c.Answers[j]
+			a.Cluster = cluster[a.Value]
+			for _, cname := range a.CNAMEs {
+				a.Cluster = a.Cluster || cluster[cname]
+			}
+			here = here || a.Cluster
+			elsewhere = elsewhere || !a.Cluster
+		}
+		switch {
+		case here && elsewhere:
+			c.Status = DNSConflict
+		case here:
+			c.Status = DNSDelegated
+		case elsewhere:
+			c.Status = DNSElsewhere
+		default:
+			c.Status = DNSMissing
+		}
+	}
+	return report
+}
+
+// resolveInZones answers host from zone, following CNAMEs through every zone export. A CNAME
+// that leads out of the exports is answered with its target.
+func resolveInZones(host string, zone *DNSZone, zones []*DNSZone) []DNSAnswer {
+	records := zone.lookup(host)
+	var cnames []string
+	for hop := 0; len(records) > 0 && records[0].Type == "CNAME"; hop++ {
+		target := records[0].Value
+		cnames = append(cnames, target)
+		if hop == maxCNAMEHops {
+			return nil
+		}
+		records = nil
+		for _, z := range zones {
+			if records = z.lookup(target); len(records) > 0 {
+				break
+			}
+		}
+	}
+	if len(records) == 0 {
+		if len(cnames) == 0 {
+			return nil
+		}
+		last := len(cnames) - 1
+		return []DNSAnswer{{Source: zone.Name, CNAMEs: cnames[:last:last], Value: cnames[last]}}
+	}
+
+	answers := make([]DNSAnswer, 0, len(records))
+	for _, r := range records {
+		if r.Type != "CNAME" {
+			answers = append(answers, DNSAnswer{Source: zone.Name, CNAMEs: cnames, Value: r.Value})
+		}
+	}
+	return answers
+}
+
+// CheckDNSReport raises a finding for every claimed hostname that DNS does not send only to this
+// cluster. A hostname served both here and elsewhere is high severity, since two environments
+// answer for it; one served only elsewhere is medium, and one without records low.
+func CheckDNSReport(report *DNSReport) []Finding {
+	var findings []Finding
+	for _, c := range report.Claims {
+		var other []string
+		for _, a := range c.Answers {
+			if !a.Cluster {
+				other = append(other, a.String())
+			}
+		}
+		claimedBy := strings.Join(c.Sources, ", ")
+
+		var f Finding
+		switch c.Status {
+		case DNSConflict:
+			f = Finding{CheckID: "dns-collision", Severity: SeverityHigh,
+				Message: fmt.Sprintf("%s is claimed by %s but is also served elsewhere: %s", c.Hostname, claimedBy, strings.Join(other, ", "))}
+		case DNSElsewhere:
+			f = Finding{CheckID: "dns-not-delegated", Severity: SeverityMedium,
+				Message: fmt.Sprintf("%s is claimed by %s but points away from this cluster: %s", c.Hostname, claimedBy, strings.Join(other, ", "))}
+		case DNSMissing:
+			if c.Error != "" {
+				continue
+			}
+			f = Finding{CheckID: "dns-missing", Severity: SeverityLow,
+				Message: fmt.Sprintf("%s is claimed by %s but has no DNS records", c.Hostname, claimedBy)}
+		default:
+			continue
+		}
+		f.Kind, f.Name = "Hostname", c.Hostname
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// PrintDNSReport writes the DNS section of the text report.
+func PrintDNSReport(w io.Writer, report *DNSReport) {
+	sources := report.Zones
+	if report.Resolved {
+		sources = append(slices.Clone(sources), dnsResolverSource)
+	}
+	fmt.Fprintf(w, "DNS delegation (checked against %s):\n", strings.Join(sources, ", "))
+	counts := map[string]int{}
+	for _, c := range report.Claims {
+		counts[c.Status]++
+	}
+	fmt.Fprintf(w, "  %d hostname(s): %d delegated, %d conflict, %d elsewhere, %d missing\n",
+		len(report.Claims), counts[DNSDelegated], counts[DNSConflict], counts[DNSElsewhere], counts[DNSMissing])
+
+	shown := 0
+	for _, c := range report.Claims {
+		if c.Status == DNSDelegated {
+			continue
+		}
+		if shown == dnsClaimsShown {
+			fmt.Fprintf(w, "    ... and %d more (see --output json)\n", len(report.Claims)-counts[DNSDelegated]-shown)
+			break
+		}
+		shown++
+		fmt.Fprintf(w, "    - %s [%s] claimed by %s\n", c.Hostname, c.Status, strings.Join(c.Sources, ", "))
+		for _, a := range c.Answers {
+			if !a.Cluster {
+				fmt.Fprintf(w, "        -> %s\n", a)
+			}
+		}
+		if c.Error != "" {
+			fmt.Fprintf(w, "        resolver error: %s\n", c.Error)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package inspect
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const prodZone = `$ORIGIN example.com.
+$TTL 300
+@       IN SOA ns1.example.com. hostmaster.example.com. (
+            2026031501 ; serial
+            3600 600 86400 300 )
+        IN NS  ns1.example.com.
+shop    300 IN A     203.0.113.10
+api         IN CNAME lb-prod.elb.amazonaws.com.
+www     IN CNAME shop
+*.preview   A     198.51.100.20
+split   A 203.0.113.10
+        A 198.51.100.30 ; a second environment behind the same name
+MiXeD.Example.Com. A 203.0.113.10
+`
+
+func TestParseDNSZone(t *testing.T) {
+	zone, err := ParseDNSZone("prod.zone", prodZone)
+	if err != nil {
+		t.Fatalf("ParseDNSZone() returned error = %v", err)
+	}
+	want := []DNSRecord{
+		{Name: "shop.example.com", Type: "A", Value: "203.0.113.10"},
+		{Name: "api.example.com", Type: "CNAME", Value: "lb-prod.elb.amazonaws.com"},
+		{Name: "www.example.com", Type: "CNAME", Value: "shop.example.com"},
+		{Name: "*.preview.example.com", Type: "A", Value: "198.51.100.20"},
+		{Name: "split.example.com", Type: "A", Value: "203.0.113.10"},
+		{Name: "split.example.com", Type: "A", Value: "198.51.100.30"},
+		{Name: "mixed.example.com", Type: "A", Value: "203.0.113.10"},
+	}
+	if !reflect.DeepEqual(zone.Records, want) {
+		t.Errorf("Records = %+v, want %+v", zone.Records, want)
+	}
+
+	for _, bad := range []string{"$ORIGIN", "  A 192.0.2.1", "$INCLUDE other.zone", "host.example.com. IN A"} {
+		if _, err := ParseDNSZone("bad.zone", bad); err == nil {
+			t.Errorf("ParseDNSZone(%q) returned error = nil, want non-nil", bad)
+		}
+	}
+}
+
+func TestDNSClaims(t *testing.T) {
+	services := []corev1.Service{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "lb", Annotations: map[string]string{
+			externalDNSHostnameAnnotation: "api.example.com., Shop.example.com",
+		}},
+		Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{
+			{Hostname: "LB-prod.elb.amazonaws.com"},
+		}}},
+	}}
+	ingresses := []networkingv1.Ingress{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "shop", Annotations: map[string]string{
+			externalDNSTargetAnnotation: "cdn.example.net",
+		}},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{Host: "shop.example.com"}, {}},
+			TLS:   []networkingv1.IngressTLS{{Hosts: []string{"shop.example.com", "www.example.com"}}},
+		},
+		Status: networkingv1.IngressStatus{LoadBalancer: networkingv1.IngressLoadBalancerStatus{Ingress: []networkingv1.IngressLoadBalancerIngress{
+			{IP: "203.0.113.10"},
+		}}},
+	}}
+	gateway := unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"namespace": "edge", "name": "public"},
+		"spec":     map[string]any{"listeners": []any{map[string]any{"name": "https", "hostname": "*.preview.example.com"}}},
+		"status":   map[string]any{"addresses": []any{map[string]any{"type": "IPAddress", "value": "203.0.113.50"}}},
+	}}
+	route := unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"namespace": "web", "name": "split"},
+		"spec":     map[string]any{"hostnames": []any{"split.example.com"}},
+	}}
+
+	claims := DNSClaims(services, ingresses, []unstructured.Unstructured{gateway}, []unstructured.Unstructured{route})
+	want := []DNSClaim{
+		{Hostname: "*.preview.example.com", Sources: []string{"Gateway edge/public"}},
+		{Hostname: "api.example.com", Sources: []string{"Service web/lb"}},
+		{Hostname: "shop.example.com", Sources: []string{"Service web/lb", "Ingress web/shop"}},
+		{Hostname: "split.example.com", Sources: []string{"HTTPRoute web/split"}},
+		{Hostname: "www.example.com", Sources: []string{"Ingress web/shop"}},
+	}
+	if !reflect.DeepEqual(claims, want) {
+		t.Errorf("DNSClaims() = %+v, want %+v", claims, want)
+	}
+
+	addresses := ClusterAddresses(services, ingresses, []unstructured.Unstructured{gateway})
+	if want := []string{"203.0.113.10", "203.0.113.50", "cdn.example.net", "lb-prod.elb.amazonaws.com"}; !reflect.DeepEqual(addresses, want) {
+		t.Errorf("ClusterAddresses() = %v, want %v", addresses, want)
+	}
+}
+
+func TestCheckDNSClaims(t *testing.T) {
+	prod, err := ParseDNSZone("prod.zone", prodZone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The staging export claims shop too, pointing at the staging load balancer.
+	staging, err := ParseDNSZone("staging.zone", "$ORIGIN example.com.\nshop A 192.0.2.77\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := []DNSClaim{
+		{Hostname: "api.example.com", Sources: []string{"Service web/lb"}},
+		{Hostname: "shop.example.com", Sources: []string{"Ingress web/shop"}},
+		{Hostname: "www.example.com", Sources: []string{"Ingress web/shop"}},
+		{Hostname: "pr-12.preview.example.com", Sources: []string{"HTTPRoute web/pr-12"}},
+		{Hostname: "split.example.com", Sources: []string{"HTTPRoute web/split"}},
+		{Hostname: "gone.example.com", Sources: []string{"Ingress web/old"}},
+		{Hostname: "*.preview.example.com", Sources: []string{"Gateway edge/public"}},
+	}
+	lookups := 0
+	lookup := func(host string) ([]DNSAnswer, error) {
+		lookups++
+		if host == "api.example.com" {
+			return []DNSAnswer{{Source: dnsResolverSource, CNAMEs: []string{"lb-prod.elb.amazonaws.com"}, Value: "192.0.2.200"}}, nil
+		}
+		return nil, nil
+	}
+	report := CheckDNSClaims(claims, []string{"203.0.113.10", "lb-prod.elb.amazonaws.com"}, []*DNSZone{prod, staging}, lookup)
+
+	statuses := map[string]string{}
+	for _, c := range report.Claims {
+		statuses[c.Hostname] = c.Status
+	}
+	want := map[string]string{
+		"api.example.com":           DNSDelegated,
+		"shop.example.com":          DNSConflict,
+		"www.example.com":           DNSDelegated,
+		"pr-12.preview.example.com": DNSElsewhere,
+		"split.example.com":         DNSConflict,
+		"gone.example.com":          DNSMissing,
+		"*.preview.example.com":     DNSElsewhere,
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+	if lookups != 6 {
+		t.Errorf("resolver was asked %d times, want 6 (not for the wildcard)", lookups)
+	}
+	if www := report.Claims[2].Answers; len(www) != 1 || !reflect.DeepEqual(www[0].CNAMEs, []string{"shop.example.com"}) || www[0].Value != "203.0.113.10" {
+		t.Errorf("www answers = %+v, want the CNAME followed to shop's address", www)
+	}
+	if !reflect.DeepEqual(report.Zones, []string{"prod.zone", "staging.zone"}) || !report.Resolved {
+		t.Errorf("Zones = %v, Resolved = %v", report.Zones, report.Resolved)
+	}
+
+	findings := CheckDNSReport(report)
+	got := map[string]string{}
+	for _, f := range findings {
+		got[f.Name] = f.CheckID
+	}
+	wantFindings := map[string]string{
+		"shop.example.com":          "dns-collision",
+		"split.example.com":         "dns-collision",
+		"pr-12.preview.example.com": "dns-not-delegated",
+		"*.preview.example.com":     "dns-not-delegated",
+		"gone.example.com":          "dns-missing",
+	}
+	if !reflect.DeepEqual(got, wantFindings) {
+		t.Errorf("findings = %v, want %v", got, wantFindings)
+	}
+	for _, f := range findings {
+		if f.Name == "shop.example.com" && !strings.Contains(f.Message, "192.0.2.77 (staging.zone)") {
+			t.Errorf("collision message %q does not name the other environment", f.Message)
+		}
+	}
+
+	var b bytes.Buffer
+	PrintDNSReport(&b, report)
+	for _, want := range []string{
+		"DNS delegation (checked against prod.zone, staging.zone, resolver):",
+		"7 hostname(s): 2 delegated, 2 conflict, 2 elsewhere, 1 missing",
+		"- shop.example.com [conflict] claimed by Ingress web/shop",
+		"-> 192.0.2.77 (staging.zone)",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestCheckDNSClaimsResolverError(t *testing.T) {
+	claims := []DNSClaim{{Hostname: "shop.example.com", Sources: []string{"Ingress web/shop"}}}
+	report := CheckDNSClaims(claims, nil, nil, func(string) ([]DNSAnswer, error) {
+		return nil, errors.New("i/o timeout")
+	})
+	if c := report.Claims[0]; c.Status != DNSMissing || c.Error != "i/o timeout" {
+		t.Errorf("claim = %+v, want missing with the resolver error", c)
+	}
+	// A failed lookup is not evidence that the records are missing.
+	if findings := CheckDNSReport(report); len(findings) != 0 {
+		t.Errorf("CheckDNSReport() = %+v, want no findings", findings)
+	}
+}
+
+func TestDNSCollector(t *testing.T) {
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "shop"},
+		Spec:       networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{Host: "shop.example.com"}}},
+	}
+	clientset, dynamicClient := newFakeClients(ing)
+	zone, err := ParseDNSZone("prod.zone", prodZone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := AutoTuneScanOptions(ClusterSize{}).WithOverrides(ScanOptions{Collectors: []string{"dns"}, DNS: DNSOptions{Zones: []*DNSZone{zone}}})
+	s := &scanState{ctx: t.Context(), clientset: clientset, dynamic: dynamicClient, opts: opts}
+	report, err := runScan(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg, ok := report.Errors[sectionDNS]; ok {
+		t.Fatalf("dns collector failed: %s", msg)
+	}
+	// The Ingress has no load balancer address yet, so the zone's record points elsewhere.
+	if report.DNS == nil || len(report.DNS.Claims) != 1 || report.DNS.Claims[0].Status != DNSElsewhere {
+		t.Errorf("DNS = %+v, want shop.example.com pointing elsewhere", report.DNS)
+	}
+	if ids := checkIDs(report.Findings); !reflect.DeepEqual(ids, []string{"dns-not-delegated"}) {
+		t.Errorf("findings = %v, want [dns-not-delegated]", ids)
+	}
+}
@@ -0,0 +1,8 @@
+// Package inspect scans a Kubernetes cluster and reports its versions, exposure, workload
+// health, and findings. It is the library behind the kube-op command.
+//
+// RunScan runs the registered collectors (see Collectors) against any kubernetes.Interface
+// and returns a typed Report; each collector's Get function, such as GetWorkloadHealth, can
+// also be called on its own. Most Check functions, which turn collected objects into findings,
+// take plain API objects, so they can run against objects from any source.
+package inspect
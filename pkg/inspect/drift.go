@@ -0,0 +1,459 @@
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+)
+
+// Drift outcomes for a single object.
+const (
+	DriftInSync   = "in-sync"
+	DriftModified = "modified"
+	DriftMissing  = "missing"
+	DriftError    = "error"
+)
+
+// manualEditManagers are the field managers kubectl records for imperative edits.
+var manualEditManagers = map[string]bool{
+	"kubectl-edit":     true,
+	"kubectl-patch":    true,
+	"kubectl-scale":    true,
+	"kubectl-label":    true,
+	"kubectl-annotate": true,
+	"kubectl-set":      true,
+	"kubectl-rollout":  true,
+}
+
+// DriftReport compares the objects of a manifest set or Helm release with the live cluster.
+type DriftReport struct {
+	// Source is the manifest paths or Helm release the objects came from.
+	Source     string           `json:"source"`
+	Namespaces []NamespaceDrift `json:"namespaces"`
+}
+
+// NamespaceDrift is the drift of the objects in one namespace; cluster-scoped objects are
+// grouped under an empty namespace.
+type NamespaceDrift struct {
+	Namespace string        `json:"namespace"`
+	Objects   []ObjectDrift `json:"objects"`
+}
+
+// ObjectDrift is how one live object differs from its source.
+type ObjectDrift struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	// Differences lists each field whose live value differs from the source.
+	Differences []string `json:"differences,omitempty"`
+	// ManualEdits lists the imperative kubectl edits recorded in the object's managed fields.
+	ManualEdits []string `json:"manualEdits,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// Drifted reports whether any object was modified or is missing.
+func (r *DriftReport) Drifted() bool {
+	for _, ns := range r.Namespaces {
+		for _, o := range ns.Objects {
+			if o.Status == DriftModified || o.Status == DriftMissing {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LoadManifests reads objects from YAML or JSON files. Directories are read non-recursively,
+// taking every .yaml, .yml, and .json file.
+func LoadManifests(paths []string) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	for _, p := range paths {
+		files := []string{p}
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			entries, err := os.ReadDir(p)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read manifest directory: %w", err)
+			}
+			files = nil
+			for _, e := range entries {
+				switch filepath.Ext(e.Name()) {
+				case ".yaml", ".yml", ".json":
+					files = append(files, filepath.Join(p, e.Name()))
+				}
+			}
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read manifest: %w", err)
+			}
+			parsed, err := ParseManifests(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+			}
+			objects = append(objects, parsed...)
+		}
+	}
+	return objects, nil
+}
+
+// ParseManifests splits a multi-document YAML or JSON stream into objects, expanding Lists.
+func ParseManifests(data []byte) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		var doc map[string]any
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objects, nil
+			}
+			return nil, err
+		}
+		if len(doc) == 0 {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: doc}
+		if obj.IsList() {
+			list, err := obj.ToList()
+			if err != nil {
+				return nil, err
+			}
+			for i := range list.Items {
+				objects = append(objects, &list.Items[i])
+			}
+			continue
+		}
+		if obj.GetKind() == "" || obj.GetName() == "" {
+			return nil, fmt.Errorf("document without kind or metadata.name")
+		}
+		objects = append(objects, obj)
+	}
+}
+
+// GetHelmReleaseManifest returns the rendered manifest of the deployed revision of a Helm release,
+// read from the release Secret Helm 3 stores in the release namespace.
+func GetHelmReleaseManifest(ctx context.Context, clientset kubernetes.Interface, namespace, release string) (string, error) {
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("owner=helm,name=%s,status=deployed", release),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list helm release secrets: %w", err)
+	}
+	if len(secrets.Items) == 0 {
+		return "", fmt.Errorf("no deployed revision of helm release %s found in namespace %s", release, namespace)
+	}
+	latest := secrets.Items[0]
+	for _, s := range secrets.Items[1:] {
+		if helmRevision(s.Labels["version"]) > helmRevision(latest.Labels["version"]) {
+			latest = s
+		}
+	}
+	return DecodeHelmRelease(latest.Data["release"])
+}
+
+func helmRevision(version string) int {
+	v, _ := strconv.Atoi(version)
+	return v
+}
+
+// DecodeHelmRelease extracts the manifest from a Helm 3 release record.
+func DecodeHelmRelease(data []byte) (string, error) {
+	record, err := decodeHelmRecord(data)
+	if err != nil {
+		return "", err
+	}
+	return record.Manifest, nil
+}
+
+// CheckDrift fetches the live counterpart of every object and diffs it against the source.
+// Objects without a namespace are looked up in defaultNamespace when they are namespaced.
+// When namespace is set, only objects in that namespace are checked.
+func CheckDrift(ctx context.Context, config *rest.Config, clientset kubernetes.Interface, objects []*unstructured.Unstructured, defaultNamespace, namespace string) (*DriftReport, error) {
+	groups, err := restmapper.GetAPIGroupResources(clientset.Discovery())
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover API resources: %w", err)
+	}
+	mapper := restmapper.NewDiscoveryRESTMapper(groups)
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	byNamespace := map[string][]ObjectDrift{}
+	for _, obj := range objects {
+		drift := ObjectDrift{APIVersion: obj.GetAPIVersion(), Kind: obj.GetKind(), Name: obj.GetName(), Namespace: obj.GetNamespace()}
+
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			drift.Status, drift.Error = DriftError, err.Error()
+			byNamespace[drift.Namespace] = append(byNamespace[drift.Namespace], drift)
+			continue
+		}
+		resource := client.Resource(mapping.Resource)
+		var live *unstructured.Unstructured
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			if drift.Namespace == "" {
+				drift.Namespace = defaultNamespace
+			}
+			if namespace != "" && drift.Namespace != namespace {
+				continue
+			}
+			live, err = resource.Namespace(drift.Namespace).Get(ctx, drift.Name, metav1.GetOptions{})
+		} else {
+			drift.Namespace = ""
+			if namespace != "" {
+				continue
+			}
+			live, err = resource.Get(ctx, drift.Name, metav1.GetOptions{})
+		}
+
+		switch {
+		case apierrors.IsNotFound(err):
+			drift.Status = DriftMissing
+		case err != nil:
+			drift.Status, drift.Error = DriftError, err.Error()
+		default:
+			drift.Differences = DiffObject(obj.Object, live.Object)
+			drift.ManualEdits = ManualEdits(live.GetManagedFields())
+			drift.Status = DriftInSync
+			if len(drift.Differences) > 0 {
+				drift.Status = DriftModified
+			}
+		}
+		byNamespace[drift.Namespace] = append(byNamespace[drift.Namespace], drift)
+	}
+
+	report := &DriftReport{}
+	for ns, objs := range byNamespace {
+		sort.Slice(objs, func(i, j int) bool {
+			if objs[i].Kind != objs[j].Kind {
+				return objs[i].Kind < objs[j].Kind
+			}
+			return objs[i].Name < objs[j].Name
+		})
+		report.Namespaces = append(report.Namespaces, NamespaceDrift{Namespace: ns, Objects: objs})
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool { return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace })
+	return report, nil
+}
+
+// DiffObject lists the fields set in desired whose live value differs. Fields only present in
+// live, such as defaults filled in by the API server, are ignored, as are status and every
+// metadata field other than labels and annotations.
+func DiffObject(desired, live map[string]any) []string {
+	desired = normalizeJSON(desired)
+	live = normalizeJSON(live)
+
+	var diffs []string
+	for key, value := range desired {
+		switch key {
+		case "apiVersion", "kind", "status":
+			continue
+		case "metadata":
+			dm, _ := value.(map[string]any)
+			lm, _ := live["metadata"].(map[string]any)
+			for _, field := range []string{"labels", "annotations"} {
+				if v, ok := dm[field]; ok {
+					diffs = append(diffs, diffValue("metadata."+field, v, lm[field])...)
+				}
+			}
+		default:
+			diffs = append(diffs, diffValue(key, value, live[key])...)
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+func diffValue(path string, desired, live any) []string {
+	switch d := desired.(type) {
+	case map[string]any:
+		l, ok := live.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: manifest sets an object, live is %s", path, describeValue(live))}
+		}
+		var diffs []string
+		for key, value := range d {
+			diffs = append(diffs, diffValue(path+"."+key, value, l[key])...)
+		}
+		return diffs
+	case []any:
+		l, ok := live.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: manifest sets a list, live is %s", path, describeValue(live))}
+		}
+		if named, ok := indexByName(d); ok {
+			liveNamed, _ := indexByName(l)
+			var diffs []string
+			for name, value := range named {
+				lv, ok := liveNamed[name]
+				if !ok {
+					diffs = append(diffs, fmt.Sprintf("%s[%s]: missing from live object", path, name))
+					continue
+				}
+				diffs = append(diffs, diffValue(fmt.Sprintf("%s[%s]", path, name), value, lv)...)
+			}
+			return diffs
+		}
+		if len(d) != len(l) {
+			return []string{fmt.Sprintf("%s: manifest has %d item(s), live has %d", path, len(d), len(l))}
+		}
+		var diffs []string
+		for i := range d {
+			diffs = append(diffs, diffValue(fmt.Sprintf("%s[%d]", path, i), d[i], l[i])...)
+		}
+		return diffs
+	default:
+		if scalarEqual(desired, live) {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: manifest %s, live %s", path, describeValue(desired), describeValue(live))}
+	}
+}
+
+// indexByName indexes a list of objects by their name field, as used for containers, ports,
+// env vars, and volumes. It reports false if any item has no name.
+func indexByName(items []any) (map[string]any, bool) {
+	if len(items) == 0 {
+		return nil, false
+	}
+	byName := make(map[string]any, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		name, ok := m["name"].(string)
+		if !ok {
+			return nil, false
+		}
+		byName[name] = item
+	}
+	return byName, true
+}
+
+// scalarEqual compares scalars loosely, treating 1 and "1", or 0.5 and "500m", as equal since
+// the API server normalizes numbers and resource quantities.
+func scalarEqual(a, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+	as, bs := fmt.Sprint(a), fmt.Sprint(b)
+	if as == bs {
+		return true
+	}
+	qa, errA := resource.ParseQuantity(as)
+	qb, errB := resource.ParseQuantity(bs)
+	return errA == nil && errB == nil && qa.Cmp(qb) == 0
+}
+
+func describeValue(v any) string {
+	if v == nil {
+		return "unset"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if s := string(data); len(s) <= 80 {
+		return s
+	}
+	return string(data[:77]) + "..."
+}
+
+// normalizeJSON round-trips obj through JSON so that numbers compare the same on both sides.
+func normalizeJSON(obj map[string]any) map[string]any {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return obj
+	}
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return obj
+	}
+	return out
+}
+
+// ManualEdits describes the managed fields entries written by imperative kubectl commands.
+func ManualEdits(entries []metav1.ManagedFieldsEntry) []string {
+	var edits []string
+	for _, e := range entries {
+		if e.Operation != metav1.ManagedFieldsOperationUpdate || !manualEditManagers[e.Manager] {
+			continue
+		}
+		edit := e.Manager
+		if e.Subresource != "" {
+			edit += " (" + e.Subresource + ")"
+		}
+		if e.Time != nil {
+			edit += " at " + e.Time.UTC().Format(time.RFC3339)
+		}
+		edits = append(edits, edit)
+	}
+	return edits
+}
+
+// PrintDriftReport writes the drift report as text, listing only objects that are not in sync.
+func PrintDriftReport(w io.Writer, r *DriftReport) {
+	fmt.Fprintf(w, "Drift against %s:\n", r.Source)
+	var inSync int
+	for _, ns := range r.Namespaces {
+		var lines []string
+		for _, o := range ns.Objects {
+			if o.Status == DriftInSync && len(o.ManualEdits) == 0 {
+				inSync++
+				continue
+			}
+			line := fmt.Sprintf("    - %s/%s: %s", o.Kind, o.Name, o.Status)
+			if o.Error != "" {
+				line += " (" + o.Error + ")"
+			}
+			for _, d := range o.Differences {
+				line += "\n        " + d
+			}
+			if len(o.ManualEdits) > 0 {
+				line += "\n        edited by " + strings.Join(o.ManualEdits, ", ")
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		name := ns.Namespace
+		if name == "" {
+			name = "(cluster-scoped)"
+		}
+		fmt.Fprintf(w, "  %s:\n", name)
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+	}
+	fmt.Fprintf(w, "%d object(s) in sync.\n", inSync)
+}
@@ -0,0 +1,121 @@
+package inspect
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const driftManifest = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  labels: {app: api}
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: app
+        image: registry.example.com/api:1.4.0
+        resources:
+          requests: {cpu: 0.5, memory: 256Mi}
+---
+# empty documents are skipped
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata: {name: settings}
+  data: {LOG_LEVEL: info}
+`
+
+func TestParseManifests(t *testing.T) {
+	objects, err := ParseManifests([]byte(driftManifest))
+	if err != nil {
+		t.Fatalf("ParseManifests() error = %v", err)
+	}
+	if len(objects) != 2 || objects[0].GetKind() != "Deployment" || objects[1].GetName() != "settings" {
+		t.Fatalf("ParseManifests() = %d objects, want the Deployment and the ConfigMap from the List", len(objects))
+	}
+
+	if _, err := ParseManifests([]byte("apiVersion: v1\nkind: ConfigMap\n")); err == nil {
+		t.Error("ParseManifests() without metadata.name error = nil, want error")
+	}
+}
+
+func TestDiffObject(t *testing.T) {
+	objects, err := ParseManifests([]byte(driftManifest))
+	if err != nil {
+		t.Fatalf("ParseManifests() error = %v", err)
+	}
+	desired := objects[0].Object
+
+	live := map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]any{
+			"name": "api", "uid": "1234", "resourceVersion": "99",
+			"labels": map[string]any{"app": "api", "team": "web"},
+		},
+		"spec": map[string]any{
+			"replicas":                int64(5),
+			"progressDeadlineSeconds": int64(600),
+			"template": map[string]any{"spec": map[string]any{"containers": []any{
+				map[string]any{"name": "sidecar", "image": "envoy"},
+				map[string]any{
+					"name": "app", "image": "registry.example.com/api:1.4.0-hotfix", "imagePullPolicy": "IfNotPresent",
+					"resources": map[string]any{"requests": map[string]any{"cpu": "500m", "memory": "256Mi"}},
+				},
+			}}},
+		},
+		"status": map[string]any{"replicas": int64(5)},
+	}
+
+	got := DiffObject(desired, live)
+	want := []string{
+		`spec.replicas: manifest 3, live 5`,
+		`spec.template.spec.containers[app].image: manifest "registry.example.com/api:1.4.0", live "registry.example.com/api:1.4.0-hotfix"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("DiffObject() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDecodeHelmRelease(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(`{"name":"shop","version":3,"manifest":"---\napiVersion: v1\nkind: Service\n"}`))
+	gz.Close()
+
+	manifest, err := DecodeHelmRelease([]byte(base64.StdEncoding.EncodeToString(compressed.Bytes())))
+	if err != nil {
+		t.Fatalf("DecodeHelmRelease() error = %v", err)
+	}
+	if !strings.Contains(manifest, "kind: Service") {
+		t.Errorf("DecodeHelmRelease() = %q, want the release manifest", manifest)
+	}
+}
+
+func TestManualEdits(t *testing.T) {
+	edited := metav1.NewTime(time.Date(2025, 6, 1, 9, 30, 0, 0, time.UTC))
+	entries := []metav1.ManagedFieldsEntry{
+		{Manager: "helm", Operation: metav1.ManagedFieldsOperationUpdate},
+		{Manager: "kube-controller-manager", Operation: metav1.ManagedFieldsOperationUpdate, Subresource: "status"},
+		{Manager: "kubectl-edit", Operation: metav1.ManagedFieldsOperationUpdate, Time: &edited},
+		{Manager: "kubectl-scale", Operation: metav1.ManagedFieldsOperationUpdate, Subresource: "scale"},
+	}
+	got := ManualEdits(entries)
+	want := []string{"kubectl-edit at 2025-06-01T09:30:00Z", "kubectl-scale (scale)"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ManualEdits() = %v, want %v", got, want)
+	}
+}
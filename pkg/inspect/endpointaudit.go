@@ -0,0 +1,233 @@
+package inspect
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ObjectChange is one write to an API object: who made it and when.
+type ObjectChange struct {
+	// User is the authenticated user, only known when an audit log recorded the change.
+	User string `json:"user,omitempty"`
+	// Manager is the field manager that made the change, such as kubectl-client-side-apply or
+	// helm, or the client named by the user agent in an audit log.
+	Manager string `json:"manager,omitempty"`
+	// Operation is the managed fields operation (Apply or Update) or the audit verb.
+	Operation string    `json:"operation,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+func (c ObjectChange) String() string {
+	var who string
+	switch {
+	case c.User != "" && c.Manager != "":
+		who = c.User + " via " + c.Manager
+	case c.User != "":
+		who = c.User
+	case c.Manager != "":
+		who = c.Manager
+	default:
+		who = "unknown"
+	}
+	return fmt.Sprintf("%s at %s", who, c.Time.UTC().Format(time.RFC3339))
+}
+
+// objectChanges derives who created and who last modified an object from its managed fields.
+// Writes to the status subresource are ignored: they come from controllers, such as the one
+// assigning a load balancer address, not from the change that exposed the object. The creator
+// is the manager whose entry is still stamped with the creation time, if any.
+func objectChanges(meta metav1.ObjectMeta) (created, lastModified *ObjectChange) {
+	if !meta.CreationTimestamp.IsZero() {
+		created = &ObjectChange{Operation: "Create", Time: meta.CreationTimestamp.Time}
+	}
+	for _, e := range meta.ManagedFields {
+		if e.Subresource != "" || e.Time == nil {
+			continue
+		}
+		if created != nil && created.Manager == "" && e.Time.Equal(&meta.CreationTimestamp) {
+			created.Manager = e.Manager
+		}
+		if lastModified == nil || e.Time.After(lastModified.Time) {
+			lastModified = &ObjectChange{Manager: e.Manager, Operation: string(e.Operation), Time: e.Time.Time}
+		}
+	}
+	return created, lastModified
+}
+
+// AuditLog holds the writes to Services and Ingresses recorded in an API server audit log,
+// which name the user behind a change where managed fields only name the client.
+type AuditLog struct {
+	created      map[string]ObjectChange
+	lastModified map[string]ObjectChange
+}
+
+// auditEvent is the part of an audit.k8s.io/v1 Event the audit trail needs.
+type auditEvent struct {
+	Stage string `json:"stage"`
+	Verb  string `json:"verb"`
+	User  struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	UserAgent string `json:"userAgent"`
+	ObjectRef *struct {
+		Resource    string `json:"resource"`
+		Namespace   string `json:"namespace"`
+		Name        string `json:"name"`
+		APIGroup    string `json:"apiGroup"`
+		Subresource string `json:"subresource"`
+	} `json:"objectRef"`
+	ResponseStatus *struct {
+		Code int `json:"code"`
+	} `json:"responseStatus"`
+	StageTimestamp metav1.MicroTime `json:"stageTimestamp"`
+}
+
+// auditedResources maps the audited resources to the kind of endpoint they expose.
+var auditedResources = map[string]string{
+	"/services":                   "Service",
+	"networking.k8s.io/ingresses": "Ingress",
+}
+
+// auditWriteVerbs are the verbs that change an object.
+var auditWriteVerbs = map[string]bool{"create": true, "update": true, "patch": true}
+
+// LoadAuditLog reads an API server audit log in the JSON lines format written by the log
+// backend. Only successful, completed writes to Services and Ingresses are kept; other events
+// and lines that are not audit events are skipped.
+func LoadAuditLog(file string) (*AuditLog, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer f.Close()
+
+	audit := &AuditLog{created: map[string]ObjectChange{}, lastModified: map[string]ObjectChange{}}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e auditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		audit.add(e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log %s: %w", file, err)
+	}
+	return audit, nil
+}
+
+func (l *AuditLog) add(e auditEvent) {
+	ref := e.ObjectRef
+	if e.Stage != "ResponseComplete" || !auditWriteVerbs[e.Verb] || ref == nil || ref.Subresource != "" {
+		return
+	}
+	if e.ResponseStatus == nil || e.ResponseStatus.Code >= 300 {
+		return
+	}
+	kind, ok := auditedResources[ref.APIGroup+"/"+ref.Resource]
+	if !ok || ref.Name == "" {
+		return
+	}
+
+	// Only the product name of the user agent, such as kubectl/v1.31.0, identifies the client.
+	agent, _, _ := strings.Cut(e.UserAgent, " ")
+	change := ObjectChange{User: e.User.Username, Manager: agent, Operation: e.Verb, Time: e.StageTimestamp.Time}
+	key := objectKey(kind, ref.Namespace, ref.Name)
+	if e.Verb == "create" {
+		l.created[key] = change
+	}
+	if last, ok := l.lastModified[key]; !ok || change.Time.After(last.Time) {
+		l.lastModified[key] = change
+	}
+}
+
+// annotate replaces the endpoints' managed fields changes with the audit log's, which also
+// name the user. Audit entries older than the object's creation or last managed fields change
+// are left out, since they belong to an earlier object of the same name or the log does not
+// cover the latest change. A nil log changes nothing.
+func (l *AuditLog) annotate(endpoints []ExposedEndpoint) {
+	if l == nil {
+		return
+	}
+	for i := range endpoints {
+		e := &endpoints[i]
+		key := objectKey(e.Kind(), e.Namespace, e.Name)
+		if change, ok := l.created[key]; ok && (e.Created == nil || !change.Time.Before(e.Created.Time)) {
+			e.Created = &change
+		}
+		if change, ok := l.lastModified[key]; ok && (e.LastModified == nil || !change.Time.Before(e.LastModified.Time)) {
+			e.LastModified = &change
+		}
+	}
+}
+
+func objectKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// endpointKey identifies an exposed endpoint across scans.
+func endpointKey(e ExposedEndpoint) string {
+	return strings.Join([]string{e.Type, e.Namespace, e.Name, e.Host, e.Path}, "/")
+}
+
+// NewEndpoints returns the exposed endpoints in current that previous did not have. It returns
+// nil unless both reports collected endpoints, so a skipped or failed collection doesn't make
+// every endpoint look new in the next scan.
+func NewEndpoints(previous, current *Report) []ExposedEndpoint {
+	collected := func(r *Report) bool {
+		_, failed := r.Errors[sectionEndpoints]
+		return !failed && !r.Skipped("endpoints")
+	}
+	if previous == nil || current == nil || !collected(previous) || !collected(current) {
+		return nil
+	}
+	seen := make(map[string]bool, len(previous.ExposedEndpoints))
+	for _, e := range previous.ExposedEndpoints {
+		seen[endpointKey(e)] = true
+	}
+
+	var added []ExposedEndpoint
+	for _, e := range current.ExposedEndpoints {
+		if !seen[endpointKey(e)] {
+			added = append(added, e)
+		}
+	}
+	return added
+}
+
+// EndpointChangeFindings raises a finding for each newly appeared endpoint, naming the change
+// behind it so that notifications point at who exposed it rather than just the object. Public
+// endpoints are high severity, the rest low.
+func EndpointChangeFindings(added []ExposedEndpoint) []Finding {
+	var findings []Finding
+	for _, e := range added {
+		severity := SeverityLow
+		if e.Exposure == AddressPublic {
+			severity = SeverityHigh
+		}
+		message := "new exposed endpoint: " + e.String()
+		if e.LastModified != nil {
+			message += "; last modified by " + e.LastModified.String()
+		}
+		if e.Created != nil && (e.LastModified == nil || e.Created.Time.Before(e.LastModified.Time)) {
+			message += "; created by " + e.Created.String()
+		}
+		findings = append(findings, Finding{
+			CheckID:   "endpoint-appeared",
+			Severity:  severity,
+			Kind:      e.Kind(),
+			Namespace: e.Namespace,
+			Name:      e.Name,
+			Message:   message,
+			keyFields: []string{e.Type, e.Host, e.Path},
+		})
+	}
+	return FinalizeFindings(findings)
+}
@@ -0,0 +1,147 @@
+package inspect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestObjectChanges(t *testing.T) {
+	created := metav1.NewTime(time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC))
+	edited := metav1.NewTime(created.Add(48 * time.Hour))
+	status := metav1.NewTime(created.Add(72 * time.Hour))
+	meta := metav1.ObjectMeta{
+		CreationTimestamp: created,
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			{Manager: "helm", Operation: metav1.ManagedFieldsOperationUpdate, Time: &created},
+			{Manager: "kubectl-edit", Operation: metav1.ManagedFieldsOperationUpdate, Time: &edited},
+			{Manager: "cloud-controller-manager", Operation: metav1.ManagedFieldsOperationUpdate, Subresource: "status", Time: &status},
+		},
+	}
+
+	first, last := objectChanges(meta)
+	if first == nil || first.Manager != "helm" || !first.Time.Equal(created.Time) {
+		t.Errorf("created = %+v, want helm at the creation time", first)
+	}
+	if last == nil || last.Manager != "kubectl-edit" || last.Operation != "Update" || !last.Time.Equal(edited.Time) {
+		t.Errorf("lastModified = %+v, want the kubectl-edit update, not the status write", last)
+	}
+
+	if first, last := objectChanges(metav1.ObjectMeta{}); first != nil || last != nil {
+		t.Errorf("objectChanges() without metadata = %+v, %+v, want nil", first, last)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	lines := []string{
+		`{"stage":"ResponseComplete","verb":"create","user":{"username":"alice"},"userAgent":"kubectl/v1.31.0 (linux/amd64) kubernetes/abc","objectRef":{"resource":"services","namespace":"web","name":"lb","apiVersion":"v1"},"responseStatus":{"code":201},"stageTimestamp":"2026-03-01T09:00:00.120000Z"}`,
+		`{"stage":"ResponseComplete","verb":"patch","user":{"username":"bob"},"userAgent":"kubectl/v1.31.0","objectRef":{"resource":"services","namespace":"web","name":"lb"},"responseStatus":{"code":200},"stageTimestamp":"2026-03-03T09:00:00.500000Z"}`,
+		// Ignored: a failed write, a status write, a read, and the request stage.
+		`{"stage":"ResponseComplete","verb":"patch","user":{"username":"mallory"},"objectRef":{"resource":"services","namespace":"web","name":"lb"},"responseStatus":{"code":403},"stageTimestamp":"2026-03-04T09:00:00Z"}`,
+		`{"stage":"ResponseComplete","verb":"update","user":{"username":"system:serviceaccount:kube-system:service-controller"},"objectRef":{"resource":"services","namespace":"web","name":"lb","subresource":"status"},"responseStatus":{"code":200},"stageTimestamp":"2026-03-04T09:00:00Z"}`,
+		`{"stage":"ResponseComplete","verb":"get","user":{"username":"carol"},"objectRef":{"resource":"services","namespace":"web","name":"lb"},"responseStatus":{"code":200},"stageTimestamp":"2026-03-04T09:00:00Z"}`,
+		`{"stage":"RequestReceived","verb":"patch","user":{"username":"dave"},"objectRef":{"resource":"services","namespace":"web","name":"lb"},"stageTimestamp":"2026-03-04T09:00:00Z"}`,
+		`not an audit event`,
+		`{"stage":"ResponseComplete","verb":"create","user":{"username":"erin"},"objectRef":{"resource":"ingresses","apiGroup":"networking.k8s.io","namespace":"web","name":"site"},"responseStatus":{"code":201},"stageTimestamp":"2025-01-01T00:00:00Z"}`,
+	}
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	audit, err := LoadAuditLog(path)
+	if err != nil {
+		t.Fatalf("LoadAuditLog() returned error = %v", err)
+	}
+
+	created := metav1.NewTime(time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC))
+	edited := metav1.NewTime(time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC))
+	endpoints := []ExposedEndpoint{
+		{
+			Type: ExposureLoadBalancer, Namespace: "web", Name: "lb",
+			Created:      &ObjectChange{Manager: "kubectl-client-side-apply", Time: created.Time},
+			LastModified: &ObjectChange{Manager: "kubectl-patch", Time: edited.Time},
+		},
+		{
+			// The logged create belongs to an earlier Ingress of the same name.
+			Type: ExposureIngress, Namespace: "web", Name: "site",
+			Created: &ObjectChange{Manager: "helm", Time: created.Time},
+		},
+	}
+	audit.annotate(endpoints)
+
+	if c := endpoints[0].Created; c == nil || c.User != "alice" || c.Manager != "kubectl/v1.31.0" {
+		t.Errorf("Created = %+v, want alice via kubectl/v1.31.0", c)
+	}
+	if c := endpoints[0].LastModified; c == nil || c.User != "bob" || c.Operation != "patch" {
+		t.Errorf("LastModified = %+v, want bob's patch", c)
+	}
+	if c := endpoints[1].Created; c == nil || c.User != "" || c.Manager != "helm" {
+		t.Errorf("Created = %+v, want the managed fields creator kept", c)
+	}
+
+	var none *AuditLog
+	none.annotate(endpoints)
+}
+
+func TestNewEndpoints(t *testing.T) {
+	lb := ExposedEndpoint{Type: ExposureLoadBalancer, Namespace: "web", Name: "lb", Addresses: []string{"203.0.113.10"}}
+	root := ExposedEndpoint{Type: ExposureIngress, Namespace: "web", Name: "site", Host: "shop.example.com", Path: "/"}
+	admin := ExposedEndpoint{Type: ExposureIngress, Namespace: "web", Name: "site", Host: "shop.example.com", Path: "/admin"}
+
+	previous := &Report{ExposedEndpoints: []ExposedEndpoint{lb, root}}
+	current := &Report{ExposedEndpoints: []ExposedEndpoint{root, admin}}
+	if got := NewEndpoints(previous, current); len(got) != 1 || got[0].Path != "/admin" {
+		t.Errorf("NewEndpoints() = %+v, want only the /admin path", got)
+	}
+
+	// Endpoints that could not be collected last time are not new.
+	failed := &Report{Errors: map[string]string{sectionEndpoints: "forbidden"}}
+	if got := NewEndpoints(failed, current); got != nil {
+		t.Errorf("NewEndpoints() after a failed collection = %+v, want nil", got)
+	}
+	skipped := &Report{SkippedCollectors: []string{"endpoints"}}
+	if got := NewEndpoints(skipped, current); got != nil {
+		t.Errorf("NewEndpoints() after a skipped collection = %+v, want nil", got)
+	}
+	if got := NewEndpoints(nil, current); got != nil {
+		t.Errorf("NewEndpoints() without a previous report = %+v, want nil", got)
+	}
+}
+
+func TestEndpointChangeFindings(t *testing.T) {
+	svc := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "web", Name: "lb",
+			CreationTimestamp: metav1.NewTime(time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)),
+		},
+		Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, Ports: []corev1.ServicePort{{Port: 443, Protocol: corev1.ProtocolTCP}}},
+		Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{
+			{IP: "203.0.113.10"},
+		}}},
+	}
+	endpoints := ServiceEndpoints([]corev1.Service{svc})
+	endpoints[0].Exposure = AddressPublic
+	endpoints[0].LastModified = &ObjectChange{User: "bob", Manager: "kubectl/v1.31.0", Operation: "patch", Time: time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC)}
+
+	findings := EndpointChangeFindings(endpoints)
+	if len(findings) != 1 {
+		t.Fatalf("EndpointChangeFindings() = %+v, want one finding", findings)
+	}
+	f := findings[0]
+	if f.CheckID != "endpoint-appeared" || f.Severity != SeverityHigh || f.Resource() != "Service/web/lb" || f.Fingerprint == "" {
+		t.Errorf("finding = %+v, want a fingerprinted high endpoint-appeared finding for Service/web/lb", f)
+	}
+	for _, want := range []string{
+		"last modified by bob via kubectl/v1.31.0 at 2026-03-03T09:00:00Z",
+		"created by unknown at 2026-03-01T09:00:00Z",
+	} {
+		if !strings.Contains(f.Message, want) {
+			t.Errorf("message %q does not contain %q", f.Message, want)
+		}
+	}
+}
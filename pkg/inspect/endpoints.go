@@ -0,0 +1,238 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Kinds of exposure an ExposedEndpoint can describe.
+const (
+	ExposureLoadBalancer = "LoadBalancer"
+	ExposureNodePort     = "NodePort"
+	ExposureIngress      = "Ingress"
+)
+
+// ExposedEndpoint is a Service or Ingress path reachable from outside the cluster.
+type ExposedEndpoint struct {
+	// Type is LoadBalancer, NodePort, or Ingress.
+	Type      string `json:"type"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Addresses are the external IPs or hostnames published in the object's status.
+	Addresses []string      `json:"addresses,omitempty"`
+	Ports     []ExposedPort `json:"ports,omitempty"`
+	// Host, Path, Backend, and TLS describe an Ingress rule path.
+	Host    string `json:"host,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Backend string `json:"backend,omitempty"`
+	TLS     bool   `json:"tls,omitempty"`
+	// Exposure is public when any address is internet-facing, private when none is, and unknown
+	// when a hostname could not be classified.
+	Exposure string `json:"exposure,omitempty"`
+	// Classified are the endpoint's addresses with their class; for NodePort services, the
+	// public node addresses.
+	Classified []ClassifiedAddress `json:"classifiedAddresses,omitempty"`
+	// Backends counts the ready endpoints behind the Service or Ingress path.
+	Backends *BackendStatus `json:"backends,omitempty"`
+	// Reachability is only populated when the scan runs with --probe.
+	Reachability []ReachabilityResult `json:"reachability,omitempty"`
+	// Created and LastModified say who created and last changed the Service or Ingress, from
+	// its managed fields or, with --audit-log, the API server audit log.
+	Created      *ObjectChange `json:"created,omitempty"`
+	LastModified *ObjectChange `json:"lastModified,omitempty"`
+
+	// internalHint is the annotation that makes a LoadBalancer service internal.
+	internalHint string
+	// backendService is the Service an Ingress path routes to.
+	backendService *networkingv1.IngressServiceBackend
+}
+
+// ExposedPort is one port of an exposed Service.
+type ExposedPort struct {
+	Port     int32  `json:"port"`
+	NodePort int32  `json:"nodePort,omitempty"`
+	Protocol string `json:"protocol"`
+}
+
+// Kind returns the API kind of the exposing object.
+func (e ExposedEndpoint) Kind() string {
+	if e.Type == ExposureIngress {
+		return "Ingress"
+	}
+	return "Service"
+}
+
+// String renders the endpoint as a single human-readable line.
+func (e ExposedEndpoint) String() string {
+	if e.Exposure == "" {
+		return e.describe()
+	}
+	return fmt.Sprintf("%s [%s]", e.describe(), e.Exposure)
+}
+
+func (e ExposedEndpoint) describe() string {
+	switch e.Type {
+	case ExposureLoadBalancer:
+		var ports []string
+		for _, p := range e.Ports {
+			ports = append(ports, fmt.Sprintf("%d/%s", p.Port, p.Protocol))
+		}
+		return fmt.Sprintf("Service (LoadBalancer): %s/%s - External Endpoint(s): [%s], Port(s): [%s]",
+			e.Namespace, e.Name, strings.Join(e.Addresses, ", "), strings.Join(ports, ", "))
+	case ExposureNodePort:
+		var ports []string
+		for _, p := range e.Ports {
+			ports = append(ports, fmt.Sprintf("%d:%d/%s", p.Port, p.NodePort, p.Protocol))
+		}
+		return fmt.Sprintf("Service (NodePort): %s/%s - NodePort(s): [%s] (exposed on all node IPs)",
+			e.Namespace, e.Name, strings.Join(ports, ", "))
+	default:
+		if len(e.Addresses) > 0 {
+			return fmt.Sprintf("Ingress: %s/%s - Host: %s, Path: %s -> %s, External Endpoint(s): [%s]",
+				e.Namespace, e.Name, e.Host, e.Path, e.Backend, strings.Join(e.Addresses, ", "))
+		}
+		return fmt.Sprintf("Ingress: %s/%s - Host: %s, Path: %s -> %s",
+			e.Namespace, e.Name, e.Host, e.Path, e.Backend)
+	}
+}
+
+// GetExposedEndpoints lists services of type LoadBalancer, NodePort, and Ingresses,
+// counts the endpoints behind them, and classifies their addresses as public or private.
+// Hostnames are only resolved with opts.ResolveHostnames.
+func GetExposedEndpoints(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) ([]ExposedEndpoint, error) {
+	services, err := listServices(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	ingresses, err := listIngresses(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+
+	nodes, err := listNodes(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	slices, err := listEndpointSlices(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list endpointslices: %w", err)
+	}
+
+	endpoints := append(ServiceEndpoints(services), IngressEndpoints(ingresses)...)
+	VerifyBackends(endpoints, services, slices)
+	ClassifyEndpoints(endpoints, ClassifyNodeAddresses(nodes), newLookup(ctx, opts.ResolveHostnames, opts.Timeout))
+	opts.AuditLog.annotate(endpoints)
+	return endpoints, nil
+}
+
+// ServiceEndpoints returns the LoadBalancer services with an assigned address and all NodePort services.
+func ServiceEndpoints(services []corev1.Service) []ExposedEndpoint {
+	var endpoints []ExposedEndpoint
+	for _, svc := range services {
+		switch svc.Spec.Type {
+		case corev1.ServiceTypeLoadBalancer:
+			lbIPs := loadBalancerAddresses(svc.Status.LoadBalancer.Ingress)
+			if len(lbIPs) == 0 {
+				continue
+			}
+			endpoint := ExposedEndpoint{Type: ExposureLoadBalancer, Namespace: svc.Namespace, Name: svc.Name, Addresses: lbIPs,
+				internalHint: internalLoadBalancer(svc.Annotations)}
+			endpoint.Created, endpoint.LastModified = objectChanges(svc.ObjectMeta)
+			for _, port := range svc.Spec.Ports {
+				endpoint.Ports = append(endpoint.Ports, ExposedPort{Port: port.Port, Protocol: string(port.Protocol)})
+			}
+			endpoints = append(endpoints, endpoint)
+		case corev1.ServiceTypeNodePort:
+			endpoint := ExposedEndpoint{Type: ExposureNodePort, Namespace: svc.Namespace, Name: svc.Name}
+			endpoint.Created, endpoint.LastModified = objectChanges(svc.ObjectMeta)
+			for _, port := range svc.Spec.Ports {
+				endpoint.Ports = append(endpoint.Ports, ExposedPort{Port: port.Port, NodePort: port.NodePort, Protocol: string(port.Protocol)})
+			}
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// IngressEndpoints returns one endpoint per Ingress rule path.
+func IngressEndpoints(ingresses []networkingv1.Ingress) []ExposedEndpoint {
+	var endpoints []ExposedEndpoint
+	for _, ing := range ingresses {
+		tlsHosts := map[string]bool{}
+		for _, t := range ing.Spec.TLS {
+			for _, h := range t.Hosts {
+				tlsHosts[h] = true
+			}
+		}
+		// Some ingress controllers might populate status with load balancer IPs/hostnames
+		var ingStatusIPs []string
+		for _, lbIngress := range ing.Status.LoadBalancer.Ingress {
+			if lbIngress.IP != "" {
+				ingStatusIPs = append(ingStatusIPs, lbIngress.IP)
+			} else if lbIngress.Hostname != "" {
+				ingStatusIPs = append(ingStatusIPs, lbIngress.Hostname)
+			}
+		}
+
+		created, lastModified := objectChanges(ing.ObjectMeta)
+		for _, rule := range ing.Spec.Rules {
+			host := rule.Host
+			if host == "" {
+				host = "*" // Default host if not specified
+			}
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				endpoints = append(endpoints, ExposedEndpoint{
+					Type:           ExposureIngress,
+					Namespace:      ing.Namespace,
+					Name:           ing.Name,
+					Addresses:      ingStatusIPs,
+					Host:           host,
+					Path:           path.Path,
+					Backend:        ingressBackendString(path.Backend),
+					backendService: path.Backend.Service,
+					TLS:            tlsHosts[rule.Host],
+					Created:        created,
+					LastModified:   lastModified,
+				})
+			}
+		}
+	}
+	return endpoints
+}
+
+func loadBalancerAddresses(ingress []corev1.LoadBalancerIngress) []string {
+	var addresses []string
+	for _, in := range ingress {
+		if in.IP != "" {
+			addresses = append(addresses, in.IP)
+		} else if in.Hostname != "" {
+			addresses = append(addresses, in.Hostname) // For ELBs that return DNS names
+		}
+	}
+	return addresses
+}
+
+// ingressBackendString renders an Ingress backend as service:port, or kind/name for resource backends.
+func ingressBackendString(backend networkingv1.IngressBackend) string {
+	switch {
+	case backend.Service != nil && backend.Service.Port.Name != "":
+		return fmt.Sprintf("%s:%s", backend.Service.Name, backend.Service.Port.Name)
+	case backend.Service != nil:
+		return fmt.Sprintf("%s:%d", backend.Service.Name, backend.Service.Port.Number)
+	case backend.Resource != nil:
+		return fmt.Sprintf("%s/%s", backend.Resource.Kind, backend.Resource.Name)
+	default:
+		return "<none>"
+	}
+}
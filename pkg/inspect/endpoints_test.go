@@ -0,0 +1,106 @@
+package inspect
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServiceEndpoints(t *testing.T) {
+	services := []corev1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "lb"},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Port: 443, Protocol: corev1.ProtocolTCP}},
+			},
+			Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{
+				{IP: "203.0.113.10"}, {Hostname: "lb.example.com"},
+			}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "pending"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ops", Name: "np"},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeNodePort,
+				Ports: []corev1.ServicePort{{Port: 80, NodePort: 30080, Protocol: corev1.ProtocolTCP}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ops", Name: "internal"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP},
+		},
+	}
+
+	endpoints := ServiceEndpoints(services)
+	if len(endpoints) != 2 {
+		t.Fatalf("ServiceEndpoints() returned %d endpoints, want 2", len(endpoints))
+	}
+
+	want := []string{
+		"Service (LoadBalancer): web/lb - External Endpoint(s): [203.0.113.10, lb.example.com], Port(s): [443/TCP]",
+		"Service (NodePort): ops/np - NodePort(s): [80:30080/TCP] (exposed on all node IPs)",
+	}
+	for i, w := range want {
+		if got := endpoints[i].String(); got != w {
+			t.Errorf("ServiceEndpoints()[%d].String() = %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestIngressEndpoints(t *testing.T) {
+	prefix := networkingv1.PathTypePrefix
+	ingresses := []networkingv1.Ingress{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "site"},
+		Spec: networkingv1.IngressSpec{
+			TLS: []networkingv1.IngressTLS{{Hosts: []string{"shop.example.com"}}},
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: "shop.example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: &prefix,
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+							Name: "shop", Port: networkingv1.ServiceBackendPort{Number: 8080},
+						}},
+					}}}},
+				},
+				{
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/static",
+						PathType: &prefix,
+						Backend: networkingv1.IngressBackend{Resource: &corev1.TypedLocalObjectReference{
+							Kind: "StorageBucket", Name: "assets",
+						}},
+					}}}},
+				},
+			},
+		},
+		Status: networkingv1.IngressStatus{LoadBalancer: networkingv1.IngressLoadBalancerStatus{
+			Ingress: []networkingv1.IngressLoadBalancerIngress{{IP: "198.51.100.7"}},
+		}},
+	}}
+
+	endpoints := IngressEndpoints(ingresses)
+	if len(endpoints) != 2 {
+		t.Fatalf("IngressEndpoints() returned %d endpoints, want 2", len(endpoints))
+	}
+	if !endpoints[0].TLS || endpoints[1].TLS {
+		t.Errorf("IngressEndpoints() TLS = %v, %v, want true, false", endpoints[0].TLS, endpoints[1].TLS)
+	}
+
+	want := []string{
+		"Ingress: web/site - Host: shop.example.com, Path: / -> shop:8080, External Endpoint(s): [198.51.100.7]",
+		"Ingress: web/site - Host: *, Path: /static -> StorageBucket/assets, External Endpoint(s): [198.51.100.7]",
+	}
+	for i, w := range want {
+		if got := endpoints[i].String(); got != w {
+			t.Errorf("IngressEndpoints()[%d].String() = %q, want %q", i, got, w)
+		}
+	}
+}
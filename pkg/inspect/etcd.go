@@ -0,0 +1,309 @@
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// defaultEtcdQuotaBytes is etcd's backend quota when --quota-backend-bytes is not set.
+const defaultEtcdQuotaBytes = 2 * 1024 * 1024 * 1024
+
+// etcdFragmentationThreshold is the share of reclaimable space above which a defrag is suggested.
+const etcdFragmentationThreshold = 0.5
+
+// EtcdHealth is the state of the etcd cluster as reported by etcdctl from inside an etcd pod.
+type EtcdHealth struct {
+	// Pod is the etcd pod etcdctl was run in.
+	Pod        string       `json:"pod"`
+	Members    []EtcdMember `json:"members"`
+	LeaderID   string       `json:"leaderId,omitempty"`
+	Alarms     []string     `json:"alarms,omitempty"`
+	QuotaBytes int64        `json:"quotaBytes"`
+}
+
+// EtcdMember is one member of the etcd cluster.
+type EtcdMember struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	Version   string `json:"version,omitempty"`
+	IsLeader  bool   `json:"isLeader"`
+	IsLearner bool   `json:"isLearner"`
+	// Reachable is false when the member is listed but did not answer a status request.
+	Reachable   bool     `json:"reachable"`
+	DBSize      int64    `json:"dbSize"`
+	DBSizeInUse int64    `json:"dbSizeInUse"`
+	Errors      []string `json:"errors,omitempty"`
+}
+
+// ReclaimableBytes is the space a defrag would return. etcd does not record when a member was
+// last defragmented, so this is the best available indicator of how overdue one is.
+func (m EtcdMember) ReclaimableBytes() int64 {
+	return m.DBSize - m.DBSizeInUse
+}
+
+type etcdctlMemberList struct {
+	Members []struct {
+		ID         uint64   `json:"ID"`
+		Name       string   `json:"name"`
+		ClientURLs []string `json:"clientURLs"`
+		IsLearner  bool     `json:"isLearner"`
+	} `json:"members"`
+}
+
+type etcdctlEndpointStatus struct {
+	Endpoint string `json:"Endpoint"`
+	Status   struct {
+		Header struct {
+			MemberID uint64 `json:"member_id"`
+		} `json:"header"`
+		Version     string   `json:"version"`
+		DBSize      int64    `json:"dbSize"`
+		DBSizeInUse int64    `json:"dbSizeInUse"`
+		Leader      uint64   `json:"leader"`
+		IsLearner   bool     `json:"isLearner"`
+		Errors      []string `json:"errors"`
+	} `json:"Status"`
+}
+
+type etcdctlAlarmList struct {
+	Alarms []struct {
+		MemberID uint64 `json:"memberID"`
+		Alarm    int    `json:"alarm"`
+	} `json:"alarms"`
+}
+
+// etcdAlarmNames maps etcd's AlarmType enum to the names etcdctl prints.
+var etcdAlarmNames = map[int]string{0: "NONE", 1: "NOSPACE", 2: "CORRUPT"}
+
+// GetEtcdHealth runs etcdctl inside a running etcd pod in kube-system to collect the member list,
+// leader, DB sizes, and active alarms. It needs pods/exec in kube-system, so it only runs when
+// requested with --etcd-deep.
+func GetEtcdHealth(ctx context.Context, clientset kubernetes.Interface, config *rest.Config) (*EtcdHealth, error) {
+	return getEtcdHealth(ctx, clientset, func(ctx context.Context, namespace, pod, container string, command []string) (string, error) {
+		return execInPod(ctx, clientset, config, namespace, pod, container, command)
+	})
+}
+
+func getEtcdHealth(ctx context.Context, clientset kubernetes.Interface, exec podExecFunc) (*EtcdHealth, error) {
+	pods, err := clientset.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{
+		LabelSelector: "component=etcd",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list etcd pods: %w", err)
+	}
+
+	var pod *corev1.Pod
+	var container *corev1.Container
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase != corev1.PodRunning {
+			continue
+		}
+		if c := findEtcdContainer(&pods.Items[i]); c != nil {
+			pod, container = &pods.Items[i], c
+			break
+		}
+	}
+	if pod == nil {
+		return nil, fmt.Errorf("no running etcd pod found in kube-system namespace")
+	}
+
+	// Reuse the TLS material etcd itself serves with; on kubeadm clusters the server
+	// certificate is also valid for client authentication.
+	base := []string{
+		"etcdctl",
+		"--endpoints=https://127.0.0.1:2379",
+		"--cacert=" + containerFlag(container, "trusted-ca-file", "/etc/kubernetes/pki/etcd/ca.crt"),
+		"--cert=" + containerFlag(container, "cert-file", "/etc/kubernetes/pki/etcd/server.crt"),
+		"--key=" + containerFlag(container, "key-file", "/etc/kubernetes/pki/etcd/server.key"),
+		"--write-out=json",
+	}
+	run := func(args ...string) (string, error) {
+		return exec(ctx, pod.Namespace, pod.Name, container.Name, append(append([]string{}, base...), args...))
+	}
+
+	membersOut, err := run("member", "list")
+	if err != nil {
+		return nil, err
+	}
+	// etcdctl exits non-zero when a member is unreachable, but still prints the status of the
+	// members it reached; the missing ones are reported unreachable from that.
+	statusOut, err := run("endpoint", "status", "--cluster")
+	if err != nil && !json.Valid([]byte(statusOut)) {
+		return nil, err
+	}
+	alarmsOut, err := run("alarm", "list")
+	if err != nil {
+		return nil, err
+	}
+
+	quota := int64(defaultEtcdQuotaBytes)
+	if q := containerFlag(container, "quota-backend-bytes", ""); q != "" {
+		if parsed, err := strconv.ParseInt(q, 10, 64); err == nil && parsed > 0 {
+			quota = parsed
+		}
+	}
+
+	health, err := ParseEtcdHealth(membersOut, statusOut, alarmsOut, quota)
+	if err != nil {
+		return nil, err
+	}
+	health.Pod = pod.Name
+	return health, nil
+}
+
+// ParseEtcdHealth combines the JSON output of etcdctl member list, endpoint status, and alarm list.
+func ParseEtcdHealth(membersOut, statusOut, alarmsOut string, quotaBytes int64) (*EtcdHealth, error) {
+	var memberList etcdctlMemberList
+	if err := json.Unmarshal([]byte(membersOut), &memberList); err != nil {
+		return nil, fmt.Errorf("failed to parse etcdctl member list: %w", err)
+	}
+	var statuses []etcdctlEndpointStatus
+	if err := json.Unmarshal([]byte(statusOut), &statuses); err != nil {
+		return nil, fmt.Errorf("failed to parse etcdctl endpoint status: %w", err)
+	}
+	var alarmList etcdctlAlarmList
+	if err := json.Unmarshal([]byte(alarmsOut), &alarmList); err != nil {
+		return nil, fmt.Errorf("failed to parse etcdctl alarm list: %w", err)
+	}
+
+	byMember := make(map[uint64]etcdctlEndpointStatus, len(statuses))
+	var leader uint64
+	for _, s := range statuses {
+		byMember[s.Status.Header.MemberID] = s
+		if s.Status.Leader != 0 {
+			leader = s.Status.Leader
+		}
+	}
+
+	health := &EtcdHealth{QuotaBytes: quotaBytes}
+	if leader != 0 {
+		health.LeaderID = etcdMemberID(leader)
+	}
+
+	names := make(map[uint64]string, len(memberList.Members))
+	for _, m := range memberList.Members {
+		names[m.ID] = m.Name
+		member := EtcdMember{
+			ID:        etcdMemberID(m.ID),
+			Name:      m.Name,
+			IsLeader:  m.ID == leader,
+			IsLearner: m.IsLearner,
+		}
+		if len(m.ClientURLs) > 0 {
+			member.Endpoint = m.ClientURLs[0]
+		}
+		if s, ok := byMember[m.ID]; ok {
+			member.Reachable = true
+			member.Endpoint = s.Endpoint
+			member.Version = s.Status.Version
+			member.DBSize = s.Status.DBSize
+			member.DBSizeInUse = s.Status.DBSizeInUse
+			member.Errors = s.Status.Errors
+		}
+		health.Members = append(health.Members, member)
+	}
+
+	for _, a := range alarmList.Alarms {
+		name, ok := etcdAlarmNames[a.Alarm]
+		if !ok {
+			name = strconv.Itoa(a.Alarm)
+		}
+		member := names[a.MemberID]
+		if member == "" {
+			member = etcdMemberID(a.MemberID)
+		}
+		health.Alarms = append(health.Alarms, fmt.Sprintf("%s on %s", name, member))
+	}
+
+	return health, nil
+}
+
+// CheckEtcdHealth raises findings for alarms, a missing leader, unreachable or erroring members,
+// databases close to their quota, and heavily fragmented databases.
+func CheckEtcdHealth(health *EtcdHealth) []Finding {
+	var findings []Finding
+	add := func(checkID string, severity Severity, name, message string) {
+		findings = append(findings, Finding{
+			CheckID:  checkID,
+			Severity: severity,
+			Kind:     "EtcdMember",
+			Name:     name,
+			Message:  message,
+		})
+	}
+
+	for _, alarm := range health.Alarms {
+		add("etcd-alarm", SeverityCritical, alarm, fmt.Sprintf("etcd alarm raised: %s", alarm))
+	}
+	if health.LeaderID == "" {
+		add("etcd-no-leader", SeverityCritical, "cluster", "no etcd member reports a leader")
+	}
+
+	for _, m := range health.Members {
+		if !m.Reachable {
+			add("etcd-member-unreachable", SeverityHigh, m.Name, fmt.Sprintf("etcd member %s did not answer a status request", m.Name))
+			continue
+		}
+		if len(m.Errors) > 0 {
+			add("etcd-member-errors", SeverityHigh, m.Name, fmt.Sprintf("etcd member %s reports errors: %s", m.Name, strings.Join(m.Errors, "; ")))
+		}
+		if health.QuotaBytes > 0 && float64(m.DBSize) >= 0.8*float64(health.QuotaBytes) {
+			add("etcd-db-near-quota", SeverityHigh, m.Name, fmt.Sprintf("etcd member %s database is %s of a %s quota",
+				m.Name, formatBytes(m.DBSize), formatBytes(health.QuotaBytes)))
+		}
+		if m.DBSize >= 100*1024*1024 && float64(m.ReclaimableBytes()) >= etcdFragmentationThreshold*float64(m.DBSize) {
+			add("etcd-fragmented", SeverityLow, m.Name, fmt.Sprintf("etcd member %s has %s of %s reclaimable; consider a defrag",
+				m.Name, formatBytes(m.ReclaimableBytes()), formatBytes(m.DBSize)))
+		}
+	}
+	return findings
+}
+
+// findEtcdContainer returns the container in pod running an etcd image.
+func findEtcdContainer(pod *corev1.Pod) *corev1.Container {
+	for i, container := range pod.Spec.Containers {
+		if strings.Contains(container.Image, "etcd") {
+			return &pod.Spec.Containers[i]
+		}
+	}
+	return nil
+}
+
+// containerFlag returns the value of a --name=value flag from a container's command or args,
+// or def when the flag is not set.
+func containerFlag(container *corev1.Container, name, def string) string {
+	prefix := "--" + name + "="
+	for _, arg := range append(append([]string{}, container.Command...), container.Args...) {
+		if strings.HasPrefix(arg, prefix) {
+			return strings.TrimPrefix(arg, prefix)
+		}
+	}
+	return def
+}
+
+func etcdMemberID(id uint64) string {
+	return strconv.FormatUint(id, 16)
+}
+
+// formatBytes renders a byte count using binary units.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,143 @@
+package inspect
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	etcdMemberListJSON = `{"header":{"cluster_id":1,"member_id":10},"members":[
+		{"ID":10,"name":"cp-1","clientURLs":["https://10.0.0.1:2379"]},
+		{"ID":11,"name":"cp-2","clientURLs":["https://10.0.0.2:2379"]},
+		{"ID":12,"name":"cp-3","clientURLs":["https://10.0.0.3:2379"]}]}`
+	etcdEndpointStatusJSON = `[
+		{"Endpoint":"https://10.0.0.1:2379","Status":{"header":{"member_id":10},"version":"3.5.16","dbSize":209715200,"dbSizeInUse":52428800,"leader":10}},
+		{"Endpoint":"https://10.0.0.2:2379","Status":{"header":{"member_id":11},"version":"3.5.16","dbSize":1800000000,"dbSizeInUse":1700000000,"leader":10}}]`
+	etcdAlarmListJSON = `{"header":{"member_id":10},"alarms":[{"memberID":11,"alarm":1}]}`
+)
+
+func TestParseEtcdHealth(t *testing.T) {
+	health, err := ParseEtcdHealth(etcdMemberListJSON, etcdEndpointStatusJSON, etcdAlarmListJSON, defaultEtcdQuotaBytes)
+	if err != nil {
+		t.Fatalf("ParseEtcdHealth() returned error = %v, want nil", err)
+	}
+
+	if len(health.Members) != 3 {
+		t.Fatalf("ParseEtcdHealth() returned %d members, want 3", len(health.Members))
+	}
+	if health.LeaderID != "a" || !health.Members[0].IsLeader {
+		t.Errorf("ParseEtcdHealth() leader = %q, want member a (cp-1)", health.LeaderID)
+	}
+	if health.Members[2].Reachable {
+		t.Errorf("ParseEtcdHealth() marked cp-3 reachable, want unreachable (no status)")
+	}
+	if len(health.Alarms) != 1 || health.Alarms[0] != "NOSPACE on cp-2" {
+		t.Errorf("ParseEtcdHealth() alarms = %v, want [NOSPACE on cp-2]", health.Alarms)
+	}
+}
+
+func TestGetEtcdHealth_UnreachableMember(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "etcd-cp-1", Labels: map[string]string{"component": "etcd"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "etcd", Image: "registry.k8s.io/etcd:3.5.16-0"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	})
+	exitErr := errors.New("command terminated with exit code 1")
+	exec := func(ctx context.Context, namespace, pod, container string, command []string) (string, error) {
+		switch {
+		case slices.Contains(command, "member"):
+			return etcdMemberListJSON, nil
+		case slices.Contains(command, "alarm"):
+			return etcdAlarmListJSON, nil
+		case slices.Contains(command, "endpoint"):
+			// cp-3 can't be reached, so etcdctl fails after printing the other members.
+			return etcdEndpointStatusJSON, exitErr
+		}
+		return "", errors.New("unexpected command " + strings.Join(command, " "))
+	}
+
+	health, err := getEtcdHealth(context.Background(), clientset, exec)
+	if err != nil {
+		t.Fatalf("getEtcdHealth() error = %v, want nil", err)
+	}
+	var unreachable []string
+	for _, f := range CheckEtcdHealth(health) {
+		if f.CheckID == "etcd-member-unreachable" {
+			unreachable = append(unreachable, f.Name)
+		}
+	}
+	if !slices.Equal(unreachable, []string{"cp-3"}) {
+		t.Errorf("etcd-member-unreachable findings = %v, want [cp-3]", unreachable)
+	}
+
+	failing := func(ctx context.Context, namespace, pod, container string, command []string) (string, error) {
+		if slices.Contains(command, "endpoint") {
+			return "", exitErr
+		}
+		return exec(ctx, namespace, pod, container, command)
+	}
+	if _, err := getEtcdHealth(context.Background(), clientset, failing); !errors.Is(err, exitErr) {
+		t.Errorf("getEtcdHealth() without status output error = %v, want %v", err, exitErr)
+	}
+}
+
+func TestParseEtcdHealth_InvalidJSON(t *testing.T) {
+	if _, err := ParseEtcdHealth("not json", etcdEndpointStatusJSON, etcdAlarmListJSON, 0); err == nil {
+		t.Errorf("ParseEtcdHealth() with invalid member list returned error = nil, want non-nil")
+	}
+}
+
+func TestCheckEtcdHealth(t *testing.T) {
+	health, err := ParseEtcdHealth(etcdMemberListJSON, etcdEndpointStatusJSON, etcdAlarmListJSON, defaultEtcdQuotaBytes)
+	if err != nil {
+		t.Fatalf("ParseEtcdHealth() returned error = %v, want nil", err)
+	}
+
+	got := map[string]string{}
+	for _, f := range CheckEtcdHealth(health) {
+		got[f.CheckID] = f.Name
+	}
+	want := map[string]string{
+		"etcd-alarm":              "NOSPACE on cp-2",
+		"etcd-member-unreachable": "cp-3",
+		"etcd-db-near-quota":      "cp-2",
+		"etcd-fragmented":         "cp-1",
+	}
+	for id, name := range want {
+		if got[id] != name {
+			t.Errorf("CheckEtcdHealth() %s finding for %q, want %q", id, got[id], name)
+		}
+	}
+	if _, ok := got["etcd-no-leader"]; ok {
+		t.Errorf("CheckEtcdHealth() raised etcd-no-leader with a leader present")
+	}
+}
+
+func TestContainerFlag(t *testing.T) {
+	c := &corev1.Container{Command: []string{"etcd", "--cert-file=/pki/server.crt"}, Args: []string{"--quota-backend-bytes=8589934592"}}
+	if got := containerFlag(c, "cert-file", "default"); got != "/pki/server.crt" {
+		t.Errorf("containerFlag(cert-file) = %q, want /pki/server.crt", got)
+	}
+	if got := containerFlag(c, "quota-backend-bytes", ""); got != "8589934592" {
+		t.Errorf("containerFlag(quota-backend-bytes) = %q, want 8589934592", got)
+	}
+	if got := containerFlag(c, "key-file", "default"); got != "default" {
+		t.Errorf("containerFlag(key-file) = %q, want default", got)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{512: "512 B", 2048: "2.0 KiB", 209715200: "200.0 MiB", defaultEtcdQuotaBytes: "2.0 GiB"}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package inspect
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// A warning reason is spiking when it fired at least spikeMinEvents times in the most recent
+// quarter of the window, at spikeFactor times or more the rate of the rest of the window.
+const (
+	spikeRecentFraction = 4
+	spikeMinEvents      = 5
+	spikeFactor         = 3
+)
+
+// EventSummary is the cluster's Events over a time window, grouped by type, reason, and
+// involved object kind.
+type EventSummary struct {
+	Since  time.Time    `json:"since"`
+	Until  time.Time    `json:"until"`
+	Total  int          `json:"total"`
+	Groups []EventGroup `json:"groups"`
+}
+
+// EventGroup aggregates the events that share a type, reason, and involved object kind.
+type EventGroup struct {
+	Type    string `json:"type"`
+	Reason  string `json:"reason"`
+	Kind    string `json:"kind"`
+	Count   int    `json:"count"`
+	Objects int    `json:"objects"`
+	// Recent is the number of events in the last quarter of the window.
+	Recent int `json:"recent"`
+	// Spiking is set for Warning reasons firing much faster recently than earlier in the window.
+	Spiking       bool      `json:"spiking"`
+	LastSeen      time.Time `json:"lastSeen"`
+	LatestMessage string    `json:"latestMessage"`
+	// LatestObject is namespace/name of the object the latest event was about.
+	LatestObject string `json:"latestObject"`
+}
+
+// Spikes returns the spiking groups.
+func (s *EventSummary) Spikes() []EventGroup {
+	var spikes []EventGroup
+	for _, g := range s.Groups {
+		if g.Spiking {
+			spikes = append(spikes, g)
+		}
+	}
+	return spikes
+}
+
+// SummarizeEvents groups the events seen in the window ending at now. The API server folds
+// repeats of an event into one object with a count and first and last timestamps, so each
+// event's count is spread evenly between those timestamps to estimate how many fell in the
+// window and how many in its most recent quarter.
+func SummarizeEvents(events []corev1.Event, window time.Duration, now time.Time) *EventSummary {
+	summary := &EventSummary{Since: now.Add(-window), Until: now}
+	recentStart := now.Add(-window / spikeRecentFraction)
+
+	type groupKey struct{ eventType, reason, kind string }
+	type groupState struct {
+		group    EventGroup
+		objects  map[string]struct{}
+		inWindow float64
+		recent   float64
+	}
+	groups := map[groupKey]*groupState{}
+
+	for _, e := range events {
+		first, last, count := eventOccurrences(e)
+		inWindow := spreadCount(first, last, count, summary.Since, now)
+		if inWindow == 0 {
+			continue
+		}
+
+		key := groupKey{e.Type, e.Reason, e.InvolvedObject.Kind}
+		g, ok := groups[key]
+		if !ok {
+			g = &groupState{
+				group:   EventGroup{Type: e.Type, Reason: e.Reason, Kind: e.InvolvedObject.Kind},
+				objects: map[string]struct{}{},
+			}
+			groups[key] = g
+		}
+		object := e.InvolvedObject.Namespace + "/" + e.InvolvedObject.Name
+		g.objects[object] = struct{}{}
+		g.inWindow += inWindow
+		g.recent += spreadCount(first, last, count, recentStart, now)
+		if last.After(g.group.LastSeen) {
+			g.group.LastSeen = last
+			g.group.LatestMessage = e.Message
+			g.group.LatestObject = object
+		}
+	}
+
+	for _, g := range groups {
+		g.group.Count = int(g.inWindow + 0.5)
+		g.group.Recent = int(g.recent + 0.5)
+		g.group.Objects = len(g.objects)
+		g.group.Spiking = g.group.Type == corev1.EventTypeWarning && isSpiking(g.recent, g.inWindow-g.recent)
+		summary.Total += g.group.Count
+		summary.Groups = append(summary.Groups, g.group)
+	}
+	sort.Slice(summary.Groups, func(i, j int) bool {
+		a, b := summary.Groups[i], summary.Groups[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Reason != b.Reason {
+			return a.Reason < b.Reason
+		}
+		return a.Kind < b.Kind
+	})
+	return summary
+}
+
+// isSpiking compares the rate of events in the recent quarter of the window with the rate
+// over the other three quarters.
+func isSpiking(recent, earlier float64) bool {
+	if recent < spikeMinEvents {
+		return false
+	}
+	earlierRate := earlier / (spikeRecentFraction - 1)
+	return recent >= spikeFactor*earlierRate
+}
+
+// eventOccurrences returns when an event was first and last seen and how many times it fired,
+// reading whichever of the legacy and series fields the reporting component filled in.
+func eventOccurrences(e corev1.Event) (first, last time.Time, count int32) {
+	first, last, count = e.FirstTimestamp.Time, e.LastTimestamp.Time, e.Count
+	if e.Series != nil {
+		last, count = e.Series.LastObservedTime.Time, e.Series.Count
+	}
+	if first.IsZero() {
+		first = e.EventTime.Time
+	}
+	if first.IsZero() {
+		first = e.CreationTimestamp.Time
+	}
+	if last.IsZero() || last.Before(first) {
+		last = first
+	}
+	if count < 1 {
+		count = 1
+	}
+	return first, last, count
+}
+
+// spreadCount returns how many of count occurrences spread evenly over [first, last] fall
+// within [from, to].
+func spreadCount(first, last time.Time, count int32, from, to time.Time) float64 {
+	if last.Before(from) || first.After(to) {
+		return 0
+	}
+	span := last.Sub(first)
+	if span <= 0 {
+		return float64(count)
+	}
+	start, end := first, last
+	if from.After(start) {
+		start = from
+	}
+	if to.Before(end) {
+		end = to
+	}
+	return float64(count) * float64(end.Sub(start)) / float64(span)
+}
+
+// PrintEventSummary writes the top groups and the spiking warning reasons as text.
+func PrintEventSummary(w io.Writer, s *EventSummary, top int) {
+	fmt.Fprintf(w, "Events since %s: %d\n", s.Since.Format(time.RFC3339), s.Total)
+	if len(s.Groups) == 0 {
+		fmt.Fprintln(w, "  No events in this window.")
+		return
+	}
+	for i, g := range s.Groups {
+		if top > 0 && i == top {
+			fmt.Fprintf(w, "  ... %d more group(s)\n", len(s.Groups)-top)
+			break
+		}
+		fmt.Fprintf(w, "  - %-7s %-24s %-12s %6d event(s) on %d object(s)\n", g.Type, g.Reason, g.Kind, g.Count, g.Objects)
+	}
+
+	spikes := s.Spikes()
+	fmt.Fprintln(w, "Spiking warnings:")
+	if len(spikes) == 0 {
+		fmt.Fprintln(w, "  None.")
+	}
+	for _, g := range spikes {
+		fmt.Fprintf(w, "  - %s on %s: %d of %d event(s) in the last %s, latest on %s: %s\n",
+			g.Reason, g.Kind, g.Recent, g.Count, s.Until.Sub(s.Since)/spikeRecentFraction, g.LatestObject, g.LatestMessage)
+	}
+}
@@ -0,0 +1,80 @@
+package inspect
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testEvent(eventType, reason, kind, name string, first, last time.Time, count int32) corev1.Event {
+	return corev1.Event{
+		Type:           eventType,
+		Reason:         reason,
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Namespace: "web", Name: name},
+		FirstTimestamp: metav1.NewTime(first),
+		LastTimestamp:  metav1.NewTime(last),
+		Count:          count,
+		Message:        reason + " on " + name,
+	}
+}
+
+func TestSummarizeEvents(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	events := []corev1.Event{
+		// Steady BackOff over the whole hour: not a spike.
+		testEvent(corev1.EventTypeWarning, "BackOff", "Pod", "api-1", ago(60*time.Minute), ago(0), 40),
+		// FailedScheduling that only started ten minutes ago on two pods: a spike.
+		testEvent(corev1.EventTypeWarning, "FailedScheduling", "Pod", "worker-1", ago(10*time.Minute), ago(time.Minute), 12),
+		testEvent(corev1.EventTypeWarning, "FailedScheduling", "Pod", "worker-2", ago(5*time.Minute), ago(5*time.Minute), 1),
+		// A Normal reason never spikes.
+		testEvent(corev1.EventTypeNormal, "Pulled", "Pod", "api-1", ago(5*time.Minute), ago(2*time.Minute), 20),
+		// Half of this one is older than the window.
+		testEvent(corev1.EventTypeWarning, "Unhealthy", "Pod", "api-2", ago(2*time.Hour), ago(0), 10),
+		// Entirely outside the window.
+		testEvent(corev1.EventTypeWarning, "OOMKilling", "Node", "worker-1", ago(3*time.Hour), ago(2*time.Hour), 3),
+	}
+
+	summary := SummarizeEvents(events, time.Hour, now)
+
+	groups := map[string]EventGroup{}
+	for _, g := range summary.Groups {
+		groups[g.Reason] = g
+	}
+	if _, ok := groups["OOMKilling"]; ok {
+		t.Error("SummarizeEvents() included an event outside the window")
+	}
+	if g := groups["Unhealthy"]; g.Count != 5 {
+		t.Errorf("SummarizeEvents() Unhealthy count = %d, want 5", g.Count)
+	}
+	if g := groups["FailedScheduling"]; g.Count != 13 || g.Objects != 2 || !g.Spiking || g.LatestObject != "web/worker-1" {
+		t.Errorf("SummarizeEvents() FailedScheduling = %+v, want 13 events on 2 objects, spiking, latest on web/worker-1", g)
+	}
+	if g := groups["BackOff"]; g.Spiking || g.Recent != 10 {
+		t.Errorf("SummarizeEvents() BackOff = %+v, want 10 recent events and not spiking", g)
+	}
+	if g := groups["Pulled"]; g.Spiking {
+		t.Errorf("SummarizeEvents() Pulled = %+v, want Normal events never spiking", g)
+	}
+	if summary.Groups[0].Reason != "BackOff" {
+		t.Errorf("SummarizeEvents() first group = %s, want BackOff (largest count)", summary.Groups[0].Reason)
+	}
+	if summary.Total != 40+13+20+5 {
+		t.Errorf("SummarizeEvents() total = %d, want %d", summary.Total, 40+13+20+5)
+	}
+}
+
+func TestEventOccurrences_Series(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	e := corev1.Event{
+		EventTime: metav1.NewMicroTime(now.Add(-time.Hour)),
+		Series:    &corev1.EventSeries{Count: 7, LastObservedTime: metav1.NewMicroTime(now)},
+	}
+	first, last, count := eventOccurrences(e)
+	if !first.Equal(now.Add(-time.Hour)) || !last.Equal(now) || count != 7 {
+		t.Errorf("eventOccurrences() = %s, %s, %d; want series timestamps and count 7", first, last, count)
+	}
+}
@@ -0,0 +1,54 @@
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// podExecFunc runs command in a container of a pod, like execInPod.
+type podExecFunc func(ctx context.Context, namespace, pod, container string, command []string) (string, error)
+
+// execInPod runs command in a container of a running pod and returns its stdout.
+// A non-zero exit is reported as an error that includes the command's stderr, along with
+// whatever the command wrote to stdout, since some commands report partial results that way.
+func execInPod(ctx context.Context, clientset kubernetes.Interface, config *rest.Config, namespace, pod, container string, command []string) (string, error) {
+	client, err := coreRESTClient(clientset)
+	if err != nil {
+		return "", err
+	}
+	req := client.Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+	if err != nil {
+		return "", fmt.Errorf("failed to create executor: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		return stdout.String(), fmt.Errorf("exec %q in %s/%s failed: %w (stderr: %s)",
+			strings.Join(command, " "), namespace, pod, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
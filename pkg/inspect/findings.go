@@ -0,0 +1,237 @@
+package inspect
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Severity ranks how urgently a finding needs attention.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+var severityRanks = map[Severity]int{
+	SeverityInfo:     0,
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// AtLeast reports whether s is as severe as, or more severe than, min.
+// An empty min matches every severity.
+func (s Severity) AtLeast(min Severity) bool {
+	return severityRanks[s] >= severityRanks[min]
+}
+
+// ParseSeverity validates a severity name.
+func ParseSeverity(name string) (Severity, error) {
+	s := Severity(strings.ToLower(name))
+	if _, ok := severityRanks[s]; !ok {
+		return "", fmt.Errorf("unknown severity %q", name)
+	}
+	return s, nil
+}
+
+// Finding is a single issue a check raised against a cluster object.
+type Finding struct {
+	// Fingerprint identifies the same issue on the same object across scans. See FinalizeFindings.
+	Fingerprint string   `json:"fingerprint"`
+	CheckID     string   `json:"checkId"`
+	Severity    Severity `json:"severity"`
+	Kind        string   `json:"kind"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Name        string   `json:"name"`
+	// UID is the flagged object's UID, when the finding is about an API object.
+	UID     string `json:"uid,omitempty"`
+	Message string `json:"message"`
+	// Owner is the top-level controller of a flagged pod, the object to change to fix it.
+	Owner *Owner `json:"owner,omitempty"`
+	// Raw is the sanitized JSON of the flagged object, only populated when the scan runs with --with-raw.
+	Raw json.RawMessage `json:"raw,omitempty"`
+
+	// object is the flagged API object, kept so Raw can be filled in on request.
+	object any
+	// keyFields distinguish separate issues a check raises against the same object,
+	// such as each exposed address of one Service.
+	keyFields []string
+}
+
+// Resource returns the kind/namespace/name of the object the finding is about.
+func (f Finding) Resource() string {
+	if f.Namespace == "" {
+		return fmt.Sprintf("%s/%s", f.Kind, f.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", f.Kind, f.Namespace, f.Name)
+}
+
+// key identifies the finding across scans so that only new findings are reported.
+func (f Finding) key() string {
+	if f.Fingerprint != "" {
+		return f.Fingerprint
+	}
+	return f.fingerprint()
+}
+
+// fingerprint hashes the check ID, the object's UID (or kind/namespace/name when the finding
+// is not about an API object), and the check's key fields. The message is deliberately left
+// out so that rewording it, or incidental changes to the object, keep the fingerprint stable.
+func (f Finding) fingerprint() string {
+	identity := f.UID
+	if identity == "" {
+		identity = f.Resource()
+	}
+
+	h := sha256.New()
+	for _, part := range append([]string{f.CheckID, identity}, f.keyFields...) {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// FinalizeFindings fills in each finding's UID and fingerprint and drops findings whose
+// fingerprint was already seen, keeping the first.
+func FinalizeFindings(findings []Finding) []Finding {
+	seen := make(map[string]struct{}, len(findings))
+	deduped := findings[:0]
+	for _, f := range findings {
+		if obj, err := meta.Accessor(f.object); err == nil {
+			f.UID = string(obj.GetUID())
+		}
+		f.Fingerprint = f.fingerprint()
+		if _, ok := seen[f.Fingerprint]; ok {
+			continue
+		}
+		seen[f.Fingerprint] = struct{}{}
+		deduped = append(deduped, f)
+	}
+	return deduped
+}
+
+// NewFindings returns the findings in current that were not present in previous.
+func NewFindings(previous, current []Finding) []Finding {
+	seen := make(map[string]struct{}, len(previous))
+	for _, f := range previous {
+		seen[f.key()] = struct{}{}
+	}
+
+	var added []Finding
+	for _, f := range current {
+		if _, ok := seen[f.key()]; !ok {
+			added = append(added, f)
+		}
+	}
+	return added
+}
+
+// sortFindings orders findings from most to least severe, then by check and resource.
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity != b.Severity {
+			return severityRanks[a.Severity] > severityRanks[b.Severity]
+		}
+		if a.CheckID != b.CheckID {
+			return a.CheckID < b.CheckID
+		}
+		return a.Resource() < b.Resource()
+	})
+}
+
+// GetNodeFindings flags nodes whose Ready condition is not True.
+func GetNodeFindings(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) ([]Finding, error) {
+	nodes, err := listNodes(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	return CheckNodeReadiness(nodes), nil
+}
+
+// CheckNodeReadiness raises a finding for every node that is not Ready.
+func CheckNodeReadiness(nodes []corev1.Node) []Finding {
+	var findings []Finding
+	for _, node := range nodes {
+		status := corev1.ConditionUnknown
+		reason := "no Ready condition reported"
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == corev1.NodeReady {
+				status = cond.Status
+				reason = cond.Reason
+				break
+			}
+		}
+		if status == corev1.ConditionTrue {
+			continue
+		}
+		findings = append(findings, Finding{
+			CheckID:  "node-not-ready",
+			Severity: SeverityHigh,
+			Kind:     "Node",
+			Name:     node.Name,
+			Message:  fmt.Sprintf("node %s is NotReady (Ready=%s: %s)", node.Name, status, reason),
+			object:   &node,
+		})
+	}
+	return findings
+}
+
+// GetExposureFindings flags LoadBalancer services that have been assigned an external address.
+func GetExposureFindings(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) ([]Finding, error) {
+	services, err := listServices(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	return CheckPublicLoadBalancers(services, newLookup(ctx, opts.ResolveHostnames, opts.Timeout)), nil
+}
+
+// CheckPublicLoadBalancers raises a finding for every LoadBalancer service with a potentially public
+// address, as classified by ClassifyAddress. Public addresses are high severity; hostnames that could
+// not be classified, which usually belong to cloud load balancers whose reachability can't be judged
+// from the name, are medium.
+func CheckPublicLoadBalancers(services []corev1.Service, lookup lookupFunc) []Finding {
+	var findings []Finding
+	for _, svc := range services {
+		if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			continue
+		}
+		internalHint := internalLoadBalancer(svc.Annotations)
+		for _, address := range loadBalancerAddresses(svc.Status.LoadBalancer.Ingress) {
+			classified := ClassifyAddress(address, internalHint, lookup)
+			severity := SeverityMedium
+			switch classified.Class {
+			case AddressPrivate:
+				continue
+			case AddressPublic:
+				severity = SeverityHigh
+			}
+			findings = append(findings, Finding{
+				CheckID:   "public-loadbalancer",
+				Severity:  severity,
+				Kind:      "Service",
+				Namespace: svc.Namespace,
+				Name:      svc.Name,
+				Message:   fmt.Sprintf("LoadBalancer service %s/%s is exposed at %s (%s: %s)", svc.Namespace, svc.Name, address, classified.Class, classified.Reason),
+				object:    &svc,
+				keyFields: []string{address},
+			})
+		}
+	}
+	return findings
+}
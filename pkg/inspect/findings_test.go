@@ -0,0 +1,131 @@
+package inspect
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSeverity_AtLeast(t *testing.T) {
+	if !SeverityCritical.AtLeast(SeverityHigh) {
+		t.Errorf("critical.AtLeast(high) = false, want true")
+	}
+	if SeverityMedium.AtLeast(SeverityHigh) {
+		t.Errorf("medium.AtLeast(high) = true, want false")
+	}
+	if !SeverityInfo.AtLeast("") {
+		t.Errorf("info.AtLeast(\"\") = false, want true")
+	}
+}
+
+func TestNewFindings(t *testing.T) {
+	a := Finding{CheckID: "node-not-ready", Kind: "Node", Name: "a"}
+	b := Finding{CheckID: "node-not-ready", Kind: "Node", Name: "b"}
+
+	added := NewFindings([]Finding{a}, []Finding{a, b})
+	if len(added) != 1 || added[0].Name != "b" {
+		t.Errorf("NewFindings() = %v, want only node b", added)
+	}
+}
+
+func TestCheckNodeReadiness(t *testing.T) {
+	nodes := []corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ready"},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "not-ready"},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionFalse, Reason: "KubeletNotReady"},
+			}},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "no-conditions"}},
+	}
+
+	findings := CheckNodeReadiness(nodes)
+	if len(findings) != 2 {
+		t.Fatalf("CheckNodeReadiness() returned %d findings, want 2", len(findings))
+	}
+	if findings[0].Name != "not-ready" || findings[1].Name != "no-conditions" {
+		t.Errorf("CheckNodeReadiness() flagged %s and %s, want not-ready and no-conditions", findings[0].Name, findings[1].Name)
+	}
+}
+
+func TestCheckPublicLoadBalancers(t *testing.T) {
+	lb := func(name string, ingress ...corev1.LoadBalancerIngress) corev1.Service {
+		return corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			Status:     corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: ingress}},
+		}
+	}
+	services := []corev1.Service{
+		lb("public", corev1.LoadBalancerIngress{IP: "203.0.113.10"}),
+		lb("private", corev1.LoadBalancerIngress{IP: "10.0.0.5"}),
+		lb("elb", corev1.LoadBalancerIngress{Hostname: "abc.elb.amazonaws.com"}),
+		lb("pending"),
+		lb("internal-elb", corev1.LoadBalancerIngress{Hostname: "internal-abc.us-east-1.elb.amazonaws.com"}),
+		lb("cgnat", corev1.LoadBalancerIngress{IP: "100.64.1.2"}),
+	}
+	annotated := lb("annotated", corev1.LoadBalancerIngress{Hostname: "xyz.cloudapp.azure.com"})
+	annotated.Annotations = map[string]string{"service.beta.kubernetes.io/azure-load-balancer-internal": "true"}
+	services = append(services, annotated)
+
+	findings := CheckPublicLoadBalancers(services, nil)
+	if len(findings) != 2 {
+		t.Fatalf("CheckPublicLoadBalancers() returned %d findings, want 2", len(findings))
+	}
+	if findings[0].Name != "public" || findings[0].Severity != SeverityHigh {
+		t.Errorf("CheckPublicLoadBalancers()[0] = %+v, want high finding for public", findings[0])
+	}
+	if findings[1].Name != "elb" || findings[1].Severity != SeverityMedium {
+		t.Errorf("CheckPublicLoadBalancers()[1] = %+v, want medium finding for elb", findings[1])
+	}
+}
+
+func TestFinalizeFindings(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n1", UID: "uid-1"}}
+	findings := FinalizeFindings([]Finding{
+		{CheckID: "node-not-ready", Kind: "Node", Name: "n1", Message: "first", object: node},
+		{CheckID: "node-not-ready", Kind: "Node", Name: "n1", Message: "duplicate", object: node},
+		{CheckID: "etcd-alarm", Kind: "EtcdMember", Name: "NOSPACE on cp-1"},
+	})
+
+	if len(findings) != 2 {
+		t.Fatalf("FinalizeFindings() returned %d findings, want 2 after dedup", len(findings))
+	}
+	if findings[0].UID != "uid-1" || findings[0].Message != "first" {
+		t.Errorf("FinalizeFindings()[0] = %+v, want first finding with uid-1", findings[0])
+	}
+	for _, f := range findings {
+		if len(f.Fingerprint) != 16 {
+			t.Errorf("FinalizeFindings() fingerprint = %q, want 16 hex characters", f.Fingerprint)
+		}
+	}
+}
+
+func TestFingerprint_Stability(t *testing.T) {
+	base := Finding{CheckID: "public-loadbalancer", Kind: "Service", Namespace: "web", Name: "lb", UID: "uid-1", keyFields: []string{"203.0.113.10"}}
+
+	reworded := base
+	reworded.Message = "a different message"
+	if base.fingerprint() != reworded.fingerprint() {
+		t.Errorf("fingerprint() changed when only the message changed")
+	}
+
+	otherAddress := base
+	otherAddress.keyFields = []string{"203.0.113.11"}
+	if base.fingerprint() == otherAddress.fingerprint() {
+		t.Errorf("fingerprint() is the same for different key fields")
+	}
+
+	recreated := base
+	recreated.UID = "uid-2"
+	if base.fingerprint() == recreated.fingerprint() {
+		t.Errorf("fingerprint() is the same for a recreated object with a new UID")
+	}
+}
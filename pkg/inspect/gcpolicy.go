@@ -0,0 +1,158 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// defaultRevisionHistoryLimit is the apps/v1 default for Deployment.spec.revisionHistoryLimit.
+	defaultRevisionHistoryLimit = 10
+	// suggestedRevisionHistoryLimit is enough old ReplicaSets to roll back a few releases.
+	suggestedRevisionHistoryLimit = 3
+	// busyDeploymentRevisions is the revision count above which a Deployment rolls out often
+	// enough that it always sits at its history limit.
+	busyDeploymentRevisions = 20
+)
+
+// GetGCPolicyFindings flags Jobs, CronJobs, and Deployments whose cleanup settings leave
+// finished Jobs, their pods, or old ReplicaSets behind.
+func GetGCPolicyFindings(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) ([]Finding, error) {
+	jobs, err := listJobs(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	cronJobs, err := listCronJobs(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cronjobs: %w", err)
+	}
+	deployments, err := listDeployments(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	replicaSets, err := listReplicaSets(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	pods, err := listPods(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return CheckGCPolicy(jobs, cronJobs, deployments, replicaSets, pods, time.Now()), nil
+}
+
+// CheckGCPolicy raises findings for finished Jobs that have no ttlSecondsAfterFinished and are
+// not cleaned up by a CronJob, for CronJobs that leave their history limits unset, and for
+// frequently rolled-out Deployments that keep the default revisionHistoryLimit. Each message
+// counts the objects the setting currently keeps around.
+func CheckGCPolicy(jobs []batchv1.Job, cronJobs []batchv1.CronJob, deployments []appsv1.Deployment, replicaSets []appsv1.ReplicaSet, pods []corev1.Pod, now time.Time) []Finding {
+	var findings []Finding
+
+	podsByOwner := map[string]int{}
+	for i := range pods {
+		if ref := metav1.GetControllerOf(&pods[i]); ref != nil {
+			podsByOwner[pods[i].Namespace+"/"+ref.Kind+"/"+ref.Name]++
+		}
+	}
+
+	type history struct{ jobs, pods int }
+	cronHistory := map[string]history{}
+	for i, job := range jobs {
+		finishedAt, finished := jobFinishedAt(job)
+		if !finished {
+			continue
+		}
+		jobPods := podsByOwner[job.Namespace+"/Job/"+job.Name]
+		if ref := metav1.GetControllerOf(&jobs[i]); ref != nil && ref.Kind == "CronJob" {
+			key := job.Namespace + "/" + ref.Name
+			h := cronHistory[key]
+			cronHistory[key] = history{h.jobs + 1, h.pods + jobPods}
+			continue
+		}
+		if job.Spec.TTLSecondsAfterFinished != nil {
+			continue
+		}
+		findings = append(findings, Finding{
+			CheckID:   "job-no-ttl",
+			Severity:  SeverityLow,
+			Kind:      "Job",
+			Namespace: job.Namespace,
+			Name:      job.Name,
+			Message: fmt.Sprintf("Job %s/%s finished %s ago and has no ttlSecondsAfterFinished, so it and its %d pod(s) are kept until deleted by hand",
+				job.Namespace, job.Name, formatAge(now.Sub(finishedAt)), jobPods),
+			object: &jobs[i],
+		})
+	}
+
+	for i, cj := range cronJobs {
+		if cj.Spec.SuccessfulJobsHistoryLimit != nil && cj.Spec.FailedJobsHistoryLimit != nil {
+			continue
+		}
+		h := cronHistory[cj.Namespace+"/"+cj.Name]
+		findings = append(findings, Finding{
+			CheckID:   "cronjob-history-limits-unset",
+			Severity:  SeverityLow,
+			Kind:      "CronJob",
+			Namespace: cj.Namespace,
+			Name:      cj.Name,
+			Message: fmt.Sprintf("CronJob %s/%s leaves successfulJobsHistoryLimit or failedJobsHistoryLimit unset and relies on the defaults (3 and 1); it currently keeps %d finished Job(s) and %d pod(s)",
+				cj.Namespace, cj.Name, h.jobs, h.pods),
+			object: &cronJobs[i],
+		})
+	}
+
+	oldReplicaSets := map[string]int{}
+	for i, rs := range replicaSets {
+		ref := metav1.GetControllerOf(&replicaSets[i])
+		if ref == nil || ref.Kind != "Deployment" || replicasOrDefault(rs.Spec.Replicas) != 0 {
+			continue
+		}
+		oldReplicaSets[rs.Namespace+"/"+ref.Name]++
+	}
+	for i, d := range deployments {
+		if d.Spec.RevisionHistoryLimit != nil && *d.Spec.RevisionHistoryLimit != defaultRevisionHistoryLimit {
+			continue
+		}
+		revision, err := strconv.Atoi(d.Annotations["deployment.kubernetes.io/revision"])
+		if err != nil || revision < busyDeploymentRevisions {
+			continue
+		}
+		old := oldReplicaSets[d.Namespace+"/"+d.Name]
+		message := fmt.Sprintf("Deployment %s/%s has rolled out %d revisions and keeps the default revisionHistoryLimit of %d, retaining %d old ReplicaSet(s)",
+			d.Namespace, d.Name, revision, defaultRevisionHistoryLimit, old)
+		if old > suggestedRevisionHistoryLimit {
+			message += fmt.Sprintf("; a limit of %d would drop %d", suggestedRevisionHistoryLimit, old-suggestedRevisionHistoryLimit)
+		}
+		findings = append(findings, Finding{
+			CheckID:   "deployment-default-revision-history",
+			Severity:  SeverityInfo,
+			Kind:      "Deployment",
+			Namespace: d.Namespace,
+			Name:      d.Name,
+			Message:   message,
+			object:    &deployments[i],
+		})
+	}
+	return findings
+}
+
+// jobFinishedAt returns when a Job completed or failed.
+func jobFinishedAt(job batchv1.Job) (time.Time, bool) {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			if job.Status.CompletionTime != nil {
+				return job.Status.CompletionTime.Time, true
+			}
+			return c.LastTransitionTime.Time, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,83 @@
+package inspect
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckGCPolicy(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	finished := func(name string, owners []metav1.OwnerReference, ttl *int32) batchv1.Job {
+		completed := metav1.NewTime(now.Add(-3 * time.Hour))
+		return batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "batch", Name: name, OwnerReferences: owners},
+			Spec:       batchv1.JobSpec{TTLSecondsAfterFinished: ttl},
+			Status: batchv1.JobStatus{
+				CompletionTime: &completed,
+				Conditions:     []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+	jobPod := func(job string) corev1.Pod {
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "batch", Name: job + "-pod", OwnerReferences: controllerRef("Job", job)}}
+	}
+	ttl := int32(600)
+	jobs := []batchv1.Job{
+		finished("migrate", nil, nil),
+		finished("cleaned", nil, &ttl),
+		finished("nightly-1", controllerRef("CronJob", "nightly"), nil),
+		finished("nightly-2", controllerRef("CronJob", "nightly"), nil),
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "batch", Name: "running"}},
+	}
+	three := int32(3)
+	cronJobs := []batchv1.CronJob{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "batch", Name: "nightly"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "batch", Name: "tidy"}, Spec: batchv1.CronJobSpec{SuccessfulJobsHistoryLimit: &three, FailedJobsHistoryLimit: &three}},
+	}
+
+	busy := testDeployment("api", 2, corev1.PodSpec{})
+	busy.Annotations = map[string]string{"deployment.kubernetes.io/revision": "42"}
+	quiet := testDeployment("docs", 2, corev1.PodSpec{})
+	quiet.Annotations = map[string]string{"deployment.kubernetes.io/revision": "4"}
+	tuned := testDeployment("worker", 2, corev1.PodSpec{})
+	tuned.Annotations = map[string]string{"deployment.kubernetes.io/revision": "90"}
+	tuned.Spec.RevisionHistoryLimit = &three
+
+	zero := int32(0)
+	var replicaSets []appsv1.ReplicaSet
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		replicaSets = append(replicaSets, appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "api-" + name, OwnerReferences: controllerRef("Deployment", "api")},
+			Spec:       appsv1.ReplicaSetSpec{Replicas: &zero},
+		})
+	}
+
+	pods := []corev1.Pod{jobPod("migrate"), jobPod("migrate"), jobPod("nightly-1"), jobPod("nightly-2")}
+
+	got := map[string]string{}
+	for _, f := range CheckGCPolicy(jobs, cronJobs, []appsv1.Deployment{busy, quiet, tuned}, replicaSets, pods, now) {
+		got[f.CheckID+" "+f.Name] = f.Message
+	}
+	want := map[string]string{
+		"job-no-ttl migrate":                      "finished 3h ago",
+		"cronjob-history-limits-unset nightly":    "keeps 2 finished Job(s) and 2 pod(s)",
+		"deployment-default-revision-history api": "retaining 10 old ReplicaSet(s); a limit of 3 would drop 7",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got findings %v, want %d", got, len(want))
+	}
+	for key, fragment := range want {
+		if !strings.Contains(got[key], fragment) {
+			t.Errorf("%s: message %q does not contain %q", key, got[key], fragment)
+		}
+	}
+	if !strings.Contains(got["job-no-ttl migrate"], "its 2 pod(s)") {
+		t.Errorf("job-no-ttl message %q does not count pods", got["job-no-ttl migrate"])
+	}
+}
@@ -0,0 +1,346 @@
+package inspect
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// Helm 3 release statuses.
+const (
+	HelmStatusDeployed        = "deployed"
+	HelmStatusFailed          = "failed"
+	HelmStatusUninstalled     = "uninstalled"
+	HelmStatusUninstalling    = "uninstalling"
+	HelmStatusPendingInstall  = "pending-install"
+	HelmStatusPendingUpgrade  = "pending-upgrade"
+	HelmStatusPendingRollback = "pending-rollback"
+)
+
+// helmPendingGrace is how long a release may stay pending before it is flagged, so that an
+// install or upgrade still in progress is not reported as stuck.
+const helmPendingGrace = 15 * time.Minute
+
+// helmReleaseSecretType is the type of the Secrets Helm 3 stores release records in.
+const helmReleaseSecretType = "helm.sh/release.v1"
+
+// helmRecord is the part of a Helm 3 release record kube-op reads. The record also holds the
+// chart's templates and the release's values, which are never kept.
+type helmRecord struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Manifest  string `json:"manifest"`
+	Info      struct {
+		Status        string    `json:"status"`
+		Description   string    `json:"description"`
+		FirstDeployed time.Time `json:"first_deployed"`
+		LastDeployed  time.Time `json:"last_deployed"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+	} `json:"chart"`
+}
+
+// decodeHelmRecord decodes a Helm 3 release record, which is base64-encoded, gzipped JSON.
+func decodeHelmRecord(data []byte) (*helmRecord, error) {
+	compressed, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode helm release: %w", err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress helm release: %w", err)
+	}
+	defer gz.Close()
+	var record helmRecord
+	if err := json.NewDecoder(gz).Decode(&record); err != nil {
+		return nil, fmt.Errorf("failed to parse helm release: %w", err)
+	}
+	return &record, nil
+}
+
+// HelmReport is the inventory of the Helm 3 releases in the cluster.
+type HelmReport struct {
+	Releases []HelmRelease `json:"releases"`
+	Findings []Finding     `json:"findings"`
+}
+
+// HelmRelease is the latest revision of one Helm release.
+type HelmRelease struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Revision  int    `json:"revision"`
+	// Revisions is how many revisions Helm still keeps in its history.
+	Revisions     int       `json:"revisions"`
+	Status        string    `json:"status"`
+	Description   string    `json:"description,omitempty"`
+	Chart         string    `json:"chart,omitempty"`
+	ChartVersion  string    `json:"chartVersion,omitempty"`
+	AppVersion    string    `json:"appVersion,omitempty"`
+	FirstDeployed time.Time `json:"firstDeployed"`
+	LastDeployed  time.Time `json:"lastDeployed"`
+	// Namespaces are the namespaces the release's objects are in, besides its own; charts can
+	// set metadata.namespace on the objects they render.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// LatestChartVersion is the newest stable version of the chart in the repository indexes.
+	LatestChartVersion string `json:"latestChartVersion,omitempty"`
+	// ChartPublished is when the deployed chart version was published, from the repository indexes.
+	ChartPublished *time.Time `json:"chartPublished,omitempty"`
+	// Error is set when the release record could not be decoded; only the fields Helm keeps in
+	// the Secret's labels are filled in then.
+	Error string `json:"error,omitempty"`
+}
+
+// Pending reports whether the release is stuck mid-operation, which makes Helm refuse to
+// upgrade it.
+func (r HelmRelease) Pending() bool {
+	switch r.Status {
+	case HelmStatusPendingInstall, HelmStatusPendingUpgrade, HelmStatusPendingRollback, HelmStatusUninstalling:
+		return true
+	}
+	return false
+}
+
+// HelmChartVersion is one published version of a chart in a repository index.
+type HelmChartVersion struct {
+	Version string    `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+// HelmChartIndex maps chart names to their published versions.
+type HelmChartIndex map[string][]HelmChartVersion
+
+// LoadHelmRepoIndexes reads Helm repository index.yaml files, as fetched by helm repo update
+// or served at a repository's /index.yaml, and merges their charts.
+func LoadHelmRepoIndexes(files []string) (HelmChartIndex, error) {
+	index := HelmChartIndex{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read helm repository index: %w", err)
+		}
+		var parsed struct {
+			Entries map[string][]HelmChartVersion `json:"entries"`
+		}
+		if err := yaml.Unmarshal(data, &parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse helm repository index %s: %w", file, err)
+		}
+		for chart, versions := range parsed.Entries {
+			index[chart] = append(index[chart], versions...)
+		}
+	}
+	return index, nil
+}
+
+// HelmCheckOptions controls which Helm releases are flagged.
+type HelmCheckOptions struct {
+	// Index holds the published chart versions to compare deployed charts with.
+	Index HelmChartIndex
+	// MaxChartAge is how old the deployed chart version may be before it is flagged. Without
+	// the chart in Index, the time since the release was last deployed is used instead.
+	MaxChartAge time.Duration
+}
+
+// GetHelmReport lists the Helm release Secrets, in namespace or in all namespaces when it is
+// empty, and checks the latest revision of every release.
+func GetHelmReport(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, check HelmCheckOptions) (*HelmReport, error) {
+	secrets, err := listSecrets(ctx, clientset, opts, namespace, metav1.ListOptions{LabelSelector: "owner=helm"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list helm release secrets: %w", err)
+	}
+	return BuildHelmReport(secrets, check, time.Now()), nil
+}
+
+// BuildHelmReport finds the latest revision of every release among the Helm storage Secrets,
+// decodes only that one, and raises findings for failed and stuck releases and old charts.
+func BuildHelmReport(secrets []corev1.Secret, check HelmCheckOptions, now time.Time) *HelmReport {
+	type history struct {
+		latest    *corev1.Secret
+		revisions int
+	}
+	releases := map[string]*history{}
+	for i := range secrets {
+		s := &secrets[i]
+		if s.Type != helmReleaseSecretType || s.Labels["name"] == "" {
+			continue
+		}
+		key := s.Namespace + "/" + s.Labels["name"]
+		h := releases[key]
+		if h == nil {
+			h = &history{}
+			releases[key] = h
+		}
+		h.revisions++
+		if h.latest == nil || helmRevision(s.Labels["version"]) > helmRevision(h.latest.Labels["version"]) {
+			h.latest = s
+		}
+	}
+
+	report := &HelmReport{}
+	for _, h := range releases {
+		s := h.latest
+		r := HelmRelease{
+			Namespace: s.Namespace,
+			Name:      s.Labels["name"],
+			Revision:  helmRevision(s.Labels["version"]),
+			Revisions: h.revisions,
+			Status:    s.Labels["status"],
+		}
+		if record, err := decodeHelmRecord(s.Data["release"]); err != nil {
+			r.Error = err.Error()
+		} else {
+			r.Status = record.Info.Status
+			r.Description = record.Info.Description
+			r.Chart = record.Chart.Metadata.Name
+			r.ChartVersion = record.Chart.Metadata.Version
+			r.AppVersion = record.Chart.Metadata.AppVersion
+			r.FirstDeployed = record.Info.FirstDeployed
+			r.LastDeployed = record.Info.LastDeployed
+			r.Namespaces = manifestNamespaces(record.Manifest, s.Namespace)
+		}
+		r.LatestChartVersion, r.ChartPublished = check.Index.lookup(r.Chart, r.ChartVersion)
+		report.Releases = append(report.Releases, r)
+	}
+	sort.Slice(report.Releases, func(i, j int) bool {
+		a, b := report.Releases[i], report.Releases[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	report.Findings = FinalizeFindings(CheckHelmReleases(report.Releases, check.MaxChartAge, now))
+	sortFindings(report.Findings)
+	return report
+}
+
+// manifestNamespaces returns the namespaces other than its own that a release's rendered
+// objects name. Documents that do not parse are skipped.
+func manifestNamespaces(manifest, namespace string) []string {
+	objects, _ := ParseManifests([]byte(manifest))
+	var namespaces []string
+	for _, obj := range objects {
+		if ns := obj.GetNamespace(); ns != "" && ns != namespace && !slices.Contains(namespaces, ns) {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// lookup returns the newest stable version of a chart and when the given version was published.
+func (index HelmChartIndex) lookup(chart, deployed string) (latest string, published *time.Time) {
+	var newest *version.Version
+	for _, v := range index[chart] {
+		if v.Version == deployed && !v.Created.IsZero() {
+			created := v.Created
+			published = &created
+		}
+		parsed, err := version.Parse(v.Version)
+		if err != nil || parsed.PreRelease() != "" {
+			continue
+		}
+		if newest == nil || newest.LessThan(parsed) {
+			newest, latest = parsed, v.Version
+		}
+	}
+	return latest, published
+}
+
+// CheckHelmReleases flags failed releases, releases stuck pending for longer than an operation
+// takes, and releases built from old chart versions. A chart version is old when a repository
+// index shows it was published more than maxChartAge ago and a newer one exists; charts not
+// in an index are judged by when the release was last deployed, as a lower bound on its age.
+func CheckHelmReleases(releases []HelmRelease, maxChartAge time.Duration, now time.Time) []Finding {
+	var findings []Finding
+	for _, r := range releases {
+		f := Finding{Kind: "HelmRelease", Namespace: r.Namespace, Name: r.Name}
+		chart := r.Chart + "-" + r.ChartVersion
+		switch {
+		case r.Status == HelmStatusFailed:
+			f.CheckID, f.Severity = "helm-release-failed", SeverityHigh
+			f.Message = fmt.Sprintf("Helm release %s/%s revision %d failed: %s", r.Namespace, r.Name, r.Revision, r.Description)
+			findings = append(findings, f)
+		case r.Pending() && now.Sub(r.LastDeployed) > helmPendingGrace:
+			f.CheckID, f.Severity = "helm-release-pending", SeverityMedium
+			f.Message = fmt.Sprintf("Helm release %s/%s has been %s since %s; Helm refuses further upgrades until it is rolled back",
+				r.Namespace, r.Name, r.Status, r.LastDeployed.UTC().Format(time.RFC3339))
+			findings = append(findings, f)
+		}
+
+		if maxChartAge <= 0 || r.Status == HelmStatusUninstalled || r.Chart == "" {
+			continue
+		}
+		f = Finding{Kind: "HelmRelease", Namespace: r.Namespace, Name: r.Name, CheckID: "helm-chart-outdated"}
+		switch {
+		case r.ChartPublished != nil:
+			if now.Sub(*r.ChartPublished) <= maxChartAge || r.LatestChartVersion == "" || r.LatestChartVersion == r.ChartVersion {
+				continue
+			}
+			f.Severity = SeverityMedium
+			f.Message = fmt.Sprintf("Helm release %s/%s runs chart %s, published %s, %d day(s) ago; the latest version is %s",
+				r.Namespace, r.Name, chart, r.ChartPublished.UTC().Format(time.DateOnly), int(now.Sub(*r.ChartPublished).Hours()/24), r.LatestChartVersion)
+		case !r.LastDeployed.IsZero() && now.Sub(r.LastDeployed) > maxChartAge:
+			f.Severity = SeverityLow
+			f.Message = fmt.Sprintf("Helm release %s/%s runs chart %s and has not been upgraded in %d day(s)",
+				r.Namespace, r.Name, chart, int(now.Sub(r.LastDeployed).Hours()/24))
+		default:
+			continue
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// PrintHelmReport writes the release inventory and its findings as text.
+func PrintHelmReport(w io.Writer, report *HelmReport) {
+	fmt.Fprintf(w, "Helm releases (%d):\n", len(report.Releases))
+	if len(report.Releases) == 0 {
+		fmt.Fprintln(w, "  No Helm 3 release Secrets found.")
+	}
+	for _, r := range report.Releases {
+		if r.Error != "" {
+			fmt.Fprintf(w, "  - %s/%s revision %d, %s: unreadable (%s)\n", r.Namespace, r.Name, r.Revision, r.Status, r.Error)
+			continue
+		}
+		line := fmt.Sprintf("  - %s/%s: chart %s-%s", r.Namespace, r.Name, r.Chart, r.ChartVersion)
+		if r.AppVersion != "" {
+			line += " (app " + r.AppVersion + ")"
+		}
+		line += fmt.Sprintf(", revision %d, %s, last deployed %s", r.Revision, r.Status, r.LastDeployed.UTC().Format(time.DateOnly))
+		if r.LatestChartVersion != "" && r.LatestChartVersion != r.ChartVersion {
+			line += ", latest chart " + r.LatestChartVersion
+		}
+		fmt.Fprintln(w, line)
+		if len(r.Namespaces) > 0 {
+			fmt.Fprintf(w, "      Also deploys to: %s\n", strings.Join(r.Namespaces, ", "))
+		}
+	}
+	fmt.Fprintln(w, "Findings:")
+	if len(report.Findings) == 0 {
+		fmt.Fprintln(w, "  No findings.")
+	}
+	for _, f := range report.Findings {
+		fmt.Fprintf(w, "  - [%s] %s: %s (%s)\n", f.Severity, f.CheckID, f.Message, f.Fingerprint)
+	}
+}
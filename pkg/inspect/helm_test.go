@@ -0,0 +1,163 @@
+package inspect
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// helmSecret encodes a release record the way Helm 3 stores it.
+func helmSecret(t *testing.T, namespace, name string, revision int, status, chart, chartVersion string, lastDeployed time.Time, manifest string) corev1.Secret {
+	t.Helper()
+	record := map[string]any{
+		"name": name, "namespace": namespace, "version": revision, "manifest": manifest,
+		"info": map[string]any{
+			"status": status, "description": "Upgrade complete",
+			"first_deployed": lastDeployed.Add(-24 * time.Hour), "last_deployed": lastDeployed,
+		},
+		"chart": map[string]any{
+			"metadata":  map[string]any{"name": chart, "version": chartVersion, "appVersion": "1.0.0"},
+			"templates": []any{map[string]any{"name": "templates/deployment.yaml", "data": "..."}},
+		},
+		"config": map[string]any{"password": "hunter2"},
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(data)
+	gz.Close()
+	return corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      "sh.helm.release.v1." + name + ".v" + strconv.Itoa(revision),
+			Labels:    map[string]string{"owner": "helm", "name": name, "version": strconv.Itoa(revision), "status": status},
+		},
+		Type: helmReleaseSecretType,
+		Data: map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(compressed.Bytes()))},
+	}
+}
+
+func TestBuildHelmReport(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	published := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	index := HelmChartIndex{"ingress-nginx": {
+		{Version: "4.10.0", Created: published},
+		{Version: "4.13.2", Created: now.Add(-30 * 24 * time.Hour)},
+		{Version: "4.14.0-beta.1", Created: now.Add(-24 * time.Hour)},
+	}}
+	crds := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  namespace: monitoring\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n"
+
+	superseded := helmSecret(t, "ingress", "edge", 2, "superseded", "ingress-nginx", "4.9.0", now.Add(-600*24*time.Hour), "")
+	corrupt := helmSecret(t, "web", "broken", 1, HelmStatusDeployed, "web", "1.0.0", now, "")
+	corrupt.Data["release"] = []byte("not base64!")
+	secrets := []corev1.Secret{
+		superseded,
+		helmSecret(t, "ingress", "edge", 3, HelmStatusDeployed, "ingress-nginx", "4.10.0", now.Add(-400*24*time.Hour), crds),
+		helmSecret(t, "shop", "api", 7, HelmStatusFailed, "api", "2.1.0", now.Add(-time.Hour), ""),
+		helmSecret(t, "shop", "worker", 2, HelmStatusPendingUpgrade, "worker", "0.3.0", now.Add(-2*time.Hour), ""),
+		// An upgrade still in progress is not stuck yet.
+		helmSecret(t, "shop", "cron", 4, HelmStatusPendingUpgrade, "cron", "0.3.0", now.Add(-time.Minute), ""),
+		helmSecret(t, "legacy", "old", 1, HelmStatusDeployed, "old", "0.1.0", now.Add(-800*24*time.Hour), ""),
+		corrupt,
+		// Not a Helm release record.
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "db", Labels: map[string]string{"owner": "helm", "name": "db"}}, Type: corev1.SecretTypeOpaque},
+	}
+
+	report := BuildHelmReport(secrets, HelmCheckOptions{Index: index, MaxChartAge: 365 * 24 * time.Hour}, now)
+	var names []string
+	for _, r := range report.Releases {
+		names = append(names, r.Namespace+"/"+r.Name)
+	}
+	if want := []string{"ingress/edge", "legacy/old", "shop/api", "shop/cron", "shop/worker", "web/broken"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("releases = %v, want %v", names, want)
+	}
+
+	edge := report.Releases[0]
+	if edge.Revision != 3 || edge.Revisions != 2 || edge.ChartVersion != "4.10.0" || edge.AppVersion != "1.0.0" || edge.Status != HelmStatusDeployed {
+		t.Errorf("edge = %+v, want revision 3 of 2 with chart 4.10.0", edge)
+	}
+	if edge.LatestChartVersion != "4.13.2" || edge.ChartPublished == nil || !edge.ChartPublished.Equal(published) {
+		t.Errorf("edge latest = %q published %v, want the latest stable 4.13.2 and 4.10.0's publish date", edge.LatestChartVersion, edge.ChartPublished)
+	}
+	if !reflect.DeepEqual(edge.Namespaces, []string{"monitoring"}) {
+		t.Errorf("edge namespaces = %v, want [monitoring]", edge.Namespaces)
+	}
+	if broken := report.Releases[5]; broken.Error == "" || broken.Status != HelmStatusDeployed || broken.Revision != 1 {
+		t.Errorf("broken = %+v, want the labels kept with a decode error", broken)
+	}
+
+	var got []string
+	for _, f := range report.Findings {
+		got = append(got, f.CheckID+" "+f.Namespace+"/"+f.Name+" "+string(f.Severity))
+	}
+	want := []string{
+		"helm-release-failed shop/api high",
+		"helm-chart-outdated ingress/edge medium",
+		"helm-release-pending shop/worker medium",
+		"helm-chart-outdated legacy/old low",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+
+	var b bytes.Buffer
+	PrintHelmReport(&b, report)
+	for _, want := range []string{
+		"Helm releases (6):",
+		"- ingress/edge: chart ingress-nginx-4.10.0 (app 1.0.0), revision 3, deployed, last deployed 2025-08-27, latest chart 4.13.2",
+		"Also deploys to: monitoring",
+		"- web/broken revision 1, deployed: unreadable",
+		"[high] helm-release-failed: Helm release shop/api revision 7 failed: Upgrade complete",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "hunter2") {
+		t.Errorf("output contains release values:\n%s", b.String())
+	}
+}
+
+func TestLoadHelmRepoIndexes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.yaml")
+	index := `apiVersion: v1
+entries:
+  ingress-nginx:
+  - version: 4.13.2
+    appVersion: 1.13.2
+    created: "2025-09-01T10:00:00.000000000Z"
+    digest: abc
+  - version: 4.10.0
+    created: "2024-05-01T00:00:00Z"
+generated: "2025-09-02T00:00:00Z"
+`
+	if err := os.WriteFile(path, []byte(index), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadHelmRepoIndexes([]string{path})
+	if err != nil {
+		t.Fatalf("LoadHelmRepoIndexes() returned error = %v", err)
+	}
+	latest, published := loaded.lookup("ingress-nginx", "4.10.0")
+	if latest != "4.13.2" || published == nil || published.Year() != 2024 {
+		t.Errorf("lookup() = %q, %v, want 4.13.2 and 2024", latest, published)
+	}
+	if _, err := LoadHelmRepoIndexes([]string{filepath.Join(dir, "missing.yaml")}); err == nil {
+		t.Error("LoadHelmRepoIndexes() of a missing file returned error = nil, want non-nil")
+	}
+}
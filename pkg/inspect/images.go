@@ -0,0 +1,211 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// criticalReplicaThreshold is the number of pods sharing an image above which a new version of
+// it is treated as a registry-heavy rollout.
+const criticalReplicaThreshold = 10
+
+// Reasons an image is considered critical.
+const (
+	CriticalDaemonSet   = "daemonset"
+	CriticalKubeSystem  = "kube-system"
+	CriticalManyReplica = "many-replicas"
+)
+
+// ImageSpreadReport shows how widely critical images are cached across nodes.
+type ImageSpreadReport struct {
+	TotalNodes int           `json:"totalNodes"`
+	Images     []ImageSpread `json:"images"`
+	// ColdPullNodes are nodes missing at least one critical image from their cache.
+	ColdPullNodes []NodeColdPull `json:"coldPullNodes,omitempty"`
+}
+
+// ImageSpread is one critical image and the nodes that run or cache it.
+type ImageSpread struct {
+	Image        string   `json:"image"`
+	SizeBytes    int64    `json:"sizeBytes"`
+	Pods         int      `json:"pods"`
+	NodesRunning int      `json:"nodesRunning"`
+	NodesCached  int      `json:"nodesCached"`
+	Reasons      []string `json:"reasons"`
+}
+
+// RolloutPullBytes estimates the registry traffic of rolling out a new version of the image:
+// every node currently running it pulls the new layers.
+func (i ImageSpread) RolloutPullBytes() int64 {
+	return int64(i.NodesRunning) * i.SizeBytes
+}
+
+// NodeColdPull lists the critical images a node would have to pull from scratch.
+type NodeColdPull struct {
+	Node          string   `json:"node"`
+	MissingImages []string `json:"missingImages"`
+	MissingBytes  int64    `json:"missingBytes"`
+}
+
+// GetImageSpread reports, for every critical image, how many nodes already cache it and which
+// nodes would cold-pull critical images when they're next rescheduled or replaced. Images run by
+// DaemonSets or in kube-system, and images shared by many pods, are critical. Kubelets only
+// report their 50 largest images by default, so small images may be under-counted.
+func GetImageSpread(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*ImageSpreadReport, error) {
+	nodes, err := listNodes(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	pods, err := listPods(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return BuildImageSpread(nodes, pods), nil
+}
+
+// BuildImageSpread computes the image spread report from nodes and the pods running on them.
+func BuildImageSpread(nodes []corev1.Node, pods []corev1.Pod) *ImageSpreadReport {
+	type usage struct {
+		pods    int
+		nodes   map[string]struct{}
+		reasons map[string]struct{}
+	}
+	images := map[string]*usage{}
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, c := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+			image := normalizeImage(c.Image)
+			u, ok := images[image]
+			if !ok {
+				u = &usage{nodes: map[string]struct{}{}, reasons: map[string]struct{}{}}
+				images[image] = u
+			}
+			u.pods++
+			if pod.Spec.NodeName != "" {
+				u.nodes[pod.Spec.NodeName] = struct{}{}
+			}
+			if pod.Namespace == "kube-system" {
+				u.reasons[CriticalKubeSystem] = struct{}{}
+			}
+			if owner := metav1.GetControllerOf(&pod); owner != nil && owner.Kind == "DaemonSet" {
+				u.reasons[CriticalDaemonSet] = struct{}{}
+			}
+		}
+	}
+
+	// Index every name each node caches its images under, along with the image size.
+	cached := make(map[string]map[string]int64, len(nodes))
+	for _, node := range nodes {
+		names := map[string]int64{}
+		for _, img := range node.Status.Images {
+			for _, name := range img.Names {
+				names[normalizeImage(name)] = img.SizeBytes
+			}
+		}
+		cached[node.Name] = names
+	}
+
+	report := &ImageSpreadReport{TotalNodes: len(nodes)}
+	var critical []string
+	for image, u := range images {
+		if u.pods >= criticalReplicaThreshold {
+			u.reasons[CriticalManyReplica] = struct{}{}
+		}
+		if len(u.reasons) == 0 {
+			continue
+		}
+		critical = append(critical, image)
+
+		spread := ImageSpread{Image: image, Pods: u.pods, NodesRunning: len(u.nodes)}
+		for _, names := range cached {
+			if size, ok := names[image]; ok {
+				spread.NodesCached++
+				spread.SizeBytes = size
+			}
+		}
+		for reason := range u.reasons {
+			spread.Reasons = append(spread.Reasons, reason)
+		}
+		sort.Strings(spread.Reasons)
+		report.Images = append(report.Images, spread)
+	}
+	sort.Slice(report.Images, func(i, j int) bool {
+		a, b := report.Images[i], report.Images[j]
+		if a.RolloutPullBytes() != b.RolloutPullBytes() {
+			return a.RolloutPullBytes() > b.RolloutPullBytes()
+		}
+		return a.Image < b.Image
+	})
+
+	sizes := make(map[string]int64, len(report.Images))
+	for _, img := range report.Images {
+		sizes[img.Image] = img.SizeBytes
+	}
+	sort.Strings(critical)
+	for _, node := range nodes {
+		coldPull := NodeColdPull{Node: node.Name}
+		for _, image := range critical {
+			if _, ok := cached[node.Name][image]; ok {
+				continue
+			}
+			coldPull.MissingImages = append(coldPull.MissingImages, image)
+			coldPull.MissingBytes += sizes[image]
+		}
+		if len(coldPull.MissingImages) > 0 {
+			report.ColdPullNodes = append(report.ColdPullNodes, coldPull)
+		}
+	}
+	return report
+}
+
+// normalizeImage expands an image reference to the fully qualified form kubelets report in
+// node status, so "nginx" and "docker.io/library/nginx:latest" compare equal.
+func normalizeImage(ref string) string {
+	name, digest, hasDigest := strings.Cut(ref, "@")
+
+	// A colon after the last slash separates the tag; one before it belongs to a registry port.
+	if !hasDigest && !strings.Contains(name[strings.LastIndex(name, "/")+1:], ":") {
+		name += ":latest"
+	}
+
+	first, _, hasSlash := strings.Cut(name, "/")
+	switch {
+	case !hasSlash:
+		name = "docker.io/library/" + name
+	case !strings.ContainsAny(first, ".:") && first != "localhost":
+		name = "docker.io/" + name
+	}
+
+	if hasDigest {
+		return name + "@" + digest
+	}
+	return name
+}
+
+// PrintImageSpread writes the image spread section of the text report.
+func PrintImageSpread(w io.Writer, r *ImageSpreadReport) {
+	fmt.Fprintln(w, "Critical image spread:")
+	if len(r.Images) == 0 {
+		fmt.Fprintln(w, "  No critical images found.")
+	}
+	for _, img := range r.Images {
+		fmt.Fprintf(w, "  - %s (%s): cached on %d/%d nodes, %d pod(s) on %d node(s), rollout pulls ~%s [%s]\n",
+			img.Image, formatBytes(img.SizeBytes), img.NodesCached, r.TotalNodes, img.Pods, img.NodesRunning,
+			formatBytes(img.RolloutPullBytes()), strings.Join(img.Reasons, ", "))
+	}
+	if len(r.ColdPullNodes) > 0 {
+		fmt.Fprintln(w, "Nodes that would cold-pull critical images:")
+		for _, n := range r.ColdPullNodes {
+			fmt.Fprintf(w, "  - %s: %d image(s), ~%s\n", n.Node, len(n.MissingImages), formatBytes(n.MissingBytes))
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package inspect
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNormalizeImage(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                                 "docker.io/library/nginx:latest",
+		"nginx:1.27":                            "docker.io/library/nginx:1.27",
+		"bitnami/redis:7":                       "docker.io/bitnami/redis:7",
+		"registry.k8s.io/kube-proxy:v1.30.1":    "registry.k8s.io/kube-proxy:v1.30.1",
+		"localhost:5000/app":                    "localhost:5000/app:latest",
+		"quay.io/cilium/cilium@sha256:abc":      "quay.io/cilium/cilium@sha256:abc",
+		"docker.io/library/nginx@sha256:abc123": "docker.io/library/nginx@sha256:abc123",
+	}
+	for in, want := range tests {
+		if got := normalizeImage(in); got != want {
+			t.Errorf("normalizeImage(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBuildImageSpread(t *testing.T) {
+	node := func(name string, images ...string) corev1.Node {
+		n := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		for _, img := range images {
+			n.Status.Images = append(n.Status.Images, corev1.ContainerImage{Names: []string{img}, SizeBytes: 100 << 20})
+		}
+		return n
+	}
+	controller := true
+	pod := func(namespace, nodeName, image, ownerKind string) corev1.Pod {
+		p := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: nodeName + "-" + image},
+			Spec:       corev1.PodSpec{NodeName: nodeName, Containers: []corev1.Container{{Image: image}}},
+		}
+		if ownerKind != "" {
+			p.OwnerReferences = []metav1.OwnerReference{{Kind: ownerKind, Name: "owner", Controller: &controller}}
+		}
+		return p
+	}
+
+	nodes := []corev1.Node{
+		node("n1", "quay.io/cilium/cilium:v1.16.0", "docker.io/library/nginx:1.27"),
+		node("n2", "quay.io/cilium/cilium:v1.16.0"),
+		node("n3"),
+	}
+	pods := []corev1.Pod{
+		pod("kube-system", "n1", "quay.io/cilium/cilium:v1.16.0", "DaemonSet"),
+		pod("kube-system", "n2", "quay.io/cilium/cilium:v1.16.0", "DaemonSet"),
+		pod("web", "n1", "nginx:1.27", "ReplicaSet"),
+	}
+
+	report := BuildImageSpread(nodes, pods)
+	if len(report.Images) != 1 {
+		t.Fatalf("BuildImageSpread() returned %d critical images, want 1 (cilium)", len(report.Images))
+	}
+	cilium := report.Images[0]
+	if cilium.NodesCached != 2 || cilium.NodesRunning != 2 || cilium.Pods != 2 {
+		t.Errorf("BuildImageSpread() cilium = %+v, want cached 2, running 2, pods 2", cilium)
+	}
+	if len(cilium.Reasons) != 2 || cilium.Reasons[0] != CriticalDaemonSet || cilium.Reasons[1] != CriticalKubeSystem {
+		t.Errorf("BuildImageSpread() cilium reasons = %v, want [daemonset kube-system]", cilium.Reasons)
+	}
+	if cilium.RolloutPullBytes() != 200<<20 {
+		t.Errorf("RolloutPullBytes() = %d, want %d", cilium.RolloutPullBytes(), 200<<20)
+	}
+
+	if len(report.ColdPullNodes) != 1 || report.ColdPullNodes[0].Node != "n3" || report.ColdPullNodes[0].MissingBytes != 100<<20 {
+		t.Errorf("BuildImageSpread() cold pull nodes = %+v, want only n3 missing 100 MiB", report.ColdPullNodes)
+	}
+}
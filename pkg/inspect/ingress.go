@@ -0,0 +1,329 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Ingress controllers kube-op recognizes.
+const (
+	IngressControllerNginx   = "nginx"
+	IngressControllerTraefik = "traefik"
+	IngressControllerHAProxy = "haproxy"
+	IngressControllerALB     = "alb"
+	IngressControllerContour = "contour"
+)
+
+// legacyIngressClassAnnotation is how Ingresses chose a controller before spec.ingressClassName.
+const legacyIngressClassAnnotation = "kubernetes.io/ingress.class"
+
+// ingressControllerSignatures identify each controller by the repositories of its images and
+// the spec.controller prefixes of the IngressClasses it serves.
+var ingressControllerSignatures = []struct {
+	controller  string
+	images      []string
+	classPrefix []string
+}{
+	{IngressControllerNginx, []string{"ingress-nginx/controller", "nginx/nginx-ingress", "nginx-ingress-controller"}, []string{"k8s.io/ingress-nginx", "nginx.org/"}},
+	{IngressControllerTraefik, []string{"traefik"}, []string{"traefik.io/"}},
+	{IngressControllerHAProxy, []string{"haproxytech/kubernetes-ingress", "haproxy-ingress"}, []string{"haproxy.org/", "haproxy-ingress.github.io/"}},
+	{IngressControllerALB, []string{"aws-load-balancer-controller", "aws-alb-ingress-controller"}, []string{"ingress.k8s.aws/"}},
+	{IngressControllerContour, []string{"projectcontour/contour"}, []string{"projectcontour.io/"}},
+}
+
+// IngressReport is the Ingress controllers running in the cluster, the IngressClasses they
+// serve, and the Ingresses no class serves.
+type IngressReport struct {
+	Controllers []IngressController `json:"controllers"`
+	Classes     []IngressClassInfo  `json:"classes"`
+	// Unresolved are the Ingresses that reference a class that does not exist, or none at all
+	// when there is no default class.
+	Unresolved []UnresolvedIngress `json:"unresolved,omitempty"`
+}
+
+// IngressController is a Deployment or DaemonSet running a recognized Ingress controller.
+type IngressController struct {
+	// Controller is nginx, traefik, haproxy, alb, or contour.
+	Controller string `json:"controller"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	Image      string `json:"image"`
+	// Version is the image tag, or empty when the image is pinned by digest only.
+	Version string `json:"version,omitempty"`
+	Ready   int32  `json:"ready"`
+	Desired int32  `json:"desired"`
+}
+
+// IngressClassInfo is an IngressClass and how many Ingresses use it.
+type IngressClassInfo struct {
+	Name string `json:"name"`
+	// Controller is the class's spec.controller, and ControllerType the recognized controller
+	// it names, if any.
+	Controller     string `json:"controller"`
+	ControllerType string `json:"controllerType,omitempty"`
+	Default        bool   `json:"default"`
+	Ingresses      int    `json:"ingresses"`
+
+	class *networkingv1.IngressClass
+}
+
+// UnresolvedIngress is an Ingress no IngressClass serves.
+type UnresolvedIngress struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Class is the class the Ingress asks for, from spec.ingressClassName or the legacy
+	// kubernetes.io/ingress.class annotation; empty when it names none.
+	Class string `json:"class,omitempty"`
+	// Annotation is set when Class comes from the legacy annotation.
+	Annotation bool `json:"annotation,omitempty"`
+
+	ingress *networkingv1.Ingress
+}
+
+func (u UnresolvedIngress) String() string {
+	switch {
+	case u.Class == "":
+		return fmt.Sprintf("%s/%s: no IngressClass set and no default class", u.Namespace, u.Name)
+	case u.Annotation:
+		return fmt.Sprintf("%s/%s: IngressClass %q from the %s annotation does not exist", u.Namespace, u.Name, u.Class, legacyIngressClassAnnotation)
+	default:
+		return fmt.Sprintf("%s/%s: IngressClass %q does not exist", u.Namespace, u.Name, u.Class)
+	}
+}
+
+// Defaults returns the names of the IngressClasses marked as the cluster default.
+func (r *IngressReport) Defaults() []string {
+	var defaults []string
+	for _, c := range r.Classes {
+		if c.Default {
+			defaults = append(defaults, c.Name)
+		}
+	}
+	return defaults
+}
+
+// GetIngressReport lists IngressClasses, Ingresses, Deployments, and DaemonSets and builds the
+// Ingress controller report.
+func GetIngressReport(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*IngressReport, error) {
+	classes, err := listIngressClasses(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingressclasses: %w", err)
+	}
+
+	ingresses, err := listIngresses(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+
+	deployments, err := listDeployments(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	daemonSets, err := listDaemonSets(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+
+	return BuildIngressReport(classes, ingresses, deployments, daemonSets), nil
+}
+
+// BuildIngressReport finds the Ingress controller workloads by their images, counts the
+// Ingresses of every IngressClass, and collects the Ingresses no class serves. An Ingress uses
+// spec.ingressClassName, then the legacy annotation, then the default class.
+func BuildIngressReport(classes []networkingv1.IngressClass, ingresses []networkingv1.Ingress, deployments []appsv1.Deployment, daemonSets []appsv1.DaemonSet) *IngressReport {
+	report := &IngressReport{Controllers: []IngressController{}, Classes: []IngressClassInfo{}}
+
+	for _, d := range deployments {
+		desired := int32(1)
+		if d.Spec.Replicas != nil {
+			desired = *d.Spec.Replicas
+		}
+		report.addControllers("Deployment", d.ObjectMeta, d.Spec.Template.Spec, d.Status.ReadyReplicas, desired)
+	}
+	for _, d := range daemonSets {
+		report.addControllers("DaemonSet", d.ObjectMeta, d.Spec.Template.Spec, d.Status.NumberReady, d.Status.DesiredNumberScheduled)
+	}
+	sort.Slice(report.Controllers, func(i, j int) bool {
+		a, b := report.Controllers[i], report.Controllers[j]
+		if a.Controller != b.Controller {
+			return a.Controller < b.Controller
+		}
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+
+	byName := map[string]*IngressClassInfo{}
+	for i := range classes {
+		c := &classes[i]
+		report.Classes = append(report.Classes, IngressClassInfo{
+			Name:           c.Name,
+			Controller:     c.Spec.Controller,
+			ControllerType: ingressClassController(c.Spec.Controller),
+			Default:        c.Annotations[networkingv1.AnnotationIsDefaultIngressClass] == "true",
+			class:          c,
+		})
+	}
+	sort.Slice(report.Classes, func(i, j int) bool { return report.Classes[i].Name < report.Classes[j].Name })
+	for i := range report.Classes {
+		byName[report.Classes[i].Name] = &report.Classes[i]
+	}
+	var defaultClass *IngressClassInfo
+	if defaults := report.Defaults(); len(defaults) == 1 {
+		defaultClass = byName[defaults[0]]
+	}
+
+	for i := range ingresses {
+		ing := &ingresses[i]
+		u := UnresolvedIngress{Namespace: ing.Namespace, Name: ing.Name, ingress: ing}
+		if ing.Spec.IngressClassName != nil && *ing.Spec.IngressClassName != "" {
+			u.Class = *ing.Spec.IngressClassName
+		} else if v := ing.Annotations[legacyIngressClassAnnotation]; v != "" {
+			u.Class, u.Annotation = v, true
+		}
+
+		class := defaultClass
+		if u.Class != "" {
+			class = byName[u.Class]
+		}
+		if class == nil {
+			report.Unresolved = append(report.Unresolved, u)
+			continue
+		}
+		class.Ingresses++
+	}
+	sort.Slice(report.Unresolved, func(i, j int) bool {
+		a, b := report.Unresolved[i], report.Unresolved[j]
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+	return report
+}
+
+// addControllers records every container of a workload that runs a recognized controller image.
+func (r *IngressReport) addControllers(kind string, meta metav1.ObjectMeta, spec corev1.PodSpec, ready, desired int32) {
+	for _, c := range spec.Containers {
+		repository, tag := splitImage(c.Image)
+		controller := ingressImageController(repository)
+		if controller == "" {
+			continue
+		}
+		r.Controllers = append(r.Controllers, IngressController{
+			Controller: controller, Kind: kind, Namespace: meta.Namespace, Name: meta.Name,
+			Image: c.Image, Version: tag, Ready: ready, Desired: desired,
+		})
+	}
+}
+
+// splitImage splits an image reference into its repository, without the registry, and its tag.
+func splitImage(ref string) (repository, tag string) {
+	name, _, _ := strings.Cut(ref, "@")
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i+1:]
+	}
+	if first, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		name = rest
+	}
+	return name, tag
+}
+
+func ingressImageController(repository string) string {
+	for _, sig := range ingressControllerSignatures {
+		for _, image := range sig.images {
+			if repository == image || strings.HasSuffix(repository, "/"+image) {
+				return sig.controller
+			}
+		}
+	}
+	return ""
+}
+
+func ingressClassController(controller string) string {
+	for _, sig := range ingressControllerSignatures {
+		for _, prefix := range sig.classPrefix {
+			if strings.HasPrefix(controller, prefix) {
+				return sig.controller
+			}
+		}
+	}
+	return ""
+}
+
+// CheckIngressClasses raises a finding for every Ingress no class serves, and for every default
+// IngressClass when more than one is marked default: the API server then rejects new Ingresses
+// that don't name a class.
+func CheckIngressClasses(report *IngressReport) []Finding {
+	var findings []Finding
+	for _, u := range report.Unresolved {
+		f := Finding{CheckID: "ingress-class-missing", Severity: SeverityHigh, Kind: "Ingress", Namespace: u.Namespace, Name: u.Name, object: u.ingress}
+		switch {
+		case u.Class == "":
+			f.CheckID, f.Severity = "ingress-class-unset", SeverityMedium
+			f.Message = fmt.Sprintf("Ingress %s/%s sets no IngressClass and the cluster has no single default class, so controllers may ignore it", u.Namespace, u.Name)
+		case u.Annotation:
+			f.Severity = SeverityMedium
+			f.Message = fmt.Sprintf("Ingress %s/%s asks for class %q with the deprecated %s annotation, but no IngressClass has that name", u.Namespace, u.Name, u.Class, legacyIngressClassAnnotation)
+		default:
+			f.Message = fmt.Sprintf("Ingress %s/%s references IngressClass %q, which does not exist, so no controller serves it", u.Namespace, u.Name, u.Class)
+		}
+		findings = append(findings, f)
+	}
+
+	if defaults := report.Defaults(); len(defaults) > 1 {
+		for _, c := range report.Classes {
+			if !c.Default {
+				continue
+			}
+			findings = append(findings, Finding{
+				CheckID:  "ingress-class-multiple-defaults",
+				Severity: SeverityMedium,
+				Kind:     "IngressClass",
+				Name:     c.Name,
+				Message:  fmt.Sprintf("IngressClass %s is one of %d default classes (%s), so new Ingresses without a class are rejected", c.Name, len(defaults), strings.Join(defaults, ", ")),
+				object:   c.class,
+			})
+		}
+	}
+	return findings
+}
+
+// PrintIngressReport writes the Ingress controller section of the text report.
+func PrintIngressReport(w io.Writer, report *IngressReport) {
+	fmt.Fprintln(w, "Ingress controllers:")
+	if len(report.Controllers) == 0 {
+		fmt.Fprintln(w, "  No nginx, Traefik, HAProxy, ALB, or Contour controller found.")
+	}
+	for _, c := range report.Controllers {
+		version := c.Version
+		if version == "" {
+			version = "unknown version"
+		}
+		fmt.Fprintf(w, "  - %s %s: %s %s/%s, %d/%d ready\n", c.Controller, version, c.Kind, c.Namespace, c.Name, c.Ready, c.Desired)
+	}
+
+	fmt.Fprintln(w, "IngressClasses:")
+	if len(report.Classes) == 0 {
+		fmt.Fprintln(w, "  No IngressClasses found.")
+	}
+	for _, c := range report.Classes {
+		name := c.Name
+		if c.Default {
+			name += " (default)"
+		}
+		fmt.Fprintf(w, "  - %s: %s, %d Ingress(es)\n", name, c.Controller, c.Ingresses)
+	}
+	if len(report.Unresolved) > 0 {
+		fmt.Fprintln(w, "  Ingresses no class serves:")
+		for _, u := range report.Unresolved {
+			fmt.Fprintf(w, "    - %s\n", u)
+		}
+	}
+}
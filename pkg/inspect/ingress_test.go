@@ -0,0 +1,145 @@
+package inspect
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func ingressClass(name, controller string, isDefault bool) networkingv1.IngressClass {
+	c := networkingv1.IngressClass{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: networkingv1.IngressClassSpec{Controller: controller}}
+	if isDefault {
+		c.Annotations = map[string]string{networkingv1.AnnotationIsDefaultIngressClass: "true"}
+	}
+	return c
+}
+
+func classedIngress(namespace, name, class, annotation string) networkingv1.Ingress {
+	ing := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	if class != "" {
+		ing.Spec.IngressClassName = &class
+	}
+	if annotation != "" {
+		ing.Annotations = map[string]string{legacyIngressClassAnnotation: annotation}
+	}
+	return ing
+}
+
+func TestBuildIngressReport(t *testing.T) {
+	replicas := int32(2)
+	podSpec := func(images ...string) corev1.PodTemplateSpec {
+		var spec corev1.PodTemplateSpec
+		for _, image := range images {
+			spec.Spec.Containers = append(spec.Spec.Containers, corev1.Container{Name: "c", Image: image})
+		}
+		return spec
+	}
+	deployments := []appsv1.Deployment{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ingress-nginx", Name: "ingress-nginx-controller"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas, Template: podSpec("registry.k8s.io/ingress-nginx/controller:v1.11.2@sha256:d5f8217feeac4887cb1ed21f27c2674e58be06bd8f5184cacea2a69abaf78dce")},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "aws-load-balancer-controller"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas, Template: podSpec("public.ecr.aws/eks/aws-load-balancer-controller:v2.8.1")},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 2},
+		},
+		// An nginx web server is not an ingress controller.
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "site"}, Spec: appsv1.DeploymentSpec{Template: podSpec("nginx:1.27")}},
+	}
+	daemonSets := []appsv1.DaemonSet{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "traefik", Name: "traefik"},
+		Spec:       appsv1.DaemonSetSpec{Template: podSpec("traefik:v3.1.4")},
+		Status:     appsv1.DaemonSetStatus{NumberReady: 3, DesiredNumberScheduled: 3},
+	}}
+	classes := []networkingv1.IngressClass{
+		ingressClass("nginx", "k8s.io/ingress-nginx", true),
+		ingressClass("alb", "ingress.k8s.aws/alb", false),
+		ingressClass("internal", "example.com/custom", false),
+	}
+	ingresses := []networkingv1.Ingress{
+		classedIngress("shop", "api", "nginx", ""),
+		classedIngress("shop", "web", "", ""),
+		classedIngress("shop", "legacy", "", "alb"),
+		classedIngress("shop", "typo", "ngnix", ""),
+		classedIngress("shop", "old", "", "traefik"),
+	}
+
+	report := BuildIngressReport(classes, ingresses, deployments, daemonSets)
+	var controllers []string
+	for _, c := range report.Controllers {
+		controllers = append(controllers, c.Controller+" "+c.Version+" "+c.Kind+" "+c.Namespace+"/"+c.Name)
+	}
+	want := []string{
+		"alb v2.8.1 Deployment kube-system/aws-load-balancer-controller",
+		"nginx v1.11.2 Deployment ingress-nginx/ingress-nginx-controller",
+		"traefik v3.1.4 DaemonSet traefik/traefik",
+	}
+	if !reflect.DeepEqual(controllers, want) {
+		t.Errorf("controllers = %q, want %q", controllers, want)
+	}
+
+	var classSummary []string
+	for _, c := range report.Classes {
+		classSummary = append(classSummary, c.Name+" "+c.ControllerType+" "+strings.Repeat("*", c.Ingresses))
+	}
+	if want := []string{"alb alb *", "internal  ", "nginx nginx **"}; !reflect.DeepEqual(classSummary, want) {
+		t.Errorf("classes = %q, want %q", classSummary, want)
+	}
+	if defaults := report.Defaults(); !reflect.DeepEqual(defaults, []string{"nginx"}) {
+		t.Errorf("Defaults() = %v, want [nginx]", defaults)
+	}
+
+	var got []string
+	for _, f := range CheckIngressClasses(report) {
+		got = append(got, f.CheckID+" "+f.Resource()+" "+string(f.Severity))
+	}
+	want = []string{
+		"ingress-class-missing Ingress/shop/old medium",
+		"ingress-class-missing Ingress/shop/typo high",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %q, want %q", got, want)
+	}
+
+	var b bytes.Buffer
+	PrintIngressReport(&b, report)
+	for _, want := range []string{
+		"  - nginx v1.11.2: Deployment ingress-nginx/ingress-nginx-controller, 1/2 ready",
+		"  - nginx (default): k8s.io/ingress-nginx, 2 Ingress(es)",
+		`    - shop/typo: IngressClass "ngnix" does not exist`,
+		`    - shop/old: IngressClass "traefik" from the kubernetes.io/ingress.class annotation does not exist`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestCheckIngressClassesDefaults(t *testing.T) {
+	classes := []networkingv1.IngressClass{
+		ingressClass("nginx", "k8s.io/ingress-nginx", true),
+		ingressClass("traefik", "traefik.io/ingress-controller", true),
+	}
+	report := BuildIngressReport(classes, []networkingv1.Ingress{classedIngress("shop", "web", "", "")}, nil, nil)
+
+	var got []string
+	for _, f := range CheckIngressClasses(report) {
+		got = append(got, f.CheckID+" "+f.Resource())
+	}
+	want := []string{
+		"ingress-class-unset Ingress/shop/web",
+		"ingress-class-multiple-defaults IngressClass/nginx",
+		"ingress-class-multiple-defaults IngressClass/traefik",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,293 @@
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ipv4OnlyServicesShown is how many IPv4-only services the text report lists.
+const ipv4OnlyServicesShown = 10
+
+// egressGatewayMarkers are substrings of workload names and images that identify NAT64
+// translators and egress gateways.
+var egressGatewayMarkers = []string{"egressgateway", "egress-gateway", "nat64", "jool", "tayga"}
+
+// IPFamilyReport is the IPv6 readiness and egress posture of the cluster, for planning a move to
+// IPv6-only networking.
+type IPFamilyReport struct {
+	Nodes    FamilyCount `json:"nodes"`
+	Pods     FamilyCount `json:"pods"`
+	Services FamilyCount `json:"services"`
+	// DNS64 is set when CoreDNS synthesizes AAAA records for IPv4-only names.
+	DNS64 bool `json:"dns64"`
+	// EgressGateways are the NAT64 translators and egress gateways found in the cluster.
+	EgressGateways []string `json:"egressGateways,omitempty"`
+	// IPv4OnlyServices would stop being reachable on an IPv6-only cluster.
+	IPv4OnlyServices []IPv4OnlyService `json:"ipv4OnlyServices,omitempty"`
+}
+
+// FamilyCount counts objects by the IP families of their addresses.
+type FamilyCount struct {
+	IPv4Only  int `json:"ipv4Only"`
+	IPv6Only  int `json:"ipv6Only"`
+	DualStack int `json:"dualStack"`
+	// NoAddress counts objects without an address yet, such as pending pods.
+	NoAddress int `json:"noAddress,omitempty"`
+}
+
+func (c *FamilyCount) add(v4, v6 bool) {
+	switch {
+	case v4 && v6:
+		c.DualStack++
+	case v4:
+		c.IPv4Only++
+	case v6:
+		c.IPv6Only++
+	default:
+		c.NoAddress++
+	}
+}
+
+func (c FamilyCount) String() string {
+	s := fmt.Sprintf("%d IPv4-only, %d IPv6-only, %d dual-stack", c.IPv4Only, c.IPv6Only, c.DualStack)
+	if c.NoAddress > 0 {
+		s += fmt.Sprintf(", %d without an address", c.NoAddress)
+	}
+	return s
+}
+
+// IPv4OnlyService is a Service that only has IPv4 addresses, and why.
+type IPv4OnlyService struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+}
+
+type ciliumEgressPolicyList struct {
+	Items []struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+	} `json:"items"`
+}
+
+// GetIPFamilyReport collects the address families of nodes, pods, and Services, CoreDNS's
+// configuration, and the workloads and Cilium policies that provide NAT64 or egress gateways.
+func GetIPFamilyReport(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*IPFamilyReport, error) {
+	nodes, err := listNodes(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	pods, err := listPods(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	services, err := listServices(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	deployments, err := listDeployments(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	daemonSets, err := listDaemonSets(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+
+	var corefile string
+	cm, err := clientset.CoreV1().ConfigMaps("kube-system").Get(ctx, "coredns", metav1.GetOptions{})
+	switch {
+	case err == nil:
+		corefile = cm.Data["Corefile"]
+	case !apierrors.IsNotFound(err):
+		return nil, fmt.Errorf("failed to get coredns configmap: %w", err)
+	}
+
+	report := BuildIPFamilyReport(nodes, pods, services, deployments, daemonSets, corefile)
+
+	data, err := getRaw(ctx, clientset, "/apis/cilium.io/v2/ciliumegressgatewaypolicies")
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return nil, fmt.Errorf("failed to list ciliumegressgatewaypolicies: %w", err)
+	default:
+		var policies ciliumEgressPolicyList
+		if err := json.Unmarshal(data, &policies); err != nil {
+			return nil, fmt.Errorf("failed to parse ciliumegressgatewaypolicies: %w", err)
+		}
+		for _, p := range policies.Items {
+			report.EgressGateways = append(report.EgressGateways, "CiliumEgressGatewayPolicy "+p.Metadata.Name)
+		}
+	}
+	return report, nil
+}
+
+// BuildIPFamilyReport computes the IP family report. Node families come from their InternalIP
+// and ExternalIP addresses, pod families from their pod IPs, and Service families from their
+// ipFamilies. A Service is IPv4-only when it has no IPv6 family or, for a LoadBalancer, when
+// its load balancer only has IPv4 addresses. ExternalName Services have no addresses and are
+// skipped.
+func BuildIPFamilyReport(nodes []corev1.Node, pods []corev1.Pod, services []corev1.Service, deployments []appsv1.Deployment, daemonSets []appsv1.DaemonSet, corefile string) *IPFamilyReport {
+	report := &IPFamilyReport{DNS64: corefileHasPlugin(corefile, "dns64")}
+
+	for _, node := range nodes {
+		var addresses []string
+		for _, a := range node.Status.Addresses {
+			if a.Type == corev1.NodeInternalIP || a.Type == corev1.NodeExternalIP {
+				addresses = append(addresses, a.Address)
+			}
+		}
+		report.Nodes.add(ipFamilies(addresses))
+	}
+
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		var addresses []string
+		for _, ip := range pod.Status.PodIPs {
+			addresses = append(addresses, ip.IP)
+		}
+		if len(addresses) == 0 && pod.Status.PodIP != "" {
+			addresses = append(addresses, pod.Status.PodIP)
+		}
+		report.Pods.add(ipFamilies(addresses))
+	}
+
+	for _, svc := range services {
+		if svc.Spec.Type == corev1.ServiceTypeExternalName {
+			continue
+		}
+		v4, v6 := false, false
+		for _, f := range svc.Spec.IPFamilies {
+			v4 = v4 || f == corev1.IPv4Protocol
+			v6 = v6 || f == corev1.IPv6Protocol
+		}
+		if len(svc.Spec.IPFamilies) == 0 {
+			v4, v6 = ipFamilies(svc.Spec.ClusterIPs)
+		}
+		report.Services.add(v4, v6)
+
+		reason := ""
+		switch {
+		case v4 && !v6:
+			reason = "single-stack IPv4 Service"
+			if svc.Spec.IPFamilyPolicy != nil {
+				reason += " (ipFamilyPolicy " + string(*svc.Spec.IPFamilyPolicy) + ")"
+			}
+		case svc.Spec.Type == corev1.ServiceTypeLoadBalancer:
+			var lbIPs []string
+			for _, in := range svc.Status.LoadBalancer.Ingress {
+				if in.IP != "" {
+					lbIPs = append(lbIPs, in.IP)
+				}
+			}
+			if lbV4, lbV6 := ipFamilies(lbIPs); lbV4 && !lbV6 {
+				reason = "load balancer only has IPv4 addresses"
+			}
+		}
+		if reason != "" {
+			report.IPv4OnlyServices = append(report.IPv4OnlyServices, IPv4OnlyService{svc.Namespace, svc.Name, reason})
+		}
+	}
+	sort.Slice(report.IPv4OnlyServices, func(i, j int) bool {
+		a, b := report.IPv4OnlyServices[i], report.IPv4OnlyServices[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	for _, d := range deployments {
+		if isEgressGateway(d.Name, d.Spec.Template.Spec) {
+			report.EgressGateways = append(report.EgressGateways, fmt.Sprintf("Deployment %s/%s", d.Namespace, d.Name))
+		}
+	}
+	for _, d := range daemonSets {
+		if isEgressGateway(d.Name, d.Spec.Template.Spec) {
+			report.EgressGateways = append(report.EgressGateways, fmt.Sprintf("DaemonSet %s/%s", d.Namespace, d.Name))
+		}
+	}
+	return report
+}
+
+// ipFamilies reports which IP families the addresses cover. Unparseable addresses are ignored.
+func ipFamilies(addresses []string) (v4, v6 bool) {
+	for _, a := range addresses {
+		addr, err := netip.ParseAddr(a)
+		if err != nil {
+			continue
+		}
+		if addr.Unmap().Is4() {
+			v4 = true
+		} else {
+			v6 = true
+		}
+	}
+	return v4, v6
+}
+
+// corefileHasPlugin reports whether a Corefile enables plugin in any server block.
+func corefileHasPlugin(corefile, plugin string) bool {
+	for _, line := range strings.Split(corefile, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == plugin {
+			return true
+		}
+	}
+	return false
+}
+
+func isEgressGateway(name string, spec corev1.PodSpec) bool {
+	candidates := []string{name}
+	for _, c := range spec.Containers {
+		candidates = append(candidates, c.Image)
+	}
+	for _, candidate := range candidates {
+		candidate = strings.ToLower(candidate)
+		for _, marker := range egressGatewayMarkers {
+			if strings.Contains(candidate, marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PrintIPFamilyReport writes the IP family section of the text report.
+func PrintIPFamilyReport(w io.Writer, report *IPFamilyReport) {
+	fmt.Fprintln(w, "IP families and egress:")
+	fmt.Fprintf(w, "  Nodes: %s\n", report.Nodes)
+	fmt.Fprintf(w, "  Pods: %s\n", report.Pods)
+	fmt.Fprintf(w, "  Services: %s\n", report.Services)
+	if report.DNS64 {
+		fmt.Fprintln(w, "  CoreDNS has the dns64 plugin enabled.")
+	} else {
+		fmt.Fprintln(w, "  CoreDNS does not synthesize AAAA records (no dns64 plugin).")
+	}
+	if len(report.EgressGateways) == 0 {
+		fmt.Fprintln(w, "  No NAT64 translators or egress gateways found.")
+	} else {
+		fmt.Fprintf(w, "  NAT64 and egress gateways: %s\n", strings.Join(report.EgressGateways, ", "))
+	}
+	if n := len(report.IPv4OnlyServices); n > 0 {
+		fmt.Fprintf(w, "  %d Service(s) would break on an IPv6-only cluster:\n", n)
+		for i, s := range report.IPv4OnlyServices {
+			if i == ipv4OnlyServicesShown {
+				fmt.Fprintf(w, "    ... and %d more (see --output json)\n", n-ipv4OnlyServicesShown)
+				break
+			}
+			fmt.Fprintf(w, "    - %s/%s: %s\n", s.Namespace, s.Name, s.Reason)
+		}
+	}
+}
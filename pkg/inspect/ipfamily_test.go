@@ -0,0 +1,96 @@
+package inspect
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildIPFamilyReport(t *testing.T) {
+	node := func(addresses ...string) corev1.Node {
+		var n corev1.Node
+		for _, a := range addresses {
+			n.Status.Addresses = append(n.Status.Addresses, corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: a})
+		}
+		return n
+	}
+	pod := func(ips ...string) corev1.Pod {
+		var p corev1.Pod
+		for _, ip := range ips {
+			p.Status.PodIPs = append(p.Status.PodIPs, corev1.PodIP{IP: ip})
+		}
+		return p
+	}
+	singleStack := corev1.IPFamilyPolicySingleStack
+	service := func(name string, svcType corev1.ServiceType, families ...corev1.IPFamily) corev1.Service {
+		return corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: name},
+			Spec:       corev1.ServiceSpec{Type: svcType, IPFamilies: families, IPFamilyPolicy: &singleStack},
+		}
+	}
+	dualLB := service("dual-lb", corev1.ServiceTypeLoadBalancer, corev1.IPv4Protocol, corev1.IPv6Protocol)
+	dualLB.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.5"}}
+
+	services := []corev1.Service{
+		service("api", corev1.ServiceTypeClusterIP, corev1.IPv4Protocol),
+		service("dual", corev1.ServiceTypeClusterIP, corev1.IPv4Protocol, corev1.IPv6Protocol),
+		service("v6", corev1.ServiceTypeClusterIP, corev1.IPv6Protocol),
+		dualLB,
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "external"}, Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName}},
+	}
+	deployments := []appsv1.Deployment{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "istio-system", Name: "istio-egressgateway"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "frontend"}},
+	}
+	daemonSets := []appsv1.DaemonSet{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "translator"},
+		Spec: appsv1.DaemonSetSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Image: "ghcr.io/example/jool:4.1"}},
+		}}},
+	}}
+	corefile := ".:53 {\n    errors\n    dns64 {\n        prefix 64:ff9b::/96\n    }\n    forward . /etc/resolv.conf\n}\n"
+
+	report := BuildIPFamilyReport(
+		[]corev1.Node{node("10.0.0.1"), node("10.0.0.2", "fd00::2")},
+		[]corev1.Pod{pod("10.1.0.1"), pod("fd00:1::1"), pod()},
+		services, deployments, daemonSets, corefile,
+	)
+
+	if want := (FamilyCount{IPv4Only: 1, DualStack: 1}); report.Nodes != want {
+		t.Errorf("Nodes = %+v, want %+v", report.Nodes, want)
+	}
+	if want := (FamilyCount{IPv4Only: 1, IPv6Only: 1, NoAddress: 1}); report.Pods != want {
+		t.Errorf("Pods = %+v, want %+v", report.Pods, want)
+	}
+	if want := (FamilyCount{IPv4Only: 1, IPv6Only: 1, DualStack: 2}); report.Services != want {
+		t.Errorf("Services = %+v, want %+v", report.Services, want)
+	}
+	if !report.DNS64 {
+		t.Error("DNS64 = false, want true")
+	}
+	if got := strings.Join(report.EgressGateways, ","); got != "Deployment istio-system/istio-egressgateway,DaemonSet kube-system/translator" {
+		t.Errorf("EgressGateways = %s", got)
+	}
+
+	var broken []string
+	for _, s := range report.IPv4OnlyServices {
+		broken = append(broken, s.Name+": "+s.Reason)
+	}
+	want := []string{"api: single-stack IPv4 Service (ipFamilyPolicy SingleStack)", "dual-lb: load balancer only has IPv4 addresses"}
+	if strings.Join(broken, "|") != strings.Join(want, "|") {
+		t.Errorf("IPv4OnlyServices = %v, want %v", broken, want)
+	}
+}
+
+func TestCorefileHasPlugin(t *testing.T) {
+	corefile := ".:53 {\n    # dns64\n    forward . /etc/resolv.conf\n}\n"
+	if corefileHasPlugin(corefile, "dns64") {
+		t.Error("commented-out plugin reported as enabled")
+	}
+	if !corefileHasPlugin(corefile, "forward") {
+		t.Error("forward plugin not found")
+	}
+}
@@ -0,0 +1,310 @@
+package inspect
+
+import (
+	"context"
+	"errors"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// getRaw GETs an API path that has no typed client, such as an optional CRD or an aggregated
+// API. Clientsets without a REST client, like the fake clientset, see every such path as not found.
+func getRaw(ctx context.Context, clientset kubernetes.Interface, path string) ([]byte, error) {
+	client := clientset.Discovery().RESTClient()
+	if client == nil {
+		return nil, apierrors.NewNotFound(schema.GroupResource{}, path)
+	}
+	return client.Get().AbsPath(path).DoRaw(ctx)
+}
+
+// coreRESTClient returns the REST client for core subresources such as node proxy and pod exec,
+// or an error for clientsets without one, like the fake clientset.
+func coreRESTClient(clientset kubernetes.Interface) (rest.Interface, error) {
+	client := clientset.CoreV1().RESTClient()
+	if c, ok := client.(*rest.RESTClient); client == nil || ok && c == nil {
+		return nil, errors.New("client has no REST client for node and pod subresources")
+	}
+	return client, nil
+}
+
+// listPaged calls list repeatedly, following continue tokens, until the API server reports no more pages.
+func listPaged[T any](pageSize int64, opts metav1.ListOptions, list func(metav1.ListOptions) ([]T, string, error)) ([]T, error) {
+	opts.Limit = pageSize
+	var items []T
+	for {
+		page, cont, err := list(opts)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+		if cont == "" {
+			return items, nil
+		}
+		opts.Continue = cont
+	}
+}
+
+func listNodes(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, listOpts metav1.ListOptions) ([]corev1.Node, error) {
+	return listPaged(opts.PageSize, listOpts, func(o metav1.ListOptions) ([]corev1.Node, string, error) {
+		l, err := clientset.CoreV1().Nodes().List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listNamespaces(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, listOpts metav1.ListOptions) ([]corev1.Namespace, error) {
+	return listPaged(opts.PageSize, listOpts, func(o metav1.ListOptions) ([]corev1.Namespace, string, error) {
+		l, err := clientset.CoreV1().Namespaces().List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listServices(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]corev1.Service, error) {
+	return listNamespaced(ctx, clientset, opts, "services", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]corev1.Service, string, error) {
+		l, err := clientset.CoreV1().Services(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listIngresses(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]networkingv1.Ingress, error) {
+	return listNamespaced(ctx, clientset, opts, "ingresses", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]networkingv1.Ingress, string, error) {
+		l, err := clientset.NetworkingV1().Ingresses(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listPods(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]corev1.Pod, error) {
+	return listNamespaced(ctx, clientset, opts, "pods", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]corev1.Pod, string, error) {
+		l, err := clientset.CoreV1().Pods(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listDeployments(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]appsv1.Deployment, error) {
+	return listNamespaced(ctx, clientset, opts, "deployments", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]appsv1.Deployment, string, error) {
+		l, err := clientset.AppsV1().Deployments(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listStatefulSets(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]appsv1.StatefulSet, error) {
+	return listNamespaced(ctx, clientset, opts, "statefulsets", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]appsv1.StatefulSet, string, error) {
+		l, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listDaemonSets(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]appsv1.DaemonSet, error) {
+	return listNamespaced(ctx, clientset, opts, "daemonsets", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]appsv1.DaemonSet, string, error) {
+		l, err := clientset.AppsV1().DaemonSets(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listReplicaSets(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]appsv1.ReplicaSet, error) {
+	return listNamespaced(ctx, clientset, opts, "replicasets", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]appsv1.ReplicaSet, string, error) {
+		l, err := clientset.AppsV1().ReplicaSets(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listJobs(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]batchv1.Job, error) {
+	return listNamespaced(ctx, clientset, opts, "jobs", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]batchv1.Job, string, error) {
+		l, err := clientset.BatchV1().Jobs(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listCronJobs(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]batchv1.CronJob, error) {
+	return listNamespaced(ctx, clientset, opts, "cronjobs", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]batchv1.CronJob, string, error) {
+		l, err := clientset.BatchV1().CronJobs(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listConfigMaps(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]corev1.ConfigMap, error) {
+	return listNamespaced(ctx, clientset, opts, "configmaps", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]corev1.ConfigMap, string, error) {
+		l, err := clientset.CoreV1().ConfigMaps(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listResourceQuotas(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]corev1.ResourceQuota, error) {
+	return listNamespaced(ctx, clientset, opts, "resourcequotas", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]corev1.ResourceQuota, string, error) {
+		l, err := clientset.CoreV1().ResourceQuotas(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listLimitRanges(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]corev1.LimitRange, error) {
+	return listNamespaced(ctx, clientset, opts, "limitranges", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]corev1.LimitRange, string, error) {
+		l, err := clientset.CoreV1().LimitRanges(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+// ListEvents lists the Events in a namespace, or in every namespace when it is empty.
+func ListEvents(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]corev1.Event, error) {
+	return listNamespaced(ctx, clientset, opts, "events", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]corev1.Event, string, error) {
+		l, err := clientset.CoreV1().Events(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listPodDisruptionBudgets(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]policyv1.PodDisruptionBudget, error) {
+	return listNamespaced(ctx, clientset, opts, "poddisruptionbudgets", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]policyv1.PodDisruptionBudget, string, error) {
+		l, err := clientset.PolicyV1().PodDisruptionBudgets(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listSecrets(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]corev1.Secret, error) {
+	return listNamespaced(ctx, clientset, opts, "secrets", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]corev1.Secret, string, error) {
+		l, err := clientset.CoreV1().Secrets(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listHorizontalPodAutoscalers(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]autoscalingv2.HorizontalPodAutoscaler, error) {
+	return listNamespaced(ctx, clientset, opts, "horizontalpodautoscalers", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]autoscalingv2.HorizontalPodAutoscaler, string, error) {
+		l, err := clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listValidatingWebhookConfigurations(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, listOpts metav1.ListOptions) ([]admissionregistrationv1.ValidatingWebhookConfiguration, error) {
+	return listPaged(opts.PageSize, listOpts, func(o metav1.ListOptions) ([]admissionregistrationv1.ValidatingWebhookConfiguration, string, error) {
+		l, err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listMutatingWebhookConfigurations(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, listOpts metav1.ListOptions) ([]admissionregistrationv1.MutatingWebhookConfiguration, error) {
+	return listPaged(opts.PageSize, listOpts, func(o metav1.ListOptions) ([]admissionregistrationv1.MutatingWebhookConfiguration, string, error) {
+		l, err := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listEndpointSlices(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]discoveryv1.EndpointSlice, error) {
+	return listNamespaced(ctx, clientset, opts, "endpointslices", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]discoveryv1.EndpointSlice, string, error) {
+		l, err := clientset.DiscoveryV1().EndpointSlices(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listIngressClasses(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, listOpts metav1.ListOptions) ([]networkingv1.IngressClass, error) {
+	return listPaged(opts.PageSize, listOpts, func(o metav1.ListOptions) ([]networkingv1.IngressClass, string, error) {
+		l, err := clientset.NetworkingV1().IngressClasses().List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listPriorityClasses(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, listOpts metav1.ListOptions) ([]schedulingv1.PriorityClass, error) {
+	return listPaged(opts.PageSize, listOpts, func(o metav1.ListOptions) ([]schedulingv1.PriorityClass, string, error) {
+		l, err := clientset.SchedulingV1().PriorityClasses().List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listPersistentVolumeClaims(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, namespace string, listOpts metav1.ListOptions) ([]corev1.PersistentVolumeClaim, error) {
+	return listNamespaced(ctx, clientset, opts, "persistentvolumeclaims", namespace, listOpts, func(namespace string, o metav1.ListOptions) ([]corev1.PersistentVolumeClaim, string, error) {
+		l, err := clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
+
+func listStorageClasses(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, listOpts metav1.ListOptions) ([]storagev1.StorageClass, error) {
+	return listPaged(opts.PageSize, listOpts, func(o metav1.ListOptions) ([]storagev1.StorageClass, string, error) {
+		l, err := clientset.StorageV1().StorageClasses().List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+}
@@ -0,0 +1,205 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Node attributes the inventory groups nodes by.
+const (
+	NodeAttributeOSImage      = "osImage"
+	NodeAttributeKernel       = "kernel"
+	NodeAttributeRuntime      = "containerRuntime"
+	NodeAttributeKubelet      = "kubelet"
+	NodeAttributeArchitecture = "architecture"
+)
+
+// driftAttributes are the attributes that should not vary within a node pool. Architecture is
+// left out: autoscalers such as Karpenter mix arm64 and amd64 in one pool on purpose.
+var driftAttributes = []string{NodeAttributeOSImage, NodeAttributeKernel, NodeAttributeRuntime, NodeAttributeKubelet}
+
+// NodeInventory is what the nodes run, grouped by node pool.
+type NodeInventory struct {
+	Pools []NodePool `json:"pools"`
+}
+
+// NodePool is the nodes sharing a node group label value. Nodes without one are grouped
+// together under an empty name.
+type NodePool struct {
+	Name  string `json:"name"`
+	Nodes int    `json:"nodes"`
+	// Variants holds, for each attribute, the distinct values in the pool with the nodes running
+	// each, most common first.
+	Variants map[string][]NodeVariant `json:"variants"`
+}
+
+// NodeVariant is one value of a node attribute and the nodes that have it.
+type NodeVariant struct {
+	Value string   `json:"value"`
+	Nodes []string `json:"nodes"`
+}
+
+// DisplayName names the pool in text output.
+func (p NodePool) DisplayName() string {
+	if p.Name == "" {
+		return "(no node group)"
+	}
+	return p.Name
+}
+
+// Drifted returns the attributes that vary within the pool, apart from architecture.
+func (p NodePool) Drifted() []string {
+	var drifted []string
+	for _, attribute := range driftAttributes {
+		if len(p.Variants[attribute]) > 1 {
+			drifted = append(drifted, attribute)
+		}
+	}
+	return drifted
+}
+
+// GetNodeInventory lists the nodes and groups them by pool.
+func GetNodeInventory(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*NodeInventory, error) {
+	nodes, err := listNodes(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes found in the cluster")
+	}
+	return BuildNodeInventory(nodes), nil
+}
+
+// nodePool returns the value of the first node group label the node carries.
+func nodePool(node corev1.Node) string {
+	for _, label := range nodeGroupLabels {
+		if v := node.Labels[label]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// BuildNodeInventory groups nodes by pool and, within each pool, by OS image, kernel, container
+// runtime, kubelet version, and architecture.
+func BuildNodeInventory(nodes []corev1.Node) *NodeInventory {
+	byPool := map[string]map[string]map[string][]string{}
+	counts := map[string]int{}
+	for _, node := range nodes {
+		info := node.Status.NodeInfo
+		pool := nodePool(node)
+		if byPool[pool] == nil {
+			byPool[pool] = map[string]map[string][]string{}
+		}
+		counts[pool]++
+		for attribute, value := range map[string]string{
+			NodeAttributeOSImage:      info.OSImage,
+			NodeAttributeKernel:       info.KernelVersion,
+			NodeAttributeRuntime:      info.ContainerRuntimeVersion,
+			NodeAttributeKubelet:      info.KubeletVersion,
+			NodeAttributeArchitecture: info.Architecture,
+		} {
+			if byPool[pool][attribute] == nil {
+				byPool[pool][attribute] = map[string][]string{}
+			}
+			byPool[pool][attribute][value] = append(byPool[pool][attribute][value], node.Name)
+		}
+	}
+
+	inventory := &NodeInventory{}
+	for pool, attributes := range byPool {
+		p := NodePool{Name: pool, Nodes: counts[pool], Variants: map[string][]NodeVariant{}}
+		for attribute, values := range attributes {
+			for value, names := range values {
+				sort.Strings(names)
+				p.Variants[attribute] = append(p.Variants[attribute], NodeVariant{Value: value, Nodes: names})
+			}
+			variants := p.Variants[attribute]
+			sort.Slice(variants, func(i, j int) bool {
+				if len(variants[i].Nodes) != len(variants[j].Nodes) {
+					return len(variants[i].Nodes) > len(variants[j].Nodes)
+				}
+				return variants[i].Value < variants[j].Value
+			})
+		}
+		inventory.Pools = append(inventory.Pools, p)
+	}
+	sort.Slice(inventory.Pools, func(i, j int) bool { return inventory.Pools[i].Name < inventory.Pools[j].Name })
+	return inventory
+}
+
+// KubeletVersions returns the distinct kubelet versions across all pools, sorted and
+// comma-separated.
+func (inv *NodeInventory) KubeletVersions() string {
+	unique := map[string]bool{}
+	for _, p := range inv.Pools {
+		for _, v := range p.Variants[NodeAttributeKubelet] {
+			unique[v.Value] = true
+		}
+	}
+	versions := make([]string, 0, len(unique))
+	for v := range unique {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return strings.Join(versions, ", ")
+}
+
+// CheckNodeDrift raises a finding for every attribute that varies within a node pool, which
+// usually means a rolling node update did not finish. Nodes outside any pool are only low
+// severity, since control-plane and hand-built nodes there may differ on purpose.
+func CheckNodeDrift(inv *NodeInventory) []Finding {
+	var findings []Finding
+	for _, p := range inv.Pools {
+		severity := SeverityMedium
+		if p.Name == "" {
+			severity = SeverityLow
+		}
+		for _, attribute := range p.Drifted() {
+			var values []string
+			for _, v := range p.Variants[attribute] {
+				values = append(values, fmt.Sprintf("%s (%d)", v.Value, len(v.Nodes)))
+			}
+			findings = append(findings, Finding{
+				CheckID:   "node-pool-drift",
+				Severity:  severity,
+				Kind:      "NodePool",
+				Name:      p.DisplayName(),
+				Message:   fmt.Sprintf("node pool %s runs %d %s versions across its %d nodes: %s", p.DisplayName(), len(values), attribute, p.Nodes, strings.Join(values, ", ")),
+				keyFields: []string{attribute},
+			})
+		}
+	}
+	return findings
+}
+
+// PrintNodeInventory writes the node pool section of the text report.
+func PrintNodeInventory(w io.Writer, inv *NodeInventory) {
+	fmt.Fprintln(w, "Node pools:")
+	for _, p := range inv.Pools {
+		fmt.Fprintf(w, "  - %s, %d node(s)\n", p.DisplayName(), p.Nodes)
+		drifted := map[string]bool{}
+		for _, attribute := range p.Drifted() {
+			drifted[attribute] = true
+		}
+		for _, attribute := range []string{NodeAttributeOSImage, NodeAttributeKernel, NodeAttributeRuntime, NodeAttributeKubelet, NodeAttributeArchitecture} {
+			var values []string
+			for _, v := range p.Variants[attribute] {
+				values = append(values, fmt.Sprintf("%s (%d)", v.Value, len(v.Nodes)))
+			}
+			line := fmt.Sprintf("      %s: %s", attribute, strings.Join(values, ", "))
+			if drifted[attribute] {
+				line += " [drift]"
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package inspect
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func inventoryNode(name, pool, kernel, arch string) corev1.Node {
+	node := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}},
+		Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{
+			OSImage:                 "Amazon Linux 2023.6.20250107",
+			KernelVersion:           kernel,
+			ContainerRuntimeVersion: "containerd://1.7.25",
+			KubeletVersion:          "v1.31.4-eks-aeac579",
+			Architecture:            arch,
+		}},
+	}
+	if pool != "" {
+		node.Labels["eks.amazonaws.com/nodegroup"] = pool
+	}
+	return node
+}
+
+func TestBuildNodeInventory(t *testing.T) {
+	nodes := []corev1.Node{
+		inventoryNode("general-1", "general", "6.1.119-129.201.amzn2023.x86_64", "amd64"),
+		inventoryNode("general-2", "general", "6.1.119-129.201.amzn2023.x86_64", "amd64"),
+		inventoryNode("general-3", "general", "6.1.115-126.197.amzn2023.x86_64", "amd64"),
+		inventoryNode("general-4", "general", "6.1.112-122.189.amzn2023.x86_64", "amd64"),
+		// Mixed architectures are not drift.
+		inventoryNode("batch-1", "batch", "6.1.119-129.201.amzn2023.x86_64", "amd64"),
+		inventoryNode("batch-2", "batch", "6.1.119-129.201.amzn2023.x86_64", "arm64"),
+		inventoryNode("control-1", "", "6.1.119-129.201.amzn2023.x86_64", "amd64"),
+	}
+
+	inv := BuildNodeInventory(nodes)
+	var pools []string
+	for _, p := range inv.Pools {
+		pools = append(pools, p.Name)
+	}
+	if want := []string{"", "batch", "general"}; !reflect.DeepEqual(pools, want) {
+		t.Fatalf("pools = %q, want %q", pools, want)
+	}
+	general := inv.Pools[2]
+	wantKernels := []NodeVariant{
+		{Value: "6.1.119-129.201.amzn2023.x86_64", Nodes: []string{"general-1", "general-2"}},
+		{Value: "6.1.112-122.189.amzn2023.x86_64", Nodes: []string{"general-4"}},
+		{Value: "6.1.115-126.197.amzn2023.x86_64", Nodes: []string{"general-3"}},
+	}
+	if general.Nodes != 4 || !reflect.DeepEqual(general.Variants[NodeAttributeKernel], wantKernels) {
+		t.Errorf("general kernels = %+v, want %+v", general.Variants[NodeAttributeKernel], wantKernels)
+	}
+	if drifted := general.Drifted(); !reflect.DeepEqual(drifted, []string{NodeAttributeKernel}) {
+		t.Errorf("general Drifted() = %v, want [kernel]", drifted)
+	}
+	if drifted := inv.Pools[1].Drifted(); len(drifted) != 0 {
+		t.Errorf("batch Drifted() = %v, want none for mixed architectures", drifted)
+	}
+	if got := inv.KubeletVersions(); got != "v1.31.4-eks-aeac579" {
+		t.Errorf("KubeletVersions() = %q", got)
+	}
+
+	findings := CheckNodeDrift(inv)
+	if len(findings) != 1 || findings[0].CheckID != "node-pool-drift" || findings[0].Severity != SeverityMedium || findings[0].Name != "general" {
+		t.Fatalf("CheckNodeDrift() = %+v, want one medium drift finding for general", findings)
+	}
+	if want := "node pool general runs 3 kernel versions across its 4 nodes"; !strings.Contains(findings[0].Message, want) {
+		t.Errorf("message %q does not contain %q", findings[0].Message, want)
+	}
+
+	var b bytes.Buffer
+	PrintNodeInventory(&b, inv)
+	for _, want := range []string{
+		"- (no node group), 1 node(s)",
+		"- general, 4 node(s)",
+		"kernel: 6.1.119-129.201.amzn2023.x86_64 (2), 6.1.112-122.189.amzn2023.x86_64 (1), 6.1.115-126.197.amzn2023.x86_64 (1) [drift]",
+		"architecture: amd64 (1), arm64 (1)\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestCheckNodeDriftUngrouped(t *testing.T) {
+	inv := BuildNodeInventory([]corev1.Node{
+		inventoryNode("n1", "", "5.15.0-1", "amd64"),
+		inventoryNode("n2", "", "5.15.0-2", "amd64"),
+	})
+	if findings := CheckNodeDrift(inv); len(findings) != 1 || findings[0].Severity != SeverityLow {
+		t.Errorf("CheckNodeDrift() = %+v, want one low finding for nodes outside any pool", findings)
+	}
+}
@@ -0,0 +1,205 @@
+package inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"text/template"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Notifier types supported in the notification config.
+const (
+	NotifierSlack   = "slack"
+	NotifierTeams   = "teams"
+	NotifierWebhook = "webhook"
+)
+
+const defaultNotificationTemplate = `kube-op found {{len .Findings}} new finding(s):
+{{range .Findings}}• [{{.Severity}}] {{.Message}} ({{.Resource}}{{if .Owner}}, owned by {{.Owner}}{{end}})
+{{end}}`
+
+// NotificationConfig is the on-disk notification config, in YAML or JSON.
+type NotificationConfig struct {
+	Notifiers []NotifierConfig `json:"notifiers"`
+}
+
+// NotifierConfig describes one notification destination and which findings are routed to it.
+type NotifierConfig struct {
+	Name string `json:"name"`
+	// Type is one of slack, teams, or webhook.
+	Type string `json:"type"`
+	// URL is the incoming webhook URL. URLEnv names an environment variable to read it
+	// from instead, so the config file can be committed without the secret.
+	URL    string `json:"url,omitempty"`
+	URLEnv string `json:"urlEnv,omitempty"`
+	// MinSeverity routes only findings at or above this severity to the notifier.
+	MinSeverity Severity `json:"minSeverity,omitempty"`
+	// Template is a text/template rendered with the routed findings as .Findings.
+	Template string `json:"template,omitempty"`
+}
+
+// notificationData is the value notification templates are executed against.
+type notificationData struct {
+	Findings []Finding
+}
+
+// Notifier sends a batch of findings to a single destination.
+type Notifier struct {
+	name        string
+	kind        string
+	url         string
+	minSeverity Severity
+	template    *template.Template
+	client      *http.Client
+}
+
+// LoadNotificationConfig reads and validates a notification config file.
+func LoadNotificationConfig(path string) (*NotificationConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification config: %w", err)
+	}
+
+	var config NotificationConfig
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse notification config %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// NewNotifiers builds a notifier for every entry in config.
+func NewNotifiers(config *NotificationConfig) ([]*Notifier, error) {
+	notifiers := make([]*Notifier, 0, len(config.Notifiers))
+	for i, nc := range config.Notifiers {
+		name := nc.Name
+		if name == "" {
+			name = fmt.Sprintf("notifiers[%d]", i)
+		}
+
+		switch nc.Type {
+		case NotifierSlack, NotifierTeams, NotifierWebhook:
+		default:
+			return nil, fmt.Errorf("notifier %s: unknown type %q (want slack, teams, or webhook)", name, nc.Type)
+		}
+
+		url := nc.URL
+		if nc.URLEnv != "" {
+			url = os.Getenv(nc.URLEnv)
+		}
+		if url == "" {
+			return nil, fmt.Errorf("notifier %s: no webhook URL configured", name)
+		}
+
+		minSeverity := SeverityHigh
+		if nc.MinSeverity != "" {
+			s, err := ParseSeverity(string(nc.MinSeverity))
+			if err != nil {
+				return nil, fmt.Errorf("notifier %s: %w", name, err)
+			}
+			minSeverity = s
+		}
+
+		text := nc.Template
+		if text == "" {
+			text = defaultNotificationTemplate
+		}
+		tmpl, err := template.New(name).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("notifier %s: invalid template: %w", name, err)
+		}
+
+		notifiers = append(notifiers, &Notifier{
+			name:        name,
+			kind:        nc.Type,
+			url:         url,
+			minSeverity: minSeverity,
+			template:    tmpl,
+			client:      &http.Client{Timeout: 10 * time.Second},
+		})
+	}
+	return notifiers, nil
+}
+
+// Route returns the findings severe enough for this notifier.
+func (n *Notifier) Route(findings []Finding) []Finding {
+	var routed []Finding
+	for _, f := range findings {
+		if f.Severity.AtLeast(n.minSeverity) {
+			routed = append(routed, f)
+		}
+	}
+	return routed
+}
+
+// Notify sends the findings routed to this notifier. Nothing is sent when none qualify.
+func (n *Notifier) Notify(findings []Finding) error {
+	routed := n.Route(findings)
+	if len(routed) == 0 {
+		return nil
+	}
+
+	payload, err := n.payload(routed)
+	if err != nil {
+		return fmt.Errorf("notifier %s: %w", n.name, err)
+	}
+
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("notifier %s: failed to send: %w", n.name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notifier %s: webhook returned %s", n.name, resp.Status)
+	}
+	return nil
+}
+
+// payload renders the message and wraps it in the body format the destination expects.
+func (n *Notifier) payload(findings []Finding) ([]byte, error) {
+	var text bytes.Buffer
+	if err := n.template.Execute(&text, notificationData{Findings: findings}); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+
+	switch n.kind {
+	case NotifierSlack:
+		return json.Marshal(map[string]any{"text": text.String()})
+	case NotifierTeams:
+		// Teams workflow webhooks accept a message carrying a single Adaptive Card.
+		return json.Marshal(map[string]any{
+			"type": "message",
+			"attachments": []any{map[string]any{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]any{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"body": []any{map[string]any{
+						"type": "TextBlock",
+						"text": text.String(),
+						"wrap": true,
+					}},
+				},
+			}},
+		})
+	default:
+		return json.Marshal(map[string]any{"text": text.String(), "findings": findings})
+	}
+}
+
+// NotifyAll sends findings to every notifier, returning the combined errors of those that failed.
+func NotifyAll(notifiers []*Notifier, findings []Finding) error {
+	var errs []error
+	for _, n := range notifiers {
+		if err := n.Notify(findings); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
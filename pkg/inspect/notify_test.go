@@ -0,0 +1,82 @@
+package inspect
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewNotifiers_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config NotifierConfig
+	}{
+		{"unknown type", NotifierConfig{Type: "pager", URL: "http://example"}},
+		{"missing url", NotifierConfig{Type: NotifierSlack}},
+		{"unknown severity", NotifierConfig{Type: NotifierSlack, URL: "http://example", MinSeverity: "urgent"}},
+		{"bad template", NotifierConfig{Type: NotifierSlack, URL: "http://example", Template: "{{"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewNotifiers(&NotificationConfig{Notifiers: []NotifierConfig{tt.config}}); err == nil {
+				t.Errorf("NewNotifiers() returned error = nil, want non-nil")
+			}
+		})
+	}
+}
+
+func TestNotifier_NotifyRoutesBySeverity(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+	}))
+	defer server.Close()
+
+	notifiers, err := NewNotifiers(&NotificationConfig{Notifiers: []NotifierConfig{
+		{Name: "slack", Type: NotifierSlack, URL: server.URL, MinSeverity: SeverityHigh},
+		{Name: "teams", Type: NotifierTeams, URL: server.URL, MinSeverity: SeverityCritical},
+	}})
+	if err != nil {
+		t.Fatalf("NewNotifiers() returned error = %v, want nil", err)
+	}
+
+	findings := []Finding{
+		{CheckID: "node-not-ready", Severity: SeverityHigh, Kind: "Node", Name: "n1", Message: "node n1 is NotReady"},
+		{CheckID: "public-loadbalancer", Severity: SeverityMedium, Kind: "Service", Namespace: "web", Name: "lb", Message: "exposed"},
+	}
+	if err := NotifyAll(notifiers, findings); err != nil {
+		t.Fatalf("NotifyAll() returned error = %v, want nil", err)
+	}
+
+	if len(bodies) != 1 {
+		t.Fatalf("NotifyAll() sent %d messages, want 1 (teams has no critical findings)", len(bodies))
+	}
+	var payload map[string]string
+	if err := json.Unmarshal([]byte(bodies[0]), &payload); err != nil {
+		t.Fatalf("Slack payload is not JSON: %v", err)
+	}
+	if !strings.Contains(payload["text"], "node n1 is NotReady") || strings.Contains(payload["text"], "exposed") {
+		t.Errorf("Slack payload text = %q, want only the high severity finding", payload["text"])
+	}
+}
+
+func TestNotifier_NotifyReportsHTTPErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	notifiers, err := NewNotifiers(&NotificationConfig{Notifiers: []NotifierConfig{
+		{Type: NotifierWebhook, URL: server.URL, MinSeverity: SeverityInfo},
+	}})
+	if err != nil {
+		t.Fatalf("NewNotifiers() returned error = %v, want nil", err)
+	}
+	if err := NotifyAll(notifiers, []Finding{{Severity: SeverityLow}}); err == nil {
+		t.Errorf("NotifyAll() returned error = nil, want non-nil for HTTP 403")
+	}
+}
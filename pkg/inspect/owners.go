@@ -0,0 +1,162 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxOwnerDepth bounds how many controller references are followed from a pod. Pod -> ReplicaSet
+// -> Deployment and Pod -> Job -> CronJob need two.
+const maxOwnerDepth = 4
+
+// Owner is the top-level controller of a pod: the object to change to fix the pod, since
+// changes to the pod itself are undone when it is recreated.
+type Owner struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// HelmRelease is the Helm release that installed the owner, from the pod's standard Helm labels.
+	HelmRelease string `json:"helmRelease,omitempty"`
+}
+
+func (o Owner) String() string {
+	s := fmt.Sprintf("%s %s/%s", o.Kind, o.Namespace, o.Name)
+	if o.HelmRelease != "" {
+		s += " (Helm release " + o.HelmRelease + ")"
+	}
+	return s
+}
+
+// describePod names a pod by its owner when it has one, falling back to namespace/name.
+func describePod(namespace, name string, owner *Owner) string {
+	if owner == nil {
+		return namespace + "/" + name
+	}
+	return fmt.Sprintf("%s, pod %s", owner, name)
+}
+
+// OwnerResolver maps pods to their top-level owners.
+type OwnerResolver struct {
+	// owners is keyed by namespace/name of the pod.
+	owners map[string]Owner
+}
+
+// GetOwnerResolver lists the pods and the ReplicaSets and Jobs between them and their owners.
+func GetOwnerResolver(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*OwnerResolver, error) {
+	pods, err := listPods(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	replicaSets, err := listReplicaSets(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	jobs, err := listJobs(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	return NewOwnerResolver(pods, replicaSets, jobs), nil
+}
+
+// NewOwnerResolver resolves the owner of every pod by following controller references through
+// ReplicaSets and Jobs. Pods without a controller are left out, since they are their own owner.
+func NewOwnerResolver(pods []corev1.Pod, replicaSets []appsv1.ReplicaSet, jobs []batchv1.Job) *OwnerResolver {
+	controllers := map[string]*metav1.OwnerReference{}
+	for i := range replicaSets {
+		rs := &replicaSets[i]
+		controllers[rs.Namespace+"/ReplicaSet/"+rs.Name] = metav1.GetControllerOf(rs)
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		controllers[job.Namespace+"/Job/"+job.Name] = metav1.GetControllerOf(job)
+	}
+
+	r := &OwnerResolver{owners: map[string]Owner{}}
+	for i := range pods {
+		pod := &pods[i]
+		ref := metav1.GetControllerOf(pod)
+		if ref == nil {
+			continue
+		}
+		for depth := 1; depth < maxOwnerDepth; depth++ {
+			next := controllers[pod.Namespace+"/"+ref.Kind+"/"+ref.Name]
+			if next == nil {
+				break
+			}
+			ref = next
+		}
+		r.owners[pod.Namespace+"/"+pod.Name] = Owner{
+			Kind:        ref.Kind,
+			Namespace:   pod.Namespace,
+			Name:        ref.Name,
+			HelmRelease: helmRelease(pod.Labels),
+		}
+	}
+	return r
+}
+
+// Owner returns the top-level owner of a pod, or nil when the pod is unknown or has no controller.
+func (r *OwnerResolver) Owner(namespace, name string) *Owner {
+	if r == nil {
+		return nil
+	}
+	owner, ok := r.owners[namespace+"/"+name]
+	if !ok {
+		return nil
+	}
+	return &owner
+}
+
+// helmRelease reads the release name from the labels Helm charts conventionally set, including
+// the heritage/release pair older charts use.
+func helmRelease(labels map[string]string) string {
+	if labels["app.kubernetes.io/managed-by"] == "Helm" {
+		return labels["app.kubernetes.io/instance"]
+	}
+	if labels["heritage"] == "Helm" || labels["heritage"] == "Tiller" {
+		return labels["release"]
+	}
+	return ""
+}
+
+// attachOwners fills in the owner of every pod the report lists.
+func attachOwners(report *Report, r *OwnerResolver) {
+	for i := range report.Findings {
+		f := &report.Findings[i]
+		if f.Kind == "Pod" {
+			f.Owner = r.Owner(f.Namespace, f.Name)
+		}
+	}
+	if report.Workloads != nil {
+		for i := range report.Workloads.Namespaces {
+			pods := report.Workloads.Namespaces[i].UnhealthyPods
+			for j := range pods {
+				pods[j].Owner = r.Owner(pods[j].Namespace, pods[j].Name)
+			}
+		}
+	}
+	if report.Scheduling != nil {
+		for i := range report.Scheduling.Pending {
+			p := &report.Scheduling.Pending[i]
+			p.Owner = r.Owner(p.Namespace, p.Name)
+		}
+	}
+	if report.Utilization != nil {
+		for i := range report.Utilization.Pods {
+			p := &report.Utilization.Pods[i]
+			p.Owner = r.Owner(p.Namespace, p.Name)
+		}
+	}
+	if report.Priorities != nil {
+		for i := range report.Priorities.AtRisk {
+			p := &report.Priorities.AtRisk[i]
+			p.Owner = r.Owner(p.Namespace, p.Name)
+		}
+	}
+}
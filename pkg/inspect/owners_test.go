@@ -0,0 +1,90 @@
+package inspect
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func controllerRef(kind, name string) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}}
+}
+
+func TestOwnerResolver(t *testing.T) {
+	meta := func(name string, labels map[string]string, owners []metav1.OwnerReference) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Namespace: "shop", Name: name, Labels: labels, OwnerReferences: owners}
+	}
+	pods := []corev1.Pod{
+		{ObjectMeta: meta("web-7d9f-abcde", map[string]string{"app.kubernetes.io/managed-by": "Helm", "app.kubernetes.io/instance": "storefront"}, controllerRef("ReplicaSet", "web-7d9f"))},
+		{ObjectMeta: meta("report-28810-xyz", nil, controllerRef("Job", "report-28810"))},
+		{ObjectMeta: meta("db-0", map[string]string{"heritage": "Helm", "release": "db"}, controllerRef("StatefulSet", "db"))},
+		{ObjectMeta: meta("orphan-rs-abc", nil, controllerRef("ReplicaSet", "orphan-rs"))},
+		{ObjectMeta: meta("standalone", nil, nil)},
+	}
+	replicaSets := []appsv1.ReplicaSet{
+		{ObjectMeta: meta("web-7d9f", nil, controllerRef("Deployment", "web"))},
+		{ObjectMeta: meta("orphan-rs", nil, nil)},
+	}
+	jobs := []batchv1.Job{{ObjectMeta: meta("report-28810", nil, controllerRef("CronJob", "report"))}}
+
+	r := NewOwnerResolver(pods, replicaSets, jobs)
+	tests := []struct {
+		pod  string
+		want string
+	}{
+		{"web-7d9f-abcde", "Deployment shop/web (Helm release storefront)"},
+		{"report-28810-xyz", "CronJob shop/report"},
+		{"db-0", "StatefulSet shop/db (Helm release db)"},
+		{"orphan-rs-abc", "ReplicaSet shop/orphan-rs"},
+	}
+	for _, tt := range tests {
+		owner := r.Owner("shop", tt.pod)
+		if owner == nil {
+			t.Errorf("Owner(%s) = nil, want %s", tt.pod, tt.want)
+			continue
+		}
+		if got := owner.String(); got != tt.want {
+			t.Errorf("Owner(%s) = %s, want %s", tt.pod, got, tt.want)
+		}
+	}
+	if owner := r.Owner("shop", "standalone"); owner != nil {
+		t.Errorf("Owner(standalone) = %s, want nil", owner)
+	}
+	if owner := (*OwnerResolver)(nil).Owner("shop", "web-7d9f-abcde"); owner != nil {
+		t.Errorf("nil resolver returned %s", owner)
+	}
+}
+
+func TestAttachOwners(t *testing.T) {
+	pods := []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web-1", OwnerReferences: controllerRef("StatefulSet", "web")}}}
+	r := NewOwnerResolver(pods, nil, nil)
+	report := &Report{
+		Findings: []Finding{
+			{CheckID: "pod-not-starting", Kind: "Pod", Namespace: "shop", Name: "web-1"},
+			{CheckID: "public-loadbalancer", Kind: "Service", Namespace: "shop", Name: "web-1"},
+		},
+		Workloads:   &WorkloadHealth{Namespaces: []NamespaceWorkloads{{Namespace: "shop", UnhealthyPods: []UnhealthyPod{{Namespace: "shop", Name: "web-1"}}}}},
+		Utilization: &Utilization{Pods: []PodUsage{{Namespace: "shop", Name: "web-1"}, {Namespace: "shop", Name: "gone"}}},
+	}
+	attachOwners(report, r)
+
+	if o := report.Findings[0].Owner; o == nil || o.Kind != "StatefulSet" {
+		t.Errorf("pod finding owner = %v, want StatefulSet", o)
+	}
+	if o := report.Findings[1].Owner; o != nil {
+		t.Errorf("service finding owner = %v, want nil", o)
+	}
+	if o := report.Workloads.Namespaces[0].UnhealthyPods[0].Owner; o == nil {
+		t.Error("unhealthy pod owner not attached")
+	}
+	if got := describePod("shop", "web-1", report.Utilization.Pods[0].Owner); got != "StatefulSet shop/web, pod web-1" {
+		t.Errorf("describePod = %q", got)
+	}
+	if got := describePod("shop", "gone", report.Utilization.Pods[1].Owner); got != "shop/gone" {
+		t.Errorf("describePod = %q", got)
+	}
+}
@@ -0,0 +1,153 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Self-managed distributions kube-op recognizes, alongside the managed PlatformEKS, PlatformGKE, and PlatformAKS.
+const (
+	PlatformOpenShift = "OpenShift"
+	PlatformK3s       = "k3s"
+	PlatformKubeadm   = "kubeadm"
+)
+
+// platformNodeSample is how many nodes are inspected for platform labels and provider IDs.
+const platformNodeSample = 5
+
+// PlatformInfo is the platform hosting the cluster.
+type PlatformInfo struct {
+	// Name is EKS, GKE, AKS, OpenShift, k3s, or kubeadm, or empty when unrecognized.
+	Name string `json:"name,omitempty"`
+	// Managed is set when the provider runs the control plane, so etcd and the control-plane
+	// nodes are not visible from inside the cluster.
+	Managed bool `json:"managed"`
+	// Provider is the infrastructure provider from the nodes' provider IDs, such as aws or gce.
+	Provider string `json:"provider,omitempty"`
+	// Version is the control-plane version the platform reports.
+	Version string `json:"version"`
+	// NodeImages are the managed node image versions, when the platform labels nodes with them.
+	NodeImages []string `json:"nodeImages,omitempty"`
+	// Evidence lists the signals the detection is based on.
+	Evidence []string `json:"evidence,omitempty"`
+}
+
+// String names the platform for the text report.
+func (p *PlatformInfo) String() string {
+	name := p.Name
+	if name == "" {
+		name = "unrecognized platform"
+	}
+	if p.Managed {
+		name += " (managed control plane)"
+	}
+	if p.Provider != "" {
+		name += " on " + p.Provider
+	}
+	return name
+}
+
+// PlatformSignals are the cluster facts platform detection works from.
+type PlatformSignals struct {
+	GitVersion string
+	Nodes      []corev1.Node
+	Namespaces []string
+	// KubeadmConfig is set when kube-system has the kubeadm-config ConfigMap.
+	KubeadmConfig bool
+}
+
+// GetPlatform collects the signals for DetectPlatform: a sample of nodes, the namespace names,
+// and whether kubeadm's ConfigMap exists.
+func GetPlatform(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, gitVersion string) (*PlatformInfo, error) {
+	signals := PlatformSignals{GitVersion: gitVersion}
+
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{Limit: platformNodeSample})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	signals.Nodes = nodes.Items
+
+	namespaces, err := listNamespaces(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	for _, ns := range namespaces {
+		signals.Namespaces = append(signals.Namespaces, ns.Name)
+	}
+
+	_, err = clientset.CoreV1().ConfigMaps("kube-system").Get(ctx, "kubeadm-config", metav1.GetOptions{})
+	switch {
+	case err == nil:
+		signals.KubeadmConfig = true
+	case !apierrors.IsNotFound(err) && !apierrors.IsForbidden(err):
+		return nil, fmt.Errorf("failed to get kubeadm-config: %w", err)
+	}
+
+	return DetectPlatform(signals), nil
+}
+
+// DetectPlatform identifies the hosting platform. Managed platforms are recognized by
+// DetectManagedPlatform; OpenShift by its openshift-* namespaces; k3s by its version suffix or
+// node labels; and kubeadm by its ConfigMap in kube-system.
+func DetectPlatform(s PlatformSignals) *PlatformInfo {
+	p := &PlatformInfo{Version: s.GitVersion}
+
+	if managed := DetectManagedPlatform(s.GitVersion, s.Nodes); managed != "" {
+		p.Name, p.Managed = managed, true
+		p.Evidence = append(p.Evidence, "API server version and node labels")
+	}
+
+	if p.Name == "" {
+		for _, ns := range s.Namespaces {
+			if ns == "openshift-apiserver" || ns == "openshift-config" {
+				p.Name = PlatformOpenShift
+				p.Evidence = append(p.Evidence, "namespace "+ns)
+				break
+			}
+		}
+	}
+	if p.Name == "" && strings.Contains(s.GitVersion, "+k3s") {
+		p.Name = PlatformK3s
+		p.Evidence = append(p.Evidence, "API server version "+s.GitVersion)
+	}
+	if p.Name == "" {
+		for _, node := range s.Nodes {
+			if node.Labels["node.kubernetes.io/instance-type"] == "k3s" || strings.HasPrefix(node.Spec.ProviderID, "k3s://") {
+				p.Name = PlatformK3s
+				p.Evidence = append(p.Evidence, "node "+node.Name+" labels")
+				break
+			}
+		}
+	}
+	if p.Name == "" && s.KubeadmConfig {
+		p.Name = PlatformKubeadm
+		p.Evidence = append(p.Evidence, "kube-system/kubeadm-config ConfigMap")
+	}
+
+	images := map[string]struct{}{}
+	for _, node := range s.Nodes {
+		if p.Provider == "" {
+			if scheme, _, ok := strings.Cut(node.Spec.ProviderID, "://"); ok && scheme != "k3s" {
+				p.Provider = scheme
+				p.Evidence = append(p.Evidence, "node provider ID")
+			}
+		}
+		for _, label := range []string{"kubernetes.azure.com/node-image-version", "eks.amazonaws.com/nodegroup-image"} {
+			if v := node.Labels[label]; v != "" {
+				images[v] = struct{}{}
+			}
+		}
+	}
+	for image := range images {
+		p.NodeImages = append(p.NodeImages, image)
+	}
+	sort.Strings(p.NodeImages)
+	return p
+}
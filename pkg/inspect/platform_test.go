@@ -0,0 +1,79 @@
+package inspect
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDetectPlatform(t *testing.T) {
+	eksNode := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "ip-10-0-0-1", Labels: map[string]string{
+			"eks.amazonaws.com/nodegroup":       "default",
+			"eks.amazonaws.com/nodegroup-image": "ami-0abc",
+		}},
+		Spec: corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-0123"},
+	}
+	k3sNode := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "pi", Labels: map[string]string{"node.kubernetes.io/instance-type": "k3s"}},
+		Spec:       corev1.NodeSpec{ProviderID: "k3s://pi"},
+	}
+
+	tests := []struct {
+		name        string
+		signals     PlatformSignals
+		wantName    string
+		wantManaged bool
+		wantProv    string
+	}{
+		{"eks", PlatformSignals{GitVersion: "v1.30.4-eks-a737599", Nodes: []corev1.Node{eksNode}}, PlatformEKS, true, "aws"},
+		{"openshift namespaces", PlatformSignals{GitVersion: "v1.30.4", Namespaces: []string{"default", "openshift-config"}}, PlatformOpenShift, false, ""},
+		{"k3s version", PlatformSignals{GitVersion: "v1.30.4+k3s1"}, PlatformK3s, false, ""},
+		{"k3s node", PlatformSignals{GitVersion: "v1.30.4", Nodes: []corev1.Node{k3sNode}}, PlatformK3s, false, ""},
+		{"kubeadm configmap", PlatformSignals{GitVersion: "v1.30.4", KubeadmConfig: true}, PlatformKubeadm, false, ""},
+		{"unrecognized", PlatformSignals{GitVersion: "v1.30.4"}, "", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectPlatform(tt.signals)
+			if got.Name != tt.wantName || got.Managed != tt.wantManaged || got.Provider != tt.wantProv {
+				t.Errorf("DetectPlatform() = %s managed=%v provider=%q, want %s managed=%v provider=%q",
+					got.Name, got.Managed, got.Provider, tt.wantName, tt.wantManaged, tt.wantProv)
+			}
+			if got.Version != tt.signals.GitVersion {
+				t.Errorf("Version = %q, want %q", got.Version, tt.signals.GitVersion)
+			}
+		})
+	}
+}
+
+func TestDetectPlatformNodeImages(t *testing.T) {
+	node := func(image string) corev1.Node {
+		return corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"kubernetes.azure.com/node-image-version": image}}}
+	}
+	got := DetectPlatform(PlatformSignals{GitVersion: "v1.30.3", Nodes: []corev1.Node{node("b"), node("a"), node("b")}})
+	if got.Name != PlatformAKS || !got.Managed {
+		t.Fatalf("got %s, want managed AKS", got)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got.NodeImages, want) {
+		t.Errorf("NodeImages = %v, want %v", got.NodeImages, want)
+	}
+}
+
+func TestPlatformInfoString(t *testing.T) {
+	tests := []struct {
+		p    PlatformInfo
+		want string
+	}{
+		{PlatformInfo{Name: PlatformGKE, Managed: true, Provider: "gce"}, "GKE (managed control plane) on gce"},
+		{PlatformInfo{Name: PlatformKubeadm}, "kubeadm"},
+		{PlatformInfo{}, "unrecognized platform"},
+	}
+	for _, tt := range tests {
+		if got := tt.p.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// ExecPluginPrefix starts the name of every exec plugin executable: kube-op-<name> on PATH.
+const ExecPluginPrefix = "kube-op-"
+
+// ExecPluginProtocol is the plugin protocol version passed to exec plugins in KUBE_OP_PLUGIN_PROTOCOL.
+const ExecPluginProtocol = "1"
+
+// pluginStderrLimit caps how much of a failed plugin's stderr is kept in its error.
+const pluginStderrLimit = 1024
+
+// pluginNamePattern is what plugin names must look like to be used as collector names.
+var pluginNamePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Plugin is a custom collector compiled into a program that uses this package, for checks
+// specific to one organization. Register it with RegisterPlugin before running any scan.
+type Plugin interface {
+	// Name is the collector name used with --collectors and --skip-collectors: lowercase
+	// letters, digits, and dashes.
+	Name() string
+	Description() string
+	// RBAC lists the API access the plugin needs, for kube-op rbac-requirements.
+	RBAC() []Permission
+	// Run returns the plugin's findings. An error is recorded under the plugin's section;
+	// findings returned with it are still reported.
+	Run(ctx context.Context, env PluginEnv) ([]Finding, error)
+}
+
+// PluginEnv is what a Plugin can use to inspect the cluster.
+type PluginEnv struct {
+	Clientset kubernetes.Interface
+	Dynamic   dynamic.Interface
+	// Config is nil when the scan runs against clients not built from a REST config.
+	Config  *rest.Config
+	Options ScanOptions
+}
+
+// RegisterPlugin adds the plugin to the collectors, after the built-in ones. It is not safe to
+// call while a scan runs.
+func RegisterPlugin(p Plugin) error {
+	name := p.Name()
+	if !pluginNamePattern.MatchString(name) {
+		return fmt.Errorf("plugin name %q must be lowercase letters, digits, and dashes", name)
+	}
+	if lookupCollector(name) != nil {
+		return fmt.Errorf("a collector named %q is already registered", name)
+	}
+	collectorRegistry = append(collectorRegistry, &Collector{
+		Name: name, Description: p.Description(), RBAC: p.RBAC(),
+		section: "plugin:" + name,
+		run: func(s *scanState) ([]Finding, error) {
+			return p.Run(s.ctx, PluginEnv{Clientset: s.clientset, Dynamic: s.dynamic, Config: s.config, Options: s.opts})
+		},
+	})
+	return nil
+}
+
+// PluginOptions controls the exec plugins a scan runs.
+type PluginOptions struct {
+	// Enabled runs every kube-op-<name> executable on PATH. They run with the user's own
+	// credentials, so they are only run when asked for.
+	Enabled bool
+	// Env is added to each plugin's environment, such as KUBECONFIG and KUBE_OP_CONTEXT.
+	Env []string
+	// Timeout bounds each plugin run.
+	Timeout time.Duration
+}
+
+// ExecPlugin is a kube-op-<name> executable found on PATH.
+type ExecPlugin struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Shadowed lists executables of the same name later on PATH, which are not run.
+	Shadowed []string `json:"shadowed,omitempty"`
+}
+
+// FindExecPlugins returns the exec plugins in the directories of path, a PATH-style list, sorted
+// by name. As with commands, the first executable of a name on the path wins.
+func FindExecPlugins(path string) []ExecPlugin {
+	byName := map[string]*ExecPlugin{}
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name, ok := strings.CutPrefix(e.Name(), ExecPluginPrefix)
+			if !ok || name == "" {
+				continue
+			}
+			file := filepath.Join(dir, e.Name())
+			info, err := os.Stat(file)
+			if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+				continue
+			}
+			if p, ok := byName[name]; ok {
+				p.Shadowed = append(p.Shadowed, file)
+				continue
+			}
+			byName[name] = &ExecPlugin{Name: name, Path: file}
+		}
+	}
+	plugins := make([]ExecPlugin, 0, len(byName))
+	for _, p := range byName {
+		plugins = append(plugins, *p)
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// PluginResult is the outcome of running one exec plugin.
+type PluginResult struct {
+	Name     string        `json:"name"`
+	Path     string        `json:"path"`
+	Findings int           `json:"findings"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// execPluginOutput is what an exec plugin writes to stdout.
+type execPluginOutput struct {
+	Findings []Finding `json:"findings"`
+}
+
+// runExecPlugin runs one exec plugin and parses its findings. The plugin gets the scan's
+// environment plus opts.Plugins.Env, KUBE_OP_PLUGIN_PROTOCOL, KUBE_OP_CLUSTER, and
+// KUBE_OP_TIMEOUT, and must write {"findings": [...]} to stdout and exit zero.
+func runExecPlugin(ctx context.Context, plugin ExecPlugin, opts ScanOptions) ([]Finding, error) {
+	if opts.Plugins.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Plugins.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, plugin.Path)
+	cmd.Env = append(os.Environ(), opts.Plugins.Env...)
+	cmd.Env = append(cmd.Env,
+		"KUBE_OP_PLUGIN_PROTOCOL="+ExecPluginProtocol,
+		"KUBE_OP_CLUSTER="+opts.ClusterName,
+		"KUBE_OP_TIMEOUT="+opts.Timeout.String(),
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			if len(msg) > pluginStderrLimit {
+				msg = msg[:pluginStderrLimit] + "..."
+			}
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	var output execPluginOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, fmt.Errorf("failed to parse findings: %w", err)
+	}
+	for i, f := range output.Findings {
+		if f.CheckID == "" || f.Kind == "" || f.Name == "" {
+			return nil, fmt.Errorf("finding %d needs checkId, kind, and name", i)
+		}
+		severity, err := ParseSeverity(string(f.Severity))
+		if err != nil {
+			return nil, fmt.Errorf("finding %d: %w", i, err)
+		}
+		// Fingerprints and owners are kube-op's to fill in.
+		output.Findings[i] = Finding{CheckID: f.CheckID, Severity: severity, Kind: f.Kind, Namespace: f.Namespace, Name: f.Name, Message: f.Message}
+	}
+	return output.Findings, nil
+}
+
+// runExecPlugins runs the exec plugins on PATH, opts.Concurrency at a time. A failed plugin is
+// recorded in its result and in the returned error, and the others are still run.
+func runExecPlugins(ctx context.Context, opts ScanOptions) ([]PluginResult, []Finding, error) {
+	plugins := FindExecPlugins(os.Getenv("PATH"))
+	results := make([]PluginResult, len(plugins))
+	found := make([][]Finding, len(plugins))
+	errs := make([]error, len(plugins))
+	tasks := make([]func(), len(plugins))
+	for i, p := range plugins {
+		tasks[i] = func() {
+			start := time.Now()
+			found[i], errs[i] = runExecPlugin(ctx, p, opts)
+			results[i] = PluginResult{Name: p.Name, Path: p.Path, Findings: len(found[i]), Duration: time.Since(start).Round(time.Millisecond)}
+			if errs[i] != nil {
+				results[i].Error = errs[i].Error()
+				errs[i] = fmt.Errorf("plugin %s: %w", p.Name, errs[i])
+			}
+		}
+	}
+	runConcurrently(opts.Concurrency, tasks...)
+
+	var findings []Finding
+	for _, f := range found {
+		findings = append(findings, f...)
+	}
+	return results, findings, errors.Join(errs...)
+}
+
+// PrintExecPlugins writes the plugin list for kube-op plugins list.
+func PrintExecPlugins(w io.Writer, plugins []ExecPlugin) {
+	if len(plugins) == 0 {
+		fmt.Fprintf(w, "No %s<name> executables found on PATH.\n", ExecPluginPrefix)
+		return
+	}
+	for _, p := range plugins {
+		fmt.Fprintf(w, "%s\t%s\n", p.Name, p.Path)
+		for _, s := range p.Shadowed {
+			fmt.Fprintf(w, "  warning: %s is shadowed by %s and will not run\n", s, p.Path)
+		}
+	}
+}
+
+// printPluginResults writes the exec plugin section of the text report.
+func printPluginResults(w io.Writer, results []PluginResult) {
+	fmt.Fprintln(w, "Plugins:")
+	if len(results) == 0 {
+		fmt.Fprintf(w, "  No %s<name> executables found on PATH.\n", ExecPluginPrefix)
+	}
+	for _, r := range results {
+		status := fmt.Sprintf("%d finding(s)", r.Findings)
+		if r.Error != "" {
+			status = "failed"
+		}
+		fmt.Fprintf(w, "  - %s (%s): %s in %s\n", r.Name, r.Path, status, r.Duration)
+	}
+}
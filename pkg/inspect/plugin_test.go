@@ -0,0 +1,130 @@
+package inspect
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	file := filepath.Join(dir, ExecPluginPrefix+name)
+	if err := os.WriteFile(file, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestFindExecPlugins(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	owners := writePlugin(t, first, "owners", "")
+	shadowed := writePlugin(t, second, "owners", "")
+	tags := writePlugin(t, second, "tags", "")
+	if err := os.WriteFile(filepath.Join(second, ExecPluginPrefix+"notes"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := FindExecPlugins(first + string(os.PathListSeparator) + second)
+	want := []ExecPlugin{{Name: "owners", Path: owners, Shadowed: []string{shadowed}}, {Name: "tags", Path: tags}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindExecPlugins() = %+v, want %+v", got, want)
+	}
+}
+
+func TestScanRunsExecPlugins(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "owners", `[ "$KUBE_OP_PLUGIN_PROTOCOL" = 1 ] || exit 3
+echo '{"findings": [{"checkId": "team-label-missing", "severity": "Medium", "kind": "Deployment", "namespace": "'"$KUBE_OP_CONTEXT"'", "name": "web", "message": "no team label"}]}'
+`)
+	writePlugin(t, dir, "broken", "echo 'cannot reach the CMDB' >&2\nexit 1\n")
+	t.Setenv("PATH", dir)
+
+	clientset, dynamicClient := newFakeClients()
+	opts := AutoTuneScanOptions(ClusterSize{}).WithOverrides(ScanOptions{
+		Collectors: []string{"debug"},
+		Plugins:    PluginOptions{Enabled: true, Env: []string{"KUBE_OP_CONTEXT=shop"}, Timeout: 10 * time.Second},
+	})
+	report, err := runScan(&scanState{ctx: context.Background(), clientset: clientset, dynamic: dynamicClient, opts: opts})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Findings) != 1 {
+		t.Fatalf("Findings = %+v, want the owners plugin's finding", report.Findings)
+	}
+	if f := report.Findings[0]; f.CheckID != "team-label-missing" || f.Severity != SeverityMedium || f.Namespace != "shop" || f.Fingerprint == "" {
+		t.Errorf("finding = %+v, want a fingerprinted medium finding in the context's namespace", f)
+	}
+	if msg := report.Errors[sectionPlugins]; !strings.Contains(msg, "plugin broken") || !strings.Contains(msg, "cannot reach the CMDB") {
+		t.Errorf("Errors[plugins] = %q, want the broken plugin's stderr", msg)
+	}
+	if len(report.Plugins) != 2 || report.Plugins[0].Error == "" || report.Plugins[1].Findings != 1 {
+		t.Errorf("Plugins = %+v, want broken failed and owners with one finding", report.Plugins)
+	}
+}
+
+func TestRunExecPluginRejectsBadFindings(t *testing.T) {
+	dir := t.TempDir()
+	for name, output := range map[string]string{
+		"not-json":      "findings",
+		"no-name":       `{"findings": [{"checkId": "x", "severity": "low", "kind": "Pod"}]}`,
+		"bad-severity":  `{"findings": [{"checkId": "x", "severity": "urgent", "kind": "Pod", "name": "web"}]}`,
+		"empty-default": `{"findings": [{"checkId": "x", "kind": "Pod", "name": "web"}]}`,
+	} {
+		path := writePlugin(t, dir, name, "echo '"+output+"'\n")
+		if _, err := runExecPlugin(context.Background(), ExecPlugin{Name: name, Path: path}, ScanOptions{}); err == nil {
+			t.Errorf("runExecPlugin(%s) returned error = nil", name)
+		}
+	}
+}
+
+type fakePlugin struct {
+	name     string
+	findings []Finding
+	err      error
+}
+
+func (p fakePlugin) Name() string        { return p.name }
+func (p fakePlugin) Description() string { return "Checks things only this organization cares about" }
+func (p fakePlugin) RBAC() []Permission {
+	return []Permission{allow("", "configmaps", "list")}
+}
+
+func (p fakePlugin) Run(ctx context.Context, env PluginEnv) ([]Finding, error) {
+	if env.Clientset == nil {
+		return nil, errors.New("no clientset")
+	}
+	return p.findings, p.err
+}
+
+func TestRegisterPlugin(t *testing.T) {
+	registry := collectorRegistry
+	t.Cleanup(func() { collectorRegistry = registry })
+
+	finding := Finding{CheckID: "cost-center-missing", Severity: SeverityLow, Kind: "Namespace", Name: "shop"}
+	if err := RegisterPlugin(fakePlugin{name: "cost-centers", findings: []Finding{finding}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterPlugin(fakePlugin{name: "flaky", err: errors.New("CMDB timed out")}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"cost-centers", "debug", "Bad_Name"} {
+		if err := RegisterPlugin(fakePlugin{name: name}); err == nil {
+			t.Errorf("RegisterPlugin(%q) returned error = nil", name)
+		}
+	}
+
+	clientset, dynamicClient := newFakeClients()
+	report := fakeScan(t, clientset, dynamicClient, "cost-centers", "flaky")
+	if got := checkIDs(report.Findings); !reflect.DeepEqual(got, []string{"cost-center-missing"}) {
+		t.Errorf("Findings = %v, want the plugin's finding", got)
+	}
+	if msg := report.Errors["plugin:flaky"]; msg != "CMDB timed out" {
+		t.Errorf("Errors[plugin:flaky] = %q", msg)
+	}
+}
@@ -0,0 +1,209 @@
+package inspect
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultPolicyFile is the policy file scans read from the working directory unless --policy
+// names another.
+const DefaultPolicyFile = ".kube-op.yaml"
+
+const policyHeader = `# kube-op policy: findings accepted here are left out of scan results, notifications, and
+# --fail-on. resource is kind/namespace/name (kind/name for cluster-scoped objects), where *
+# matches any part of one segment.
+`
+
+// Policy is the on-disk policy file, in YAML or JSON: the findings the team has accepted, so
+// repeated scans only report new ones.
+type Policy struct {
+	Accepted []AcceptedFinding `json:"accepted"`
+}
+
+// AcceptedFinding accepts the findings of one check against the resources it matches.
+type AcceptedFinding struct {
+	// CheckID is the check to accept, or * for every check.
+	CheckID string `json:"checkId"`
+	// Resource is a glob matched against the finding's kind/namespace/name.
+	Resource string `json:"resource"`
+	Reason   string `json:"reason,omitempty"`
+	// Expires is the date, as YYYY-MM-DD, from which the finding is reported again.
+	Expires string `json:"expires,omitempty"`
+
+	expires time.Time
+}
+
+// LoadPolicy reads and validates a policy file.
+func LoadPolicy(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var policy Policy
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file %s: %w", file, err)
+	}
+	for i := range policy.Accepted {
+		a := &policy.Accepted[i]
+		if a.CheckID == "" || a.Resource == "" {
+			return nil, fmt.Errorf("policy file %s: accepted[%d] needs both checkId and resource", file, i)
+		}
+		if _, err := path.Match(a.Resource, ""); err != nil {
+			return nil, fmt.Errorf("policy file %s: accepted[%d] resource %q: %w", file, i, a.Resource, err)
+		}
+		if a.Expires != "" {
+			if a.expires, err = time.Parse(time.DateOnly, a.Expires); err != nil {
+				return nil, fmt.Errorf("policy file %s: accepted[%d] expires %q is not a YYYY-MM-DD date", file, i, a.Expires)
+			}
+		}
+	}
+	return &policy, nil
+}
+
+// matches reports whether the entry covers the finding, regardless of expiry.
+func (a AcceptedFinding) matches(f Finding) bool {
+	if a.CheckID != "*" && a.CheckID != f.CheckID {
+		return false
+	}
+	ok, _ := path.Match(a.Resource, f.Resource())
+	return ok
+}
+
+// Expired reports whether the acceptance has ended by now.
+func (a AcceptedFinding) Expired(now time.Time) bool {
+	return !a.expires.IsZero() && !now.Before(a.expires)
+}
+
+// Apply splits findings into those the policy does not accept and those it does. Expired
+// entries accept nothing.
+func (p *Policy) Apply(findings []Finding, now time.Time) (kept, suppressed []Finding) {
+	kept = make([]Finding, 0, len(findings))
+	for _, f := range findings {
+		accepted := false
+		for _, a := range p.Accepted {
+			if a.matches(f) && !a.Expired(now) {
+				accepted = true
+				break
+			}
+		}
+		if accepted {
+			suppressed = append(suppressed, f)
+		} else {
+			kept = append(kept, f)
+		}
+	}
+	return kept, suppressed
+}
+
+// ApplyPolicy replaces the policy a report was scanned with, such as when rendering a bundle.
+// The findings the old policy accepted are judged again, with expiry as of the scan.
+func (r *Report) ApplyPolicy(p *Policy) {
+	findings := append(r.Findings, r.Suppressed...)
+	sortFindings(findings)
+	r.Findings, r.Suppressed = p.Apply(findings, r.GeneratedAt)
+}
+
+// Baseline returns a policy that accepts every finding: the existing entries, expired or not,
+// plus an entry with reason for each check and resource no entry covers yet. Existing entries
+// that match none of the findings are dropped when prunable reports that the scan would have
+// found what they match, so the file reflects the cluster as it is; a nil prunable keeps them
+// all. It also returns how many entries were added, and how many that matched nothing were kept.
+func (p *Policy) Baseline(findings []Finding, prunable func(AcceptedFinding) bool, reason string) (*Policy, int, int) {
+	baseline := &Policy{Accepted: []AcceptedFinding{}}
+	kept := 0
+	for _, a := range p.Accepted {
+		used := false
+		for _, f := range findings {
+			used = used || a.matches(f)
+		}
+		if !used {
+			if prunable != nil && prunable(a) {
+				continue
+			}
+			kept++
+		}
+		baseline.Accepted = append(baseline.Accepted, a)
+	}
+
+	var added []AcceptedFinding
+	seen := map[string]bool{}
+	for _, f := range findings {
+		covered := false
+		for _, a := range baseline.Accepted {
+			covered = covered || a.matches(f)
+		}
+		key := f.CheckID + " " + f.Resource()
+		if covered || seen[key] {
+			continue
+		}
+		seen[key] = true
+		added = append(added, AcceptedFinding{CheckID: f.CheckID, Resource: escapeGlob(f.Resource()), Reason: reason})
+	}
+	sort.Slice(added, func(i, j int) bool {
+		if added[i].CheckID != added[j].CheckID {
+			return added[i].CheckID < added[j].CheckID
+		}
+		return added[i].Resource < added[j].Resource
+	})
+	baseline.Accepted = append(baseline.Accepted, added...)
+	return baseline, len(added), kept
+}
+
+// Covers reports whether the scan would have found what the entry accepts: its check belongs to
+// a collector in CompletedCollectors, and its resource can't be in a namespace sharded lists
+// failed in. Entries for every check, and for checks outside the collectors, such as those of
+// plugins, are never covered.
+func (r *Report) Covers(a AcceptedFinding) bool {
+	covered := false
+	for _, name := range r.CompletedCollectors {
+		if c := lookupCollector(name); c != nil && slices.Contains(c.Checks, a.CheckID) {
+			covered = true
+		}
+	}
+	if !covered {
+		return false
+	}
+	// Resources are kind/namespace/name, or kind/name when cluster-scoped.
+	if parts := strings.Split(a.Resource, "/"); len(parts) == 3 {
+		for namespace := range r.NamespaceErrors {
+			if ok, _ := path.Match(parts[1], namespace); ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// escapeGlob quotes the characters path.Match treats specially, so a resource matches itself only.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// WritePolicy writes the policy as YAML, with a comment explaining the format.
+func WritePolicy(w io.Writer, p *Policy) error {
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to encode policy: %w", err)
+	}
+	if _, err := io.WriteString(w, policyHeader); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
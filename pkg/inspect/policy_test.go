@@ -0,0 +1,184 @@
+package inspect
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), DefaultPolicyFile)
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestPolicyApply(t *testing.T) {
+	policy, err := LoadPolicy(writePolicy(t, `accepted:
+- checkId: public-loadbalancer
+  resource: Service/web/frontend
+  reason: public storefront
+- checkId: "*"
+  resource: Pod/sandbox/*
+- checkId: namespace-without-quota
+  resource: Namespace/*
+  expires: "2026-10-01"
+`))
+	if err != nil {
+		t.Fatalf("LoadPolicy() returned error = %v", err)
+	}
+
+	findings := []Finding{
+		{CheckID: "public-loadbalancer", Kind: "Service", Namespace: "web", Name: "frontend"},
+		{CheckID: "public-loadbalancer", Kind: "Service", Namespace: "web", Name: "admin"},
+		{CheckID: "pod-crash-looping", Kind: "Pod", Namespace: "sandbox", Name: "try-1"},
+		{CheckID: "namespace-without-quota", Kind: "Namespace", Name: "shop"},
+	}
+	kept, suppressed := policy.Apply(findings, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC))
+	resources := func(findings []Finding) []string {
+		var r []string
+		for _, f := range findings {
+			r = append(r, f.Resource())
+		}
+		return r
+	}
+	if got, want := resources(kept), []string{"Service/web/admin", "Namespace/shop"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("kept = %v, want %v (the quota entry has expired)", got, want)
+	}
+	if got, want := resources(suppressed), []string{"Service/web/frontend", "Pod/sandbox/try-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("suppressed = %v, want %v", got, want)
+	}
+}
+
+func TestReportApplyPolicy(t *testing.T) {
+	policy, err := LoadPolicy(writePolicy(t, "accepted:\n- checkId: public-loadbalancer\n  resource: Service/web/admin\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := &Report{
+		GeneratedAt: time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+		Findings:    []Finding{{CheckID: "public-loadbalancer", Severity: SeverityHigh, Kind: "Service", Namespace: "web", Name: "admin"}},
+		Suppressed:  []Finding{{CheckID: "pod-crash-looping", Severity: SeverityHigh, Kind: "Pod", Namespace: "sandbox", Name: "try-1"}},
+	}
+	report.ApplyPolicy(policy)
+	if len(report.Findings) != 1 || report.Findings[0].Name != "try-1" || len(report.Suppressed) != 1 || report.Suppressed[0].Name != "admin" {
+		t.Errorf("after ApplyPolicy: Findings = %+v, Suppressed = %+v, want the two swapped", report.Findings, report.Suppressed)
+	}
+}
+
+func TestLoadPolicyInvalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown field":   "accepted:\n- checkId: a\n  resource: Pod/x/y\n  severity: high\n",
+		"missing check":   "accepted:\n- resource: Pod/x/y\n",
+		"bad pattern":     "accepted:\n- checkId: a\n  resource: Pod/x/[y\n",
+		"bad expiry date": "accepted:\n- checkId: a\n  resource: Pod/x/y\n  expires: next week\n",
+	} {
+		if _, err := LoadPolicy(writePolicy(t, content)); err == nil {
+			t.Errorf("%s: LoadPolicy() returned error = nil, want non-nil", name)
+		}
+	}
+}
+
+func TestPolicyBaseline(t *testing.T) {
+	existing := &Policy{Accepted: []AcceptedFinding{
+		{CheckID: "public-loadbalancer", Resource: "Service/web/*", Reason: "public storefront"},
+		{CheckID: "debug-pod", Resource: "Pod/tools/shell"},
+	}}
+	findings := []Finding{
+		{CheckID: "public-loadbalancer", Kind: "Service", Namespace: "web", Name: "frontend"},
+		{CheckID: "quota-exhausted", Kind: "ResourceQuota", Namespace: "shop", Name: "compute", keyFields: []string{"cpu"}},
+		{CheckID: "quota-exhausted", Kind: "ResourceQuota", Namespace: "shop", Name: "compute", keyFields: []string{"memory"}},
+		{CheckID: "node-pool-drift", Kind: "NodePool", Name: "(no node group)"},
+		{CheckID: "ingress-class-unset", Kind: "Ingress", Namespace: "shop", Name: "web"},
+	}
+
+	prunable := func(AcceptedFinding) bool { return true }
+	baseline, added, kept := existing.Baseline(findings, prunable, "baseline")
+	want := []AcceptedFinding{
+		{CheckID: "public-loadbalancer", Resource: "Service/web/*", Reason: "public storefront"},
+		{CheckID: "ingress-class-unset", Resource: "Ingress/shop/web", Reason: "baseline"},
+		{CheckID: "node-pool-drift", Resource: "NodePool/(no node group)", Reason: "baseline"},
+		{CheckID: "quota-exhausted", Resource: "ResourceQuota/shop/compute", Reason: "baseline"},
+	}
+	if added != 3 || kept != 0 || !reflect.DeepEqual(baseline.Accepted, want) {
+		t.Errorf("Baseline() = %+v, %d, %d; want %+v, 3, 0", baseline.Accepted, added, kept, want)
+	}
+	if kept, _ := baseline.Apply(findings, time.Now()); len(kept) != 0 {
+		t.Errorf("baseline policy keeps %v, want every finding accepted", kept)
+	}
+
+	if unpruned, _, kept := existing.Baseline(findings, nil, "baseline"); len(unpruned.Accepted) != 5 || kept != 1 {
+		t.Errorf("Baseline() without prunable has %d entries, %d kept; want the unused debug-pod entry kept too", len(unpruned.Accepted), kept)
+	}
+
+	var b bytes.Buffer
+	if err := WritePolicy(&b, baseline); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := LoadPolicy(writePolicy(t, b.String()))
+	if err != nil {
+		t.Fatalf("LoadPolicy() of the written baseline returned error = %v", err)
+	}
+	if !reflect.DeepEqual(reloaded.Accepted, baseline.Accepted) {
+		t.Errorf("reloaded = %+v, want %+v", reloaded.Accepted, baseline.Accepted)
+	}
+}
+
+func TestBaselinePrunesCompletedChecks(t *testing.T) {
+	clientset, dynamicClient := newFakeClients(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "frontend"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		Status:     corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "34.102.136.180"}}}},
+	})
+	existing := &Policy{Accepted: []AcceptedFinding{
+		{CheckID: "public-loadbalancer", Resource: "Service/web/frontend"},
+		{CheckID: "public-loadbalancer", Resource: "Service/web/retired"},
+		{CheckID: "quota-exhausted", Resource: "ResourceQuota/shop/compute"},
+		{CheckID: "*", Resource: "Service/legacy/*"},
+		{CheckID: "plugin-check", Resource: "Pod/web/api"},
+	}}
+
+	// Only exposure ran, so the entries for the other checks say nothing about the cluster.
+	report := fakeScan(t, clientset, dynamicClient, "exposure")
+	baseline, added, kept := existing.Baseline(report.Findings, report.Covers, "baseline")
+	want := []AcceptedFinding{
+		{CheckID: "public-loadbalancer", Resource: "Service/web/frontend"},
+		{CheckID: "quota-exhausted", Resource: "ResourceQuota/shop/compute"},
+		{CheckID: "*", Resource: "Service/legacy/*"},
+		{CheckID: "plugin-check", Resource: "Pod/web/api"},
+	}
+	if added != 0 || kept != 3 || !reflect.DeepEqual(baseline.Accepted, want) {
+		t.Errorf("Baseline() = %+v, %d, %d; want %+v, 0, 3", baseline.Accepted, added, kept, want)
+	}
+
+	// A namespace sharded lists failed in may still hold the retired load balancer.
+	report.NamespaceErrors = map[string]string{"web": "services is forbidden"}
+	if baseline, _, kept := existing.Baseline(report.Findings, report.Covers, "baseline"); len(baseline.Accepted) != 5 || kept != 4 {
+		t.Errorf("Baseline() with web failed = %+v, %d kept; want every entry kept", baseline.Accepted, kept)
+	}
+}
+
+func TestScanAppliesPolicy(t *testing.T) {
+	clientset, dynamicClient := newFakeClients(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n1"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n2"}},
+	)
+	policy := &Policy{Accepted: []AcceptedFinding{{CheckID: "node-not-ready", Resource: "Node/n1"}}}
+
+	opts := AutoTuneScanOptions(ClusterSize{}).WithOverrides(ScanOptions{Collectors: []string{"node-health"}, Policy: policy})
+	report, err := runScan(&scanState{ctx: t.Context(), clientset: clientset, dynamic: dynamicClient, opts: opts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].Name != "n2" || len(report.Suppressed) != 1 || report.Suppressed[0].Name != "n1" {
+		t.Errorf("Findings = %+v, Suppressed = %+v; want n2 reported and n1 accepted", report.Findings, report.Suppressed)
+	}
+}
@@ -0,0 +1,309 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// systemCriticalPriority is the lowest priority of the built-in system-cluster-critical and
+// system-node-critical classes; user-defined classes can't reach it.
+const systemCriticalPriority = 2000000000
+
+// preemptionRequestPercent is how full a node's CPU or memory requests must be before a
+// higher-priority pod needing room there is likely to preempt the pods on it.
+const preemptionRequestPercent = 90
+
+// PriorityReport is the PriorityClasses in the cluster and how workloads use them.
+type PriorityReport struct {
+	Classes []PriorityClassUsage `json:"classes"`
+	// Unassigned are the namespaces, other than kube-*, whose pods all run at the default priority.
+	Unassigned []string `json:"unassigned,omitempty"`
+	// SharedNodes are the nodes where default-priority pods run beside system-critical ones.
+	SharedNodes []CriticalNodeShare `json:"sharedNodes,omitempty"`
+	// AtRisk are the pods likely to be preempted or evicted first when their node runs short.
+	AtRisk []PreemptionRisk `json:"atRisk,omitempty"`
+}
+
+// PriorityClassUsage is a PriorityClass and how many running pods use it.
+type PriorityClassUsage struct {
+	Name             string `json:"name"`
+	Value            int32  `json:"value"`
+	GlobalDefault    bool   `json:"globalDefault"`
+	PreemptionPolicy string `json:"preemptionPolicy"`
+	Pods             int    `json:"pods"`
+}
+
+// CriticalNodeShare is a node running system-critical pods and pods at the default priority.
+// DaemonSet and static pods are left out: they run on every node regardless.
+type CriticalNodeShare struct {
+	Node string `json:"node"`
+	// Critical and Default are the namespace/name of the pods.
+	Critical []string `json:"critical"`
+	Default  []string `json:"default"`
+}
+
+// PreemptionRisk is a pod likely to be removed when its node runs short.
+type PreemptionRisk struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Node      string `json:"node"`
+	Priority  int32  `json:"priority"`
+	Reason    string `json:"reason"`
+	Owner     *Owner `json:"owner,omitempty"`
+
+	pod *corev1.Pod
+}
+
+// GetPriorityReport lists PriorityClasses, nodes, and pods and builds the priority report.
+func GetPriorityReport(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*PriorityReport, error) {
+	classes, err := listPriorityClasses(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list priorityclasses: %w", err)
+	}
+
+	nodes, err := listNodes(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	pods, err := listPods(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	return BuildPriorityReport(classes, nodes, pods), nil
+}
+
+// BuildPriorityReport counts the pods of every PriorityClass and finds the namespaces without
+// priorities, the nodes where default-priority pods share with system-critical ones, and the
+// pods at risk. A pod is at risk when it is BestEffort on a node reporting resource pressure,
+// since the kubelet evicts those first, or when its node's requests are nearly full and pods
+// of a higher, preempting priority run in the cluster, since the scheduler would preempt it to
+// make room for one of them.
+func BuildPriorityReport(classes []schedulingv1.PriorityClass, nodes []corev1.Node, pods []corev1.Pod) *PriorityReport {
+	report := &PriorityReport{Classes: []PriorityClassUsage{}}
+	byName := map[string]*schedulingv1.PriorityClass{}
+	var defaultValue int32
+	for i := range classes {
+		c := &classes[i]
+		byName[c.Name] = c
+		if c.GlobalDefault {
+			defaultValue = c.Value
+		}
+	}
+	// The admission plugin records each pod's priority; fall back to the class for pods
+	// created before it was enabled.
+	priority := func(pod corev1.Pod) int32 {
+		if pod.Spec.Priority != nil {
+			return *pod.Spec.Priority
+		}
+		if c := byName[pod.Spec.PriorityClassName]; c != nil {
+			return c.Value
+		}
+		return defaultValue
+	}
+
+	var running []corev1.Pod
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			running = append(running, pod)
+		}
+	}
+
+	usage := map[string]int{}
+	assigned := map[string]bool{}
+	namespaces := map[string]bool{}
+	// preemptor is the highest priority below the system classes that a running pod uses and
+	// that may preempt others.
+	var preemptor int32
+	preemptorClass := ""
+	for _, pod := range running {
+		namespaces[pod.Namespace] = true
+		if pod.Spec.PriorityClassName == "" {
+			continue
+		}
+		usage[pod.Spec.PriorityClassName]++
+		assigned[pod.Namespace] = true
+		c := byName[pod.Spec.PriorityClassName]
+		if c == nil || c.Value >= systemCriticalPriority || c.PreemptionPolicy != nil && *c.PreemptionPolicy == corev1.PreemptNever {
+			continue
+		}
+		if c.Value > preemptor || preemptorClass == "" {
+			preemptor, preemptorClass = c.Value, c.Name
+		}
+	}
+
+	for _, c := range classes {
+		policy := string(corev1.PreemptLowerPriority)
+		if c.PreemptionPolicy != nil {
+			policy = string(*c.PreemptionPolicy)
+		}
+		report.Classes = append(report.Classes, PriorityClassUsage{
+			Name: c.Name, Value: c.Value, GlobalDefault: c.GlobalDefault, PreemptionPolicy: policy, Pods: usage[c.Name],
+		})
+	}
+	sort.Slice(report.Classes, func(i, j int) bool {
+		if report.Classes[i].Value != report.Classes[j].Value {
+			return report.Classes[i].Value > report.Classes[j].Value
+		}
+		return report.Classes[i].Name < report.Classes[j].Name
+	})
+
+	for ns := range namespaces {
+		if !assigned[ns] && !strings.HasPrefix(ns, "kube-") {
+			report.Unassigned = append(report.Unassigned, ns)
+		}
+	}
+	sort.Strings(report.Unassigned)
+
+	shares := map[string]*CriticalNodeShare{}
+	requested := map[string][2]int64{}
+	for _, pod := range running {
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		cpu, memory := podRequests(pod)
+		r := requested[pod.Spec.NodeName]
+		requested[pod.Spec.NodeName] = [2]int64{r[0] + cpu, r[1] + memory}
+		if isDaemonOrStaticPod(pod) {
+			continue
+		}
+		share := shares[pod.Spec.NodeName]
+		if share == nil {
+			share = &CriticalNodeShare{Node: pod.Spec.NodeName}
+			shares[pod.Spec.NodeName] = share
+		}
+		switch {
+		case priority(pod) >= systemCriticalPriority:
+			share.Critical = append(share.Critical, pod.Namespace+"/"+pod.Name)
+		case pod.Spec.PriorityClassName == "":
+			share.Default = append(share.Default, pod.Namespace+"/"+pod.Name)
+		}
+	}
+	for _, share := range shares {
+		if len(share.Critical) > 0 && len(share.Default) > 0 {
+			sort.Strings(share.Critical)
+			sort.Strings(share.Default)
+			report.SharedNodes = append(report.SharedNodes, *share)
+		}
+	}
+	sort.Slice(report.SharedNodes, func(i, j int) bool { return report.SharedNodes[i].Node < report.SharedNodes[j].Node })
+
+	nodesByName := map[string]*corev1.Node{}
+	for i := range nodes {
+		nodesByName[nodes[i].Name] = &nodes[i]
+	}
+	for i := range running {
+		pod := &running[i]
+		node := nodesByName[pod.Spec.NodeName]
+		p := priority(*pod)
+		if node == nil || p >= systemCriticalPriority || isDaemonOrStaticPod(*pod) {
+			continue
+		}
+		risk := PreemptionRisk{Namespace: pod.Namespace, Name: pod.Name, Node: node.Name, Priority: p, pod: pod}
+		r := requested[node.Name]
+		cpu, memory := node.Status.Allocatable.Cpu().MilliValue(), node.Status.Allocatable.Memory().Value()
+		if pressure := nodePressure(*node); pressure != "" && pod.Status.QOSClass == corev1.PodQOSBestEffort {
+			risk.Reason = fmt.Sprintf("node %s reports %s and the pod is BestEffort, so the kubelet evicts it first", node.Name, pressure)
+		} else if p < preemptor && (cpu > 0 && r[0]*100 >= cpu*preemptionRequestPercent || memory > 0 && r[1]*100 >= memory*preemptionRequestPercent) {
+			risk.Reason = fmt.Sprintf("node %s has %s of its CPU and %s of its memory requested, and pods of class %s (priority %d) can preempt it",
+				node.Name, percent(r[0], cpu), percent(r[1], memory), preemptorClass, preemptor)
+		} else {
+			continue
+		}
+		report.AtRisk = append(report.AtRisk, risk)
+	}
+	sort.Slice(report.AtRisk, func(i, j int) bool {
+		a, b := report.AtRisk[i], report.AtRisk[j]
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+	return report
+}
+
+// nodePressure returns the first resource pressure condition the node reports, if any.
+func nodePressure(node corev1.Node) string {
+	for _, c := range node.Status.Conditions {
+		switch c.Type {
+		case corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure:
+			if c.Status == corev1.ConditionTrue {
+				return string(c.Type)
+			}
+		}
+	}
+	return ""
+}
+
+// CheckPriorities raises findings for pods at risk of preemption or eviction, nodes where
+// default-priority pods share with system-critical ones, and namespaces without priorities.
+func CheckPriorities(report *PriorityReport) []Finding {
+	var findings []Finding
+	for _, r := range report.AtRisk {
+		findings = append(findings, Finding{
+			CheckID:   "pod-preemption-risk",
+			Severity:  SeverityMedium,
+			Kind:      "Pod",
+			Namespace: r.Namespace,
+			Name:      r.Name,
+			Message:   fmt.Sprintf("pod %s/%s at priority %d is likely to be removed first: %s", r.Namespace, r.Name, r.Priority, r.Reason),
+			object:    r.pod,
+		})
+	}
+	for _, s := range report.SharedNodes {
+		findings = append(findings, Finding{
+			CheckID:  "priority-default-beside-critical",
+			Severity: SeverityLow,
+			Kind:     "Node",
+			Name:     s.Node,
+			Message: fmt.Sprintf("node %s runs %d default-priority pod(s) beside system-critical pod(s) %s; without a priority, the scheduler can't tell which of them matters when the node runs short",
+				s.Node, len(s.Default), strings.Join(s.Critical, ", ")),
+		})
+	}
+	for _, ns := range report.Unassigned {
+		findings = append(findings, Finding{
+			CheckID:  "namespace-without-priority",
+			Severity: SeverityLow,
+			Kind:     "Namespace",
+			Name:     ns,
+			Message:  fmt.Sprintf("no pod in namespace %s sets a PriorityClass, so all of them run at the default priority", ns),
+		})
+	}
+	return findings
+}
+
+// PrintPriorityReport writes the priority section of the text report.
+func PrintPriorityReport(w io.Writer, report *PriorityReport) {
+	fmt.Fprintln(w, "Priority classes:")
+	if len(report.Classes) == 0 {
+		fmt.Fprintln(w, "  No PriorityClasses found.")
+	}
+	for _, c := range report.Classes {
+		name := c.Name
+		if c.GlobalDefault {
+			name += " (default)"
+		}
+		fmt.Fprintf(w, "  - %s: %d, %s, %d pod(s)\n", name, c.Value, c.PreemptionPolicy, c.Pods)
+	}
+	if n := len(report.Unassigned); n > 0 {
+		fmt.Fprintf(w, "  %d namespace(s) with every pod at the default priority: %s\n", n, strings.Join(report.Unassigned, ", "))
+	}
+	if len(report.SharedNodes) > 0 {
+		fmt.Fprintln(w, "  Nodes running default-priority pods beside system-critical ones:")
+		for _, s := range report.SharedNodes {
+			fmt.Fprintf(w, "    - %s: %d default-priority pod(s) beside %s\n", s.Node, len(s.Default), strings.Join(s.Critical, ", "))
+		}
+	}
+	if len(report.AtRisk) > 0 {
+		fmt.Fprintln(w, "  Pods likely to be preempted or evicted first:")
+		for _, r := range report.AtRisk {
+			fmt.Fprintf(w, "    - %s: %s\n", describePod(r.Namespace, r.Name, r.Owner), r.Reason)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package inspect
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func priorityPod(namespace, name, node, class string, priority int32, cpu string) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: corev1.PodSpec{
+			NodeName: node, PriorityClassName: class, Priority: &priority,
+			Containers: []corev1.Container{{Name: "c"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, QOSClass: corev1.PodQOSBurstable},
+	}
+	if cpu == "" {
+		pod.Status.QOSClass = corev1.PodQOSBestEffort
+	} else {
+		pod.Spec.Containers[0].Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}
+	}
+	return pod
+}
+
+func TestBuildPriorityReport(t *testing.T) {
+	never := corev1.PreemptNever
+	classes := []schedulingv1.PriorityClass{
+		{ObjectMeta: metav1.ObjectMeta{Name: "system-cluster-critical"}, Value: 2000000000},
+		{ObjectMeta: metav1.ObjectMeta{Name: "business-critical"}, Value: 100000},
+		{ObjectMeta: metav1.ObjectMeta{Name: "batch-low"}, Value: -10, PreemptionPolicy: &never},
+		// Non-preempting classes never push other pods out.
+		{ObjectMeta: metav1.ObjectMeta{Name: "reports"}, Value: 500000, PreemptionPolicy: &never},
+	}
+	allocatable := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("16Gi")}
+	nodes := []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "full"}, Status: corev1.NodeStatus{Allocatable: allocatable}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "roomy"}, Status: corev1.NodeStatus{Allocatable: allocatable}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pressured"}, Status: corev1.NodeStatus{Allocatable: allocatable, Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionTrue},
+		}}},
+	}
+	pods := []corev1.Pod{
+		priorityPod("kube-system", "coredns-1", "full", "system-cluster-critical", 2000000000, "100m"),
+		priorityPod("shop", "api-1", "roomy", "business-critical", 100000, "500m"),
+		priorityPod("shop", "reports-1", "roomy", "reports", 500000, "100m"),
+		priorityPod("shop", "web-1", "full", "", 0, "3"),
+		priorityPod("shop", "web-2", "full", "", 0, "500m"),
+		priorityPod("batch", "job-1", "full", "batch-low", -10, "100m"),
+		priorityPod("tools", "scratch", "pressured", "", 0, ""),
+		priorityPod("tools", "sized", "pressured", "", 0, "100m"),
+	}
+	done := priorityPod("tools", "done", "roomy", "", 0, "")
+	done.Status.Phase = corev1.PodSucceeded
+	pods = append(pods, done)
+
+	report := BuildPriorityReport(classes, nodes, pods)
+	var classSummary []string
+	for _, c := range report.Classes {
+		classSummary = append(classSummary, c.Name+" "+c.PreemptionPolicy+" "+strings.Repeat("*", c.Pods))
+	}
+	want := []string{
+		"system-cluster-critical PreemptLowerPriority *",
+		"reports Never *",
+		"business-critical PreemptLowerPriority *",
+		"batch-low Never *",
+	}
+	if !reflect.DeepEqual(classSummary, want) {
+		t.Errorf("classes = %q, want %q", classSummary, want)
+	}
+	if !reflect.DeepEqual(report.Unassigned, []string{"tools"}) {
+		t.Errorf("Unassigned = %v, want [tools]", report.Unassigned)
+	}
+	wantShared := []CriticalNodeShare{{Node: "full", Critical: []string{"kube-system/coredns-1"}, Default: []string{"shop/web-1", "shop/web-2"}}}
+	if !reflect.DeepEqual(report.SharedNodes, wantShared) {
+		t.Errorf("SharedNodes = %+v, want %+v", report.SharedNodes, wantShared)
+	}
+
+	var got []string
+	for _, f := range CheckPriorities(report) {
+		got = append(got, f.CheckID+" "+f.Resource()+" "+string(f.Severity))
+	}
+	want = []string{
+		"pod-preemption-risk Pod/batch/job-1 medium",
+		"pod-preemption-risk Pod/shop/web-1 medium",
+		"pod-preemption-risk Pod/shop/web-2 medium",
+		"pod-preemption-risk Pod/tools/scratch medium",
+		"priority-default-beside-critical Node/full low",
+		"namespace-without-priority Namespace/tools low",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %q, want %q", got, want)
+	}
+
+	var b bytes.Buffer
+	PrintPriorityReport(&b, report)
+	for _, want := range []string{
+		"  - business-critical: 100000, PreemptLowerPriority, 1 pod(s)",
+		"  1 namespace(s) with every pod at the default priority: tools",
+		"    - full: 2 default-priority pod(s) beside kube-system/coredns-1",
+		"    - shop/web-1: node full has 92% of its CPU and 0% of its memory requested, and pods of class business-critical (priority 100000) can preempt it",
+		"    - tools/scratch: node pressured reports MemoryPressure and the pod is BestEffort, so the kubelet evicts it first",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// probeLinePrefix marks the result lines the diagnostic pod prints, one per check.
+const probeLinePrefix = "KUBEOP-PROBE|"
+
+// probePollInterval is how often the diagnostic pod's phase is checked.
+const probePollInterval = 2 * time.Second
+
+// ProbeTarget is a Service to resolve and connect to from inside the cluster.
+type ProbeTarget struct {
+	Name      string
+	Namespace string
+	Port      int
+}
+
+// FQDN returns the target's cluster DNS name.
+func (t ProbeTarget) FQDN(clusterDomain string) string {
+	return fmt.Sprintf("%s.%s.svc.%s", t.Name, t.Namespace, clusterDomain)
+}
+
+// ProbeResult is the outcome of one check run inside the diagnostic pod.
+type ProbeResult struct {
+	Check   string        `json:"check"`
+	Target  string        `json:"target"`
+	OK      bool          `json:"ok"`
+	Latency time.Duration `json:"latency"`
+	Detail  string        `json:"detail,omitempty"`
+}
+
+// ProbeConfig controls the diagnostic pod.
+type ProbeConfig struct {
+	Namespace     string
+	Image         string
+	ClusterDomain string
+	Targets       []ProbeTarget
+	Wait          time.Duration
+}
+
+// ParseProbeTarget parses a service given as name.namespace:port, defaulting the namespace to "default".
+func ParseProbeTarget(s string) (ProbeTarget, error) {
+	host, portStr, err := net.SplitHostPort(s)
+	if err != nil {
+		return ProbeTarget{}, fmt.Errorf("invalid probe target %q, want name.namespace:port: %w", s, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return ProbeTarget{}, fmt.Errorf("invalid port in probe target %q", s)
+	}
+
+	name, namespace, found := strings.Cut(host, ".")
+	if !found {
+		namespace = "default"
+	}
+	if name == "" || namespace == "" {
+		return ProbeTarget{}, fmt.Errorf("invalid probe target %q, want name.namespace:port", s)
+	}
+	return ProbeTarget{Name: name, Namespace: namespace, Port: port}, nil
+}
+
+// BuildProbeScript returns the shell script the diagnostic pod runs. Every check prints one
+// result line with its exit code and latency, measured from /proc/uptime so the script only
+// needs busybox.
+func BuildProbeScript(targets []ProbeTarget, clusterDomain string) string {
+	var b strings.Builder
+	b.WriteString(`now() { cut -d' ' -f1 /proc/uptime; }
+check() {
+  name=$1; target=$2; shift 2
+  start=$(now)
+  out=$("$@" 2>&1); rc=$?
+  end=$(now)
+  ms=$(awk "BEGIN { printf \"%d\", ($end - $start) * 1000 }")
+  echo "` + probeLinePrefix + `$name|$target|$rc|$ms|$(echo "$out" | tr '\n|' '  ' | cut -c1-200)"
+}
+`)
+
+	apiServer := "kubernetes.default.svc." + clusterDomain
+	fmt.Fprintf(&b, "check dns %s nslookup %s\n", apiServer, apiServer)
+	fmt.Fprintf(&b, "check tcp %s:443 nc -z -w 3 %s 443\n", apiServer, apiServer)
+	for _, t := range targets {
+		fqdn := t.FQDN(clusterDomain)
+		fmt.Fprintf(&b, "check dns %s nslookup %s\n", fqdn, fqdn)
+		fmt.Fprintf(&b, "check tcp %s:%d nc -z -w 3 %s %d\n", fqdn, t.Port, fqdn, t.Port)
+	}
+	return b.String()
+}
+
+// ParseProbeOutput extracts the check results from the diagnostic pod's log.
+func ParseProbeOutput(logs string) []ProbeResult {
+	var results []ProbeResult
+	for _, line := range strings.Split(logs, "\n") {
+		if !strings.HasPrefix(line, probeLinePrefix) {
+			continue
+		}
+		fields := strings.SplitN(strings.TrimPrefix(line, probeLinePrefix), "|", 5)
+		if len(fields) != 5 {
+			continue
+		}
+		rc, _ := strconv.Atoi(fields[2])
+		ms, _ := strconv.Atoi(fields[3])
+		results = append(results, ProbeResult{
+			Check:   fields[0],
+			Target:  fields[1],
+			OK:      rc == 0,
+			Latency: time.Duration(ms) * time.Millisecond,
+			Detail:  strings.TrimSpace(fields[4]),
+		})
+	}
+	return results
+}
+
+// RunProbe launches a short-lived diagnostic pod that checks DNS resolution and TCP connectivity
+// to the kubernetes.default service and each target, waits for it to finish, and returns the
+// parsed results. The pod is always deleted afterwards.
+func RunProbe(ctx context.Context, clientset kubernetes.Interface, config ProbeConfig) ([]ProbeResult, error) {
+	nonRoot := true
+	user := int64(65534)
+	noEscalation := false
+	automountToken := false
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "kube-op-probe-",
+			Namespace:    config.Namespace,
+			Labels:       map[string]string{"app.kubernetes.io/name": "kube-op-probe"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                corev1.RestartPolicyNever,
+			AutomountServiceAccountToken: &automountToken,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   &nonRoot,
+				RunAsUser:      &user,
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+			Containers: []corev1.Container{{
+				Name:    "probe",
+				Image:   config.Image,
+				Command: []string{"sh", "-c", BuildProbeScript(config.Targets, config.ClusterDomain)},
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: &noEscalation,
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+			}},
+		},
+	}
+
+	created, err := clientset.CoreV1().Pods(config.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create probe pod: %w", err)
+	}
+	defer func() {
+		// Clean up even when the wait below was cancelled.
+		if err := clientset.CoreV1().Pods(created.Namespace).Delete(context.WithoutCancel(ctx), created.Name, metav1.DeleteOptions{}); err != nil {
+			log.Printf("Failed to delete probe pod %s/%s: %v", created.Namespace, created.Name, err)
+		}
+	}()
+
+	deadline := time.Now().Add(config.Wait)
+	for {
+		current, err := clientset.CoreV1().Pods(created.Namespace).Get(ctx, created.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get probe pod: %w", err)
+		}
+		if current.Status.Phase == corev1.PodSucceeded || current.Status.Phase == corev1.PodFailed {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("probe pod %s/%s did not finish within %s (phase %s)", created.Namespace, created.Name, config.Wait, current.Status.Phase)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped waiting for probe pod %s/%s: %w", created.Namespace, created.Name, ctx.Err())
+		case <-time.After(probePollInterval):
+		}
+	}
+
+	logs, err := clientset.CoreV1().Pods(created.Namespace).GetLogs(created.Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read probe pod logs: %w", err)
+	}
+	return ParseProbeOutput(string(logs)), nil
+}
+
+// PrintProbeResults writes the results as human-readable text.
+func PrintProbeResults(w io.Writer, results []ProbeResult) {
+	fmt.Fprintln(w, "In-cluster connectivity:")
+	for _, r := range results {
+		status := "ok"
+		if !r.OK {
+			status = "FAILED"
+		}
+		fmt.Fprintf(w, "  - %-4s %s: %s (%s)", r.Check, r.Target, status, r.Latency)
+		if !r.OK && r.Detail != "" {
+			fmt.Fprintf(w, " - %s", r.Detail)
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,68 @@
+package inspect
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseProbeTarget(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ProbeTarget
+	}{
+		{"web.shop:80", ProbeTarget{Name: "web", Namespace: "shop", Port: 80}},
+		{"redis:6379", ProbeTarget{Name: "redis", Namespace: "default", Port: 6379}},
+	}
+	for _, tt := range tests {
+		got, err := ParseProbeTarget(tt.in)
+		if err != nil {
+			t.Errorf("ParseProbeTarget(%q) returned error = %v, want nil", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseProbeTarget(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"web.shop", "web.shop:http", "web.shop:0", ".shop:80"} {
+		if _, err := ParseProbeTarget(bad); err == nil {
+			t.Errorf("ParseProbeTarget(%q) returned error = nil, want non-nil", bad)
+		}
+	}
+}
+
+func TestBuildProbeScript(t *testing.T) {
+	script := BuildProbeScript([]ProbeTarget{{Name: "web", Namespace: "shop", Port: 8080}}, "cluster.local")
+
+	for _, want := range []string{
+		"check dns kubernetes.default.svc.cluster.local nslookup kubernetes.default.svc.cluster.local",
+		"check tcp kubernetes.default.svc.cluster.local:443 nc -z -w 3 kubernetes.default.svc.cluster.local 443",
+		"check dns web.shop.svc.cluster.local nslookup web.shop.svc.cluster.local",
+		"check tcp web.shop.svc.cluster.local:8080 nc -z -w 3 web.shop.svc.cluster.local 8080",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("BuildProbeScript() is missing %q", want)
+		}
+	}
+}
+
+func TestParseProbeOutput(t *testing.T) {
+	logs := strings.Join([]string{
+		"some unrelated output",
+		probeLinePrefix + "dns|kubernetes.default.svc.cluster.local|0|12|Server: 10.96.0.10",
+		probeLinePrefix + "tcp|web.shop.svc.cluster.local:8080|1|3004|nc: connection timed out",
+		probeLinePrefix + "truncated",
+	}, "\n")
+
+	results := ParseProbeOutput(logs)
+	if len(results) != 2 {
+		t.Fatalf("ParseProbeOutput() returned %d results, want 2", len(results))
+	}
+	if !results[0].OK || results[0].Latency != 12*time.Millisecond {
+		t.Errorf("ParseProbeOutput()[0] = %+v, want ok with 12ms latency", results[0])
+	}
+	if results[1].OK || results[1].Detail != "nc: connection timed out" {
+		t.Errorf("ParseProbeOutput()[1] = %+v, want failure with detail", results[1])
+	}
+}
@@ -0,0 +1,187 @@
+package inspect
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Probe outcomes for an HTTP endpoint, distinguishing open endpoints from protected ones.
+const (
+	ProbeAuthOpen          = "open"
+	ProbeAuthRequired      = "requires-auth"
+	ProbeAuthAuthenticated = "authenticated"
+	ProbeAuthRejected      = "auth-rejected"
+)
+
+// ProbeCredentialsConfig is the on-disk probe credential config, in YAML or JSON.
+type ProbeCredentialsConfig struct {
+	Credentials []ProbeCredential `json:"credentials"`
+}
+
+// ProbeCredential holds the credentials presented to hosts matching Host when probing them.
+// Secrets are read from environment variables or files so the config can be committed.
+// Probe targets come from Service and Ingress status, which anyone who can edit those objects
+// controls, so credentials are only presented over TLS to a server verified against the
+// system roots or CAFile.
+type ProbeCredential struct {
+	// Host is a hostname or IP, optionally with a shell-style wildcard such as *.example.com.
+	Host       string          `json:"host"`
+	BasicAuth  *ProbeBasicAuth `json:"basicAuth,omitempty"`
+	BearerEnv  string          `json:"bearerTokenEnv,omitempty"`
+	BearerFile string          `json:"bearerTokenFile,omitempty"`
+	ClientCert *ProbeClientTLS `json:"clientCert,omitempty"`
+	// CAFile verifies the server instead of the system roots.
+	CAFile string `json:"caFile,omitempty"`
+	// Insecure allows presenting the credentials over plain HTTP and to servers that fail
+	// verification.
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// ProbeBasicAuth is an HTTP basic auth username and the environment variable holding its password.
+type ProbeBasicAuth struct {
+	Username    string `json:"username"`
+	PasswordEnv string `json:"passwordEnv"`
+}
+
+// ProbeClientTLS is a client certificate for mTLS, with an optional CA to verify the server against.
+type ProbeClientTLS struct {
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+	CAFile   string `json:"caFile,omitempty"`
+}
+
+// LoadProbeCredentials reads and validates a probe credential config file.
+func LoadProbeCredentials(file string) (*ProbeCredentialsConfig, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read probe credentials: %w", err)
+	}
+
+	var config ProbeCredentialsConfig
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse probe credentials %s: %w", file, err)
+	}
+	for i, c := range config.Credentials {
+		if c.Host == "" {
+			return nil, fmt.Errorf("probe credentials[%d]: host is required", i)
+		}
+		if c.BasicAuth != nil && (c.BearerEnv != "" || c.BearerFile != "") {
+			return nil, fmt.Errorf("probe credentials for %s: basicAuth and bearer token are mutually exclusive", c.Host)
+		}
+		if _, err := path.Match(c.Host, ""); err != nil {
+			return nil, fmt.Errorf("probe credentials for %s: invalid host pattern: %w", c.Host, err)
+		}
+	}
+	return &config, nil
+}
+
+// ForHost returns the first credential whose host pattern matches host, or nil.
+// A nil config has no credentials.
+func (c *ProbeCredentialsConfig) ForHost(host string) *ProbeCredential {
+	if c == nil {
+		return nil
+	}
+	host = strings.ToLower(host)
+	for i, cred := range c.Credentials {
+		if ok, _ := path.Match(strings.ToLower(cred.Host), host); ok {
+			return &c.Credentials[i]
+		}
+	}
+	return nil
+}
+
+// Apply adds the credential's basic auth or bearer token header to req. It refuses to for a
+// plain HTTP request unless the credential is Insecure.
+func (c *ProbeCredential) Apply(req *http.Request) error {
+	if c == nil || (c.BasicAuth == nil && c.BearerEnv == "" && c.BearerFile == "") {
+		return nil
+	}
+	if req.URL.Scheme != "https" && !c.Insecure {
+		return fmt.Errorf("refusing to send credentials for %s over plain HTTP (set insecure: true to allow)", c.Host)
+	}
+	if c.BasicAuth != nil {
+		req.SetBasicAuth(c.BasicAuth.Username, os.Getenv(c.BasicAuth.PasswordEnv))
+		return nil
+	}
+
+	token := ""
+	switch {
+	case c.BearerEnv != "":
+		token = os.Getenv(c.BearerEnv)
+	case c.BearerFile != "":
+		data, err := os.ReadFile(c.BearerFile)
+		if err != nil {
+			return fmt.Errorf("failed to read bearer token for %s: %w", c.Host, err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// TLSConfig returns the TLS settings for probing the credential's host. Anonymous probes only
+// judge reachability, so they don't verify the server. Probes that present credentials verify
+// it against the system roots or the configured CA, unless the credential is Insecure, so the
+// credentials can't be collected by a hostile address or a man in the middle.
+func (c *ProbeCredential) TLSConfig() (*tls.Config, error) {
+	if !c.HasCredentials() {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+	config := &tls.Config{}
+	caFile := c.CAFile
+	if c.ClientCert != nil {
+		cert, err := tls.LoadX509KeyPair(c.ClientCert.CertFile, c.ClientCert.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate for %s: %w", c.Host, err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+		if c.ClientCert.CAFile != "" {
+			caFile = c.ClientCert.CAFile
+		}
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA for %s: %w", c.Host, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file for %s", c.Host)
+		}
+		config.RootCAs = pool
+	} else {
+		config.InsecureSkipVerify = c.Insecure
+	}
+	return config, nil
+}
+
+// HasCredentials reports whether probing with c presents any credential.
+func (c *ProbeCredential) HasCredentials() bool {
+	return c != nil && (c.BasicAuth != nil || c.BearerEnv != "" || c.BearerFile != "" || c.ClientCert != nil)
+}
+
+// ClassifyAuth maps an HTTP status code to a probe outcome. A 401 or 403 means the endpoint is
+// protected: either no credential was configured for it, or the configured one was refused.
+func ClassifyAuth(statusCode int, presentedCredentials bool) string {
+	protected := statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden
+	switch {
+	case protected && presentedCredentials:
+		return ProbeAuthRejected
+	case protected:
+		return ProbeAuthRequired
+	case presentedCredentials:
+		return ProbeAuthAuthenticated
+	default:
+		return ProbeAuthOpen
+	}
+}
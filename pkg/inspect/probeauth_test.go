@@ -0,0 +1,110 @@
+package inspect
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const probeCredentialsContent = `credentials:
+- host: "*.internal.example.com"
+  basicAuth:
+    username: probe
+    passwordEnv: PROBE_PASSWORD
+- host: grafana.example.com
+  bearerTokenEnv: PROBE_TOKEN
+`
+
+func writeProbeCredentials(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "probe-credentials.yaml")
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write probe credentials: %v", err)
+	}
+	return file
+}
+
+func TestLoadProbeCredentials_ForHost(t *testing.T) {
+	config, err := LoadProbeCredentials(writeProbeCredentials(t, probeCredentialsContent))
+	if err != nil {
+		t.Fatalf("LoadProbeCredentials() returned error = %v, want nil", err)
+	}
+
+	if c := config.ForHost("API.internal.example.com"); c == nil || c.BasicAuth == nil {
+		t.Errorf("ForHost(api.internal.example.com) = %+v, want basic auth credential", c)
+	}
+	if c := config.ForHost("grafana.example.com"); c == nil || c.BearerEnv != "PROBE_TOKEN" {
+		t.Errorf("ForHost(grafana.example.com) = %+v, want bearer credential", c)
+	}
+	if c := config.ForHost("example.org"); c != nil {
+		t.Errorf("ForHost(example.org) = %+v, want nil", c)
+	}
+
+	var none *ProbeCredentialsConfig
+	if c := none.ForHost("grafana.example.com"); c != nil {
+		t.Errorf("nil config ForHost() = %+v, want nil", c)
+	}
+}
+
+func TestLoadProbeCredentials_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"missing host":   "credentials:\n- bearerTokenEnv: X\n",
+		"both basic and": "credentials:\n- host: a\n  bearerTokenEnv: X\n  basicAuth: {username: u, passwordEnv: P}\n",
+		"unknown field":  "credentials:\n- host: a\n  token: X\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadProbeCredentials(writeProbeCredentials(t, content)); err == nil {
+				t.Errorf("LoadProbeCredentials() returned error = nil, want non-nil")
+			}
+		})
+	}
+}
+
+func TestProbeCredential_Apply(t *testing.T) {
+	t.Setenv("PROBE_PASSWORD", "s3cret")
+	t.Setenv("PROBE_TOKEN", "tok")
+
+	req, _ := http.NewRequest(http.MethodGet, "https://a.internal.example.com", nil)
+	basic := &ProbeCredential{Host: "a", BasicAuth: &ProbeBasicAuth{Username: "probe", PasswordEnv: "PROBE_PASSWORD"}}
+	if err := basic.Apply(req); err != nil {
+		t.Fatalf("Apply() returned error = %v, want nil", err)
+	}
+	if user, pass, ok := req.BasicAuth(); !ok || user != "probe" || pass != "s3cret" {
+		t.Errorf("Apply() basic auth = (%q, %q, %v), want (probe, s3cret, true)", user, pass, ok)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "https://grafana.example.com", nil)
+	bearer := &ProbeCredential{Host: "b", BearerEnv: "PROBE_TOKEN"}
+	if err := bearer.Apply(req); err != nil {
+		t.Fatalf("Apply() returned error = %v, want nil", err)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer tok" {
+		t.Errorf("Apply() Authorization = %q, want %q", got, "Bearer tok")
+	}
+	req, _ = http.NewRequest(http.MethodGet, "http://grafana.example.com", nil)
+	if err := bearer.Apply(req); err == nil || req.Header.Get("Authorization") != "" {
+		t.Errorf("Apply() over plain HTTP = %v with Authorization %q, want an error and no header", err, req.Header.Get("Authorization"))
+	}
+}
+
+func TestClassifyAuth(t *testing.T) {
+	tests := []struct {
+		status    int
+		presented bool
+		want      string
+	}{
+		{http.StatusOK, false, ProbeAuthOpen},
+		{http.StatusFound, false, ProbeAuthOpen},
+		{http.StatusUnauthorized, false, ProbeAuthRequired},
+		{http.StatusForbidden, false, ProbeAuthRequired},
+		{http.StatusOK, true, ProbeAuthAuthenticated},
+		{http.StatusUnauthorized, true, ProbeAuthRejected},
+	}
+	for _, tt := range tests {
+		if got := ClassifyAuth(tt.status, tt.presented); got != tt.want {
+			t.Errorf("ClassifyAuth(%d, %v) = %q, want %q", tt.status, tt.presented, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package inspect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/time/rate"
+)
+
+// errProbeNotAcknowledged is returned when probing is enabled without --i-own-these-targets.
+var errProbeNotAcknowledged = errors.New("--probe sends traffic to every exposed endpoint in the cluster; pass --i-own-these-targets to confirm you are authorized to probe them")
+
+// Validate checks that probing was acknowledged and that the scope patterns and limits are usable.
+func (o ProbeOptions) Validate() error {
+	if !o.Enabled {
+		return nil
+	}
+	if !o.Acknowledged {
+		return errProbeNotAcknowledged
+	}
+	if o.Concurrency < 1 {
+		return fmt.Errorf("probe concurrency must be at least 1, got %d", o.Concurrency)
+	}
+	if o.RatePerHost <= 0 {
+		return fmt.Errorf("probe rate must be positive, got %g", o.RatePerHost)
+	}
+	if len(o.Allow) > 0 && !slices.ContainsFunc(o.Allow, func(pattern string) bool { return !isHostnamePattern(pattern) }) {
+		return fmt.Errorf("probe allowlist %s has no CIDR or IP address; hostname globs only narrow the Ingress hosts probed at allowed addresses", strings.Join(o.Allow, ", "))
+	}
+	for _, pattern := range append(append([]string{}, o.Allow...), o.Deny...) {
+		if strings.Contains(pattern, "/") {
+			if _, err := netip.ParsePrefix(pattern); err != nil {
+				return fmt.Errorf("invalid probe target CIDR %q: %w", pattern, err)
+			}
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid probe target pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Permits reports whether the probe scope allows dialing ip, and sending vhost as the virtual
+// host for Ingress rules. A deny match on either wins. When an allowlist is set, ip must match
+// it on its own, so a virtual host can't take the probe outside the allowed addresses; if the
+// allowlist also has hostname globs, vhost must match one of them too. The reason explains a
+// refusal.
+func (o ProbeOptions) Permits(ip, vhost string) (bool, string) {
+	for _, h := range []string{ip, vhost} {
+		for _, pattern := range o.Deny {
+			if matchesProbeTarget(pattern, h) {
+				return false, fmt.Sprintf("%s is denied by %s", h, pattern)
+			}
+		}
+	}
+	if len(o.Allow) == 0 {
+		return true, ""
+	}
+	if !slices.ContainsFunc(o.Allow, func(pattern string) bool { return matchesProbeTarget(pattern, ip) }) {
+		return false, fmt.Sprintf("%s is not in the probe allowlist", ip)
+	}
+	if vhost == "" || !slices.ContainsFunc(o.Allow, isHostnamePattern) {
+		return true, ""
+	}
+	for _, pattern := range o.Allow {
+		if isHostnamePattern(pattern) && matchesProbeTarget(pattern, vhost) {
+			return true, ""
+		}
+	}
+	return false, fmt.Sprintf("%s is not in the probe allowlist", vhost)
+}
+
+// isHostnamePattern reports whether a probe scope pattern is a hostname glob rather than a
+// CIDR or an IP address.
+func isHostnamePattern(pattern string) bool {
+	return !strings.ContainsAny(pattern, "/:") && strings.IndexFunc(pattern, unicode.IsLetter) >= 0
+}
+
+// matchesProbeTarget matches host against a CIDR, such as 10.0.0.0/8, or a case-insensitive
+// glob, such as *.example.com.
+func matchesProbeTarget(pattern, host string) bool {
+	if host == "" {
+		return false
+	}
+	if strings.Contains(pattern, "/") {
+		prefix, err := netip.ParsePrefix(pattern)
+		if err != nil {
+			return false
+		}
+		addr, err := netip.ParseAddr(host)
+		return err == nil && prefix.Contains(addr)
+	}
+	ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(host))
+	return ok
+}
+
+// probeTarget returns the IP a probe request dials and the virtual host it sends, if any.
+func probeTarget(req probeRequest) (ip, vhost string) {
+	ip, _, err := net.SplitHostPort(req.address)
+	if err != nil {
+		ip = req.address
+	}
+	return ip, req.host
+}
+
+// hostLimiter spaces out requests to the same host so that a cluster with many endpoints behind
+// one load balancer doesn't turn into a burst against it.
+type hostLimiter struct {
+	limit    rate.Limit
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func newHostLimiter(perSecond float64) *hostLimiter {
+	return &hostLimiter{limit: rate.Limit(perSecond), limiters: map[string]*rate.Limiter{}}
+}
+
+// Wait blocks until another request to host is allowed, or returns an error if ctx is
+// cancelled first.
+func (l *hostLimiter) Wait(ctx context.Context, host string) error {
+	l.mu.Lock()
+	limiter, ok := l.limiters[host]
+	if !ok {
+		limiter = rate.NewLimiter(l.limit, 1)
+		l.limiters[host] = limiter
+	}
+	l.mu.Unlock()
+	return limiter.Wait(ctx)
+}
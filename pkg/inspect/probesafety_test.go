@@ -0,0 +1,100 @@
+package inspect
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProbeOptions_Validate(t *testing.T) {
+	valid := ProbeOptions{Enabled: true, Acknowledged: true, Concurrency: 4, RatePerHost: 1, Allow: []string{"10.0.0.0/8", "*.example.com"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+
+	unacknowledged := valid
+	unacknowledged.Acknowledged = false
+	if err := unacknowledged.Validate(); !errors.Is(err, errProbeNotAcknowledged) {
+		t.Errorf("Validate() without acknowledgement error = %v, want %v", err, errProbeNotAcknowledged)
+	}
+
+	badCIDR := valid
+	badCIDR.Deny = []string{"10.0.0.0/33"}
+	if err := badCIDR.Validate(); err == nil {
+		t.Error("Validate() with an invalid CIDR error = nil, want error")
+	}
+
+	hostnamesOnly := valid
+	hostnamesOnly.Allow = []string{"*.example.com"}
+	if err := hostnamesOnly.Validate(); err == nil {
+		t.Error("Validate() with only hostname globs allowed error = nil, want error")
+	}
+
+	if err := (ProbeOptions{}).Validate(); err != nil {
+		t.Errorf("Validate() with probing disabled error = %v, want nil", err)
+	}
+}
+
+func TestProbeOptions_Permits(t *testing.T) {
+	opts := ProbeOptions{Allow: []string{"203.0.113.0/24", "*.example.com"}, Deny: []string{"203.0.113.66", "admin.example.com"}}
+	tests := []struct {
+		ip, vhost string
+		want      bool
+	}{
+		{"203.0.113.10", "", true},
+		{"203.0.113.66", "", false},
+		{"198.51.100.7", "", false},
+		{"203.0.113.10", "Shop.Example.com", true},
+		// An allowed virtual host doesn't make an address outside the allowlist probeable.
+		{"198.51.100.7", "Shop.Example.com", false},
+		{"203.0.113.10", "shop.other.org", false},
+		{"203.0.113.10", "admin.example.com", false},
+	}
+	for _, tt := range tests {
+		if got, reason := opts.Permits(tt.ip, tt.vhost); got != tt.want {
+			t.Errorf("Permits(%q, %q) = %v (%s), want %v", tt.ip, tt.vhost, got, reason, tt.want)
+		}
+	}
+
+	cidrsOnly := ProbeOptions{Allow: []string{"203.0.113.0/24"}}
+	if ok, reason := cidrsOnly.Permits("203.0.113.10", "shop.other.org"); !ok {
+		t.Errorf("Permits() with only CIDRs allowed = false (%s), want any virtual host at an allowed address", reason)
+	}
+	if ok, _ := (ProbeOptions{}).Permits("198.51.100.7", ""); !ok {
+		t.Error("Permits() with no scope = false, want true")
+	}
+}
+
+func TestProbeEndpoints_SkipsTargetsOutOfScope(t *testing.T) {
+	endpoints := []ExposedEndpoint{
+		{Type: ExposureLoadBalancer, Addresses: []string{"127.0.0.1", "203.0.113.10"}, Ports: []ExposedPort{{Port: 1, Protocol: "TCP"}}},
+	}
+	opts := ScanOptions{Probe: ProbeOptions{Enabled: true, Acknowledged: true, Timeout: time.Second, Concurrency: 1, RatePerHost: 100, Allow: []string{"127.0.0.0/8"}}}
+
+	if err := ProbeEndpoints(context.Background(), nil, opts, endpoints); err != nil {
+		t.Fatalf("ProbeEndpoints() error = %v", err)
+	}
+	results := endpoints[0].Reachability
+	if len(results) != 2 {
+		t.Fatalf("ProbeEndpoints() recorded %d results, want 2", len(results))
+	}
+	if results[0].Status == ReachSkipped {
+		t.Errorf("ProbeEndpoints() skipped allowed target: %+v", results[0])
+	}
+	if results[1].Status != ReachSkipped || results[1].Error == "" {
+		t.Errorf("ProbeEndpoints() result for 203.0.113.10 = %+v, want skipped with a reason", results[1])
+	}
+}
+
+func TestHostLimiter_SpacesRequests(t *testing.T) {
+	limiter := newHostLimiter(20)
+	start := time.Now()
+	for range 3 {
+		limiter.Wait(context.Background(), "203.0.113.10")
+	}
+	limiter.Wait(context.Background(), "198.51.100.7")
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("three requests to one host at 20/s took %s, want at least 100ms", elapsed)
+	}
+}
@@ -0,0 +1,402 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// quotaWarnPercent is the share of a quota's hard limit at which its usage is flagged.
+const quotaWarnPercent = 90
+
+// uncoveredNamespacesShown is how many namespaces without a quota or LimitRange the text
+// report lists.
+const uncoveredNamespacesShown = 10
+
+// podQuotaResources are the quota resources a workload's pods are charged against. cpu and
+// memory are the older names of requests.cpu and requests.memory.
+var podQuotaResources = map[corev1.ResourceName]corev1.ResourceName{
+	corev1.ResourcePods:           corev1.ResourcePods,
+	corev1.ResourceCPU:            corev1.ResourceRequestsCPU,
+	corev1.ResourceMemory:         corev1.ResourceRequestsMemory,
+	corev1.ResourceRequestsCPU:    corev1.ResourceRequestsCPU,
+	corev1.ResourceRequestsMemory: corev1.ResourceRequestsMemory,
+	corev1.ResourceLimitsCPU:      corev1.ResourceLimitsCPU,
+	corev1.ResourceLimitsMemory:   corev1.ResourceLimitsMemory,
+}
+
+// QuotaReport is ResourceQuota consumption and LimitRange coverage across namespaces, for
+// governing capacity on multi-tenant clusters.
+type QuotaReport struct {
+	Quotas []QuotaStatus `json:"quotas"`
+	// Uncovered are the namespaces, other than kube-*, with neither a ResourceQuota nor a
+	// LimitRange, whose workloads can consume capacity without bound.
+	Uncovered []string `json:"uncovered,omitempty"`
+	// Blocked are workloads that would exceed the quota left in their namespace when they scale
+	// to their replicas or roll out.
+	Blocked []QuotaBlockedWorkload `json:"blocked,omitempty"`
+}
+
+// QuotaStatus is the consumption of one ResourceQuota.
+type QuotaStatus struct {
+	Namespace string          `json:"namespace"`
+	Name      string          `json:"name"`
+	Resources []QuotaResource `json:"resources"`
+}
+
+// QuotaResource is the usage of one resource against its hard limit.
+type QuotaResource struct {
+	Resource string `json:"resource"`
+	Used     string `json:"used"`
+	Hard     string `json:"hard"`
+	// Percent is used as a share of hard; a hard limit of zero counts as fully used.
+	Percent int `json:"percent"`
+}
+
+func (r QuotaResource) String() string {
+	return fmt.Sprintf("%s %s/%s (%d%%)", r.Resource, r.Used, r.Hard, r.Percent)
+}
+
+// QuotaBlockedWorkload is a workload whose pending pods don't fit in the remaining quota.
+type QuotaBlockedWorkload struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Pods is how many more pods the workload needs: the replicas it is short of, plus a
+	// Deployment's rolling update surge.
+	Pods       int32            `json:"pods"`
+	Shortfalls []QuotaShortfall `json:"shortfalls"`
+
+	object any
+}
+
+// QuotaShortfall is a quota resource the pods need more of than is left.
+type QuotaShortfall struct {
+	Quota     string `json:"quota"`
+	Resource  string `json:"resource"`
+	Needed    string `json:"needed"`
+	Remaining string `json:"remaining"`
+}
+
+func (s QuotaShortfall) String() string {
+	return fmt.Sprintf("%s needs %s, %s left in quota %s", s.Resource, s.Needed, s.Remaining, s.Quota)
+}
+
+// GetQuotaReport collects the quota and LimitRange coverage report.
+func GetQuotaReport(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*QuotaReport, error) {
+	namespaces, err := listNamespaces(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	quotas, err := listResourceQuotas(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resourcequotas: %w", err)
+	}
+	limitRanges, err := listLimitRanges(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list limitranges: %w", err)
+	}
+	deployments, err := listDeployments(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	statefulSets, err := listStatefulSets(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	return BuildQuotaReport(namespaces, quotas, limitRanges, deployments, statefulSets), nil
+}
+
+// BuildQuotaReport summarizes quota usage, finds the namespaces without a quota or LimitRange,
+// and checks every Deployment and StatefulSet's pending pods against the quota left. Pod
+// templates get their LimitRange defaults first, as admission would apply them. Quotas with
+// scopes only charge some pods, so they are summarized but not used for the workload check.
+func BuildQuotaReport(namespaces []corev1.Namespace, quotas []corev1.ResourceQuota, limitRanges []corev1.LimitRange,
+	deployments []appsv1.Deployment, statefulSets []appsv1.StatefulSet) *QuotaReport {
+	report := &QuotaReport{}
+	covered := map[string]bool{}
+	quotasByNamespace := map[string][]corev1.ResourceQuota{}
+	for _, q := range quotas {
+		covered[q.Namespace] = true
+		quotasByNamespace[q.Namespace] = append(quotasByNamespace[q.Namespace], q)
+		report.Quotas = append(report.Quotas, QuotaStatus{Namespace: q.Namespace, Name: q.Name, Resources: quotaResources(q)})
+	}
+	limitsByNamespace := map[string][]corev1.LimitRange{}
+	for _, lr := range limitRanges {
+		covered[lr.Namespace] = true
+		limitsByNamespace[lr.Namespace] = append(limitsByNamespace[lr.Namespace], lr)
+	}
+	for _, ns := range namespaces {
+		if !covered[ns.Name] && !strings.HasPrefix(ns.Name, "kube-") {
+			report.Uncovered = append(report.Uncovered, ns.Name)
+		}
+	}
+	sort.Strings(report.Uncovered)
+	sort.Slice(report.Quotas, func(i, j int) bool {
+		a, b := report.Quotas[i], report.Quotas[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	check := func(kind string, meta metav1.ObjectMeta, template corev1.PodSpec, pods int32, object any) {
+		if pods <= 0 || len(quotasByNamespace[meta.Namespace]) == 0 {
+			return
+		}
+		shortfalls := quotaShortfalls(quotasByNamespace[meta.Namespace], podQuotaUsage(template, limitsByNamespace[meta.Namespace]), pods)
+		if len(shortfalls) > 0 {
+			report.Blocked = append(report.Blocked, QuotaBlockedWorkload{
+				Kind: kind, Namespace: meta.Namespace, Name: meta.Name, Pods: pods, Shortfalls: shortfalls, object: object,
+			})
+		}
+	}
+	for i := range deployments {
+		d := &deployments[i]
+		check("Deployment", d.ObjectMeta, d.Spec.Template.Spec, pendingPods(d.Spec.Replicas, d.Status.Replicas)+deploymentSurge(d), d)
+	}
+	for i := range statefulSets {
+		sts := &statefulSets[i]
+		check("StatefulSet", sts.ObjectMeta, sts.Spec.Template.Spec, pendingPods(sts.Spec.Replicas, sts.Status.Replicas), sts)
+	}
+	return report
+}
+
+// quotaResources returns a quota's resources in name order with their usage.
+func quotaResources(q corev1.ResourceQuota) []QuotaResource {
+	var resources []QuotaResource
+	for name, hard := range q.Status.Hard {
+		used := q.Status.Used[name]
+		percent := 100
+		if hard.MilliValue() > 0 {
+			percent = int(used.MilliValue() * 100 / hard.MilliValue())
+		}
+		resources = append(resources, QuotaResource{Resource: string(name), Used: used.String(), Hard: hard.String(), Percent: percent})
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Resource < resources[j].Resource })
+	return resources
+}
+
+// pendingPods is how many replicas a workload is short of.
+func pendingPods(replicas *int32, current int32) int32 {
+	desired := int32(1)
+	if replicas != nil {
+		desired = *replicas
+	}
+	return max(desired-current, 0)
+}
+
+// deploymentSurge is how many pods above its replicas a Deployment creates during a rolling
+// update: maxSurge, 25% rounded up by default.
+func deploymentSurge(d *appsv1.Deployment) int32 {
+	if d.Spec.Strategy.Type == appsv1.RecreateDeploymentStrategyType {
+		return 0
+	}
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	surge := intstr.FromString("25%")
+	if ru := d.Spec.Strategy.RollingUpdate; ru != nil && ru.MaxSurge != nil {
+		surge = *ru.MaxSurge
+	}
+	value, err := intstr.GetScaledValueFromIntOrPercent(&surge, int(replicas), true)
+	if err != nil {
+		return 0
+	}
+	return int32(value)
+}
+
+// podQuotaUsage returns what one pod from spec is charged against a quota, in milli-units, after
+// the namespace's LimitRange container defaults fill in missing requests and limits. A container
+// with a limit but no request is admitted with the limit as its request. As for scheduling, init
+// containers count when they need more than the app containers together.
+func podQuotaUsage(spec corev1.PodSpec, limitRanges []corev1.LimitRange) map[corev1.ResourceName]int64 {
+	var defaultRequests, defaultLimits corev1.ResourceList
+	for _, lr := range limitRanges {
+		for _, item := range lr.Spec.Limits {
+			if item.Type == corev1.LimitTypeContainer {
+				defaultRequests, defaultLimits = item.DefaultRequest, item.Default
+			}
+		}
+	}
+	container := func(c corev1.Container) (requests, limits map[corev1.ResourceName]int64) {
+		requests, limits = map[corev1.ResourceName]int64{}, map[corev1.ResourceName]int64{}
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			limit, hasLimit := c.Resources.Limits[name]
+			if !hasLimit {
+				limit, hasLimit = defaultLimits[name]
+			}
+			request, hasRequest := c.Resources.Requests[name]
+			if !hasRequest {
+				request, hasRequest = c.Resources.Limits[name]
+			}
+			if !hasRequest {
+				request, hasRequest = defaultRequests[name]
+			}
+			if !hasRequest {
+				request = limit
+			}
+			requests[name], limits[name] = request.MilliValue(), limit.MilliValue()
+		}
+		return requests, limits
+	}
+
+	usage := map[corev1.ResourceName]int64{corev1.ResourcePods: 1000}
+	apps := map[corev1.ResourceName]int64{}
+	for _, c := range spec.Containers {
+		requests, limits := container(c)
+		apps[corev1.ResourceRequestsCPU] += requests[corev1.ResourceCPU]
+		apps[corev1.ResourceRequestsMemory] += requests[corev1.ResourceMemory]
+		apps[corev1.ResourceLimitsCPU] += limits[corev1.ResourceCPU]
+		apps[corev1.ResourceLimitsMemory] += limits[corev1.ResourceMemory]
+	}
+	for name, value := range apps {
+		usage[name] = value
+	}
+	for _, c := range spec.InitContainers {
+		requests, limits := container(c)
+		usage[corev1.ResourceRequestsCPU] = max(usage[corev1.ResourceRequestsCPU], requests[corev1.ResourceCPU])
+		usage[corev1.ResourceRequestsMemory] = max(usage[corev1.ResourceRequestsMemory], requests[corev1.ResourceMemory])
+		usage[corev1.ResourceLimitsCPU] = max(usage[corev1.ResourceLimitsCPU], limits[corev1.ResourceCPU])
+		usage[corev1.ResourceLimitsMemory] = max(usage[corev1.ResourceLimitsMemory], limits[corev1.ResourceMemory])
+	}
+	// The pod overhead of a RuntimeClass is charged on top of the containers.
+	if overhead, ok := spec.Overhead[corev1.ResourceCPU]; ok {
+		usage[corev1.ResourceRequestsCPU] += overhead.MilliValue()
+		usage[corev1.ResourceLimitsCPU] += overhead.MilliValue()
+	}
+	if overhead, ok := spec.Overhead[corev1.ResourceMemory]; ok {
+		usage[corev1.ResourceRequestsMemory] += overhead.MilliValue()
+		usage[corev1.ResourceLimitsMemory] += overhead.MilliValue()
+	}
+	return usage
+}
+
+// quotaShortfalls returns the quota resources that the given number of pods, each charged
+// perPod, would exceed. Scoped quotas are skipped.
+func quotaShortfalls(quotas []corev1.ResourceQuota, perPod map[corev1.ResourceName]int64, pods int32) []QuotaShortfall {
+	var shortfalls []QuotaShortfall
+	for _, q := range quotas {
+		if len(q.Spec.Scopes) > 0 || q.Spec.ScopeSelector != nil {
+			continue
+		}
+		for name, hard := range q.Status.Hard {
+			charged, ok := podQuotaResources[name]
+			if !ok {
+				continue
+			}
+			used := q.Status.Used[name]
+			remaining := hard.MilliValue() - used.MilliValue()
+			needed := perPod[charged] * int64(pods)
+			if needed <= remaining {
+				continue
+			}
+			shortfalls = append(shortfalls, QuotaShortfall{
+				Quota:     q.Name,
+				Resource:  string(name),
+				Needed:    resource.NewMilliQuantity(needed, hard.Format).String(),
+				Remaining: resource.NewMilliQuantity(max(remaining, 0), hard.Format).String(),
+			})
+		}
+	}
+	sort.Slice(shortfalls, func(i, j int) bool {
+		a, b := shortfalls[i], shortfalls[j]
+		if a.Quota != b.Quota {
+			return a.Quota < b.Quota
+		}
+		return a.Resource < b.Resource
+	})
+	return shortfalls
+}
+
+// CheckQuotas raises findings for exhausted and nearly exhausted quotas, workloads the quota
+// left can't fit, and namespaces without a quota or LimitRange.
+func CheckQuotas(report *QuotaReport) []Finding {
+	var findings []Finding
+	for _, q := range report.Quotas {
+		for _, r := range q.Resources {
+			f := Finding{Kind: "ResourceQuota", Namespace: q.Namespace, Name: q.Name, keyFields: []string{r.Resource}}
+			switch {
+			case r.Percent >= 100:
+				f.CheckID, f.Severity = "quota-exhausted", SeverityHigh
+				f.Message = fmt.Sprintf("ResourceQuota %s/%s has used all of its %s: %s of %s", q.Namespace, q.Name, r.Resource, r.Used, r.Hard)
+			case r.Percent >= quotaWarnPercent:
+				f.CheckID, f.Severity = "quota-near-limit", SeverityMedium
+				f.Message = fmt.Sprintf("ResourceQuota %s/%s has used %d%% of its %s: %s of %s", q.Namespace, q.Name, r.Percent, r.Resource, r.Used, r.Hard)
+			default:
+				continue
+			}
+			findings = append(findings, f)
+		}
+	}
+	for _, w := range report.Blocked {
+		var reasons []string
+		for _, s := range w.Shortfalls {
+			reasons = append(reasons, s.String())
+		}
+		findings = append(findings, Finding{
+			CheckID:   "quota-blocks-workload",
+			Severity:  SeverityHigh,
+			Kind:      w.Kind,
+			Namespace: w.Namespace,
+			Name:      w.Name,
+			Message:   fmt.Sprintf("%s %s/%s needs %d more pod(s) than its namespace's quota allows: %s", w.Kind, w.Namespace, w.Name, w.Pods, strings.Join(reasons, "; ")),
+			object:    w.object,
+		})
+	}
+	for _, ns := range report.Uncovered {
+		findings = append(findings, Finding{
+			CheckID:  "namespace-without-quota",
+			Severity: SeverityLow,
+			Kind:     "Namespace",
+			Name:     ns,
+			Message:  fmt.Sprintf("namespace %s has neither a ResourceQuota nor a LimitRange, so its workloads can use unbounded capacity", ns),
+		})
+	}
+	return findings
+}
+
+// PrintQuotaReport writes the quota section of the text report.
+func PrintQuotaReport(w io.Writer, report *QuotaReport) {
+	fmt.Fprintln(w, "Resource quotas:")
+	if len(report.Quotas) == 0 {
+		fmt.Fprintln(w, "  No ResourceQuotas found.")
+	}
+	for _, q := range report.Quotas {
+		var resources []string
+		for _, r := range q.Resources {
+			resources = append(resources, r.String())
+		}
+		fmt.Fprintf(w, "  - %s/%s: %s\n", q.Namespace, q.Name, strings.Join(resources, ", "))
+	}
+	if n := len(report.Uncovered); n > 0 {
+		shown := report.Uncovered
+		if n > uncoveredNamespacesShown {
+			shown = shown[:uncoveredNamespacesShown]
+		}
+		more := ""
+		if n > len(shown) {
+			more = fmt.Sprintf(" and %d more (see --output json)", n-len(shown))
+		}
+		fmt.Fprintf(w, "  %d namespace(s) with neither a ResourceQuota nor a LimitRange: %s%s\n", n, strings.Join(shown, ", "), more)
+	}
+	if len(report.Blocked) > 0 {
+		fmt.Fprintln(w, "  Workloads that would exceed the remaining quota:")
+		for _, b := range report.Blocked {
+			fmt.Fprintf(w, "    - %s %s/%s, %d more pod(s):\n", b.Kind, b.Namespace, b.Name, b.Pods)
+			for _, s := range b.Shortfalls {
+				fmt.Fprintf(w, "        %s\n", s)
+			}
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package inspect
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func quotaList(pairs ...string) corev1.ResourceList {
+	list := corev1.ResourceList{}
+	for i := 0; i < len(pairs); i += 2 {
+		list[corev1.ResourceName(pairs[i])] = resource.MustParse(pairs[i+1])
+	}
+	return list
+}
+
+func TestBuildQuotaReport(t *testing.T) {
+	namespaces := []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "scratch"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+	}
+	quotas := []corev1.ResourceQuota{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "compute"},
+			Status: corev1.ResourceQuotaStatus{
+				Hard: quotaList("requests.cpu", "4", "requests.memory", "8Gi", "pods", "10"),
+				Used: quotaList("requests.cpu", "3700m", "requests.memory", "2Gi", "pods", "10"),
+			},
+		},
+		{
+			// Scoped quotas only charge some pods, so they don't block workloads.
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "best-effort"},
+			Spec:       corev1.ResourceQuotaSpec{Scopes: []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort}},
+			Status: corev1.ResourceQuotaStatus{
+				Hard: quotaList("pods", "0"),
+				Used: quotaList("pods", "0"),
+			},
+		},
+	}
+	limitRanges := []corev1.LimitRange{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "defaults"},
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+			Type:           corev1.LimitTypeContainer,
+			Default:        quotaList("cpu", "500m"),
+			DefaultRequest: quotaList("cpu", "100m"),
+		}}},
+	}}
+	replicas := int32(4)
+	deployments := []appsv1.Deployment{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "api"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:      "api",
+				Resources: corev1.ResourceRequirements{Requests: quotaList("cpu", "250m", "memory", "256Mi")},
+			}}}},
+		},
+		Status: appsv1.DeploymentStatus{Replicas: 3},
+	}}
+
+	report := BuildQuotaReport(namespaces, quotas, limitRanges, deployments, nil)
+	if want := []string{"scratch"}; !reflect.DeepEqual(report.Uncovered, want) {
+		t.Errorf("Uncovered = %v, want %v", report.Uncovered, want)
+	}
+	if len(report.Quotas) != 2 || report.Quotas[1].Name != "compute" {
+		t.Fatalf("Quotas = %+v, want best-effort and compute", report.Quotas)
+	}
+	wantResources := []QuotaResource{
+		{Resource: "pods", Used: "10", Hard: "10", Percent: 100},
+		{Resource: "requests.cpu", Used: "3700m", Hard: "4", Percent: 92},
+		{Resource: "requests.memory", Used: "2Gi", Hard: "8Gi", Percent: 25},
+	}
+	if !reflect.DeepEqual(report.Quotas[1].Resources, wantResources) {
+		t.Errorf("Resources = %+v, want %+v", report.Quotas[1].Resources, wantResources)
+	}
+
+	// One missing replica plus a surge of one: two pods at 250m each against 300m left.
+	if len(report.Blocked) != 1 {
+		t.Fatalf("Blocked = %+v, want the api Deployment", report.Blocked)
+	}
+	blocked := report.Blocked[0]
+	wantShortfalls := []QuotaShortfall{
+		{Quota: "compute", Resource: "pods", Needed: "2", Remaining: "0"},
+		{Quota: "compute", Resource: "requests.cpu", Needed: "500m", Remaining: "300m"},
+	}
+	if blocked.Pods != 2 || !reflect.DeepEqual(blocked.Shortfalls, wantShortfalls) {
+		t.Errorf("Blocked = %+v, want 2 pods short of %+v", blocked, wantShortfalls)
+	}
+
+	findings := CheckQuotas(report)
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.CheckID+" "+f.Resource())
+	}
+	want := []string{
+		"quota-exhausted ResourceQuota/team-a/best-effort",
+		"quota-exhausted ResourceQuota/team-a/compute",
+		"quota-near-limit ResourceQuota/team-a/compute",
+		"quota-blocks-workload Deployment/team-a/api",
+		"namespace-without-quota Namespace/scratch",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+
+	var b bytes.Buffer
+	PrintQuotaReport(&b, report)
+	for _, want := range []string{
+		"- team-a/compute: pods 10/10 (100%), requests.cpu 3700m/4 (92%)",
+		"1 namespace(s) with neither a ResourceQuota nor a LimitRange: scratch",
+		"- Deployment team-a/api, 2 more pod(s):",
+		"requests.cpu needs 500m, 300m left in quota compute",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestPodQuotaUsage(t *testing.T) {
+	limitRanges := []corev1.LimitRange{{Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+		Type:           corev1.LimitTypeContainer,
+		Default:        quotaList("cpu", "1", "memory", "512Mi"),
+		DefaultRequest: quotaList("cpu", "200m"),
+	}}}}}
+	spec := corev1.PodSpec{
+		Containers: []corev1.Container{
+			// Gets both defaults; memory has no default request, so it is requested at its limit.
+			{Name: "app"},
+			// A limit without a request is requested at the limit.
+			{Name: "sidecar", Resources: corev1.ResourceRequirements{Limits: quotaList("cpu", "300m", "memory", "128Mi")}},
+		},
+		InitContainers: []corev1.Container{
+			{Name: "migrate", Resources: corev1.ResourceRequirements{Requests: quotaList("cpu", "2"), Limits: quotaList("cpu", "2")}},
+		},
+	}
+
+	usage := podQuotaUsage(spec, limitRanges)
+	want := map[corev1.ResourceName]int64{
+		corev1.ResourcePods:           1000,
+		corev1.ResourceRequestsCPU:    2000, // the init container needs more than the 500m of the apps
+		corev1.ResourceLimitsCPU:      2000,
+		corev1.ResourceRequestsMemory: (512 + 128) << 20 * 1000,
+		corev1.ResourceLimitsMemory:   (512 + 128) << 20 * 1000,
+	}
+	if !reflect.DeepEqual(usage, want) {
+		t.Errorf("podQuotaUsage() = %v, want %v", usage, want)
+	}
+}
+
+func TestDeploymentSurge(t *testing.T) {
+	replicas := int32(10)
+	surge := intstr.FromInt32(3)
+	tests := []struct {
+		name     string
+		strategy appsv1.DeploymentStrategy
+		want     int32
+	}{
+		{name: "default 25% rounded up", want: 3},
+		{name: "explicit", strategy: appsv1.DeploymentStrategy{RollingUpdate: &appsv1.RollingUpdateDeployment{MaxSurge: &surge}}, want: 3},
+		{name: "recreate", strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &replicas, Strategy: tt.strategy}}
+			if got := deploymentSurge(d); got != tt.want {
+				t.Errorf("deploymentSurge() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuotasCollector(t *testing.T) {
+	clientset, dynamicClient := newFakeClients(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "scratch"}},
+		&corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "compute"},
+			Status:     corev1.ResourceQuotaStatus{Hard: quotaList("pods", "10"), Used: quotaList("pods", "2")},
+		},
+	)
+	report := fakeScan(t, clientset, dynamicClient, "quotas")
+	if report.Quotas == nil || len(report.Quotas.Quotas) != 1 {
+		t.Fatalf("Quotas = %+v, want the compute quota", report.Quotas)
+	}
+	if ids := checkIDs(report.Findings); !reflect.DeepEqual(ids, []string{"namespace-without-quota"}) {
+		t.Errorf("findings = %v, want [namespace-without-quota]", ids)
+	}
+}
@@ -0,0 +1,67 @@
+package inspect
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// redactedValue replaces every secret value in raw output.
+const redactedValue = "REDACTED"
+
+// lastAppliedAnnotation holds a copy of the object as last applied with kubectl, secret data included.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// attachRaw fills in Raw from the flagged object, with secret values stripped.
+func (f *Finding) attachRaw() error {
+	if f.object == nil {
+		return nil
+	}
+	raw, err := SanitizedJSON(f.Kind, f.object)
+	if err != nil {
+		return fmt.Errorf("failed to serialize %s: %w", f.Resource(), err)
+	}
+	f.Raw = raw
+	return nil
+}
+
+// SanitizedJSON serializes an API object for inclusion in a report. Managed fields and the
+// last-applied-configuration annotation are dropped, and for Secrets every value under data
+// and stringData is redacted, so raw output never carries secret material.
+func SanitizedJSON(kind string, obj any) (json.RawMessage, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	sanitizeFields(kind, fields)
+
+	return json.Marshal(fields)
+}
+
+func sanitizeFields(kind string, fields map[string]any) {
+	// Objects returned by list calls have no TypeMeta, so record the kind explicitly.
+	if _, ok := fields["kind"]; !ok && kind != "" {
+		fields["kind"] = kind
+	}
+
+	if metadata, ok := fields["metadata"].(map[string]any); ok {
+		delete(metadata, "managedFields")
+		if annotations, ok := metadata["annotations"].(map[string]any); ok {
+			delete(annotations, lastAppliedAnnotation)
+		}
+	}
+
+	if kind == "Secret" {
+		for _, key := range []string{"data", "stringData"} {
+			if values, ok := fields[key].(map[string]any); ok {
+				for k := range values {
+					values[k] = redactedValue
+				}
+			}
+		}
+	}
+}
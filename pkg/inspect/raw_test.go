@@ -0,0 +1,64 @@
+package inspect
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSanitizedJSON_RedactsSecrets(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:          "db",
+			Namespace:     "prod",
+			Annotations:   map[string]string{lastAppliedAnnotation: `{"stringData":{"password":"hunter2"}}`},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+		StringData: map[string]string{"token": "hunter2"},
+	}
+
+	raw, err := SanitizedJSON("Secret", secret)
+	if err != nil {
+		t.Fatalf("SanitizedJSON() returned error = %v, want nil", err)
+	}
+
+	out := string(raw)
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "aHVudGVyMg") {
+		t.Errorf("SanitizedJSON() leaked secret value: %s", out)
+	}
+	if strings.Contains(out, "managedFields") {
+		t.Errorf("SanitizedJSON() kept managedFields: %s", out)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatalf("SanitizedJSON() returned invalid JSON: %v", err)
+	}
+	if fields["kind"] != "Secret" {
+		t.Errorf("SanitizedJSON() kind = %v, want Secret", fields["kind"])
+	}
+	if data := fields["data"].(map[string]any); data["password"] != redactedValue {
+		t.Errorf("SanitizedJSON() data.password = %v, want %s", data["password"], redactedValue)
+	}
+}
+
+func TestFinding_AttachRaw(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n1"}}
+	f := Finding{CheckID: "node-not-ready", Kind: "Node", Name: "n1", object: node}
+
+	if err := f.attachRaw(); err != nil {
+		t.Fatalf("attachRaw() returned error = %v, want nil", err)
+	}
+	if !strings.Contains(string(f.Raw), `"name":"n1"`) {
+		t.Errorf("attachRaw() Raw = %s, want the node's JSON", f.Raw)
+	}
+
+	empty := Finding{CheckID: "x"}
+	if err := empty.attachRaw(); err != nil || empty.Raw != nil {
+		t.Errorf("attachRaw() without object = (%s, %v), want (nil, nil)", empty.Raw, err)
+	}
+}
@@ -0,0 +1,232 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// RequiredPermission is a permission kube-op needs and the collectors that need it.
+type RequiredPermission struct {
+	Permission
+	// Collectors is empty for permissions every scan needs.
+	Collectors []string `json:"collectors,omitempty"`
+}
+
+// RequiredPermissions merges the permissions of every scan with those of the collectors that
+// run with opts, combining the verbs on each resource. It leaves out the permissions that depend
+// on the cluster; ResolveRequiredPermissions adds those.
+func RequiredPermissions(collectors []*Collector, opts ScanOptions) []RequiredPermission {
+	type key struct{ namespace, group, resource string }
+	type entry struct {
+		verbs      map[string]bool
+		collectors map[string]bool
+	}
+	merged := map[key]*entry{}
+	add := func(p Permission, collector string) {
+		k := key{p.Namespace, p.Group, p.Resource}
+		e := merged[k]
+		if e == nil {
+			e = &entry{verbs: map[string]bool{}, collectors: map[string]bool{}}
+			merged[k] = e
+		}
+		for _, v := range p.Verbs {
+			e.verbs[v] = true
+		}
+		if collector != "" {
+			e.collectors[collector] = true
+		}
+	}
+	for _, p := range corePermissions {
+		add(p, "")
+	}
+	for _, c := range collectors {
+		if !c.Enabled(opts) {
+			continue
+		}
+		for _, p := range c.RBAC {
+			add(p, c.Name)
+		}
+	}
+
+	var required []RequiredPermission
+	for k, e := range merged {
+		r := RequiredPermission{Permission: Permission{Group: k.group, Resource: k.resource, Namespace: k.namespace}}
+		for v := range e.verbs {
+			r.Verbs = append(r.Verbs, v)
+		}
+		for c := range e.collectors {
+			r.Collectors = append(r.Collectors, c)
+		}
+		sort.Strings(r.Verbs)
+		sort.Strings(r.Collectors)
+		required = append(required, r)
+	}
+	sort.Slice(required, func(i, j int) bool {
+		a, b := required[i], required[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		return a.Resource < b.Resource
+	})
+	return required
+}
+
+// ResolveRequiredPermissions is RequiredPermissions plus the permissions that depend on what
+// the cluster serves, such as list on each custom resource kind the custom-resources collector
+// checks, which are looked up with client.
+func ResolveRequiredPermissions(ctx context.Context, client dynamic.Interface, collectors []*Collector, opts ScanOptions) ([]RequiredPermission, error) {
+	resolved := make([]*Collector, len(collectors))
+	for i, c := range collectors {
+		resolved[i] = c
+		if c.clusterRBAC == nil || !c.Enabled(opts) {
+			continue
+		}
+		permissions, err := c.clusterRBAC(ctx, client, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve the permissions of collector %s: %w", c.Name, err)
+		}
+		withCluster := *c
+		withCluster.RBAC = append(slices.Clone(c.RBAC), permissions...)
+		resolved[i] = &withCluster
+	}
+	return RequiredPermissions(resolved, opts), nil
+}
+
+// AccessCheck is the result of a SelfSubjectAccessReview for one verb of a required permission.
+type AccessCheck struct {
+	RequiredPermission
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// CheckAccess asks the API server whether the current identity holds every required permission,
+// one SelfSubjectAccessReview per verb.
+func CheckAccess(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, required []RequiredPermission) ([]AccessCheck, error) {
+	var checks []AccessCheck
+	for _, r := range required {
+		for _, verb := range r.Verbs {
+			check := r
+			check.Verbs = []string{verb}
+			checks = append(checks, AccessCheck{RequiredPermission: check})
+		}
+	}
+
+	errs := make([]error, len(checks))
+	tasks := make([]func(), len(checks))
+	for i := range checks {
+		tasks[i] = func() {
+			c := &checks[i]
+			resource, subresource, _ := strings.Cut(c.Resource, "/")
+			review, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: c.Namespace, Verb: c.Verbs[0], Group: c.Group, Resource: resource, Subresource: subresource,
+				}},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to review access to %s: %w", c.Permission, err)
+				return
+			}
+			c.Allowed, c.Reason = review.Status.Allowed, review.Status.Reason
+		}
+	}
+	runConcurrently(opts.Concurrency, tasks...)
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return checks, nil
+}
+
+// PrintAccessChecks writes the preflight results, listing the collectors each missing
+// permission affects.
+func PrintAccessChecks(w io.Writer, checks []AccessCheck) {
+	var missing []AccessCheck
+	for _, c := range checks {
+		if !c.Allowed {
+			missing = append(missing, c)
+		}
+	}
+	fmt.Fprintf(w, "%d of %d required permission(s) granted\n", len(checks)-len(missing), len(checks))
+	for _, c := range missing {
+		affected := "every scan"
+		if len(c.Collectors) > 0 {
+			affected = "collectors " + strings.Join(c.Collectors, ", ")
+		}
+		fmt.Fprintf(w, "  MISSING %s (needed by %s)\n", c.Permission, affected)
+	}
+}
+
+// RBACManifest renders a ClusterRole for the cluster-wide permissions and a Role per namespace
+// for the namespaced ones, each bound to the service account, as multi-document YAML.
+func RBACManifest(required []RequiredPermission, name string, subject rbacv1.Subject) ([]byte, error) {
+	rulesByNamespace := map[string][]rbacv1.PolicyRule{}
+	var namespaces []string
+	for _, r := range required {
+		if _, ok := rulesByNamespace[r.Namespace]; !ok {
+			namespaces = append(namespaces, r.Namespace)
+		}
+		rules := rulesByNamespace[r.Namespace]
+		// Resources sharing an API group and verbs go in one rule.
+		merged := false
+		for i := range rules {
+			if rules[i].APIGroups[0] == r.Group && strings.Join(rules[i].Verbs, ",") == strings.Join(r.Verbs, ",") {
+				rules[i].Resources = append(rules[i].Resources, r.Resource)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			rules = append(rules, rbacv1.PolicyRule{APIGroups: []string{r.Group}, Resources: []string{r.Resource}, Verbs: r.Verbs})
+		}
+		rulesByNamespace[r.Namespace] = rules
+	}
+	sort.Strings(namespaces)
+
+	var objects []any
+	for _, ns := range namespaces {
+		meta := metav1.ObjectMeta{Name: name, Namespace: ns}
+		if ns == "" {
+			objects = append(objects,
+				rbacv1.ClusterRole{TypeMeta: metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"}, ObjectMeta: meta, Rules: rulesByNamespace[ns]},
+				rbacv1.ClusterRoleBinding{
+					TypeMeta: metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"}, ObjectMeta: meta,
+					RoleRef:  rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: name},
+					Subjects: []rbacv1.Subject{subject},
+				})
+			continue
+		}
+		objects = append(objects,
+			rbacv1.Role{TypeMeta: metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"}, ObjectMeta: meta, Rules: rulesByNamespace[ns]},
+			rbacv1.RoleBinding{
+				TypeMeta: metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"}, ObjectMeta: meta,
+				RoleRef:  rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name},
+				Subjects: []rbacv1.Subject{subject},
+			})
+	}
+
+	var docs []string
+	for _, o := range objects {
+		data, err := yaml.Marshal(o)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render manifest: %w", err)
+		}
+		// Drop the empty creationTimestamp that ObjectMeta always marshals.
+		docs = append(docs, strings.Replace(string(data), "  creationTimestamp: null\n", "", 1))
+	}
+	return []byte(strings.Join(docs, "---\n")), nil
+}
@@ -0,0 +1,137 @@
+package inspect
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"sigs.k8s.io/yaml"
+)
+
+func TestRequiredPermissions(t *testing.T) {
+	collectors := []*Collector{
+		{Name: "a", RBAC: []Permission{allow("", "pods", "list"), allow("apps", "deployments", "list")}},
+		{Name: "b", RBAC: []Permission{allow("", "pods", "get", "list")}},
+		{Name: "deep", RBAC: []Permission{allowIn("kube-system", "", "pods/exec", "create")}, enabled: func(opts ScanOptions) bool { return opts.EtcdDeep }},
+	}
+
+	required := RequiredPermissions(collectors, ScanOptions{})
+
+	byResource := map[string]RequiredPermission{}
+	for _, r := range required {
+		byResource[r.Permission.Namespace+"/"+r.Resource] = r
+	}
+	if pods := byResource["/pods"]; !reflect.DeepEqual(pods.Verbs, []string{"get", "list"}) || !reflect.DeepEqual(pods.Collectors, []string{"a", "b"}) {
+		t.Errorf("RequiredPermissions() pods = %+v, want get, list merged from collectors a and b", pods)
+	}
+	if nodes := byResource["/nodes"]; len(nodes.Collectors) != 0 {
+		t.Errorf("RequiredPermissions() nodes = %+v, want the core permission without collectors", nodes)
+	}
+	if _, ok := byResource["kube-system/pods/exec"]; ok {
+		t.Error("RequiredPermissions() includes pods/exec in kube-system, want the opt-in collector left out without its flag")
+	}
+
+	required = RequiredPermissions(collectors, ScanOptions{EtcdDeep: true})
+	found := false
+	for _, r := range required {
+		found = found || (r.Resource == "pods/exec" && r.Namespace == "kube-system")
+	}
+	if !found {
+		t.Error("RequiredPermissions() with --etcd-deep is missing pods/exec in kube-system")
+	}
+}
+
+func TestResolveRequiredPermissions(t *testing.T) {
+	crd := func(group, kind, plural string, conditions bool) *unstructured.Unstructured {
+		u := testCRD(group, kind, plural, "Namespaced", crdVersion("v1", true, true, conditions))
+		u.SetAPIVersion("apiextensions.k8s.io/v1")
+		u.SetKind("CustomResourceDefinition")
+		u.SetName(plural + "." + group)
+		return &u
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{crdResource: "CustomResourceDefinitionList"},
+		crd("cert-manager.io", "Certificate", "certificates", true), crd("example.com", "Widget", "widgets", false))
+	collectors, err := SelectCollectors([]string{"custom-resources"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	required, err := ResolveRequiredPermissions(context.Background(), dynamicClient, collectors, ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range required {
+		if len(r.Collectors) > 0 {
+			got = append(got, r.Group+"/"+r.Resource)
+		}
+		if r.Group == "*" || r.Resource == "*" {
+			t.Errorf("ResolveRequiredPermissions() includes wildcard %+v, want only the kinds the collector reads", r)
+		}
+	}
+	if want := []string{"apiextensions.k8s.io/customresourcedefinitions", "cert-manager.io/certificates"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveRequiredPermissions() collector permissions = %v, want %v", got, want)
+	}
+
+	for _, c := range collectorRegistry {
+		for _, p := range c.RBAC {
+			if p.Group == "*" || p.Resource == "*" {
+				t.Errorf("collector %s declares wildcard permission %s; declare the resources it reads", c.Name, p)
+			}
+		}
+	}
+}
+
+func TestRBACManifest(t *testing.T) {
+	required := RequiredPermissions([]*Collector{
+		{Name: "a", RBAC: []Permission{allow("", "pods", "list"), allow("apps", "deployments", "list"), allowIn("kube-system", "", "pods/exec", "create")}},
+	}, ScanOptions{})
+
+	data, err := RBACManifest(required, "kube-op", rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: "ops", Name: "scanner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := strings.Split(string(data), "---\n")
+	if len(docs) != 4 {
+		t.Fatalf("RBACManifest() = %d documents, want a ClusterRole, Role, and their bindings:\n%s", len(docs), data)
+	}
+
+	var clusterRole rbacv1.ClusterRole
+	if err := yaml.Unmarshal([]byte(docs[0]), &clusterRole); err != nil {
+		t.Fatal(err)
+	}
+	want := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: []string{"get", "list"}},
+		{APIGroups: []string{""}, Resources: []string{"nodes", "pods"}, Verbs: []string{"list"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"list"}},
+	}
+	if clusterRole.Kind != "ClusterRole" || !reflect.DeepEqual(clusterRole.Rules, want) {
+		t.Errorf("ClusterRole = %+v, want rules %+v", clusterRole, want)
+	}
+
+	var role rbacv1.Role
+	if err := yaml.Unmarshal([]byte(docs[2]), &role); err != nil {
+		t.Fatal(err)
+	}
+	if role.Namespace != "kube-system" || len(role.Rules) != 2 {
+		t.Errorf("Role = %+v, want 2 rules in kube-system", role)
+	}
+
+	var binding rbacv1.RoleBinding
+	if err := yaml.Unmarshal([]byte(docs[3]), &binding); err != nil {
+		t.Fatal(err)
+	}
+	if binding.RoleRef.Kind != "Role" || binding.Subjects[0].Namespace != "ops" || binding.Subjects[0].Name != "scanner" {
+		t.Errorf("RoleBinding = %+v, want the Role bound to ops/scanner", binding)
+	}
+	if strings.Contains(string(data), "creationTimestamp") {
+		t.Errorf("RBACManifest() = %s, want empty creation timestamps dropped", data)
+	}
+}
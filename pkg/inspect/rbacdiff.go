@@ -0,0 +1,390 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Identity is a user, group, or service account whose RBAC permissions are resolved.
+type Identity struct {
+	// Kind is User, Group, or ServiceAccount, as in RBAC subjects.
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+func (i Identity) String() string {
+	if i.Kind == rbacv1.ServiceAccountKind {
+		return fmt.Sprintf("%s %s/%s", i.Kind, i.Namespace, i.Name)
+	}
+	return i.Kind + " " + i.Name
+}
+
+// ParseIdentity parses a --as or --as-group value. Service accounts are given by their username,
+// system:serviceaccount:<namespace>:<name>.
+func ParseIdentity(value string, group bool) (Identity, error) {
+	if value == "" {
+		return Identity{}, fmt.Errorf("empty identity")
+	}
+	if group {
+		return Identity{Kind: rbacv1.GroupKind, Name: value}, nil
+	}
+	if rest, ok := strings.CutPrefix(value, "system:serviceaccount:"); ok {
+		namespace, name, ok := strings.Cut(rest, ":")
+		if !ok || namespace == "" || name == "" {
+			return Identity{}, fmt.Errorf("invalid service account %q (want system:serviceaccount:<namespace>:<name>)", value)
+		}
+		return Identity{Kind: rbacv1.ServiceAccountKind, Namespace: namespace, Name: name}, nil
+	}
+	return Identity{Kind: rbacv1.UserKind, Name: value}, nil
+}
+
+// groups are the groups the API server puts every request of the identity in. Other memberships
+// come from the authenticator and are not visible in the cluster.
+func (i Identity) groups() []string {
+	switch i.Kind {
+	case rbacv1.GroupKind:
+		return []string{i.Name}
+	case rbacv1.ServiceAccountKind:
+		return []string{"system:serviceaccounts", "system:serviceaccounts:" + i.Namespace, "system:authenticated"}
+	default:
+		return []string{"system:authenticated"}
+	}
+}
+
+// matches reports whether a subject of a binding in namespace refers to the identity.
+func (i Identity) matches(subject rbacv1.Subject, namespace string) bool {
+	switch subject.Kind {
+	case rbacv1.UserKind:
+		return i.Kind == rbacv1.UserKind && subject.Name == i.Name
+	case rbacv1.GroupKind:
+		for _, g := range i.groups() {
+			if subject.Name == g {
+				return true
+			}
+		}
+		return false
+	case rbacv1.ServiceAccountKind:
+		if subject.Namespace != "" {
+			namespace = subject.Namespace
+		}
+		return i.Kind == rbacv1.ServiceAccountKind && subject.Name == i.Name && namespace == i.Namespace
+	}
+	return false
+}
+
+// RBACPolicy holds the RBAC objects rule resolution works from.
+type RBACPolicy struct {
+	ClusterRoles        []rbacv1.ClusterRole
+	ClusterRoleBindings []rbacv1.ClusterRoleBinding
+	Roles               []rbacv1.Role
+	RoleBindings        []rbacv1.RoleBinding
+}
+
+// GetRBACPolicy lists the cluster's roles and bindings.
+func GetRBACPolicy(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*RBACPolicy, error) {
+	policy := &RBACPolicy{}
+	var err error
+	policy.ClusterRoles, err = listPaged(opts.PageSize, metav1.ListOptions{}, func(o metav1.ListOptions) ([]rbacv1.ClusterRole, string, error) {
+		l, err := clientset.RbacV1().ClusterRoles().List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusterroles: %w", err)
+	}
+	policy.ClusterRoleBindings, err = listPaged(opts.PageSize, metav1.ListOptions{}, func(o metav1.ListOptions) ([]rbacv1.ClusterRoleBinding, string, error) {
+		l, err := clientset.RbacV1().ClusterRoleBindings().List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusterrolebindings: %w", err)
+	}
+	policy.Roles, err = listPaged(opts.PageSize, metav1.ListOptions{}, func(o metav1.ListOptions) ([]rbacv1.Role, string, error) {
+		l, err := clientset.RbacV1().Roles("").List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	policy.RoleBindings, err = listPaged(opts.PageSize, metav1.ListOptions{}, func(o metav1.ListOptions) ([]rbacv1.RoleBinding, string, error) {
+		l, err := clientset.RbacV1().RoleBindings("").List(ctx, o)
+		if err != nil {
+			return nil, "", err
+		}
+		return l.Items, l.Continue, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rolebindings: %w", err)
+	}
+	return policy, nil
+}
+
+// EffectiveRule is a single verb an identity may use on a resource or non-resource URL, either
+// cluster-wide or in one namespace. Wildcards are kept as "*".
+type EffectiveRule struct {
+	// Namespace is empty for rules granted cluster-wide by a ClusterRoleBinding.
+	Namespace      string `json:"namespace,omitempty"`
+	APIGroup       string `json:"apiGroup"`
+	Resource       string `json:"resource,omitempty"`
+	ResourceName   string `json:"resourceName,omitempty"`
+	NonResourceURL string `json:"nonResourceURL,omitempty"`
+	Verb           string `json:"verb"`
+	// Via lists the bindings and roles that grant the rule.
+	Via []string `json:"via"`
+}
+
+// target names what the rule applies to, such as deployments.apps/web or /healthz.
+func (r EffectiveRule) target() string {
+	if r.NonResourceURL != "" {
+		return r.NonResourceURL
+	}
+	s := r.Resource
+	if r.APIGroup != "" {
+		s += "." + r.APIGroup
+	}
+	if r.ResourceName != "" {
+		s += "/" + r.ResourceName
+	}
+	return s
+}
+
+// scope is the namespace the rule applies in, for the text output.
+func (r EffectiveRule) scope() string {
+	if r.Namespace == "" {
+		return "cluster-wide"
+	}
+	return "namespace " + r.Namespace
+}
+
+// covers reports whether r grants other, following the RBAC authorizer's wildcard rules.
+func (r EffectiveRule) covers(other EffectiveRule) bool {
+	if r.Namespace != "" && r.Namespace != other.Namespace {
+		return false
+	}
+	if r.Verb != rbacv1.VerbAll && r.Verb != other.Verb {
+		return false
+	}
+	if other.NonResourceURL != "" {
+		return r.NonResourceURL == other.NonResourceURL || r.NonResourceURL == rbacv1.NonResourceAll ||
+			strings.HasSuffix(r.NonResourceURL, "*") && strings.HasPrefix(other.NonResourceURL, strings.TrimSuffix(r.NonResourceURL, "*"))
+	}
+	if r.NonResourceURL != "" {
+		return false
+	}
+	if r.APIGroup != rbacv1.APIGroupAll && r.APIGroup != other.APIGroup {
+		return false
+	}
+	if r.Resource != rbacv1.ResourceAll && r.Resource != other.Resource {
+		_, sub, ok := strings.Cut(other.Resource, "/")
+		if !ok || r.Resource != "*/"+sub {
+			return false
+		}
+	}
+	return r.ResourceName == "" || r.ResourceName == other.ResourceName
+}
+
+// ResolveRules returns the rules the policy grants identity, one per verb and resource, with the
+// bindings that grant each. Bindings that reference a role that does not exist are returned as
+// dangling, since they grant nothing until the role is created.
+func ResolveRules(policy *RBACPolicy, identity Identity) (rules []EffectiveRule, dangling []string) {
+	clusterRoles := map[string][]rbacv1.PolicyRule{}
+	for _, r := range policy.ClusterRoles {
+		clusterRoles[r.Name] = r.Rules
+	}
+	roles := map[string][]rbacv1.PolicyRule{}
+	for _, r := range policy.Roles {
+		roles[r.Namespace+"/"+r.Name] = r.Rules
+	}
+
+	type key struct{ namespace, group, resource, name, url, verb string }
+	byKey := map[key]int{}
+	add := func(namespace string, policyRules []rbacv1.PolicyRule, via string) {
+		for _, pr := range policyRules {
+			for _, rule := range expandPolicyRule(namespace, pr) {
+				k := key{rule.Namespace, rule.APIGroup, rule.Resource, rule.ResourceName, rule.NonResourceURL, rule.Verb}
+				if i, ok := byKey[k]; ok {
+					rules[i].Via = append(rules[i].Via, via)
+					continue
+				}
+				byKey[k] = len(rules)
+				rule.Via = []string{via}
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	for _, b := range policy.ClusterRoleBindings {
+		if !matchesAny(identity, b.Subjects, "") {
+			continue
+		}
+		via := fmt.Sprintf("ClusterRoleBinding %s -> ClusterRole %s", b.Name, b.RoleRef.Name)
+		policyRules, ok := clusterRoles[b.RoleRef.Name]
+		if !ok {
+			dangling = append(dangling, via)
+			continue
+		}
+		add("", policyRules, via)
+	}
+	for _, b := range policy.RoleBindings {
+		if !matchesAny(identity, b.Subjects, b.Namespace) {
+			continue
+		}
+		via := fmt.Sprintf("RoleBinding %s/%s -> %s %s", b.Namespace, b.Name, b.RoleRef.Kind, b.RoleRef.Name)
+		var policyRules []rbacv1.PolicyRule
+		var ok bool
+		if b.RoleRef.Kind == "ClusterRole" {
+			policyRules, ok = clusterRoles[b.RoleRef.Name]
+		} else {
+			policyRules, ok = roles[b.Namespace+"/"+b.RoleRef.Name]
+		}
+		if !ok {
+			dangling = append(dangling, via)
+			continue
+		}
+		add(b.Namespace, policyRules, via)
+	}
+
+	sortEffectiveRules(rules)
+	return rules, dangling
+}
+
+func matchesAny(identity Identity, subjects []rbacv1.Subject, namespace string) bool {
+	for _, s := range subjects {
+		if identity.matches(s, namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandPolicyRule splits a policy rule into one EffectiveRule per verb and resource. Non-resource
+// URLs only apply cluster-wide, so they are dropped from rules bound in a namespace.
+func expandPolicyRule(namespace string, pr rbacv1.PolicyRule) []EffectiveRule {
+	var rules []EffectiveRule
+	for _, verb := range pr.Verbs {
+		if namespace == "" {
+			for _, url := range pr.NonResourceURLs {
+				rules = append(rules, EffectiveRule{NonResourceURL: url, Verb: verb})
+			}
+		}
+		names := pr.ResourceNames
+		if len(names) == 0 {
+			names = []string{""}
+		}
+		for _, group := range pr.APIGroups {
+			for _, resource := range pr.Resources {
+				for _, name := range names {
+					rules = append(rules, EffectiveRule{Namespace: namespace, APIGroup: group, Resource: resource, ResourceName: name, Verb: verb})
+				}
+			}
+		}
+	}
+	return rules
+}
+
+func sortEffectiveRules(rules []EffectiveRule) {
+	sort.Slice(rules, func(i, j int) bool {
+		a, b := rules[i], rules[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.target() != b.target() {
+			return a.target() < b.target()
+		}
+		return a.Verb < b.Verb
+	})
+}
+
+// RBACDiff compares the effective permissions of two identities.
+type RBACDiff struct {
+	A Identity `json:"a"`
+	B Identity `json:"b"`
+	// OnlyA are the rules A has that none of B's rules grant, and OnlyB the reverse.
+	OnlyA []EffectiveRule `json:"onlyA"`
+	OnlyB []EffectiveRule `json:"onlyB"`
+	// Shared counts A's rules that B is also granted.
+	Shared int `json:"shared"`
+	// Dangling are bindings of either identity whose role does not exist.
+	Dangling []string `json:"dangling,omitempty"`
+}
+
+// DiffRBAC resolves the rules of both identities and diffs them. A rule only counts as extra when
+// no rule of the other identity covers it, so a wildcard on one side absorbs the specific verbs
+// and resources it grants on the other.
+func DiffRBAC(policy *RBACPolicy, a, b Identity) *RBACDiff {
+	rulesA, danglingA := ResolveRules(policy, a)
+	rulesB, danglingB := ResolveRules(policy, b)
+	diff := &RBACDiff{A: a, B: b, Dangling: append(danglingA, danglingB...)}
+	for _, r := range rulesA {
+		if coveredBy(r, rulesB) {
+			diff.Shared++
+		} else {
+			diff.OnlyA = append(diff.OnlyA, r)
+		}
+	}
+	for _, r := range rulesB {
+		if !coveredBy(r, rulesA) {
+			diff.OnlyB = append(diff.OnlyB, r)
+		}
+	}
+	return diff
+}
+
+func coveredBy(rule EffectiveRule, rules []EffectiveRule) bool {
+	for _, r := range rules {
+		if r.covers(rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintRBACDiff writes the diff, merging the verbs each binding grants on the same target onto
+// one line.
+func PrintRBACDiff(w io.Writer, diff *RBACDiff) {
+	fmt.Fprintf(w, "Effective permissions of %s vs %s (%d shared)\n", diff.A, diff.B, diff.Shared)
+	printOnly := func(identity Identity, rules []EffectiveRule) {
+		fmt.Fprintf(w, "\nOnly %s (%d):\n", identity, len(rules))
+		if len(rules) == 0 {
+			fmt.Fprintln(w, "  (none)")
+			return
+		}
+		type line struct{ scope, target, via string }
+		var order []line
+		verbs := map[line][]string{}
+		for _, r := range rules {
+			l := line{r.scope(), r.target(), strings.Join(r.Via, "; ")}
+			if _, ok := verbs[l]; !ok {
+				order = append(order, l)
+			}
+			verbs[l] = append(verbs[l], r.Verb)
+		}
+		for _, l := range order {
+			fmt.Fprintf(w, "  %s: %s %s (via %s)\n", l.scope, strings.Join(verbs[l], ","), l.target, l.via)
+		}
+	}
+	printOnly(diff.A, diff.OnlyA)
+	printOnly(diff.B, diff.OnlyB)
+	if len(diff.Dangling) > 0 {
+		fmt.Fprintf(w, "\nBindings to roles that do not exist (%d):\n", len(diff.Dangling))
+		for _, d := range diff.Dangling {
+			fmt.Fprintf(w, "  %s\n", d)
+		}
+	}
+}
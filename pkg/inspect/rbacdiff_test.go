@@ -0,0 +1,190 @@
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseIdentity(t *testing.T) {
+	tests := []struct {
+		value   string
+		group   bool
+		want    Identity
+		wantErr bool
+	}{
+		{value: "alice", want: Identity{Kind: rbacv1.UserKind, Name: "alice"}},
+		{value: "devs", group: true, want: Identity{Kind: rbacv1.GroupKind, Name: "devs"}},
+		{value: "system:serviceaccount:ci:deployer", want: Identity{Kind: rbacv1.ServiceAccountKind, Namespace: "ci", Name: "deployer"}},
+		{value: "system:serviceaccount:ci", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseIdentity(tt.value, tt.group)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseIdentity(%q, %v) = %+v, %v", tt.value, tt.group, got, err)
+		}
+	}
+}
+
+func testRBACPolicy() *RBACPolicy {
+	subject := func(kind, namespace, name string) rbacv1.Subject {
+		return rbacv1.Subject{Kind: kind, Namespace: namespace, Name: name}
+	}
+	return &RBACPolicy{
+		ClusterRoles: []rbacv1.ClusterRole{
+			{ObjectMeta: metav1.ObjectMeta{Name: "view"}, Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"", "apps"}, Resources: []string{"pods", "deployments"}, Verbs: []string{"get", "list"}},
+			}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "secret-reader"}, Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+				{NonResourceURLs: []string{"/metrics"}, Verbs: []string{"get"}},
+			}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "admin"}, Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+			}},
+		},
+		ClusterRoleBindings: []rbacv1.ClusterRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "everyone-view"},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"},
+				Subjects:   []rbacv1.Subject{subject(rbacv1.GroupKind, "", "system:authenticated")},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "alice-secrets"},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "secret-reader"},
+				Subjects:   []rbacv1.Subject{subject(rbacv1.UserKind, "", "alice")},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "stale"},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "deleted"},
+				Subjects:   []rbacv1.Subject{subject(rbacv1.UserKind, "", "alice")},
+			},
+		},
+		Roles: []rbacv1.Role{
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "scaler"}, Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments/scale"}, ResourceNames: []string{"web"}, Verbs: []string{"update"}},
+			}},
+		},
+		RoleBindings: []rbacv1.RoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "deployer-scale"},
+				RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "scaler"},
+				Subjects:   []rbacv1.Subject{subject(rbacv1.ServiceAccountKind, "", "deployer")},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "bob-admin"},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "admin"},
+				Subjects:   []rbacv1.Subject{subject(rbacv1.UserKind, "", "bob")},
+			},
+		},
+	}
+}
+
+func TestResolveRules(t *testing.T) {
+	policy := testRBACPolicy()
+
+	rules, dangling := ResolveRules(policy, Identity{Kind: rbacv1.UserKind, Name: "alice"})
+	if len(rules) != 10 {
+		t.Errorf("len(ResolveRules(alice)) = %d, want 10: 8 view rules, secrets get, and /metrics get: %+v", len(rules), rules)
+	}
+	if want := []string{"ClusterRoleBinding stale -> ClusterRole deleted"}; !reflect.DeepEqual(dangling, want) {
+		t.Errorf("dangling = %v, want %v", dangling, want)
+	}
+
+	rules, _ = ResolveRules(policy, Identity{Kind: rbacv1.ServiceAccountKind, Namespace: "ci", Name: "deployer"})
+	var scale []EffectiveRule
+	for _, r := range rules {
+		if r.Namespace == "ci" {
+			scale = append(scale, r)
+		}
+	}
+	want := []EffectiveRule{{
+		Namespace: "ci", APIGroup: "apps", Resource: "deployments/scale", ResourceName: "web", Verb: "update",
+		Via: []string{"RoleBinding ci/deployer-scale -> Role scaler"},
+	}}
+	if !reflect.DeepEqual(scale, want) {
+		t.Errorf("ResolveRules(ci/deployer) in ci = %+v, want %+v with the subject namespace defaulted to the binding's", scale, want)
+	}
+
+	// Groups only get what is bound to the group itself.
+	if rules, _ := ResolveRules(policy, Identity{Kind: rbacv1.GroupKind, Name: "devs"}); len(rules) != 0 {
+		t.Errorf("ResolveRules(devs) = %+v, want none for an unbound group", rules)
+	}
+}
+
+func TestEffectiveRuleCovers(t *testing.T) {
+	podsGet := EffectiveRule{Namespace: "web", Resource: "pods", Verb: "get"}
+	tests := []struct {
+		rule  EffectiveRule
+		other EffectiveRule
+		want  bool
+	}{
+		{EffectiveRule{APIGroup: "*", Resource: "*", Verb: "*"}, podsGet, true},
+		{EffectiveRule{Namespace: "other", Resource: "pods", Verb: "get"}, podsGet, false},
+		{EffectiveRule{Namespace: "web", Resource: "pods", Verb: "get"}, EffectiveRule{Resource: "pods", Verb: "get"}, false},
+		{EffectiveRule{Resource: "pods", ResourceName: "api", Verb: "get"}, podsGet, false},
+		{EffectiveRule{Resource: "*/status", Verb: "get"}, EffectiveRule{Resource: "pods/status", Verb: "get"}, true},
+		{EffectiveRule{Resource: "*/status", Verb: "get"}, podsGet, false},
+		{EffectiveRule{NonResourceURL: "/healthz/*", Verb: "get"}, EffectiveRule{NonResourceURL: "/healthz/etcd", Verb: "get"}, true},
+		{EffectiveRule{APIGroup: "*", Resource: "*", Verb: "*"}, EffectiveRule{NonResourceURL: "/metrics", Verb: "get"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.covers(tt.other); got != tt.want {
+			t.Errorf("%+v covers %+v = %v, want %v", tt.rule, tt.other, got, tt.want)
+		}
+	}
+}
+
+func TestDiffRBAC(t *testing.T) {
+	alice := Identity{Kind: rbacv1.UserKind, Name: "alice"}
+	bob := Identity{Kind: rbacv1.UserKind, Name: "bob"}
+	diff := DiffRBAC(testRBACPolicy(), alice, bob)
+
+	var onlyAlice []string
+	for _, r := range diff.OnlyA {
+		onlyAlice = append(onlyAlice, r.target())
+	}
+	if want := []string{"/metrics", "secrets"}; !reflect.DeepEqual(onlyAlice, want) {
+		t.Errorf("OnlyA = %v, want %v", onlyAlice, want)
+	}
+	if diff.Shared != 8 {
+		t.Errorf("Shared = %d, want the 8 view rules", diff.Shared)
+	}
+	// bob's namespaced wildcard is extra, even though it includes everything view grants there.
+	if len(diff.OnlyB) != 1 || diff.OnlyB[0].Namespace != "web" || diff.OnlyB[0].Verb != "*" {
+		t.Errorf("OnlyB = %+v, want bob's wildcard in web", diff.OnlyB)
+	}
+
+	var b bytes.Buffer
+	PrintRBACDiff(&b, diff)
+	for _, want := range []string{
+		"Effective permissions of User alice vs User bob (8 shared)",
+		"cluster-wide: get secrets (via ClusterRoleBinding alice-secrets -> ClusterRole secret-reader)",
+		"namespace web: * *.* (via RoleBinding web/bob-admin -> ClusterRole admin)",
+		"ClusterRoleBinding stale -> ClusterRole deleted",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestGetRBACPolicy(t *testing.T) {
+	policy := testRBACPolicy()
+	clientset := fake.NewClientset(&policy.ClusterRoles[0], &policy.ClusterRoleBindings[0], &policy.Roles[0], &policy.RoleBindings[0])
+
+	got, err := GetRBACPolicy(context.Background(), clientset, ScanOptions{PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.ClusterRoles) != 1 || len(got.ClusterRoleBindings) != 1 || len(got.Roles) != 1 || len(got.RoleBindings) != 1 {
+		t.Errorf("GetRBACPolicy() = %+v, want one of each", got)
+	}
+}
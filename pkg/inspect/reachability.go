@@ -0,0 +1,314 @@
+package inspect
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Reachability outcomes of a single connection attempt.
+const (
+	ReachOpen    = "open"
+	ReachClosed  = "closed"
+	ReachTimeout = "timeout"
+	ReachError   = "error"
+	// ReachSkipped means the probe scope did not allow contacting the target.
+	ReachSkipped = "skipped"
+)
+
+// ProbeOptions controls active reachability checks against exposed endpoints.
+type ProbeOptions struct {
+	// Enabled turns on probing from the machine running kube-op.
+	Enabled bool
+	// Timeout bounds each connection attempt or HTTP request.
+	Timeout time.Duration
+	// Credentials are presented to matching hosts so protected endpoints aren't reported as closed.
+	Credentials *ProbeCredentialsConfig
+	// Acknowledged confirms the operator is authorized to probe the cluster's endpoints; probing
+	// refuses to start without it.
+	Acknowledged bool
+	// Concurrency caps the probes in flight at once, independently of the scan concurrency.
+	Concurrency int
+	// RatePerHost is the maximum number of probes per second sent to any one host.
+	RatePerHost float64
+	// Allow and Deny scope probing to hosts matching CIDRs or hostname globs. Deny wins; an
+	// empty allowlist allows every host not denied.
+	Allow, Deny []string
+}
+
+// ReachabilityResult is the outcome of probing one address and port of an exposed endpoint.
+type ReachabilityResult struct {
+	// Protocol is tcp for a plain connect, or http/https when a request was sent.
+	Protocol   string        `json:"protocol"`
+	Target     string        `json:"target"`
+	Status     string        `json:"status"`
+	HTTPStatus int           `json:"httpStatus,omitempty"`
+	Auth       string        `json:"auth,omitempty"`
+	Latency    time.Duration `json:"latency"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// String renders the result as a single human-readable line.
+func (r ReachabilityResult) String() string {
+	s := fmt.Sprintf("%s %s: %s", r.Protocol, r.Target, r.Status)
+	if r.HTTPStatus != 0 {
+		s += fmt.Sprintf(" (HTTP %d, %s)", r.HTTPStatus, r.Auth)
+	}
+	if r.Error != "" {
+		s += " - " + r.Error
+	}
+	return s + fmt.Sprintf(" [%s]", r.Latency.Round(time.Millisecond))
+}
+
+// probeRequest is one connection attempt derived from an exposed endpoint.
+type probeRequest struct {
+	endpoint int
+	protocol string
+	address  string
+	// host and path are only set for HTTP probes of Ingress rules.
+	host string
+	path string
+}
+
+// ProbeEndpoints actively checks every exposed endpoint from the machine running kube-op and
+// stores the results on the endpoints. NodePort services are probed on the first node with an
+// ExternalIP, since they're exposed on every node. Targets outside the probe scope are reported
+// as skipped without being contacted, and requests are capped and rate limited per host.
+func ProbeEndpoints(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, endpoints []ExposedEndpoint) error {
+	var nodeAddress string
+	if hasNodePorts(endpoints) {
+		nodes, err := listNodes(ctx, clientset, opts, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list nodes: %w", err)
+		}
+		nodeAddress = firstExternalIP(nodes)
+	}
+
+	requests := planProbes(endpoints, nodeAddress)
+	results := make([]ReachabilityResult, len(requests))
+	limiter := newHostLimiter(opts.Probe.RatePerHost)
+	var tasks []func()
+	for i, req := range requests {
+		ip, vhost := probeTarget(req)
+		if ok, reason := opts.Probe.Permits(ip, vhost); !ok {
+			results[i] = ReachabilityResult{Protocol: req.protocol, Target: probeTargetString(req), Status: ReachSkipped, Error: reason}
+			continue
+		}
+		tasks = append(tasks, func() {
+			if err := limiter.Wait(ctx, ip); err != nil {
+				results[i] = ReachabilityResult{Protocol: req.protocol, Target: probeTargetString(req), Status: ReachSkipped, Error: err.Error()}
+				return
+			}
+			results[i] = runProbeRequest(ctx, req, opts.Probe)
+		})
+	}
+	runConcurrently(opts.Probe.Concurrency, tasks...)
+
+	for i, req := range requests {
+		endpoints[req.endpoint].Reachability = append(endpoints[req.endpoint].Reachability, results[i])
+	}
+	return nil
+}
+
+// planProbes turns endpoints into connection attempts. Well-known web ports get an HTTP(S)
+// request so response codes and auth can be reported; everything else gets a TCP connect.
+func planProbes(endpoints []ExposedEndpoint, nodeAddress string) []probeRequest {
+	var requests []probeRequest
+	for i, e := range endpoints {
+		switch e.Type {
+		case ExposureLoadBalancer:
+			for _, addr := range e.Addresses {
+				for _, p := range e.Ports {
+					if p.Protocol != string(corev1.ProtocolTCP) {
+						continue
+					}
+					requests = append(requests, probeRequest{endpoint: i, protocol: protocolForPort(p.Port), address: net.JoinHostPort(addr, strconv.Itoa(int(p.Port)))})
+				}
+			}
+		case ExposureNodePort:
+			if nodeAddress == "" {
+				continue
+			}
+			for _, p := range e.Ports {
+				if p.Protocol != string(corev1.ProtocolTCP) || p.NodePort == 0 {
+					continue
+				}
+				requests = append(requests, probeRequest{endpoint: i, protocol: "tcp", address: net.JoinHostPort(nodeAddress, strconv.Itoa(int(p.NodePort)))})
+			}
+		case ExposureIngress:
+			protocol, port := "http", "80"
+			if e.TLS {
+				protocol, port = "https", "443"
+			}
+			host := e.Host
+			if host == "*" {
+				host = ""
+			}
+			for _, addr := range e.Addresses {
+				requests = append(requests, probeRequest{endpoint: i, protocol: protocol, address: net.JoinHostPort(addr, port), host: host, path: e.Path})
+			}
+		}
+	}
+	return requests
+}
+
+func protocolForPort(port int32) string {
+	switch port {
+	case 80, 8080:
+		return "http"
+	case 443, 8443:
+		return "https"
+	default:
+		return "tcp"
+	}
+}
+
+// probeTargetString describes what a probe request connects to.
+func probeTargetString(req probeRequest) string {
+	if req.host != "" {
+		return req.host + req.path + " via " + req.address
+	}
+	return req.address
+}
+
+// runProbeRequest performs a single TCP connect or HTTP(S) request.
+func runProbeRequest(ctx context.Context, req probeRequest, opts ProbeOptions) ReachabilityResult {
+	result := ReachabilityResult{Protocol: req.protocol, Target: probeTargetString(req)}
+	start := time.Now()
+	defer func() { result.Latency = time.Since(start) }()
+
+	if req.protocol == "tcp" {
+		dialer := &net.Dialer{Timeout: opts.Timeout}
+		conn, err := dialer.DialContext(ctx, "tcp", req.address)
+		if err != nil {
+			result.Status, result.Error = classifyDialError(err), err.Error()
+			return result
+		}
+		conn.Close()
+		result.Status = ReachOpen
+		return result
+	}
+
+	hostname, _, _ := net.SplitHostPort(req.address)
+	if req.host != "" {
+		hostname = req.host
+	}
+	cred := opts.Credentials.ForHost(hostname)
+	tlsConfig, err := cred.TLSConfig()
+	if err != nil {
+		result.Status, result.Error = ReachError, err.Error()
+		return result
+	}
+	tlsConfig.ServerName = hostname
+
+	// Dial the published address while sending the rule's Host header and SNI name.
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	client := &http.Client{
+		Timeout: opts.Timeout,
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, req.address)
+			},
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	defer client.CloseIdleConnections()
+
+	u := url.URL{Scheme: req.protocol, Host: req.address, Path: req.path}
+	if req.host != "" {
+		u.Host = req.host
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		result.Status, result.Error = ReachError, err.Error()
+		return result
+	}
+	if err := cred.Apply(httpReq); err != nil {
+		result.Status, result.Error = ReachError, err.Error()
+		return result
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		result.Status, result.Error = classifyDialError(err), err.Error()
+		return result
+	}
+	resp.Body.Close()
+
+	result.Status = ReachOpen
+	result.HTTPStatus = resp.StatusCode
+	result.Auth = ClassifyAuth(resp.StatusCode, cred.HasCredentials())
+	return result
+}
+
+// classifyDialError distinguishes refused connections from timeouts and other failures.
+func classifyDialError(err error) string {
+	var netErr net.Error
+	var tlsErr tls.RecordHeaderError
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ReachClosed
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ReachTimeout
+	case errors.As(err, &tlsErr):
+		// Something answered, just not with TLS.
+		return ReachOpen
+	default:
+		return ReachError
+	}
+}
+
+// CheckReachability raises a finding for every exposed HTTP endpoint that answered without
+// requiring authentication, i.e. one that is actually reachable and open from here.
+func CheckReachability(endpoints []ExposedEndpoint) []Finding {
+	var findings []Finding
+	for _, e := range endpoints {
+		for _, r := range e.Reachability {
+			if r.Status != ReachOpen || r.Auth != ProbeAuthOpen {
+				continue
+			}
+			findings = append(findings, Finding{
+				CheckID:   "endpoint-open-without-auth",
+				Severity:  SeverityMedium,
+				Kind:      e.Kind(),
+				Namespace: e.Namespace,
+				Name:      e.Name,
+				Message:   fmt.Sprintf("%s %s/%s answered %s %s with HTTP %d without authentication", e.Kind(), e.Namespace, e.Name, r.Protocol, r.Target, r.HTTPStatus),
+				keyFields: []string{r.Protocol, r.Target},
+			})
+		}
+	}
+	return findings
+}
+
+func hasNodePorts(endpoints []ExposedEndpoint) bool {
+	for _, e := range endpoints {
+		if e.Type == ExposureNodePort {
+			return true
+		}
+	}
+	return false
+}
+
+func firstExternalIP(nodes []corev1.Node) string {
+	for _, node := range nodes {
+		for _, addr := range node.Status.Addresses {
+			if addr.Type == corev1.NodeExternalIP {
+				return addr.Address
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,156 @@
+package inspect
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPlanProbes(t *testing.T) {
+	endpoints := []ExposedEndpoint{
+		{Type: ExposureLoadBalancer, Addresses: []string{"203.0.113.10"}, Ports: []ExposedPort{
+			{Port: 443, Protocol: "TCP"}, {Port: 5432, Protocol: "TCP"}, {Port: 53, Protocol: "UDP"},
+		}},
+		{Type: ExposureNodePort, Ports: []ExposedPort{{Port: 80, NodePort: 30080, Protocol: "TCP"}}},
+		{Type: ExposureIngress, Addresses: []string{"198.51.100.7"}, Host: "shop.example.com", Path: "/", TLS: true},
+	}
+
+	requests := planProbes(endpoints, "192.0.2.1")
+	want := []probeRequest{
+		{endpoint: 0, protocol: "https", address: "203.0.113.10:443"},
+		{endpoint: 0, protocol: "tcp", address: "203.0.113.10:5432"},
+		{endpoint: 1, protocol: "tcp", address: "192.0.2.1:30080"},
+		{endpoint: 2, protocol: "https", address: "198.51.100.7:443", host: "shop.example.com", path: "/"},
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("planProbes() returned %d requests, want %d: %+v", len(requests), len(want), requests)
+	}
+	for i := range want {
+		if requests[i] != want[i] {
+			t.Errorf("planProbes()[%d] = %+v, want %+v", i, requests[i], want[i])
+		}
+	}
+
+	if got := planProbes(endpoints[1:2], ""); len(got) != 0 {
+		t.Errorf("planProbes() without a node address returned %d NodePort requests, want 0", len(got))
+	}
+}
+
+func TestRunProbeRequest_HTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/admin" && r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "http://")
+	opts := ProbeOptions{Timeout: time.Second}
+
+	open := runProbeRequest(context.Background(), probeRequest{protocol: "http", address: address}, opts)
+	if open.Status != ReachOpen || open.HTTPStatus != http.StatusOK || open.Auth != ProbeAuthOpen {
+		t.Errorf("runProbeRequest(/) = %+v, want open, HTTP 200, auth open", open)
+	}
+
+	protected := runProbeRequest(context.Background(), probeRequest{protocol: "http", address: address, host: "127.0.0.1", path: "/admin"}, opts)
+	if protected.Status != ReachOpen || protected.Auth != ProbeAuthRequired {
+		t.Errorf("runProbeRequest(/admin) = %+v, want open with auth required", protected)
+	}
+
+	t.Setenv("PROBE_TOKEN", "tok")
+	opts.Credentials = &ProbeCredentialsConfig{Credentials: []ProbeCredential{{Host: "127.0.0.1", BearerEnv: "PROBE_TOKEN"}}}
+	refused := runProbeRequest(context.Background(), probeRequest{protocol: "http", address: address, host: "127.0.0.1", path: "/admin"}, opts)
+	if refused.Status != ReachError || !strings.Contains(refused.Error, "plain HTTP") {
+		t.Errorf("runProbeRequest(/admin) with credentials over HTTP = %+v, want an error", refused)
+	}
+
+	opts.Credentials.Credentials[0].Insecure = true
+	authed := runProbeRequest(context.Background(), probeRequest{protocol: "http", address: address, host: "127.0.0.1", path: "/admin"}, opts)
+	if authed.Auth != ProbeAuthAuthenticated {
+		t.Errorf("runProbeRequest(/admin) with insecure credentials = %+v, want authenticated", authed)
+	}
+}
+
+func TestRunProbeRequest_CredentialsNeedVerifiedServer(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+	sentHeaders := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), sent...)
+	}
+	address := strings.TrimPrefix(server.URL, "https://")
+	t.Setenv("PROBE_TOKEN", "tok")
+	opts := ProbeOptions{Timeout: time.Second, Credentials: &ProbeCredentialsConfig{Credentials: []ProbeCredential{{Host: "127.0.0.1", BearerEnv: "PROBE_TOKEN"}}}}
+
+	unverified := runProbeRequest(context.Background(), probeRequest{protocol: "https", address: address}, opts)
+	if unverified.Status != ReachError || len(sentHeaders()) != 0 {
+		t.Fatalf("runProbeRequest() to an unverified server = %+v, sent %q, want an error and no request", unverified, sentHeaders())
+	}
+
+	anonymous := runProbeRequest(context.Background(), probeRequest{protocol: "https", address: address}, ProbeOptions{Timeout: time.Second})
+	if anonymous.Status != ReachOpen || anonymous.Auth != ProbeAuthOpen {
+		t.Errorf("runProbeRequest() without credentials = %+v, want open without verifying the server", anonymous)
+	}
+
+	ca := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts.Credentials.Credentials[0].CAFile = ca
+	verified := runProbeRequest(context.Background(), probeRequest{protocol: "https", address: address}, opts)
+	if got, want := sentHeaders(), []string{"", "Bearer tok"}; verified.Auth != ProbeAuthAuthenticated || !reflect.DeepEqual(got, want) {
+		t.Errorf("runProbeRequest() to a server verified with caFile = %+v, sent %q, want %q", verified, got, want)
+	}
+}
+
+func TestRunProbeRequest_TCPClosed(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	address := listener.Addr().String()
+
+	open := runProbeRequest(context.Background(), probeRequest{protocol: "tcp", address: address}, ProbeOptions{Timeout: time.Second})
+	if open.Status != ReachOpen {
+		t.Errorf("runProbeRequest() on listening port = %+v, want open", open)
+	}
+
+	listener.Close()
+	closed := runProbeRequest(context.Background(), probeRequest{protocol: "tcp", address: address}, ProbeOptions{Timeout: time.Second})
+	if closed.Status != ReachClosed {
+		t.Errorf("runProbeRequest() on closed port = %+v, want closed", closed)
+	}
+}
+
+func TestCheckReachability(t *testing.T) {
+	endpoints := []ExposedEndpoint{{
+		Type: ExposureIngress, Namespace: "web", Name: "site",
+		Reachability: []ReachabilityResult{
+			{Protocol: "https", Target: "a", Status: ReachOpen, HTTPStatus: 200, Auth: ProbeAuthOpen},
+			{Protocol: "https", Target: "b", Status: ReachOpen, HTTPStatus: 401, Auth: ProbeAuthRequired},
+			{Protocol: "tcp", Target: "c", Status: ReachTimeout},
+		},
+	}}
+
+	findings := CheckReachability(endpoints)
+	if len(findings) != 1 || findings[0].Kind != "Ingress" {
+		t.Errorf("CheckReachability() = %+v, want one Ingress finding for the open endpoint", findings)
+	}
+}
@@ -0,0 +1,150 @@
+package inspect
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Managed Kubernetes platforms with a published release and support calendar.
+const (
+	PlatformEKS = "EKS"
+	PlatformGKE = "GKE"
+	PlatformAKS = "AKS"
+)
+
+// Support tiers a managed minor version moves through.
+const (
+	SupportTierStandard  = "standard"
+	SupportTierExtended  = "extended"
+	SupportTierEndOfLife = "end-of-life"
+)
+
+// forcedUpgradeWarningWindow is how far ahead of a forced upgrade kube-op starts warning about it.
+const forcedUpgradeWarningWindow = 90 * 24 * time.Hour
+
+//go:embed release_schedule.json
+var releaseScheduleJSON []byte
+
+// ReleaseSchedule is the support calendar of one minor version on one managed platform.
+type ReleaseSchedule struct {
+	Platform           string `json:"platform"`
+	Minor              string `json:"minor"`
+	StandardSupportEnd string `json:"standardSupportEnd"`
+	EndOfLife          string `json:"endOfLife"`
+}
+
+type releaseScheduleData struct {
+	Updated   string            `json:"updated"`
+	Schedules []ReleaseSchedule `json:"schedules"`
+}
+
+// ReleaseInfo describes where a managed cluster's running minor sits in its platform's support calendar.
+type ReleaseInfo struct {
+	Platform           string    `json:"platform"`
+	Minor              string    `json:"minor"`
+	SupportTier        string    `json:"supportTier"`
+	StandardSupportEnd time.Time `json:"standardSupportEnd"`
+	EndOfLife          time.Time `json:"endOfLife"`
+	// DataUpdated is the date the embedded release calendar was last refreshed.
+	DataUpdated string `json:"dataUpdated"`
+}
+
+// DaysUntilForcedUpgrade returns the number of whole days until the platform auto-upgrades the cluster.
+// Clusters not enrolled in extended support are upgraded at the end of standard support instead.
+func (r ReleaseInfo) DaysUntilForcedUpgrade(now time.Time) int {
+	return int(r.EndOfLife.Sub(now).Hours() / 24)
+}
+
+var minorPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+// DetectManagedPlatform identifies EKS, GKE, or AKS from the API server version string
+// and well-known node labels and provider IDs. It returns "" for self-managed clusters.
+func DetectManagedPlatform(gitVersion string, nodes []corev1.Node) string {
+	switch {
+	case strings.Contains(gitVersion, "-eks-"):
+		return PlatformEKS
+	case strings.Contains(gitVersion, "-gke."):
+		return PlatformGKE
+	}
+
+	for _, node := range nodes {
+		for label := range node.Labels {
+			switch {
+			case strings.HasPrefix(label, "eks.amazonaws.com/"):
+				return PlatformEKS
+			case strings.HasPrefix(label, "cloud.google.com/gke-"):
+				return PlatformGKE
+			case strings.HasPrefix(label, "kubernetes.azure.com/"):
+				return PlatformAKS
+			}
+		}
+	}
+	return ""
+}
+
+// EvaluateReleaseSupport looks up the running minor of gitVersion in the embedded release calendar.
+func EvaluateReleaseSupport(platform, gitVersion string, now time.Time) (ReleaseInfo, error) {
+	m := minorPattern.FindStringSubmatch(gitVersion)
+	if m == nil {
+		return ReleaseInfo{}, fmt.Errorf("could not parse minor version from %q", gitVersion)
+	}
+	minor := m[1] + "." + m[2]
+
+	var data releaseScheduleData
+	if err := json.Unmarshal(releaseScheduleJSON, &data); err != nil {
+		return ReleaseInfo{}, fmt.Errorf("failed to parse embedded release schedule: %w", err)
+	}
+
+	for _, s := range data.Schedules {
+		if s.Platform != platform || s.Minor != minor {
+			continue
+		}
+		standardEnd, err := time.Parse(time.DateOnly, s.StandardSupportEnd)
+		if err != nil {
+			return ReleaseInfo{}, fmt.Errorf("invalid standard support end for %s %s: %w", platform, minor, err)
+		}
+		endOfLife, err := time.Parse(time.DateOnly, s.EndOfLife)
+		if err != nil {
+			return ReleaseInfo{}, fmt.Errorf("invalid end of life for %s %s: %w", platform, minor, err)
+		}
+
+		tier := SupportTierStandard
+		switch {
+		case !now.Before(endOfLife):
+			tier = SupportTierEndOfLife
+		case !now.Before(standardEnd):
+			tier = SupportTierExtended
+		}
+
+		return ReleaseInfo{
+			Platform:           platform,
+			Minor:              minor,
+			SupportTier:        tier,
+			StandardSupportEnd: standardEnd,
+			EndOfLife:          endOfLife,
+			DataUpdated:        data.Updated,
+		}, nil
+	}
+
+	return ReleaseInfo{}, fmt.Errorf("%s %s is not in the embedded release schedule (last updated %s)", platform, minor, data.Updated)
+}
+
+// GetReleaseInfo looks up where the running minor of a managed platform sits in the platform's
+// support calendar. It returns nil for self-managed clusters.
+func GetReleaseInfo(platform *PlatformInfo) (*ReleaseInfo, error) {
+	if !platform.Managed {
+		return nil, nil
+	}
+
+	info, err := EvaluateReleaseSupport(platform.Name, platform.Version, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
@@ -0,0 +1,71 @@
+package inspect
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDetectManagedPlatform(t *testing.T) {
+	nodeWithLabel := func(label string) []corev1.Node {
+		return []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{label: "x"}}}}
+	}
+
+	tests := []struct {
+		name       string
+		gitVersion string
+		nodes      []corev1.Node
+		want       string
+	}{
+		{"eks version suffix", "v1.30.4-eks-a737599", nil, PlatformEKS},
+		{"gke version suffix", "v1.31.1-gke.1146000", nil, PlatformGKE},
+		{"aks node label", "v1.30.3", nodeWithLabel("kubernetes.azure.com/cluster"), PlatformAKS},
+		{"eks node label", "v1.30.3", nodeWithLabel("eks.amazonaws.com/nodegroup"), PlatformEKS},
+		{"self-managed", "v1.30.3", nodeWithLabel("kubernetes.io/hostname"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectManagedPlatform(tt.gitVersion, tt.nodes); got != tt.want {
+				t.Errorf("DetectManagedPlatform(%q) = %q, want %q", tt.gitVersion, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateReleaseSupport(t *testing.T) {
+	tests := []struct {
+		name string
+		now  string
+		want string
+	}{
+		{"before standard end", "2025-01-01", SupportTierStandard},
+		{"in extended support", "2025-06-01", SupportTierExtended},
+		{"past end of life", "2026-04-01", SupportTierEndOfLife},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now, _ := time.Parse(time.DateOnly, tt.now)
+			info, err := EvaluateReleaseSupport(PlatformEKS, "v1.29.10-eks-7f9249a", now)
+			if err != nil {
+				t.Fatalf("EvaluateReleaseSupport() returned error = %v, want nil", err)
+			}
+			if info.Minor != "1.29" {
+				t.Errorf("EvaluateReleaseSupport() minor = %q, want %q", info.Minor, "1.29")
+			}
+			if info.SupportTier != tt.want {
+				t.Errorf("EvaluateReleaseSupport() tier = %q, want %q", info.SupportTier, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateReleaseSupport_UnknownMinor(t *testing.T) {
+	if _, err := EvaluateReleaseSupport(PlatformGKE, "v1.12.0-gke.1", time.Now()); err == nil {
+		t.Errorf("EvaluateReleaseSupport() for unknown minor returned error = nil, want non-nil")
+	}
+	if _, err := EvaluateReleaseSupport(PlatformGKE, "garbage", time.Now()); err == nil {
+		t.Errorf("EvaluateReleaseSupport() for unparsable version returned error = nil, want non-nil")
+	}
+}
@@ -0,0 +1,312 @@
+package inspect
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolURI      = "https://github.com/nazufel/kube-op"
+)
+
+// SecurityRule describes a security check for SARIF consumers such as GitHub code scanning.
+type SecurityRule struct {
+	CheckID string
+	// Name is the rule's PascalCase name; Description says what a finding means, and Help what to do.
+	Name        string
+	Description string
+	Help        string
+	// Severity is the rule's typical severity, which code scanning ranks alerts by; each
+	// result still carries the severity of its finding.
+	Severity Severity
+	// Category is exposure, rbac, workload, admission, tls, or cis.
+	Category string
+}
+
+// securityRules are the checks written to SARIF output; the other checks are about
+// reliability and cost rather than security.
+var securityRules = []SecurityRule{
+	{
+		CheckID: "public-loadbalancer", Name: "PublicLoadBalancer", Category: "exposure", Severity: SeverityMedium,
+		Description: "A LoadBalancer Service has an internet-facing address.",
+		Help:        "Make the load balancer internal with your provider's annotation, or restrict it with loadBalancerSourceRanges, unless it is meant to be public.",
+	},
+	{
+		CheckID: "endpoint-open-without-auth", Name: "EndpointOpenWithoutAuth", Category: "exposure", Severity: SeverityMedium,
+		Description: "An exposed endpoint answered a probe without asking for credentials.",
+		Help:        "Put authentication in front of the endpoint, or stop exposing it outside the cluster.",
+	},
+	{
+		CheckID: "dns-collision", Name: "DNSCollision", Category: "exposure", Severity: SeverityHigh,
+		Description: "A hostname the cluster claims also resolves to another environment.",
+		Help:        "Remove the hostname from the Ingress, Gateway, or Service that should not serve it, or fix the DNS record.",
+	},
+	{
+		CheckID: "dns-not-delegated", Name: "DNSNotDelegated", Category: "exposure", Severity: SeverityMedium,
+		Description: "A hostname the cluster claims resolves somewhere else, so another party may serve its traffic.",
+		Help:        "Point the DNS record at the cluster's load balancer, or drop the hostname from the cluster.",
+	},
+	{
+		CheckID: "legacy-sa-token", Name: "LegacyServiceAccountToken", Category: "rbac", Severity: SeverityMedium,
+		Description: "A long-lived ServiceAccount token Secret grants the account's permissions until it is deleted.",
+		Help:        "Delete the Secret and have workloads use projected, expiring tokens instead.",
+	},
+	{
+		CheckID: "privileged-node-shell", Name: "PrivilegedNodeShell", Category: "workload", Severity: SeverityHigh,
+		Description: "A privileged pod with the node's namespaces, such as an nsenter shell, is still running.",
+		Help:        "Delete the pod once the debugging session is over.",
+	},
+	{
+		CheckID: "leftover-node-debugger", Name: "LeftoverNodeDebugger", Category: "workload", Severity: SeverityHigh,
+		Description: "A kubectl debug node pod with the host filesystem mounted is still running.",
+		Help:        "Delete the pod once the debugging session is over.",
+	},
+	{
+		CheckID: "ephemeral-debug-container", Name: "EphemeralDebugContainer", Category: "workload", Severity: SeverityMedium,
+		Description: "An ephemeral debug container has been running in a pod for longer than expected.",
+		Help:        "Restart the pod to remove the ephemeral container once debugging is done.",
+	},
+	{
+		CheckID: "webhook-fail-closed-broad", Name: "WebhookFailClosedBroad", Category: "admission", Severity: SeverityMedium,
+		Description: "A fail-closed admission webhook intercepts every namespace, including kube-system, so its outage blocks API writes cluster-wide.",
+		Help:        "Exclude kube-system and the webhook's own namespace with a namespaceSelector, or narrow its rules to the resources it needs.",
+	},
+	{
+		CheckID: "webhook-backend-unavailable", Name: "WebhookBackendUnavailable", Category: "admission", Severity: SeverityCritical,
+		Description: "An admission webhook points at a service that is missing or has no ready endpoints, so the requests it matches are rejected or skip its policy.",
+		Help:        "Restore the webhook's backend, or delete the webhook configuration if it is no longer used.",
+	},
+	{
+		CheckID: "cert-expiring", Name: "CertificateExpiring", Category: "tls", Severity: SeverityHigh,
+		Description: "A control-plane or kubelet certificate expires soon.",
+		Help:        "Rotate the certificate before it expires.",
+	},
+	{
+		CheckID: "cis-control-failed", Name: "CISControlFailed", Category: "cis", Severity: SeverityHigh,
+		Description: "The API server fails a CIS Kubernetes Benchmark control.",
+		Help:        "Change the kube-apiserver flag the control names; the finding's message gives the current value and the control number.",
+	},
+	{
+		CheckID: "cis-control-warning", Name: "CISControlWarning", Category: "cis", Severity: SeverityLow,
+		Description: "A CIS Kubernetes Benchmark control needs review, such as RBAC bindings that may grant more than required.",
+		Help:        "Review the objects the finding lists against the control and remove what is not required.",
+	},
+}
+
+// SecurityRules returns the checks included in SARIF output.
+func SecurityRules() []SecurityRule {
+	return append([]SecurityRule(nil), securityRules...)
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool              sarifTool         `json:"tool"`
+	AutomationDetails *sarifAutomation  `json:"automationDetails,omitempty"`
+	Results           []sarifResult     `json:"results"`
+	Properties        map[string]any    `json:"properties,omitempty"`
+	Invocations       []sarifInvocation `json:"invocations,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	Name                 string            `json:"name"`
+	ShortDescription     sarifMessage      `json:"shortDescription"`
+	Help                 sarifMessage      `json:"help"`
+	DefaultConfiguration sarifRuleDefaults `json:"defaultConfiguration"`
+	Properties           sarifRuleProps    `json:"properties"`
+}
+
+type sarifRuleDefaults struct {
+	Level string `json:"level"`
+}
+
+type sarifRuleProps struct {
+	Tags []string `json:"tags"`
+	// SecuritySeverity is the CVSS-like score GitHub code scanning ranks alerts by.
+	SecuritySeverity string `json:"security-severity"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifAutomation struct {
+	ID string `json:"id"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful bool                    `json:"executionSuccessful"`
+	Notifications       []sarifToolNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifToolNotification struct {
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]any    `json:"properties"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifLevel maps a severity to a SARIF result level.
+func sarifLevel(s Severity) string {
+	switch {
+	case s.AtLeast(SeverityHigh):
+		return "error"
+	case s.AtLeast(SeverityMedium):
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// securitySeverity maps a severity to the score GitHub code scanning turns back into
+// critical, high, medium, or low.
+func securitySeverity(s Severity) string {
+	switch s {
+	case SeverityCritical:
+		return "9.5"
+	case SeverityHigh:
+		return "8.0"
+	case SeverityMedium:
+		return "5.5"
+	case SeverityLow:
+		return "3.0"
+	default:
+		return "0.0"
+	}
+}
+
+// resourcePath locates a finding's object as namespace/kind/name, or kind/name for
+// cluster-scoped objects, which code scanning shows as the alert's file.
+func resourcePath(f Finding) string {
+	if f.Namespace == "" {
+		return f.Kind + "/" + f.Name
+	}
+	return f.Namespace + "/" + f.Kind + "/" + f.Name
+}
+
+// WriteReportSARIF writes the report's security findings as a SARIF 2.1.0 log with one run.
+// Every security rule is listed, whether or not it raised findings, so consumers can close
+// alerts that are gone. toolVersion is the kube-op release that ran the scan.
+func WriteReportSARIF(w io.Writer, report *Report, toolVersion string) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name: "kube-op", Version: toolVersion, InformationURI: toolURI, Rules: []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	ruleIndex := map[string]int{}
+	for i, r := range securityRules {
+		ruleIndex[r.CheckID] = i
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   r.CheckID,
+			Name:                 r.Name,
+			ShortDescription:     sarifMessage{Text: r.Description},
+			Help:                 sarifMessage{Text: r.Help},
+			DefaultConfiguration: sarifRuleDefaults{Level: sarifLevel(r.Severity)},
+			Properties:           sarifRuleProps{Tags: []string{"security", r.Category}, SecuritySeverity: securitySeverity(r.Severity)},
+		})
+	}
+
+	if c := report.Cluster; c != nil {
+		// The automation ID keeps the alerts of different clusters apart in code scanning; the
+		// cluster ID is preferred because names are often reused.
+		id := c.ID
+		if id == "" {
+			id = c.Name
+		}
+		if id != "" {
+			run.AutomationDetails = &sarifAutomation{ID: "kube-op/" + id + "/"}
+		}
+		run.Properties = map[string]any{"cluster": c}
+	}
+
+	for _, f := range report.Findings {
+		index, ok := ruleIndex[f.CheckID]
+		if !ok {
+			continue
+		}
+		path := resourcePath(f)
+		properties := map[string]any{"severity": f.Severity, "kind": f.Kind, "name": f.Name}
+		if f.Namespace != "" {
+			properties["namespace"] = f.Namespace
+		}
+		if f.Owner != nil {
+			properties["owner"] = f.Owner.String()
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    f.CheckID,
+			RuleIndex: index,
+			Level:     sarifLevel(f.Severity),
+			Message:   sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: path}},
+				LogicalLocations: []sarifLogicalLocation{{Name: f.Name, FullyQualifiedName: path, Kind: "resource"}},
+			}},
+			PartialFingerprints: map[string]string{"kubeOpFingerprint/v1": f.Fingerprint},
+			Properties:          properties,
+		})
+	}
+
+	// A partial scan is reported as unsuccessful, so missing results are not read as fixed.
+	invocation := sarifInvocation{ExecutionSuccessful: !report.Interrupted && len(report.Errors) == 0}
+	for section, msg := range report.Errors {
+		invocation.Notifications = append(invocation.Notifications, sarifToolNotification{
+			Level: "error", Message: sarifMessage{Text: section + ": " + msg},
+		})
+	}
+	sort.Slice(invocation.Notifications, func(i, j int) bool {
+		return invocation.Notifications[i].Message.Text < invocation.Notifications[j].Message.Text
+	})
+	run.Invocations = []sarifInvocation{invocation}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
@@ -0,0 +1,177 @@
+package inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWriteReportSARIF(t *testing.T) {
+	report := &Report{
+		Cluster: &ClusterIdentity{ID: "0b5c", Name: "prod"},
+		Findings: []Finding{
+			{CheckID: "public-loadbalancer", Severity: SeverityHigh, Kind: "Service", Namespace: "web", Name: "frontend", Message: "public"},
+			{CheckID: "pod-crash-looping", Severity: SeverityHigh, Kind: "Pod", Namespace: "web", Name: "api-1"},
+			{CheckID: "cert-expiring", Severity: SeverityLow, Kind: "Node", Name: "cp-1", Message: "expires soon"},
+		},
+		Errors: map[string]string{sectionDNS: "forbidden"},
+	}
+	report.Findings = FinalizeFindings(report.Findings)
+
+	var b bytes.Buffer
+	if err := WriteReportSARIF(&b, report, "v1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Version string `json:"version"`
+					Rules   []struct {
+						ID         string `json:"id"`
+						Properties struct {
+							SecuritySeverity string `json:"security-severity"`
+						} `json:"properties"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			AutomationDetails struct {
+				ID string `json:"id"`
+			} `json:"automationDetails"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+				PartialFingerprints map[string]string `json:"partialFingerprints"`
+			} `json:"results"`
+			Invocations []struct {
+				ExecutionSuccessful bool `json:"executionSuccessful"`
+			} `json:"invocations"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &log); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("version = %q with %d runs, want 2.1.0 with 1", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Version != "v1.2.3" || len(run.Tool.Driver.Rules) != len(SecurityRules()) {
+		t.Errorf("driver version %q with %d rules, want v1.2.3 with every security rule", run.Tool.Driver.Version, len(run.Tool.Driver.Rules))
+	}
+	if run.AutomationDetails.ID != "kube-op/0b5c/" {
+		t.Errorf("automationDetails.id = %q, want kube-op/0b5c/", run.AutomationDetails.ID)
+	}
+	if len(run.Invocations) != 1 || run.Invocations[0].ExecutionSuccessful {
+		t.Errorf("invocations = %+v, want one unsuccessful invocation for the failed section", run.Invocations)
+	}
+
+	want := []struct{ rule, level, uri string }{
+		{"public-loadbalancer", "error", "web/Service/frontend"},
+		{"cert-expiring", "note", "Node/cp-1"},
+	}
+	if len(run.Results) != len(want) {
+		t.Fatalf("got %d results, want %d (non-security findings left out)", len(run.Results), len(want))
+	}
+	for i, w := range want {
+		r := run.Results[i]
+		if r.RuleID != w.rule || r.Level != w.level || r.Locations[0].PhysicalLocation.ArtifactLocation.URI != w.uri {
+			t.Errorf("result %d = %s %s at %s, want %s %s at %s", i, r.RuleID, r.Level, r.Locations[0].PhysicalLocation.ArtifactLocation.URI, w.rule, w.level, w.uri)
+		}
+		if run.Tool.Driver.Rules[r.RuleIndex].ID != r.RuleID {
+			t.Errorf("result %d ruleIndex %d points at %s", i, r.RuleIndex, run.Tool.Driver.Rules[r.RuleIndex].ID)
+		}
+		if r.PartialFingerprints["kubeOpFingerprint/v1"] != report.Findings[2*i].Fingerprint {
+			t.Errorf("result %d fingerprint = %v, want the finding's", i, r.PartialFingerprints)
+		}
+	}
+	if got := run.Tool.Driver.Rules[0].Properties.SecuritySeverity; got != "5.5" {
+		t.Errorf("public-loadbalancer security-severity = %q, want 5.5", got)
+	}
+}
+
+// nonSecurityChecks are the checks deliberately left out of SARIF output because they are
+// about reliability, capacity, or cost. A new check must be added here or to securityRules.
+var nonSecurityChecks = []string{
+	"backup-paused", "backup-stale", "cronjob-history-limits-unset", "custom-resource-not-ready",
+	"deployment-default-revision-history", "dns-missing", "endpoint-appeared",
+	"etcd-alarm", "etcd-backup-missing", "etcd-db-near-quota", "etcd-fragmented", "etcd-member-errors",
+	"etcd-member-unreachable", "etcd-no-leader", "exposure-no-ready-backends", "helm-chart-outdated",
+	"helm-release-failed", "helm-release-pending", "hpa-at-max", "hpa-missing-metrics", "hpa-target-missing",
+	"ingress-backend-missing", "ingress-class-missing", "ingress-class-multiple-defaults", "ingress-class-unset",
+	"job-no-ttl", "namespace-without-priority", "namespace-without-quota", "no-autoscaling-or-requests",
+	"node-not-ready", "node-pool-drift", "pdb-blocks-drain", "pod-not-starting", "pod-preemption-risk",
+	"pod-unschedulable", "priority-default-beside-critical", "proxy-missing-ca-bundle", "quota-blocks-workload",
+	"quota-exhausted", "quota-near-limit", "replicas-not-spread", "rollout-stuck", "single-replica-workload",
+	"snapshot-class-missing", "stateful-workload-unprotected", "webhook-long-timeout", "workload-no-pdb",
+	"workload-unavailable",
+}
+
+// checkIDPattern matches the ways the package sets a finding's check ID: CheckID fields and
+// assignments, and the first argument of the newFinding and add helpers.
+var checkIDPattern = regexp.MustCompile(`(?:CheckID(?:, \w+\.Severity)?\s*(?::|=)\s*|\b(?:newFinding|add)\()"([a-z0-9]+(?:-[a-z0-9]+)+)"`)
+
+// raisedCheckIDs returns the check IDs the package's source raises.
+func raisedCheckIDs(t *testing.T) map[string]bool {
+	t.Helper()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, file := range files {
+		// The rules themselves would otherwise count as checks the package raises.
+		if strings.HasSuffix(file, "_test.go") || file == "sarif.go" {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range checkIDPattern.FindAllStringSubmatch(string(data), -1) {
+			found[m[1]] = true
+		}
+	}
+	return found
+}
+
+func TestSecurityRulesCoverChecks(t *testing.T) {
+	found := raisedCheckIDs(t)
+	known := map[string]bool{}
+	for _, r := range securityRules {
+		if !found[r.CheckID] {
+			t.Errorf("security rule %s is not a check the package raises", r.CheckID)
+		}
+		known[r.CheckID] = true
+	}
+	for _, id := range nonSecurityChecks {
+		if known[id] {
+			t.Errorf("check %s is both a security rule and in nonSecurityChecks", id)
+		}
+		known[id] = true
+	}
+	var missing []string
+	for id := range found {
+		if !known[id] {
+			missing = append(missing, id)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("checks %v are in neither securityRules nor nonSecurityChecks; add a SARIF rule for each security check", missing)
+	}
+}
@@ -0,0 +1,421 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// nodeGroupLabels are the labels managed platforms and autoscalers put the node group name in.
+var nodeGroupLabels = []string{
+	"eks.amazonaws.com/nodegroup",
+	"alpha.eksctl.io/nodegroup-name",
+	"cloud.google.com/gke-nodepool",
+	"kubernetes.azure.com/agentpool",
+	"karpenter.sh/nodepool",
+	"node.kubernetes.io/nodegroup",
+}
+
+// ScaleDownReport is the outcome of simulating the removal of a group of nodes.
+type ScaleDownReport struct {
+	NodeGroup string   `json:"nodeGroup"`
+	Nodes     []string `json:"nodes"`
+	// Evicted is the number of pods that would have to move; DaemonSet and static pods are not counted.
+	Evicted int `json:"evicted"`
+	// Unplaceable are the pods that fit on none of the remaining nodes.
+	Unplaceable []UnplaceablePod `json:"unplaceable,omitempty"`
+	// BlockedBudgets are the PDBs that allow fewer disruptions than the pods they cover that would be evicted.
+	BlockedBudgets []BudgetImpact `json:"blockedBudgets,omitempty"`
+	Before         Headroom       `json:"before"`
+	After          Headroom       `json:"after"`
+}
+
+// UnplaceablePod is a pod that could not be rescheduled, and why.
+type UnplaceablePod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+	Owner     *Owner `json:"owner,omitempty"`
+}
+
+// BudgetImpact compares what a PDB allows with what the scale-down would evict.
+type BudgetImpact struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Evicting  int32  `json:"evicting"`
+	Allowed   int32  `json:"allowed"`
+}
+
+// Headroom is the unrequested capacity of a set of schedulable nodes.
+type Headroom struct {
+	Nodes                  int   `json:"nodes"`
+	CPUAllocatableMillis   int64 `json:"cpuAllocatableMillis"`
+	CPURequestedMillis     int64 `json:"cpuRequestedMillis"`
+	MemoryAllocatableBytes int64 `json:"memoryAllocatableBytes"`
+	MemoryRequestedBytes   int64 `json:"memoryRequestedBytes"`
+}
+
+func (h Headroom) String() string {
+	return fmt.Sprintf("%d node(s), CPU %dm/%dm requested (%s), memory %s/%s requested (%s)",
+		h.Nodes, h.CPURequestedMillis, h.CPUAllocatableMillis, percent(h.CPURequestedMillis, h.CPUAllocatableMillis),
+		formatBytes(h.MemoryRequestedBytes), formatBytes(h.MemoryAllocatableBytes), percent(h.MemoryRequestedBytes, h.MemoryAllocatableBytes))
+}
+
+// simNode tracks the remaining capacity of a node during the simulation.
+type simNode struct {
+	node                *corev1.Node
+	cpuFree, memoryFree int64
+	podsFree            int64
+	pods                []*corev1.Pod
+}
+
+// InNodeGroup reports whether node belongs to the named group by any of the well-known node group labels.
+func InNodeGroup(node corev1.Node, group string) bool {
+	for _, label := range nodeGroupLabels {
+		if node.Labels[label] == group {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckScaleDown lists the cluster's nodes, pods, and PDBs and simulates removing the selected nodes.
+func CheckScaleDown(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, remove func(corev1.Node) bool) (*ScaleDownReport, error) {
+	nodes, err := listNodes(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	pods, err := listPods(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	pdbs, err := listPodDisruptionBudgets(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list poddisruptionbudgets: %w", err)
+	}
+	return SimulateScaleDown(nodes, pods, pdbs, remove), nil
+}
+
+// SimulateScaleDown removes the nodes for which remove returns true and tries to place every pod
+// they ran on the remaining schedulable nodes, largest CPU request first. A pod fits a node when
+// the node has room for its requests and pod count, matches its node selector and required node
+// affinity, carries no taint it doesn't tolerate, and runs no pod its required hostname
+// anti-affinity excludes. Pods without a controller are reported as unplaceable since nothing
+// would recreate them.
+func SimulateScaleDown(nodes []corev1.Node, pods []corev1.Pod, pdbs []policyv1.PodDisruptionBudget, remove func(corev1.Node) bool) *ScaleDownReport {
+	report := &ScaleDownReport{}
+
+	removed := map[string]bool{}
+	var remaining []*simNode
+	byName := map[string]*simNode{}
+	for i, node := range nodes {
+		if remove(node) {
+			removed[node.Name] = true
+			report.Nodes = append(report.Nodes, node.Name)
+		}
+		if node.Spec.Unschedulable || !nodeReady(node) {
+			continue
+		}
+		n := &simNode{
+			node:       &nodes[i],
+			cpuFree:    node.Status.Allocatable.Cpu().MilliValue(),
+			memoryFree: node.Status.Allocatable.Memory().Value(),
+			podsFree:   node.Status.Allocatable.Pods().Value(),
+		}
+		byName[node.Name] = n
+		if !removed[node.Name] {
+			remaining = append(remaining, n)
+		}
+	}
+
+	var evicted []*corev1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if n, ok := byName[pod.Spec.NodeName]; ok && !removed[pod.Spec.NodeName] {
+			cpu, memory := podRequests(*pod)
+			n.cpuFree -= cpu
+			n.memoryFree -= memory
+			n.podsFree--
+			n.pods = append(n.pods, pod)
+		}
+		if removed[pod.Spec.NodeName] && !isDaemonOrStaticPod(*pod) {
+			evicted = append(evicted, pod)
+		}
+	}
+	report.Evicted = len(evicted)
+	report.Before = headroom(nodes, pods, func(n corev1.Node) bool { return byName[n.Name] != nil })
+
+	sort.SliceStable(evicted, func(i, j int) bool {
+		ci, _ := podRequests(*evicted[i])
+		cj, _ := podRequests(*evicted[j])
+		return ci > cj
+	})
+	for _, pod := range evicted {
+		if metav1.GetControllerOf(pod) == nil {
+			report.Unplaceable = append(report.Unplaceable, UnplaceablePod{Namespace: pod.Namespace, Name: pod.Name, Reason: "not managed by a controller, so it would not be recreated"})
+			continue
+		}
+		cpu, memory := podRequests(*pod)
+		var placed bool
+		reason := "no schedulable nodes remain"
+		for _, n := range remaining {
+			why := fits(pod, n, cpu, memory)
+			if why == "" {
+				n.cpuFree -= cpu
+				n.memoryFree -= memory
+				n.podsFree--
+				n.pods = append(n.pods, pod)
+				placed = true
+				break
+			}
+			reason = why
+		}
+		if !placed {
+			report.Unplaceable = append(report.Unplaceable, UnplaceablePod{Namespace: pod.Namespace, Name: pod.Name, Reason: fmt.Sprintf("fits on none of %d remaining node(s) (last: %s)", len(remaining), reason)})
+		}
+	}
+
+	for _, pdb := range pdbs {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || pdb.Spec.Selector == nil {
+			continue
+		}
+		var evicting int32
+		for _, pod := range evicted {
+			if pod.Namespace == pdb.Namespace && selector.Matches(labels.Set(pod.Labels)) {
+				evicting++
+			}
+		}
+		if evicting > pdb.Status.DisruptionsAllowed {
+			report.BlockedBudgets = append(report.BlockedBudgets, BudgetImpact{pdb.Namespace, pdb.Name, evicting, pdb.Status.DisruptionsAllowed})
+		}
+	}
+
+	var after Headroom
+	for _, n := range remaining {
+		after.Nodes++
+		after.CPUAllocatableMillis += n.node.Status.Allocatable.Cpu().MilliValue()
+		after.MemoryAllocatableBytes += n.node.Status.Allocatable.Memory().Value()
+		after.CPURequestedMillis += n.node.Status.Allocatable.Cpu().MilliValue() - n.cpuFree
+		after.MemoryRequestedBytes += n.node.Status.Allocatable.Memory().Value() - n.memoryFree
+	}
+	report.After = after
+	return report
+}
+
+// fits returns why pod can't be placed on n, or "" if it can.
+func fits(pod *corev1.Pod, n *simNode, cpu, memory int64) string {
+	switch {
+	case n.podsFree < 1:
+		return fmt.Sprintf("%s is at its pod limit", n.node.Name)
+	case cpu > n.cpuFree:
+		return fmt.Sprintf("%s has %dm CPU free, needs %dm", n.node.Name, n.cpuFree, cpu)
+	case memory > n.memoryFree:
+		return fmt.Sprintf("%s has %s memory free, needs %s", n.node.Name, formatBytes(n.memoryFree), formatBytes(memory))
+	}
+	if !labels.SelectorFromSet(pod.Spec.NodeSelector).Matches(labels.Set(n.node.Labels)) {
+		return fmt.Sprintf("%s does not match the node selector", n.node.Name)
+	}
+	if !matchesRequiredNodeAffinity(pod.Spec.Affinity, n.node.Labels) {
+		return fmt.Sprintf("%s does not match the required node affinity", n.node.Name)
+	}
+	for _, taint := range n.node.Spec.Taints {
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !tolerates(pod.Spec.Tolerations, taint) {
+			return fmt.Sprintf("%s has untolerated taint %s", n.node.Name, taint.ToString())
+		}
+	}
+	if conflictsWithAntiAffinity(pod, n.pods) {
+		return fmt.Sprintf("%s runs a pod excluded by the required anti-affinity", n.node.Name)
+	}
+	return ""
+}
+
+func tolerates(tolerations []corev1.Toleration, taint corev1.Taint) bool {
+	for _, t := range tolerations {
+		if t.ToleratesTaint(&taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesRequiredNodeAffinity evaluates the required node affinity terms, which are ORed, against
+// the node's labels. Field selectors are not evaluated.
+func matchesRequiredNodeAffinity(affinity *corev1.Affinity, nodeLabels map[string]string) bool {
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true
+	}
+	terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	for _, term := range terms {
+		if matchesNodeSelectorTerm(term, nodeLabels) {
+			return true
+		}
+	}
+	return len(terms) == 0
+}
+
+func matchesNodeSelectorTerm(term corev1.NodeSelectorTerm, nodeLabels map[string]string) bool {
+	for _, expr := range term.MatchExpressions {
+		value, ok := nodeLabels[expr.Key]
+		switch expr.Operator {
+		case corev1.NodeSelectorOpIn:
+			if !ok || !contains(expr.Values, value) {
+				return false
+			}
+		case corev1.NodeSelectorOpNotIn:
+			if ok && contains(expr.Values, value) {
+				return false
+			}
+		case corev1.NodeSelectorOpExists:
+			if !ok {
+				return false
+			}
+		case corev1.NodeSelectorOpDoesNotExist:
+			if ok {
+				return false
+			}
+		case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+			if !ok || len(expr.Values) != 1 {
+				return false
+			}
+			have, err1 := strconv.ParseInt(value, 10, 64)
+			want, err2 := strconv.ParseInt(expr.Values[0], 10, 64)
+			if err1 != nil || err2 != nil {
+				return false
+			}
+			if (expr.Operator == corev1.NodeSelectorOpGt && have <= want) || (expr.Operator == corev1.NodeSelectorOpLt && have >= want) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// conflictsWithAntiAffinity reports whether pod's required anti-affinity on the hostname
+// topology excludes any of the pods already on the node.
+func conflictsWithAntiAffinity(pod *corev1.Pod, others []*corev1.Pod) bool {
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.PodAntiAffinity == nil {
+		return false
+	}
+	for _, term := range pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+		if term.TopologyKey != corev1.LabelHostname {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+		if err != nil {
+			continue
+		}
+		namespaces := term.Namespaces
+		if len(namespaces) == 0 {
+			namespaces = []string{pod.Namespace}
+		}
+		for _, other := range others {
+			if contains(namespaces, other.Namespace) && selector.Matches(labels.Set(other.Labels)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// podRequests returns the CPU and memory a pod reserves on its node: the larger of its
+// containers' summed requests and its largest init container request, plus pod overhead.
+func podRequests(pod corev1.Pod) (cpuMillis, memoryBytes int64) {
+	for _, c := range pod.Spec.Containers {
+		cpuMillis += c.Resources.Requests.Cpu().MilliValue()
+		memoryBytes += c.Resources.Requests.Memory().Value()
+	}
+	for _, c := range pod.Spec.InitContainers {
+		cpuMillis = max(cpuMillis, c.Resources.Requests.Cpu().MilliValue())
+		memoryBytes = max(memoryBytes, c.Resources.Requests.Memory().Value())
+	}
+	cpuMillis += pod.Spec.Overhead.Cpu().MilliValue()
+	memoryBytes += pod.Spec.Overhead.Memory().Value()
+	return cpuMillis, memoryBytes
+}
+
+// headroom sums the allocatable and requested capacity of the nodes include selects.
+func headroom(nodes []corev1.Node, pods []corev1.Pod, include func(corev1.Node) bool) Headroom {
+	var h Headroom
+	included := map[string]bool{}
+	for _, node := range nodes {
+		if !include(node) {
+			continue
+		}
+		included[node.Name] = true
+		h.Nodes++
+		h.CPUAllocatableMillis += node.Status.Allocatable.Cpu().MilliValue()
+		h.MemoryAllocatableBytes += node.Status.Allocatable.Memory().Value()
+	}
+	for _, pod := range pods {
+		if !included[pod.Spec.NodeName] || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		cpu, memory := podRequests(pod)
+		h.CPURequestedMillis += cpu
+		h.MemoryRequestedBytes += memory
+	}
+	return h
+}
+
+func nodeReady(node corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// isDaemonOrStaticPod reports whether a pod is tied to its node and is not evicted by a drain.
+func isDaemonOrStaticPod(pod corev1.Pod) bool {
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return true
+	}
+	owner := metav1.GetControllerOf(&pod)
+	return owner != nil && owner.Kind == "DaemonSet"
+}
+
+// PrintScaleDownReport writes the simulation result as text.
+func PrintScaleDownReport(w io.Writer, r *ScaleDownReport) {
+	fmt.Fprintf(w, "Removing node group %s (%d node(s)) evicts %d pod(s).\n", r.NodeGroup, len(r.Nodes), r.Evicted)
+	fmt.Fprintf(w, "Headroom before: %s\n", r.Before)
+	fmt.Fprintf(w, "Headroom after:  %s\n", r.After)
+	if len(r.Unplaceable) == 0 {
+		fmt.Fprintln(w, "Every evicted pod fits on the remaining nodes.")
+	} else {
+		fmt.Fprintf(w, "%d pod(s) could not be rescheduled:\n", len(r.Unplaceable))
+		for _, p := range r.Unplaceable {
+			fmt.Fprintf(w, "  - %s: %s\n", describePod(p.Namespace, p.Name, p.Owner), p.Reason)
+		}
+	}
+	if len(r.BlockedBudgets) > 0 {
+		fmt.Fprintln(w, "PodDisruptionBudgets that will slow or block the drain:")
+		for _, b := range r.BlockedBudgets {
+			fmt.Fprintf(w, "  - %s/%s: evicting %d pod(s), %d disruption(s) allowed\n", b.Namespace, b.Name, b.Evicting, b.Allowed)
+		}
+	}
+}
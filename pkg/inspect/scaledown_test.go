@@ -0,0 +1,99 @@
+package inspect
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testNode(name, group, cpu, memory string, taints ...corev1.Taint) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"eks.amazonaws.com/nodegroup": group, corev1.LabelHostname: name}},
+		Spec:       corev1.NodeSpec{Taints: taints},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory), corev1.ResourcePods: resource.MustParse("110")},
+			Conditions:  []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+func testPod(name, node, cpu, memory string, owner string) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: name, Labels: map[string]string{"app": name}},
+		Spec: corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory),
+		}}}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if owner != "" {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: owner, Name: name, Controller: &controller}}
+	}
+	return pod
+}
+
+func TestSimulateScaleDown(t *testing.T) {
+	nodes := []corev1.Node{
+		testNode("spot-1", "spot", "2", "4Gi"),
+		testNode("spot-2", "spot", "2", "4Gi"),
+		testNode("general-1", "general", "4", "8Gi"),
+		testNode("gpu-1", "gpu", "8", "32Gi", corev1.Taint{Key: "nvidia.com/gpu", Effect: corev1.TaintEffectNoSchedule}),
+	}
+	big := testPod("big", "spot-1", "1500m", "1Gi", "ReplicaSet")
+	big.Labels["app"] = "api"
+	api := testPod("api", "spot-2", "1", "1Gi", "ReplicaSet")
+	pods := []corev1.Pod{
+		testPod("existing", "general-1", "2", "2Gi", "ReplicaSet"),
+		big,
+		api,
+		testPod("bare", "spot-2", "100m", "64Mi", ""),
+		testPod("logs", "spot-1", "100m", "64Mi", "DaemonSet"),
+		testPod("pinned", "spot-2", "100m", "64Mi", "ReplicaSet"),
+	}
+	pods[5].Spec.NodeSelector = map[string]string{"eks.amazonaws.com/nodegroup": "spot"}
+	pdbs := []policyv1.PodDisruptionBudget{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "api"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}}},
+		Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 1},
+	}}
+
+	report := SimulateScaleDown(nodes, pods, pdbs, func(n corev1.Node) bool { return InNodeGroup(n, "spot") })
+
+	if len(report.Nodes) != 2 || report.Evicted != 4 {
+		t.Fatalf("SimulateScaleDown() removed %v evicting %d pods, want 2 nodes and 4 pods (DaemonSet pod excluded)", report.Nodes, report.Evicted)
+	}
+
+	// general-1 has 2 CPU free: big (1.5) fits, then api (1) does not, and the GPU node's taint is not tolerated.
+	unplaceable := map[string]string{}
+	for _, p := range report.Unplaceable {
+		unplaceable[p.Name] = p.Reason
+	}
+	for _, name := range []string{"api", "bare", "pinned"} {
+		if _, ok := unplaceable[name]; !ok {
+			t.Errorf("SimulateScaleDown() placed %s, want it unplaceable", name)
+		}
+	}
+	if _, ok := unplaceable["big"]; ok || len(unplaceable) != 3 {
+		t.Errorf("SimulateScaleDown() unplaceable = %v, want api, bare, and pinned only", unplaceable)
+	}
+
+	if len(report.BlockedBudgets) != 1 || report.BlockedBudgets[0].Evicting != 2 {
+		t.Errorf("SimulateScaleDown() blocked budgets = %+v, want api evicting 2 with 1 allowed", report.BlockedBudgets)
+	}
+
+	if report.Before.Nodes != 4 || report.After.Nodes != 2 || report.After.CPURequestedMillis != 3500 || report.After.CPUAllocatableMillis != 12000 {
+		t.Errorf("SimulateScaleDown() headroom before %+v after %+v, want 4 then 2 nodes with 3500m/12000m requested after", report.Before, report.After)
+	}
+}
+
+func TestPodRequests_InitContainersAndOverhead(t *testing.T) {
+	pod := testPod("job", "", "100m", "64Mi", "")
+	pod.Spec.InitContainers = []corev1.Container{{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}}}}
+	pod.Spec.Overhead = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m")}
+	if cpu, memory := podRequests(pod); cpu != 550 || memory != 64<<20 {
+		t.Errorf("podRequests() = %dm, %d bytes; want 550m, %d bytes", cpu, memory, 64<<20)
+	}
+}
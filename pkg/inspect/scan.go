@@ -0,0 +1,462 @@
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Report is the result of one full scan of the cluster.
+type Report struct {
+	// Cluster identifies the scanned cluster.
+	Cluster           *ClusterIdentity `json:"cluster"`
+	GeneratedAt       time.Time        `json:"generatedAt"`
+	KubernetesVersion string           `json:"kubernetesVersion"`
+	Platform          *PlatformInfo    `json:"platform,omitempty"`
+	Release           *ReleaseInfo     `json:"release,omitempty"`
+	EtcdVersion       string           `json:"etcdVersion,omitempty"`
+	EtcdHealth        *EtcdHealth      `json:"etcdHealth,omitempty"`
+	// Certificates is only populated when the scan runs with --check-certs.
+	Certificates     []CertificateStatus `json:"certificates,omitempty"`
+	NodeVersions     string              `json:"nodeVersions,omitempty"`
+	NodeInventory    *NodeInventory      `json:"nodeInventory,omitempty"`
+	ExposedEndpoints []ExposedEndpoint   `json:"exposedEndpoints"`
+	Ingress          *IngressReport      `json:"ingress,omitempty"`
+	// DNS is only populated when the scan runs with --dns-zone or --dns-resolve.
+	DNS                  *DNSReport            `json:"dns,omitempty"`
+	Utilization          *Utilization          `json:"utilization,omitempty"`
+	ImageSpread          *ImageSpreadReport    `json:"imageSpread,omitempty"`
+	IPFamilies           *IPFamilyReport       `json:"ipFamilies,omitempty"`
+	CustomResources      *CustomResourceHealth `json:"customResources,omitempty"`
+	TrustBundles         *TrustBundleReport    `json:"trustBundles,omitempty"`
+	Workloads            *WorkloadHealth       `json:"workloads,omitempty"`
+	Scheduling           *SchedulingReport     `json:"scheduling,omitempty"`
+	Autoscaling          *AutoscalingReport    `json:"autoscaling,omitempty"`
+	ServiceAccountTokens *TokenReport          `json:"serviceAccountTokens,omitempty"`
+	CIS                  *CISReport            `json:"cis,omitempty"`
+	Backups              *BackupReport         `json:"backups,omitempty"`
+	Quotas               *QuotaReport          `json:"quotas,omitempty"`
+	Priorities           *PriorityReport       `json:"priorities,omitempty"`
+	// Cost is only populated when the scan runs with --cost or --price-table.
+	Cost *CostReport `json:"cost,omitempty"`
+	// Plugins is only populated when the scan runs exec plugins with --plugins.
+	Plugins  []PluginResult `json:"plugins,omitempty"`
+	Findings []Finding      `json:"findings"`
+	// Suppressed are the findings the policy file accepts. They are left out of Findings, and
+	// so out of notifications and --fail-on.
+	Suppressed []Finding `json:"suppressed,omitempty"`
+	// SkippedCollectors are the collectors left out by --collectors or --skip-collectors.
+	SkippedCollectors []string `json:"skippedCollectors,omitempty"`
+	// CompletedCollectors are the collectors that ran without an error, as did every collector
+	// they use, so their findings are complete.
+	CompletedCollectors []string `json:"completedCollectors,omitempty"`
+	// Interrupted is set when the scan was cancelled before every collector finished. The
+	// report then holds what was collected so far, with the unfinished sections in Errors.
+	Interrupted bool `json:"interrupted,omitempty"`
+	// Errors holds the error message of every section that could not be collected, keyed by section.
+	Errors map[string]string `json:"errors,omitempty"`
+	// NamespaceErrors holds, for scans with ShardWorkers set, the namespaces some lists failed
+	// in, with the errors. Those lists still returned the objects of every other namespace.
+	NamespaceErrors map[string]string `json:"namespaceErrors,omitempty"`
+}
+
+// Report sections, used as keys in Report.Errors.
+const (
+	sectionCluster         = "cluster"
+	sectionPlatform        = "platform"
+	sectionOwners          = "owners"
+	sectionRelease         = "release"
+	sectionEtcd            = "etcd"
+	sectionEtcdDeep        = "etcdHealth"
+	sectionCerts           = "certificates"
+	sectionReachability    = "reachability"
+	sectionUtilization     = "utilization"
+	sectionImages          = "images"
+	sectionIPFamilies      = "ipFamilies"
+	sectionCustomResources = "customResources"
+	sectionTrustBundles    = "trustBundles"
+	sectionWorkloads       = "workloads"
+	sectionScheduling      = "scheduling"
+	sectionTokens          = "serviceAccountTokens"
+	sectionCIS             = "cis"
+	sectionBackups         = "backups"
+	sectionAutoscaling     = "autoscaling"
+	sectionQuotas          = "quotas"
+	sectionPriorities      = "priorities"
+	sectionCost            = "cost"
+	sectionNodes           = "nodes"
+	sectionEndpoints       = "endpoints"
+	sectionIngress         = "ingress"
+	sectionDNS             = "dns"
+	sectionPlugins         = "plugins"
+	sectionFindings        = "findings"
+)
+
+// RunScan runs the selected collectors against the cluster and gathers the results into a Report.
+// Only a failure to reach the API server or an unknown collector name is returned as an error;
+// collectors that fail are recorded in Report.Errors so the rest of the report is still usable.
+// Cancelling ctx stops the scan early: collectors that have not started are recorded as errors
+// and the partial report is returned with Interrupted set.
+// The hosting platform is detected first so that collectors for components a managed control
+// plane hides, like etcd, can be skipped.
+func RunScan(ctx context.Context, clientset kubernetes.Interface, config *rest.Config, opts ScanOptions) (*Report, error) {
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	return runScan(&scanState{ctx: ctx, clientset: clientset, dynamic: client, config: config, opts: opts})
+}
+
+// runScan is RunScan with the clients already built, so tests can pass fakes.
+func runScan(s *scanState) (*Report, error) {
+	if s.opts.ShardWorkers > 0 {
+		s.opts.shards = &namespaceShards{}
+	}
+	ctx, clientset, opts := s.ctx, s.clientset, s.opts
+	collectors, err := SelectCollectors(opts.Collectors, opts.SkipCollectors)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{GeneratedAt: time.Now(), Errors: map[string]string{}}
+	s.report = report
+	for _, c := range collectorRegistry {
+		if !slices.Contains(collectors, c) {
+			report.SkippedCollectors = append(report.SkippedCollectors, c.Name)
+		}
+	}
+
+	version, err := GetKubernetesAPIServerVersion(clientset)
+	if err != nil {
+		return nil, err
+	}
+	report.KubernetesVersion = version
+
+	cluster, err := GetClusterIdentity(ctx, clientset, s.config, opts)
+	report.Cluster = cluster
+	recordError(report, sectionCluster, err)
+
+	platform, err := GetPlatform(ctx, clientset, opts, version)
+	recordError(report, sectionPlatform, err)
+	if platform == nil {
+		platform = DetectPlatform(PlatformSignals{GitVersion: version})
+	}
+	report.Platform = platform
+	report.Cluster.Provider = clusterProvider(platform)
+
+	release, err := GetReleaseInfo(platform)
+	report.Release = release
+	recordError(report, sectionRelease, err)
+
+	report.Findings = runCollectors(s, collectors)
+	if opts.Plugins.Enabled {
+		results, findings, err := runExecPlugins(ctx, opts)
+		report.Plugins = results
+		report.Findings = append(report.Findings, findings...)
+		recordError(report, sectionPlugins, err)
+	}
+	report.Interrupted = ctx.Err() != nil
+	if opts.shards != nil {
+		report.NamespaceErrors = opts.shards.failures()
+	}
+
+	report.Findings = FinalizeFindings(report.Findings)
+	sortFindings(report.Findings)
+	attachOwners(report, s.owners)
+	if opts.Policy != nil {
+		report.Findings, report.Suppressed = opts.Policy.Apply(report.Findings, report.GeneratedAt)
+	}
+
+	if opts.WithRaw {
+		for i := range report.Findings {
+			if err := report.Findings[i].attachRaw(); err != nil {
+				recordError(report, sectionFindings, err)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// Skipped reports whether the named collector was left out of the scan.
+func (r *Report) Skipped(collector string) bool {
+	return slices.Contains(r.SkippedCollectors, collector)
+}
+
+func recordError(report *Report, section string, err error) {
+	if err == nil {
+		return
+	}
+	if existing, ok := report.Errors[section]; ok {
+		report.Errors[section] = existing + "; " + err.Error()
+		return
+	}
+	report.Errors[section] = err.Error()
+}
+
+// WriteReportJSON writes the report as indented JSON.
+func WriteReportJSON(w io.Writer, report *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// PrintReport writes the report as human-readable text.
+func PrintReport(w io.Writer, report *Report) {
+	printClusterIdentity(w, report)
+	if report.Interrupted {
+		fmt.Fprintln(w, "Scan interrupted: this report is partial, and sections that did not finish are reported as errors.")
+	}
+	fmt.Fprintf(w, "Kubernetes API server version: %s\n", report.KubernetesVersion)
+
+	if msg, ok := report.Errors[sectionPlatform]; ok {
+		fmt.Fprintf(w, "Could not fully detect the hosting platform: %s\n", msg)
+	}
+	if p := report.Platform; p != nil {
+		fmt.Fprintf(w, "Platform: %s\n", p)
+		if len(p.NodeImages) > 0 {
+			fmt.Fprintf(w, "  Node images: %s\n", strings.Join(p.NodeImages, ", "))
+		}
+	}
+
+	if len(report.SkippedCollectors) > 0 {
+		fmt.Fprintf(w, "Skipped collectors: %s\n", strings.Join(report.SkippedCollectors, ", "))
+	}
+
+	if msg, ok := report.Errors[sectionRelease]; ok {
+		fmt.Fprintf(w, "Could not get managed platform release info: %s\n", msg)
+	} else if r := report.Release; r != nil {
+		fmt.Fprintf(w, "Managed platform: %s, running %s (%s support)\n", r.Platform, r.Minor, r.SupportTier)
+		fmt.Fprintf(w, "  Standard support ends %s, forced upgrade from %s (earlier if not enrolled in extended support; calendar as of %s)\n",
+			r.StandardSupportEnd.Format(time.DateOnly), r.EndOfLife.Format(time.DateOnly), r.DataUpdated)
+		days := r.DaysUntilForcedUpgrade(report.GeneratedAt)
+		switch {
+		case days < 0:
+			fmt.Fprintf(w, "  WARNING: %s %s is past end of life; %s may auto-upgrade this cluster at any time\n", r.Platform, r.Minor, r.Platform)
+		case time.Duration(days)*24*time.Hour <= forcedUpgradeWarningWindow:
+			fmt.Fprintf(w, "  WARNING: %s will auto-upgrade this cluster in %d day(s)\n", r.Platform, days)
+		}
+	}
+
+	if msg, ok := report.Errors[sectionEtcd]; ok {
+		fmt.Fprintf(w, "Could not get etcd version: %s\n", msg)
+	} else if p := report.Platform; p != nil && p.Managed {
+		fmt.Fprintf(w, "etcd: managed by %s, not visible from the cluster (control plane %s)\n", p.Name, p.Version)
+	} else if !report.Skipped("etcd") {
+		fmt.Fprintf(w, "Detected etcd version: %s\n", report.EtcdVersion)
+	}
+
+	if msg, ok := report.Errors[sectionEtcdDeep]; ok {
+		fmt.Fprintf(w, "Could not get etcd health: %s\n", msg)
+	} else if h := report.EtcdHealth; h != nil {
+		fmt.Fprintf(w, "etcd cluster health (via %s, quota %s):\n", h.Pod, formatBytes(h.QuotaBytes))
+		for _, m := range h.Members {
+			role := "follower"
+			switch {
+			case m.IsLeader:
+				role = "leader"
+			case m.IsLearner:
+				role = "learner"
+			}
+			if !m.Reachable {
+				fmt.Fprintf(w, "  - %s (%s): unreachable\n", m.Name, m.Endpoint)
+				continue
+			}
+			fmt.Fprintf(w, "  - %s (%s): %s, v%s, DB %s (%s reclaimable by defrag)\n",
+				m.Name, m.Endpoint, role, m.Version, formatBytes(m.DBSize), formatBytes(m.ReclaimableBytes()))
+		}
+		if len(h.Alarms) == 0 {
+			fmt.Fprintln(w, "  Alarms: none")
+		} else {
+			fmt.Fprintf(w, "  Alarms: %s\n", strings.Join(h.Alarms, ", "))
+		}
+	}
+
+	if msg, ok := report.Errors[sectionCerts]; ok {
+		fmt.Fprintf(w, "Could not check certificates: %s\n", msg)
+	} else if len(report.Certificates) > 0 {
+		fmt.Fprintln(w, "Control-plane certificates:")
+		for _, c := range report.Certificates {
+			if c.Error != "" {
+				fmt.Fprintf(w, "  - %s %s: unreadable (%s)\n", c.Component, c.Target, c.Error)
+				continue
+			}
+			fmt.Fprintf(w, "  - %s %s: expires %s (%d day(s))\n",
+				c.Component, c.Target, c.NotAfter.Format(time.DateOnly), c.DaysRemaining(report.GeneratedAt))
+		}
+	}
+
+	if msg, ok := report.Errors[sectionNodes]; ok {
+		fmt.Fprintf(w, "Could not get node versions: %s\n", msg)
+	} else if !report.Skipped("nodes") {
+		fmt.Fprintf(w, "Detected node versions: %s\n", report.NodeVersions)
+		if report.NodeInventory != nil {
+			PrintNodeInventory(w, report.NodeInventory)
+		}
+	}
+
+	if msg, ok := report.Errors[sectionEndpoints]; ok {
+		fmt.Fprintf(w, "Could not get exposed endpoints: %s\n", msg)
+	} else if !report.Skipped("endpoints") {
+		fmt.Fprintln(w, "Detected Exposed Endpoints:")
+		if len(report.ExposedEndpoints) == 0 {
+			fmt.Fprintln(w, "  No exposed LoadBalancer, NodePort services, or Ingresses found.")
+		} else {
+			for _, endpoint := range report.ExposedEndpoints {
+				fmt.Fprintf(w, "  - %s\n", endpoint)
+				if endpoint.Backends != nil {
+					fmt.Fprintf(w, "      Backends: %s\n", endpoint.Backends)
+				}
+				if endpoint.LastModified != nil {
+					fmt.Fprintf(w, "      Last modified by %s\n", endpoint.LastModified)
+				}
+				for _, r := range endpoint.Reachability {
+					fmt.Fprintf(w, "      %s\n", r)
+				}
+			}
+		}
+	}
+
+	if msg, ok := report.Errors[sectionReachability]; ok {
+		fmt.Fprintf(w, "Could not probe exposed endpoints: %s\n", msg)
+	}
+
+	if msg, ok := report.Errors[sectionIngress]; ok {
+		fmt.Fprintf(w, "Could not detect ingress controllers: %s\n", msg)
+	} else if report.Ingress != nil {
+		PrintIngressReport(w, report.Ingress)
+	}
+
+	if msg, ok := report.Errors[sectionDNS]; ok {
+		fmt.Fprintf(w, "Could not check DNS delegation: %s\n", msg)
+	} else if report.DNS != nil {
+		PrintDNSReport(w, report.DNS)
+	}
+
+	if msg, ok := report.Errors[sectionWorkloads]; ok {
+		fmt.Fprintf(w, "Could not get workload health: %s\n", msg)
+	} else if report.Workloads != nil {
+		PrintWorkloadHealth(w, report.Workloads)
+	}
+
+	if msg, ok := report.Errors[sectionScheduling]; ok {
+		fmt.Fprintf(w, "Could not analyze scheduling failures: %s\n", msg)
+	} else if report.Scheduling != nil {
+		PrintSchedulingReport(w, report.Scheduling)
+	}
+
+	if msg, ok := report.Errors[sectionCustomResources]; ok {
+		fmt.Fprintf(w, "Could not check all custom resources: %s\n", msg)
+	}
+	if report.CustomResources != nil {
+		PrintCustomResourceHealth(w, report.CustomResources)
+	}
+
+	if msg, ok := report.Errors[sectionAutoscaling]; ok {
+		fmt.Fprintf(w, "Could not get autoscaling configuration: %s\n", msg)
+	} else if report.Autoscaling != nil {
+		PrintAutoscaling(w, report.Autoscaling)
+	}
+
+	if msg, ok := report.Errors[sectionQuotas]; ok {
+		fmt.Fprintf(w, "Could not get resource quotas: %s\n", msg)
+	} else if report.Quotas != nil {
+		PrintQuotaReport(w, report.Quotas)
+	}
+
+	if msg, ok := report.Errors[sectionPriorities]; ok {
+		fmt.Fprintf(w, "Could not get priority classes: %s\n", msg)
+	} else if report.Priorities != nil {
+		PrintPriorityReport(w, report.Priorities)
+	}
+
+	if msg, ok := report.Errors[sectionCost]; ok {
+		fmt.Fprintf(w, "Could not estimate cost: %s\n", msg)
+	} else if report.Cost != nil {
+		PrintCostReport(w, report.Cost)
+	}
+
+	if msg, ok := report.Errors[sectionTokens]; ok {
+		fmt.Fprintf(w, "Could not get service account tokens: %s\n", msg)
+	} else if report.ServiceAccountTokens != nil {
+		PrintTokenReport(w, report.ServiceAccountTokens, report.GeneratedAt)
+	}
+
+	if msg, ok := report.Errors[sectionCIS]; ok {
+		fmt.Fprintf(w, "Could not assess CIS benchmark controls: %s\n", msg)
+	} else if report.CIS != nil {
+		PrintCISReport(w, report.CIS, false)
+	}
+
+	if msg, ok := report.Errors[sectionBackups]; ok {
+		fmt.Fprintf(w, "Could not check backups: %s\n", msg)
+	} else if report.Backups != nil {
+		PrintBackupReport(w, report.Backups, report.GeneratedAt)
+	}
+
+	if msg, ok := report.Errors[sectionUtilization]; ok {
+		fmt.Fprintf(w, "Could not get resource utilization: %s\n", msg)
+	} else if report.Utilization != nil {
+		PrintUtilization(w, report.Utilization)
+	}
+
+	if msg, ok := report.Errors[sectionImages]; ok {
+		fmt.Fprintf(w, "Could not get image spread: %s\n", msg)
+	} else if report.ImageSpread != nil {
+		PrintImageSpread(w, report.ImageSpread)
+	}
+
+	if msg, ok := report.Errors[sectionIPFamilies]; ok {
+		fmt.Fprintf(w, "Could not get IP families: %s\n", msg)
+	} else if report.IPFamilies != nil {
+		PrintIPFamilyReport(w, report.IPFamilies)
+	}
+
+	if msg, ok := report.Errors[sectionTrustBundles]; ok {
+		fmt.Fprintf(w, "Could not check CA trust bundles: %s\n", msg)
+	} else if report.TrustBundles != nil {
+		PrintTrustBundleReport(w, report.TrustBundles)
+	}
+
+	for _, section := range slices.Sorted(maps.Keys(report.Errors)) {
+		if name, ok := strings.CutPrefix(section, "plugin:"); ok {
+			fmt.Fprintf(w, "Could not run plugin %s: %s\n", name, report.Errors[section])
+		}
+	}
+	if msg, ok := report.Errors[sectionPlugins]; ok {
+		fmt.Fprintf(w, "Could not run every exec plugin: %s\n", msg)
+	}
+	if report.Plugins != nil {
+		printPluginResults(w, report.Plugins)
+	}
+
+	printNamespaceErrors(w, report.NamespaceErrors)
+	if msg, ok := report.Errors[sectionFindings]; ok {
+		fmt.Fprintf(w, "Could not run all checks: %s\n", msg)
+	}
+	fmt.Fprintln(w, "Findings:")
+	if len(report.Findings) == 0 {
+		fmt.Fprintln(w, "  No findings.")
+	}
+	if msg, ok := report.Errors[sectionOwners]; ok {
+		fmt.Fprintf(w, "Could not resolve pod owners: %s\n", msg)
+	}
+	for _, f := range report.Findings {
+		message := f.Message
+		if f.Owner != nil {
+			message = f.Owner.String() + ": " + message
+		}
+		fmt.Fprintf(w, "  - [%s] %s: %s (%s)\n", f.Severity, f.CheckID, message, f.Fingerprint)
+	}
+	if len(report.Suppressed) > 0 {
+		fmt.Fprintf(w, "  %d accepted finding(s) not shown (see the policy file, or --output json).\n", len(report.Suppressed))
+	}
+}
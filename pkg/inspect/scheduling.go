@@ -0,0 +1,295 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Scheduling failure causes, classified from the reasons the scheduler gives per node.
+const (
+	CauseInsufficientCPU      = "insufficient-cpu"
+	CauseInsufficientMemory   = "insufficient-memory"
+	CauseInsufficientResource = "insufficient-resource"
+	CauseTooManyPods          = "too-many-pods"
+	CauseNodesCordoned        = "nodes-cordoned"
+	CauseTaint                = "untolerated-taint"
+	CauseNodeAffinity         = "node-affinity"
+	CausePodAffinity          = "pod-affinity"
+	CauseTopologySpread       = "topology-spread"
+	CauseVolume               = "volume"
+	CauseHostPort             = "host-port"
+	CauseSchedulingGated      = "scheduling-gated"
+	CauseOther                = "other"
+)
+
+// Cause categories: capacity problems are fixed by adding or freeing nodes, configuration
+// problems by changing the pod or the nodes' labels and taints.
+const (
+	CategoryCapacity      = "capacity"
+	CategoryConfiguration = "configuration"
+)
+
+// SchedulingReport explains why Pending pods are not being scheduled.
+type SchedulingReport struct {
+	Pending []PendingPod `json:"pending"`
+	// Causes counts the pending pods affected by each cause, most common first.
+	Causes []CauseCount `json:"causes"`
+	// CapacityPods and ConfigurationPods count the pods with at least one cause in each category.
+	CapacityPods      int `json:"capacityPods"`
+	ConfigurationPods int `json:"configurationPods"`
+}
+
+// PendingPod is a pod the scheduler could not place.
+type PendingPod struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Since     time.Time `json:"since"`
+	// Message is the scheduler's latest explanation, from a FailedScheduling event or the pod's
+	// PodScheduled condition.
+	Message string       `json:"message"`
+	Reasons []NodeReason `json:"reasons"`
+	Owner   *Owner       `json:"owner,omitempty"`
+
+	object any
+}
+
+// NodeReason is one reason the scheduler rejected some of the nodes.
+type NodeReason struct {
+	Cause    string `json:"cause"`
+	Category string `json:"category"`
+	// Nodes is how many nodes were rejected for this reason, or 0 when the scheduler gave none.
+	Nodes  int    `json:"nodes"`
+	Detail string `json:"detail"`
+}
+
+// CauseCount is how many pending pods a cause affects.
+type CauseCount struct {
+	Cause    string `json:"cause"`
+	Category string `json:"category"`
+	Pods     int    `json:"pods"`
+}
+
+// GetSchedulingReport lists the Pending pods and the FailedScheduling events.
+func GetSchedulingReport(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*SchedulingReport, error) {
+	pods, err := listPods(ctx, clientset, opts, "", metav1.ListOptions{FieldSelector: "status.phase=Pending"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending pods: %w", err)
+	}
+	events, err := ListEvents(ctx, clientset, opts, "", metav1.ListOptions{FieldSelector: "reason=FailedScheduling,involvedObject.kind=Pod"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduling events: %w", err)
+	}
+	return BuildSchedulingReport(pods, events), nil
+}
+
+// BuildSchedulingReport classifies why each unscheduled Pending pod is stuck. The latest
+// FailedScheduling event is used when there is one, since it is the most recent attempt;
+// otherwise the PodScheduled condition, which outlives the event. Pods already bound to a node
+// are pending for other reasons, like image pulls, and are left out.
+func BuildSchedulingReport(pods []corev1.Pod, events []corev1.Event) *SchedulingReport {
+	latest := map[string]corev1.Event{}
+	for _, e := range events {
+		key := e.InvolvedObject.Namespace + "/" + e.InvolvedObject.Name
+		if prev, ok := latest[key]; !ok || eventTime(e).After(eventTime(prev)) {
+			latest[key] = e
+		}
+	}
+
+	report := &SchedulingReport{}
+	counts := map[string]*CauseCount{}
+	for i, pod := range pods {
+		if pod.Status.Phase != corev1.PodPending || pod.Spec.NodeName != "" {
+			continue
+		}
+		p := PendingPod{Namespace: pod.Namespace, Name: pod.Name, Since: pod.CreationTimestamp.Time, object: &pods[i]}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse {
+				p.Message, p.Since = cond.Message, cond.LastTransitionTime.Time
+				if cond.Reason == corev1.PodReasonSchedulingGated {
+					p.Reasons = []NodeReason{{Cause: CauseSchedulingGated, Category: CategoryConfiguration, Detail: cond.Message}}
+				}
+			}
+		}
+		if e, ok := latest[pod.Namespace+"/"+pod.Name]; ok && p.Reasons == nil {
+			p.Message = e.Message
+		}
+		if p.Reasons == nil {
+			if p.Message == "" {
+				continue
+			}
+			p.Reasons = ParseSchedulingMessage(p.Message)
+		}
+
+		categories := map[string]bool{}
+		seen := map[string]bool{}
+		for _, r := range p.Reasons {
+			categories[r.Category] = true
+			if seen[r.Cause] {
+				continue
+			}
+			seen[r.Cause] = true
+			if counts[r.Cause] == nil {
+				counts[r.Cause] = &CauseCount{Cause: r.Cause, Category: r.Category}
+			}
+			counts[r.Cause].Pods++
+		}
+		if categories[CategoryCapacity] {
+			report.CapacityPods++
+		}
+		if categories[CategoryConfiguration] {
+			report.ConfigurationPods++
+		}
+		report.Pending = append(report.Pending, p)
+	}
+
+	for _, c := range counts {
+		report.Causes = append(report.Causes, *c)
+	}
+	sort.Slice(report.Causes, func(i, j int) bool {
+		if report.Causes[i].Pods != report.Causes[j].Pods {
+			return report.Causes[i].Pods > report.Causes[j].Pods
+		}
+		return report.Causes[i].Cause < report.Causes[j].Cause
+	})
+	sort.Slice(report.Pending, func(i, j int) bool {
+		a, b := report.Pending[i], report.Pending[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
+
+func eventTime(e corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	}
+	return e.CreationTimestamp.Time
+}
+
+// ParseSchedulingMessage splits a scheduler message such as "0/5 nodes are available:
+// 2 Insufficient cpu, 3 node(s) had untolerated taint {dedicated: gpu}. preemption: ..." into
+// its per-node reasons. The preemption summary that follows repeats the same reasons and is
+// ignored. A message in another format is returned as a single reason.
+func ParseSchedulingMessage(message string) []NodeReason {
+	_, body, ok := strings.Cut(message, "are available: ")
+	if !ok {
+		return []NodeReason{classifySchedulingReason(0, strings.TrimSpace(message))}
+	}
+	body, _, _ = strings.Cut(body, " preemption:")
+	body = strings.TrimSuffix(strings.TrimSpace(body), ".")
+
+	// Taint and selector details can contain ", ", so a piece that doesn't start with a node
+	// count continues the previous reason.
+	var items []string
+	for _, piece := range strings.Split(body, ", ") {
+		if len(items) > 0 && (piece == "" || piece[0] < '0' || piece[0] > '9') {
+			items[len(items)-1] += ", " + piece
+			continue
+		}
+		items = append(items, piece)
+	}
+
+	var reasons []NodeReason
+	for _, item := range items {
+		nodes := 0
+		if count, rest, ok := strings.Cut(item, " "); ok {
+			if n, err := strconv.Atoi(count); err == nil {
+				nodes, item = n, rest
+			}
+		}
+		reasons = append(reasons, classifySchedulingReason(nodes, item))
+	}
+	return reasons
+}
+
+func classifySchedulingReason(nodes int, detail string) NodeReason {
+	r := NodeReason{Nodes: nodes, Detail: detail, Category: CategoryConfiguration}
+	lower := strings.ToLower(detail)
+	switch {
+	case strings.HasPrefix(lower, "insufficient cpu"):
+		r.Cause, r.Category = CauseInsufficientCPU, CategoryCapacity
+	case strings.HasPrefix(lower, "insufficient memory"):
+		r.Cause, r.Category = CauseInsufficientMemory, CategoryCapacity
+	case strings.HasPrefix(lower, "insufficient"):
+		r.Cause, r.Category = CauseInsufficientResource, CategoryCapacity
+	case strings.Contains(lower, "too many pods"):
+		r.Cause, r.Category = CauseTooManyPods, CategoryCapacity
+	case strings.Contains(lower, "volume") || strings.Contains(lower, "persistentvolumeclaim"):
+		r.Cause = CauseVolume
+	case strings.Contains(lower, "taint"):
+		r.Cause = CauseTaint
+	case strings.Contains(lower, "were unschedulable"):
+		r.Cause, r.Category = CauseNodesCordoned, CategoryCapacity
+	case strings.Contains(lower, "node affinity") || strings.Contains(lower, "node selector"):
+		r.Cause = CauseNodeAffinity
+	case strings.Contains(lower, "affinity"):
+		r.Cause = CausePodAffinity
+	case strings.Contains(lower, "topology spread"):
+		r.Cause = CauseTopologySpread
+	case strings.Contains(lower, "free ports"):
+		r.Cause = CauseHostPort
+	default:
+		r.Cause = CauseOther
+	}
+	return r
+}
+
+// CheckScheduling raises a finding for every pod the scheduler can't place.
+func CheckScheduling(report *SchedulingReport, now time.Time) []Finding {
+	var findings []Finding
+	for _, p := range report.Pending {
+		var details []string
+		for _, r := range p.Reasons {
+			if r.Nodes > 0 {
+				details = append(details, fmt.Sprintf("%d %s", r.Nodes, r.Detail))
+			} else {
+				details = append(details, r.Detail)
+			}
+		}
+		findings = append(findings, Finding{
+			CheckID:   "pod-unschedulable",
+			Severity:  SeverityMedium,
+			Kind:      "Pod",
+			Namespace: p.Namespace,
+			Name:      p.Name,
+			Message: fmt.Sprintf("pod %s/%s has not been scheduled for %s: %s",
+				p.Namespace, p.Name, now.Sub(p.Since).Round(time.Minute), strings.Join(details, "; ")),
+			object: p.object,
+		})
+	}
+	return findings
+}
+
+// PrintSchedulingReport writes the scheduling section of the text report, listing the causes
+// by how many pods they affect.
+func PrintSchedulingReport(w io.Writer, report *SchedulingReport) {
+	if len(report.Pending) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Scheduling failures: %d pending pod(s), %d blocked by capacity, %d by configuration\n",
+		len(report.Pending), report.CapacityPods, report.ConfigurationPods)
+	for _, c := range report.Causes {
+		fmt.Fprintf(w, "  - %s (%s): %d pod(s)\n", c.Cause, c.Category, c.Pods)
+	}
+	for _, p := range report.Pending {
+		var causes []string
+		for _, r := range p.Reasons {
+			causes = append(causes, r.Cause)
+		}
+		fmt.Fprintf(w, "    %s: %s\n", describePod(p.Namespace, p.Name, p.Owner), strings.Join(causes, ", "))
+	}
+}
@@ -0,0 +1,105 @@
+package inspect
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseSchedulingMessage(t *testing.T) {
+	message := "0/7 nodes are available: 2 Insufficient cpu, 1 Insufficient nvidia.com/gpu, " +
+		"1 node(s) had untolerated taint {node-role.kubernetes.io/control-plane: }, " +
+		"1 node(s) had volume node affinity conflict, 1 node(s) didn't match Pod's node affinity/selector, " +
+		"1 node(s) were unschedulable. preemption: 0/7 nodes are available: 2 No preemption victims found for incoming pod, 5 Preemption is not helpful for scheduling."
+
+	var causes []string
+	for _, r := range ParseSchedulingMessage(message) {
+		causes = append(causes, r.Cause)
+	}
+	want := []string{CauseInsufficientCPU, CauseInsufficientResource, CauseTaint, CauseVolume, CauseNodeAffinity, CauseNodesCordoned}
+	if !reflect.DeepEqual(causes, want) {
+		t.Errorf("ParseSchedulingMessage() causes = %v, want %v", causes, want)
+	}
+
+	reasons := ParseSchedulingMessage("0/3 nodes are available: 3 node(s) didn't match pod anti-affinity rules, 1 node(s) didn't match pod topology spread constraints (missing required label).")
+	if len(reasons) != 2 || reasons[0].Cause != CausePodAffinity || reasons[0].Nodes != 3 || reasons[1].Cause != CauseTopologySpread {
+		t.Errorf("ParseSchedulingMessage() = %+v, want 3 nodes of pod affinity and topology spread", reasons)
+	}
+
+	reasons = ParseSchedulingMessage("0/3 nodes are available: pod has unbound immediate PersistentVolumeClaims. preemption: not eligible")
+	if len(reasons) != 1 || reasons[0].Cause != CauseVolume || reasons[0].Nodes != 0 {
+		t.Errorf("ParseSchedulingMessage() = %+v, want a volume cause without a node count", reasons)
+	}
+
+	reasons = ParseSchedulingMessage("running PreFilter plugin \"VolumeBinding\": error")
+	if len(reasons) != 1 || reasons[0].Cause != CauseVolume {
+		t.Errorf("ParseSchedulingMessage() = %+v, want a volume cause", reasons)
+	}
+}
+
+func TestBuildSchedulingReport(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	pending := func(name, message string, reason string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name, CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+			Status: corev1.PodStatus{Phase: corev1.PodPending, Conditions: []corev1.PodCondition{{
+				Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: reason, Message: message,
+				LastTransitionTime: metav1.NewTime(now.Add(-30 * time.Minute)),
+			}}},
+		}
+	}
+	bound := pending("pulling", "", "")
+	bound.Spec.NodeName = "node-1"
+	pods := []corev1.Pod{
+		pending("web-1", "0/3 nodes are available: 3 Insufficient memory.", corev1.PodReasonUnschedulable),
+		pending("web-2", "stale message", corev1.PodReasonUnschedulable),
+		pending("gpu-1", "0/3 nodes are available: 3 node(s) had untolerated taint {gpu: true}.", corev1.PodReasonUnschedulable),
+		pending("gated", "waiting for scheduling gates: [example.com/quota]", corev1.PodReasonSchedulingGated),
+		bound,
+	}
+	events := []corev1.Event{
+		{
+			InvolvedObject: corev1.ObjectReference{Namespace: "shop", Name: "web-2"},
+			Message:        "0/3 nodes are available: 1 Insufficient memory, 2 node(s) had untolerated taint {gpu: true}.",
+			LastTimestamp:  metav1.NewTime(now.Add(-time.Minute)),
+		},
+		{
+			InvolvedObject: corev1.ObjectReference{Namespace: "shop", Name: "web-2"},
+			Message:        "older",
+			LastTimestamp:  metav1.NewTime(now.Add(-time.Hour)),
+		},
+	}
+
+	report := BuildSchedulingReport(pods, events)
+
+	var names []string
+	for _, p := range report.Pending {
+		names = append(names, p.Name)
+	}
+	if got, want := strings.Join(names, ","), "gated,gpu-1,web-1,web-2"; got != want {
+		t.Errorf("Pending = %s, want %s", got, want)
+	}
+	if report.CapacityPods != 2 || report.ConfigurationPods != 3 {
+		t.Errorf("CapacityPods, ConfigurationPods = %d, %d, want 2, 3", report.CapacityPods, report.ConfigurationPods)
+	}
+	want := []CauseCount{
+		{Cause: CauseInsufficientMemory, Category: CategoryCapacity, Pods: 2},
+		{Cause: CauseTaint, Category: CategoryConfiguration, Pods: 2},
+		{Cause: CauseSchedulingGated, Category: CategoryConfiguration, Pods: 1},
+	}
+	if !reflect.DeepEqual(report.Causes, want) {
+		t.Errorf("Causes = %+v, want %+v", report.Causes, want)
+	}
+
+	findings := CheckScheduling(report, now)
+	if len(findings) != 4 {
+		t.Fatalf("len(CheckScheduling()) = %d, want 4, one per pending pod", len(findings))
+	}
+	if f := findings[3]; f.Name != "web-2" || !strings.Contains(f.Message, "for 30m0s: 1 Insufficient memory; 2 node(s) had untolerated taint") {
+		t.Errorf("findings[3] = %+v, want web-2 pending for 30m0s on memory and taints", f)
+	}
+}
@@ -0,0 +1,168 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// namespaceErrorLimit caps how many failed namespaces the text report names.
+const namespaceErrorLimit = 10
+
+// namespaceShards lists namespaced objects one namespace at a time during a scan run with
+// ShardWorkers set. The namespaces are listed once per scan, and the namespaces whose lists
+// fail are recorded rather than failing the whole list.
+type namespaceShards struct {
+	once       sync.Once
+	namespaces []string
+	err        error
+
+	mu     sync.Mutex
+	failed map[string][]string
+}
+
+// names lists the cluster's namespaces the first time it is called.
+func (s *namespaceShards) names(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) ([]string, error) {
+	s.once.Do(func() {
+		namespaces, err := listNamespaces(ctx, clientset, opts, metav1.ListOptions{})
+		if err != nil {
+			s.err = fmt.Errorf("failed to list namespaces to shard by: %w", err)
+			return
+		}
+		for _, ns := range namespaces {
+			s.namespaces = append(s.namespaces, ns.Name)
+		}
+		sort.Strings(s.namespaces)
+	})
+	return s.namespaces, s.err
+}
+
+// fail records that listing resource failed in namespace.
+func (s *namespaceShards) fail(namespace, resource string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failed == nil {
+		s.failed = map[string][]string{}
+	}
+	s.failed[namespace] = append(s.failed[namespace], resource+": "+err.Error())
+}
+
+// failures returns the recorded errors by namespace, or nil when every list succeeded.
+func (s *namespaceShards) failures() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failed) == 0 {
+		return nil
+	}
+	failures := make(map[string]string, len(s.failed))
+	for ns, errs := range s.failed {
+		sort.Strings(errs)
+		failures[ns] = strings.Join(errs, "; ")
+	}
+	return failures
+}
+
+// shardProgress reports how many namespaces a sharded list has covered, at every tenth.
+type shardProgress struct {
+	w               io.Writer
+	resource        string
+	mu              sync.Mutex
+	done, total     int
+	failed, printed int
+}
+
+func (p *shardProgress) add(failed bool) {
+	if p.w == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	if failed {
+		p.failed++
+	}
+	if tenth := p.done * 10 / p.total; tenth > p.printed {
+		p.printed = tenth
+		line := fmt.Sprintf("Listed %s in %d/%d namespaces", p.resource, p.done, p.total)
+		if p.failed > 0 {
+			line += fmt.Sprintf(" (%d failed)", p.failed)
+		}
+		fmt.Fprintln(p.w, line)
+	}
+}
+
+// listNamespaced lists a namespaced resource. A single namespace, or a scan without sharding,
+// is one paged list. Otherwise every namespace is listed separately, opts.ShardWorkers at a
+// time; namespaces whose list fails are recorded in the scan's namespace errors and left out,
+// unless every one fails, which fails the list.
+func listNamespaced[T any](ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, resource, namespace string, listOpts metav1.ListOptions, list func(namespace string, o metav1.ListOptions) ([]T, string, error)) ([]T, error) {
+	if namespace != "" || opts.shards == nil {
+		return listPaged(opts.PageSize, listOpts, func(o metav1.ListOptions) ([]T, string, error) { return list(namespace, o) })
+	}
+
+	namespaces, err := opts.shards.names(ctx, clientset, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := make([][]T, len(namespaces))
+	errs := make([]error, len(namespaces))
+	progress := &shardProgress{w: opts.Progress, resource: resource, total: len(namespaces)}
+	tasks := make([]func(), len(namespaces))
+	for i, ns := range namespaces {
+		tasks[i] = func() {
+			if errs[i] = ctx.Err(); errs[i] == nil {
+				results[i], errs[i] = listPaged(opts.PageSize, listOpts, func(o metav1.ListOptions) ([]T, string, error) { return list(ns, o) })
+			}
+			progress.add(errs[i] != nil)
+		}
+	}
+	runConcurrently(opts.ShardWorkers, tasks...)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed > 0 && failed == len(namespaces) {
+		return nil, fmt.Errorf("failed in every namespace: %w", errs[0])
+	}
+	var items []T
+	for i, ns := range namespaces {
+		if errs[i] != nil {
+			opts.shards.fail(ns, resource, errs[i])
+			continue
+		}
+		items = append(items, results[i]...)
+	}
+	return items, nil
+}
+
+// printNamespaceErrors writes the namespaces some sharded lists failed in.
+func printNamespaceErrors(w io.Writer, failures map[string]string) {
+	if len(failures) == 0 {
+		return
+	}
+	namespaces := make([]string, 0, len(failures))
+	for ns := range failures {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	fmt.Fprintf(w, "Could not list some objects in %d namespace(s), so the report leaves them out:\n", len(namespaces))
+	for i, ns := range namespaces {
+		if i == namespaceErrorLimit {
+			fmt.Fprintf(w, "  ... and %d more (see namespaceErrors in --output json)\n", len(namespaces)-i)
+			break
+		}
+		fmt.Fprintf(w, "  - %s: %s\n", ns, failures[ns])
+	}
+}
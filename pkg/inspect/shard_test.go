@@ -0,0 +1,96 @@
+package inspect
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestListNamespacedSharded(t *testing.T) {
+	var objects []runtime.Object
+	for _, ns := range []string{"a", "b", "c"} {
+		objects = append(objects,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: "web"}},
+		)
+	}
+	clientset, _ := newFakeClients(objects...)
+	var namespaced []string
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		namespaced = append(namespaced, action.GetNamespace())
+		if action.GetNamespace() == "b" {
+			return true, nil, apierrors.NewForbidden(action.GetResource().GroupResource(), "", errors.New("denied"))
+		}
+		return false, nil, nil
+	})
+
+	var progress bytes.Buffer
+	opts := AutoTuneScanOptions(ClusterSize{}).WithOverrides(ScanOptions{ShardWorkers: 1, Progress: &progress})
+	opts.shards = &namespaceShards{}
+	pods, err := listPods(t.Context(), clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("listPods() returned error = %v", err)
+	}
+
+	var got []string
+	for _, p := range pods {
+		got = append(got, p.Namespace)
+	}
+	if want := []string{"a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pods from %v, want %v with b left out", got, want)
+	}
+	if want := []string{"a", "b", "c", "kube-system"}; !reflect.DeepEqual(namespaced, want) {
+		t.Errorf("listed pods in %v, want one list per namespace: %v", namespaced, want)
+	}
+	if failures := opts.shards.failures(); len(failures) != 1 || !strings.HasPrefix(failures["b"], "pods: ") {
+		t.Errorf("failures = %v, want pods in b", failures)
+	}
+	if !strings.Contains(progress.String(), "Listed pods in 4/4 namespaces (1 failed)") {
+		t.Errorf("progress = %q, want a final line with the failure count", progress.String())
+	}
+}
+
+func TestListNamespacedShardedAllFail(t *testing.T) {
+	clientset, _ := newFakeClients(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "a"}})
+	clientset.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(action.GetResource().GroupResource(), "", errors.New("denied"))
+	})
+	opts := AutoTuneScanOptions(ClusterSize{}).WithOverrides(ScanOptions{ShardWorkers: 4})
+	opts.shards = &namespaceShards{}
+	if _, err := listSecrets(t.Context(), clientset, opts, "", metav1.ListOptions{}); err == nil {
+		t.Error("listSecrets() returned error = nil, want the error when every namespace fails")
+	}
+}
+
+func TestScanRecordsNamespaceErrors(t *testing.T) {
+	clientset, dynamicClient := newFakeClients(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "locked"}},
+	)
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() == "locked" {
+			return true, nil, apierrors.NewForbidden(action.GetResource().GroupResource(), "", errors.New("denied"))
+		}
+		return false, nil, nil
+	})
+
+	opts := AutoTuneScanOptions(ClusterSize{}).WithOverrides(ScanOptions{Collectors: []string{"debug"}, ShardWorkers: 2})
+	report, err := runScan(&scanState{ctx: t.Context(), clientset: clientset, dynamic: dynamicClient, opts: opts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) != 0 {
+		t.Errorf("Errors = %v, want the section collected from the other namespaces", report.Errors)
+	}
+	if _, ok := report.NamespaceErrors["locked"]; !ok || len(report.NamespaceErrors) != 1 {
+		t.Errorf("NamespaceErrors = %v, want locked", report.NamespaceErrors)
+	}
+}
@@ -0,0 +1,195 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Labels the legacy token tracking and cleanup controllers (Kubernetes 1.26+) set on
+// ServiceAccount token Secrets.
+const (
+	legacyTokenLastUsedLabel      = "kubernetes.io/legacy-token-last-used"
+	legacyTokenInvalidSinceLabel  = "kubernetes.io/legacy-token-invalid-since"
+	serviceAccountTokenSecretType = "kubernetes.io/service-account-token"
+)
+
+// Whether pods get their API tokens from projected, bound ServiceAccount token volumes.
+const (
+	BoundTokensActive   = "active"
+	BoundTokensInactive = "inactive"
+	BoundTokensUnknown  = "unknown"
+)
+
+// TokenReport lists the long-lived ServiceAccount token Secrets in the cluster.
+type TokenReport struct {
+	// BoundTokenVolumes is active when running pods mount projected ServiceAccount tokens,
+	// inactive when they only mount token Secrets, and unknown when no pod mounts either.
+	BoundTokenVolumes string        `json:"boundTokenVolumes"`
+	Secrets           []TokenSecret `json:"secrets"`
+}
+
+// TokenSecret is a long-lived ServiceAccount token stored in a Secret.
+type TokenSecret struct {
+	Namespace      string    `json:"namespace"`
+	Name           string    `json:"name"`
+	ServiceAccount string    `json:"serviceAccount"`
+	Created        time.Time `json:"created"`
+	// LastUsed and InvalidSince are the dates recorded by the legacy token tracking controller, if any.
+	LastUsed     string `json:"lastUsed,omitempty"`
+	InvalidSince string `json:"invalidSince,omitempty"`
+	// MountedBy lists the pods, as namespace/name, that mount the Secret or read it into their environment.
+	MountedBy []string `json:"mountedBy,omitempty"`
+
+	secret *corev1.Secret
+}
+
+// Age returns how old the token was at now.
+func (t TokenSecret) Age(now time.Time) time.Duration {
+	return now.Sub(t.Created)
+}
+
+// GetTokenReport collects ServiceAccount token Secrets and the pods that use them.
+func GetTokenReport(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*TokenReport, error) {
+	secrets, err := listSecrets(ctx, clientset, opts, "", metav1.ListOptions{FieldSelector: "type=" + serviceAccountTokenSecretType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service account token secrets: %w", err)
+	}
+	pods, err := listPods(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return BuildTokenReport(secrets, pods), nil
+}
+
+// BuildTokenReport matches token Secrets with the pods that mount them. Secret data is never read.
+func BuildTokenReport(secrets []corev1.Secret, pods []corev1.Pod) *TokenReport {
+	report := &TokenReport{BoundTokenVolumes: BoundTokensUnknown}
+
+	mountedBy := map[string][]string{}
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, v := range pod.Spec.Volumes {
+			if v.Projected != nil {
+				for _, src := range v.Projected.Sources {
+					if src.ServiceAccountToken != nil {
+						report.BoundTokenVolumes = BoundTokensActive
+					}
+				}
+			}
+		}
+		for name := range podSecretRefs(pod) {
+			key := pod.Namespace + "/" + name
+			mountedBy[key] = append(mountedBy[key], pod.Namespace+"/"+pod.Name)
+		}
+	}
+
+	for i, s := range secrets {
+		if s.Type != serviceAccountTokenSecretType {
+			continue
+		}
+		mounted := mountedBy[s.Namespace+"/"+s.Name]
+		sort.Strings(mounted)
+		report.Secrets = append(report.Secrets, TokenSecret{
+			Namespace:      s.Namespace,
+			Name:           s.Name,
+			ServiceAccount: s.Annotations[corev1.ServiceAccountNameKey],
+			Created:        s.CreationTimestamp.Time,
+			LastUsed:       s.Labels[legacyTokenLastUsedLabel],
+			InvalidSince:   s.Labels[legacyTokenInvalidSinceLabel],
+			MountedBy:      mounted,
+			secret:         &secrets[i],
+		})
+		if len(mounted) > 0 && report.BoundTokenVolumes == BoundTokensUnknown {
+			report.BoundTokenVolumes = BoundTokensInactive
+		}
+	}
+	sort.Slice(report.Secrets, func(i, j int) bool { return report.Secrets[i].Created.Before(report.Secrets[j].Created) })
+	return report
+}
+
+// podSecretRefs returns the names of the Secrets a pod mounts as volumes or reads into its environment.
+func podSecretRefs(pod corev1.Pod) map[string]struct{} {
+	refs := map[string]struct{}{}
+	for _, v := range pod.Spec.Volumes {
+		if v.Secret != nil {
+			refs[v.Secret.SecretName] = struct{}{}
+		}
+		if v.Projected != nil {
+			for _, src := range v.Projected.Sources {
+				if src.Secret != nil {
+					refs[src.Secret.Name] = struct{}{}
+				}
+			}
+		}
+	}
+	for _, c := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		for _, e := range c.EnvFrom {
+			if e.SecretRef != nil {
+				refs[e.SecretRef.Name] = struct{}{}
+			}
+		}
+		for _, e := range c.Env {
+			if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil {
+				refs[e.ValueFrom.SecretKeyRef.Name] = struct{}{}
+			}
+		}
+	}
+	return refs
+}
+
+// CheckTokenSecrets raises a finding for every long-lived ServiceAccount token. Tokens that
+// running pods still use are medium severity, since rotating them needs a workload change;
+// unused ones are low and can usually just be deleted.
+func CheckTokenSecrets(report *TokenReport, now time.Time) []Finding {
+	var findings []Finding
+	for _, t := range report.Secrets {
+		severity := SeverityLow
+		usage := "not mounted by any pod"
+		if len(t.MountedBy) > 0 {
+			severity = SeverityMedium
+			usage = fmt.Sprintf("mounted by %d pod(s)", len(t.MountedBy))
+		}
+		findings = append(findings, Finding{
+			CheckID:   "legacy-sa-token",
+			Severity:  severity,
+			Kind:      "Secret",
+			Namespace: t.Namespace,
+			Name:      t.Name,
+			Message: fmt.Sprintf("long-lived token Secret %s/%s for ServiceAccount %s is %s old and %s",
+				t.Namespace, t.Name, t.ServiceAccount, formatAge(t.Age(now)), usage),
+			object: t.secret,
+		})
+	}
+	return findings
+}
+
+// PrintTokenReport writes the ServiceAccount token section of the text report.
+func PrintTokenReport(w io.Writer, report *TokenReport, now time.Time) {
+	fmt.Fprintf(w, "ServiceAccount tokens (bound token volumes: %s):\n", report.BoundTokenVolumes)
+	if len(report.Secrets) == 0 {
+		fmt.Fprintln(w, "  No long-lived token Secrets found.")
+	}
+	for _, t := range report.Secrets {
+		line := fmt.Sprintf("%s/%s (sa %s): %s old", t.Namespace, t.Name, t.ServiceAccount, formatAge(t.Age(now)))
+		if t.LastUsed != "" {
+			line += ", last used " + t.LastUsed
+		}
+		if t.InvalidSince != "" {
+			line += ", invalid since " + t.InvalidSince
+		}
+		if len(t.MountedBy) > 0 {
+			line += ", mounted by " + strings.Join(t.MountedBy, ", ")
+		}
+		fmt.Fprintf(w, "  - %s\n", line)
+	}
+}
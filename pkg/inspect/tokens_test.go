@@ -0,0 +1,85 @@
+package inspect
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildTokenReport(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tokenSecret := func(name string, created time.Time, labels map[string]string) corev1.Secret {
+		return corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ci", Name: name, CreationTimestamp: metav1.NewTime(created), Labels: labels,
+				Annotations: map[string]string{corev1.ServiceAccountNameKey: "deployer"},
+			},
+			Type: serviceAccountTokenSecretType,
+		}
+	}
+	secrets := []corev1.Secret{
+		tokenSecret("deployer-token-new", now.AddDate(0, 0, -10), nil),
+		tokenSecret("deployer-token-old", now.AddDate(-2, 0, 0), map[string]string{legacyTokenLastUsedLabel: "2025-05-30"}),
+	}
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "runner-1"},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{{Name: "token", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "deployer-token-old"}}}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "runner-2"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Env: []corev1.EnvVar{{
+				Name: "TOKEN", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "deployer-token-old"}, Key: "token",
+				}},
+			}}}}},
+		},
+		{
+			// Same Secret name in another namespace is a different Secret.
+			ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "app"},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{{Name: "token", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "deployer-token-new"}}}},
+			},
+		},
+	}
+
+	report := BuildTokenReport(secrets, pods)
+
+	if report.BoundTokenVolumes != BoundTokensInactive {
+		t.Errorf("BuildTokenReport() BoundTokenVolumes = %s, want %s", report.BoundTokenVolumes, BoundTokensInactive)
+	}
+	if len(report.Secrets) != 2 || report.Secrets[0].Name != "deployer-token-old" {
+		t.Fatalf("BuildTokenReport() secrets = %+v, want oldest first", report.Secrets)
+	}
+	old := report.Secrets[0]
+	if len(old.MountedBy) != 2 || old.LastUsed != "2025-05-30" || old.ServiceAccount != "deployer" {
+		t.Errorf("BuildTokenReport() old token = %+v, want 2 pods, last used 2025-05-30, sa deployer", old)
+	}
+	if len(report.Secrets[1].MountedBy) != 0 {
+		t.Errorf("BuildTokenReport() new token mounted by %v, want none", report.Secrets[1].MountedBy)
+	}
+
+	findings := CheckTokenSecrets(report, now)
+	if len(findings) != 2 || findings[0].Severity != SeverityMedium || findings[1].Severity != SeverityLow {
+		t.Errorf("CheckTokenSecrets() = %+v, want medium for the mounted token and low for the unused one", findings)
+	}
+}
+
+func TestBuildTokenReport_BoundTokenVolumes(t *testing.T) {
+	pods := []corev1.Pod{{Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+		Name: "kube-api-access-abcde",
+		VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+			{ServiceAccountToken: &corev1.ServiceAccountTokenProjection{Path: "token"}},
+		}}},
+	}}}}}
+	if got := BuildTokenReport(nil, pods).BoundTokenVolumes; got != BoundTokensActive {
+		t.Errorf("BuildTokenReport() BoundTokenVolumes = %s, want %s", got, BoundTokensActive)
+	}
+	if got := BuildTokenReport(nil, nil).BoundTokenVolumes; got != BoundTokensUnknown {
+		t.Errorf("BuildTokenReport() BoundTokenVolumes = %s, want %s", got, BoundTokensUnknown)
+	}
+}
@@ -0,0 +1,298 @@
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// proxyEnvVars are the environment variables that send a workload's egress through a proxy.
+var proxyEnvVars = []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"}
+
+// caEnvVars point TLS clients at a custom CA bundle, so a workload that sets one is assumed to
+// trust the proxy's CA.
+var caEnvVars = []string{"SSL_CERT_FILE", "SSL_CERT_DIR", "REQUESTS_CA_BUNDLE", "CURL_CA_BUNDLE", "NODE_EXTRA_CA_CERTS"}
+
+// TrustBundleReport is how custom CA bundles are distributed and which proxied workloads lack one.
+type TrustBundleReport struct {
+	Bundles []TrustBundle `json:"bundles"`
+	// ClusterTrustBundlesServed is false when the ClusterTrustBundle API is not enabled.
+	ClusterTrustBundlesServed bool `json:"clusterTrustBundlesServed"`
+	// ProxiedWorkloads counts the workloads with a proxy environment variable.
+	ProxiedWorkloads int `json:"proxiedWorkloads"`
+	// MissingCA are the proxied workloads that mount no CA bundle, when any bundle exists.
+	MissingCA []ProxiedWorkload `json:"missingCA,omitempty"`
+}
+
+// TrustBundle is a ConfigMap or ClusterTrustBundle carrying CA certificates.
+type TrustBundle struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Source is how the bundle was recognized: trust-manager, OpenShift CA injection, or PEM data.
+	Source       string `json:"source"`
+	Certificates int    `json:"certificates"`
+}
+
+// ProxiedWorkload is a workload whose egress goes through a proxy.
+type ProxiedWorkload struct {
+	WorkloadRef
+	// Proxy is the proxy URL with any credentials removed.
+	Proxy string `json:"proxy"`
+	// NamespaceBundles are the CA bundle ConfigMaps in the workload's namespace it does not mount.
+	NamespaceBundles []string `json:"namespaceBundles,omitempty"`
+
+	object any
+}
+
+type clusterTrustBundleList struct {
+	Items []struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+		Spec     struct {
+			SignerName  string `json:"signerName"`
+			TrustBundle string `json:"trustBundle"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// GetTrustBundleReport collects CA bundle ConfigMaps, ClusterTrustBundles, and the pod templates
+// of Deployments, StatefulSets, DaemonSets, and CronJobs.
+func GetTrustBundleReport(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*TrustBundleReport, error) {
+	configMaps, err := listConfigMaps(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configmaps: %w", err)
+	}
+	deployments, err := listDeployments(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	statefulSets, err := listStatefulSets(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	daemonSets, err := listDaemonSets(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	cronJobs, err := listCronJobs(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cronjobs: %w", err)
+	}
+
+	var clusterBundles []TrustBundle
+	served := false
+	for _, version := range []string{"v1beta1", "v1alpha1"} {
+		data, err := getRaw(ctx, clientset, "/apis/certificates.k8s.io/"+version+"/clustertrustbundles")
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list clustertrustbundles: %w", err)
+		}
+		var list clusterTrustBundleList
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("failed to parse clustertrustbundles: %w", err)
+		}
+		for _, b := range list.Items {
+			clusterBundles = append(clusterBundles, TrustBundle{
+				Kind: "ClusterTrustBundle", Name: b.Metadata.Name, Source: "signer " + b.Spec.SignerName,
+				Certificates: strings.Count(b.Spec.TrustBundle, "BEGIN CERTIFICATE"),
+			})
+		}
+		served = true
+		break
+	}
+
+	report := BuildTrustBundleReport(configMaps, clusterBundles, deployments, statefulSets, daemonSets, cronJobs)
+	report.ClusterTrustBundlesServed = served
+	return report, nil
+}
+
+// BuildTrustBundleReport finds the CA bundle ConfigMaps and the proxied workloads that mount
+// none. A proxy that intercepts TLS can't be told apart from one that tunnels it, so proxied
+// workloads are only reported when a custom CA bundle is distributed somewhere in the cluster.
+// A workload counts as having a CA when it mounts a bundle ConfigMap or a ClusterTrustBundle
+// projection, or sets a variable such as SSL_CERT_FILE.
+func BuildTrustBundleReport(configMaps []corev1.ConfigMap, clusterBundles []TrustBundle, deployments []appsv1.Deployment, statefulSets []appsv1.StatefulSet, daemonSets []appsv1.DaemonSet, cronJobs []batchv1.CronJob) *TrustBundleReport {
+	report := &TrustBundleReport{}
+	bundlesByNamespace := map[string][]string{}
+	for _, cm := range configMaps {
+		source, certificates := trustBundleSource(cm)
+		if source == "" {
+			continue
+		}
+		report.Bundles = append(report.Bundles, TrustBundle{
+			Kind: "ConfigMap", Namespace: cm.Namespace, Name: cm.Name, Source: source, Certificates: certificates,
+		})
+		bundlesByNamespace[cm.Namespace] = append(bundlesByNamespace[cm.Namespace], cm.Name)
+	}
+	report.Bundles = append(report.Bundles, clusterBundles...)
+
+	type template struct {
+		ref    WorkloadRef
+		spec   corev1.PodSpec
+		object any
+	}
+	var templates []template
+	for i, d := range deployments {
+		templates = append(templates, template{WorkloadRef{"Deployment", d.Namespace, d.Name}, d.Spec.Template.Spec, &deployments[i]})
+	}
+	for i, s := range statefulSets {
+		templates = append(templates, template{WorkloadRef{"StatefulSet", s.Namespace, s.Name}, s.Spec.Template.Spec, &statefulSets[i]})
+	}
+	for i, d := range daemonSets {
+		templates = append(templates, template{WorkloadRef{"DaemonSet", d.Namespace, d.Name}, d.Spec.Template.Spec, &daemonSets[i]})
+	}
+	for i, c := range cronJobs {
+		templates = append(templates, template{WorkloadRef{"CronJob", c.Namespace, c.Name}, c.Spec.JobTemplate.Spec.Template.Spec, &cronJobs[i]})
+	}
+
+	for _, t := range templates {
+		proxy := podProxy(t.spec)
+		if proxy == "" {
+			continue
+		}
+		report.ProxiedWorkloads++
+		if len(report.Bundles) == 0 || mountsCABundle(t.spec, bundlesByNamespace[t.ref.Namespace]) {
+			continue
+		}
+		report.MissingCA = append(report.MissingCA, ProxiedWorkload{
+			WorkloadRef: t.ref, Proxy: proxy, NamespaceBundles: bundlesByNamespace[t.ref.Namespace], object: t.object,
+		})
+	}
+	sort.Slice(report.MissingCA, func(i, j int) bool { return report.MissingCA[i].String() < report.MissingCA[j].String() })
+	return report
+}
+
+// trustBundleSource reports whether a ConfigMap carries CA certificates, how it was recognized,
+// and how many certificates it holds. kube-root-ca.crt, which every namespace gets with the
+// cluster's own CA, is not a custom bundle.
+func trustBundleSource(cm corev1.ConfigMap) (string, int) {
+	if cm.Name == "kube-root-ca.crt" {
+		return "", 0
+	}
+	var certificates int
+	for _, v := range cm.Data {
+		certificates += strings.Count(v, "BEGIN CERTIFICATE")
+	}
+	switch {
+	case cm.Labels["trust.cert-manager.io/bundle"] != "" || cm.Annotations["trust.cert-manager.io/hash"] != "":
+		return "trust-manager", certificates
+	case cm.Labels["config.openshift.io/inject-trusted-cabundle"] == "true":
+		return "OpenShift CA injection", certificates
+	case certificates > 0:
+		return "PEM data", certificates
+	}
+	return "", 0
+}
+
+// podProxy returns the proxy the first container sending traffic through one uses, with
+// credentials removed.
+func podProxy(spec corev1.PodSpec) string {
+	for _, c := range append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...) {
+		for _, name := range proxyEnvVars {
+			for _, env := range c.Env {
+				if env.Name != name {
+					continue
+				}
+				if env.ValueFrom != nil {
+					return name + " (from a reference)"
+				}
+				if env.Value != "" {
+					return redactProxyURL(env.Value)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func redactProxyURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		if _, host, ok := strings.Cut(value, "@"); ok {
+			return host
+		}
+		return value
+	}
+	u.User = nil
+	return u.String()
+}
+
+// mountsCABundle reports whether a pod template mounts one of the namespace's bundle ConfigMaps
+// or a ClusterTrustBundle, or points TLS clients at a custom bundle through the environment.
+func mountsCABundle(spec corev1.PodSpec, bundles []string) bool {
+	for _, v := range spec.Volumes {
+		if v.ConfigMap != nil && contains(bundles, v.ConfigMap.Name) {
+			return true
+		}
+		if v.Projected == nil {
+			continue
+		}
+		for _, s := range v.Projected.Sources {
+			if s.ClusterTrustBundle != nil || (s.ConfigMap != nil && contains(bundles, s.ConfigMap.Name)) {
+				return true
+			}
+		}
+	}
+	for _, c := range spec.Containers {
+		for _, env := range c.Env {
+			if contains(caEnvVars, env.Name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckTrustBundles raises a finding for every proxied workload that mounts no CA bundle.
+func CheckTrustBundles(report *TrustBundleReport) []Finding {
+	var findings []Finding
+	for _, w := range report.MissingCA {
+		message := fmt.Sprintf("%s sends traffic through proxy %s but mounts no CA bundle", w.WorkloadRef, w.Proxy)
+		if len(w.NamespaceBundles) > 0 {
+			message += fmt.Sprintf("; namespace %s has %s", w.Namespace, strings.Join(w.NamespaceBundles, ", "))
+		} else {
+			message += fmt.Sprintf("; namespace %s has no CA bundle, while %d bundle(s) exist elsewhere", w.Namespace, len(report.Bundles))
+		}
+		findings = append(findings, Finding{
+			CheckID:   "proxy-missing-ca-bundle",
+			Severity:  SeverityMedium,
+			Kind:      w.Kind,
+			Namespace: w.Namespace,
+			Name:      w.Name,
+			Message:   message,
+			object:    w.object,
+		})
+	}
+	return findings
+}
+
+// PrintTrustBundleReport writes the trust bundle section of the text report.
+func PrintTrustBundleReport(w io.Writer, report *TrustBundleReport) {
+	fmt.Fprintf(w, "CA trust bundles (%d proxied workload(s)):\n", report.ProxiedWorkloads)
+	if len(report.Bundles) == 0 {
+		fmt.Fprintln(w, "  No custom CA bundles found.")
+	}
+	for _, b := range report.Bundles {
+		name := b.Name
+		if b.Namespace != "" {
+			name = b.Namespace + "/" + b.Name
+		}
+		fmt.Fprintf(w, "  - %s %s: %d certificate(s) (%s)\n", b.Kind, name, b.Certificates, b.Source)
+	}
+	if len(report.MissingCA) > 0 {
+		fmt.Fprintf(w, "  %d proxied workload(s) mount no CA bundle\n", len(report.MissingCA))
+	}
+}
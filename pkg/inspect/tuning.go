@@ -0,0 +1,193 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ScanOptions controls how hard a scan leans on the API server.
+type ScanOptions struct {
+	// PageSize is the number of objects requested per list call.
+	PageSize int64
+	// Concurrency is the number of collectors run in parallel.
+	Concurrency int
+	// Timeout bounds each individual request to the API server.
+	Timeout time.Duration
+	// ShardWorkers, when set, makes a scan list namespaced objects one namespace at a time with
+	// this many namespaces in flight, instead of one cluster-wide list per resource. On clusters
+	// with thousands of namespaces the smaller lists finish sooner, and a namespace whose list
+	// fails only drops that namespace.
+	ShardWorkers int
+	// Progress, when set, receives progress lines from sharded lists.
+	Progress io.Writer
+	// WithRaw embeds the sanitized JSON of every flagged object in the findings.
+	WithRaw bool
+	// EtcdDeep execs etcdctl in an etcd pod to collect member, leader, DB size, and alarm status.
+	EtcdDeep bool
+	// CheckCerts connects to the API server, etcd, and kubelet ports to check certificate expiry.
+	CheckCerts bool
+	// ResolveHostnames looks up load balancer and Ingress hostnames to classify them as public or private.
+	ResolveHostnames bool
+	// CertWarningDays is how close to expiry a certificate must be to raise a finding.
+	CertWarningDays int
+	// KubeadmCertificateKeyFile holds the hex key kubeadm init --upload-certs encrypted the
+	// kubeadm-certs Secret with, so the API server's etcd client certificate can be read from it.
+	KubeadmCertificateKeyFile string
+	// Probe controls active reachability checks of exposed endpoints.
+	Probe ProbeOptions
+	// DebugMaxAge is how long ephemeral containers and debug pods may run before they are flagged.
+	DebugMaxAge time.Duration
+	// Collectors selects the collectors to run; empty runs them all.
+	Collectors []string
+	// SkipCollectors are left out of the scan.
+	SkipCollectors []string
+	// ClusterName is the kubeconfig cluster name recorded in the report's cluster identity.
+	ClusterName string
+	// AuditLog, when set, names the users who created and last modified exposed endpoints.
+	AuditLog *AuditLog
+	// DNS enables the check of claimed hostnames against zone exports or the resolver.
+	DNS DNSOptions
+	// Prices, when set, enables the monthly cost estimate.
+	Prices *PriceTable
+	// Policy, when set, moves the findings it accepts out of the report's findings.
+	Policy *Policy
+	// Plugins controls the kube-op-<name> exec plugins run after the collectors.
+	Plugins PluginOptions
+
+	// shards is set by RunScan when ShardWorkers is, for the list helpers to share.
+	shards *namespaceShards
+}
+
+// ClusterSize holds the object counts used to pick scan options.
+type ClusterSize struct {
+	Nodes      int
+	Namespaces int
+}
+
+// GetClusterSize counts nodes and namespaces without listing them in full.
+// Each list is limited to a single item and the total is taken from the
+// remaining item count the API server reports alongside the continue token.
+func GetClusterSize(ctx context.Context, clientset kubernetes.Interface) (ClusterSize, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return ClusterSize{}, fmt.Errorf("failed to count nodes: %w", err)
+	}
+
+	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return ClusterSize{}, fmt.Errorf("failed to count namespaces: %w", err)
+	}
+
+	return ClusterSize{
+		Nodes:      len(nodes.Items) + remainingItems(nodes.RemainingItemCount),
+		Namespaces: len(namespaces.Items) + remainingItems(namespaces.RemainingItemCount),
+	}, nil
+}
+
+func remainingItems(count *int64) int {
+	if count == nil {
+		return 0
+	}
+	return int(*count)
+}
+
+// AutoTuneScanOptions picks scan options for a cluster of the given size.
+// Small clusters get wide parallelism and short timeouts; large clusters get
+// smaller pages, fewer concurrent lists, and more patience per request so a
+// scan doesn't add noticeable load to a busy control plane.
+func AutoTuneScanOptions(size ClusterSize) ScanOptions {
+	switch {
+	case size.Nodes <= 50 && size.Namespaces <= 200:
+		return ScanOptions{PageSize: 500, Concurrency: 8, Timeout: 30 * time.Second}
+	case size.Nodes <= 500 && size.Namespaces <= 2000:
+		return ScanOptions{PageSize: 500, Concurrency: 4, Timeout: 60 * time.Second}
+	default:
+		return ScanOptions{PageSize: 250, Concurrency: 2, Timeout: 2 * time.Minute}
+	}
+}
+
+// WithOverrides returns a copy of o where every non-zero field of override replaces the tuned value
+// and every feature enabled in override is enabled.
+func (o ScanOptions) WithOverrides(override ScanOptions) ScanOptions {
+	if override.PageSize > 0 {
+		o.PageSize = override.PageSize
+	}
+	if override.Concurrency > 0 {
+		o.Concurrency = override.Concurrency
+	}
+	if override.Timeout > 0 {
+		o.Timeout = override.Timeout
+	}
+	if override.ShardWorkers > 0 {
+		o.ShardWorkers = override.ShardWorkers
+	}
+	if override.Progress != nil {
+		o.Progress = override.Progress
+	}
+	o.WithRaw = o.WithRaw || override.WithRaw
+	o.EtcdDeep = o.EtcdDeep || override.EtcdDeep
+	o.CheckCerts = o.CheckCerts || override.CheckCerts
+	o.ResolveHostnames = o.ResolveHostnames || override.ResolveHostnames
+	if override.CertWarningDays > 0 {
+		o.CertWarningDays = override.CertWarningDays
+	}
+	if override.KubeadmCertificateKeyFile != "" {
+		o.KubeadmCertificateKeyFile = override.KubeadmCertificateKeyFile
+	}
+	if override.Probe.Enabled {
+		o.Probe = override.Probe
+	}
+	if override.DebugMaxAge > 0 {
+		o.DebugMaxAge = override.DebugMaxAge
+	}
+	if len(override.Collectors) > 0 {
+		o.Collectors = override.Collectors
+	}
+	if len(override.SkipCollectors) > 0 {
+		o.SkipCollectors = override.SkipCollectors
+	}
+	if override.ClusterName != "" {
+		o.ClusterName = override.ClusterName
+	}
+	if override.AuditLog != nil {
+		o.AuditLog = override.AuditLog
+	}
+	if override.DNS.Enabled() {
+		o.DNS = override.DNS
+	}
+	if override.Prices != nil {
+		o.Prices = override.Prices
+	}
+	if override.Policy != nil {
+		o.Policy = override.Policy
+	}
+	if override.Plugins.Enabled {
+		o.Plugins = override.Plugins
+	}
+	return o
+}
+
+// runConcurrently runs tasks with at most limit of them in flight and waits for all to finish.
+func runConcurrently(limit int, tasks ...func()) {
+	if limit < 1 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(task func()) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			task()
+		}(task)
+	}
+	wg.Wait()
+}
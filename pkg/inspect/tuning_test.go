@@ -0,0 +1,111 @@
+package inspect
+
+import (
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAutoTuneScanOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		size ClusterSize
+		want ScanOptions
+	}{
+		{"unknown size", ClusterSize{}, ScanOptions{PageSize: 500, Concurrency: 8, Timeout: 30 * time.Second}},
+		{"lab cluster", ClusterSize{Nodes: 3, Namespaces: 12}, ScanOptions{PageSize: 500, Concurrency: 8, Timeout: 30 * time.Second}},
+		{"many namespaces", ClusterSize{Nodes: 10, Namespaces: 800}, ScanOptions{PageSize: 500, Concurrency: 4, Timeout: 60 * time.Second}},
+		{"large cluster", ClusterSize{Nodes: 5000, Namespaces: 300}, ScanOptions{PageSize: 250, Concurrency: 2, Timeout: 2 * time.Minute}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AutoTuneScanOptions(tt.size); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AutoTuneScanOptions(%+v) = %+v, want %+v", tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanOptions_WithOverrides(t *testing.T) {
+	tuned := ScanOptions{PageSize: 500, Concurrency: 8, Timeout: 30 * time.Second}
+
+	if got := tuned.WithOverrides(ScanOptions{}); !reflect.DeepEqual(got, tuned) {
+		t.Errorf("WithOverrides(zero) = %+v, want %+v", got, tuned)
+	}
+
+	got := tuned.WithOverrides(ScanOptions{Concurrency: 1, Timeout: time.Minute})
+	want := ScanOptions{PageSize: 500, Concurrency: 1, Timeout: time.Minute}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WithOverrides() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRunConcurrently_RespectsLimit(t *testing.T) {
+	var inFlight, maxInFlight, ran int32
+	task := func() {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		atomic.AddInt32(&ran, 1)
+	}
+
+	runConcurrently(2, task, task, task, task, task)
+
+	if ran != 5 {
+		t.Errorf("runConcurrently() ran %d tasks, want 5", ran)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("runConcurrently() had %d tasks in flight, want at most 2", maxInFlight)
+	}
+}
+
+func TestListPaged_FollowsContinueTokens(t *testing.T) {
+	pages := map[string]struct {
+		items []int
+		next  string
+	}{
+		"":  {[]int{1, 2}, "a"},
+		"a": {[]int{3, 4}, "b"},
+		"b": {[]int{5}, ""},
+	}
+
+	var limits []int64
+	items, err := listPaged(2, metav1.ListOptions{}, func(o metav1.ListOptions) ([]int, string, error) {
+		limits = append(limits, o.Limit)
+		p := pages[o.Continue]
+		return p.items, p.next, nil
+	})
+	if err != nil {
+		t.Fatalf("listPaged() returned error = %v, want nil", err)
+	}
+	if len(items) != 5 {
+		t.Errorf("listPaged() returned %d items, want 5", len(items))
+	}
+	for _, l := range limits {
+		if l != 2 {
+			t.Errorf("listPaged() requested limit %d, want 2", l)
+		}
+	}
+}
+
+func TestListPaged_ReturnsError(t *testing.T) {
+	items, err := listPaged(10, metav1.ListOptions{}, func(metav1.ListOptions) ([]int, string, error) {
+		return nil, "", errors.New("boom")
+	})
+	if err == nil {
+		t.Errorf("listPaged() returned error = nil, want non-nil")
+	}
+	if items != nil {
+		t.Errorf("listPaged() returned items = %v, want nil", items)
+	}
+}
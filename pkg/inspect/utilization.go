@@ -0,0 +1,299 @@
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Sources the utilization figures can come from.
+const (
+	UsageSourceMetricsServer  = "metrics-server"
+	UsageSourceKubeletSummary = "kubelet-summary"
+)
+
+// topPodsShown is how many of the heaviest pods the text report lists per resource.
+const topPodsShown = 5
+
+// Utilization is the current CPU and memory usage of nodes and pods.
+type Utilization struct {
+	// Source is metrics-server, or kubelet-summary when metrics-server is unavailable.
+	Source string `json:"source"`
+	// FallbackReason explains why metrics-server was not used.
+	FallbackReason string      `json:"fallbackReason,omitempty"`
+	Nodes          []NodeUsage `json:"nodes"`
+	Pods           []PodUsage  `json:"pods"`
+}
+
+// NodeUsage is one node's usage next to its allocatable capacity.
+type NodeUsage struct {
+	Name                   string `json:"name"`
+	CPUMillis              int64  `json:"cpuMillis"`
+	MemoryBytes            int64  `json:"memoryBytes"`
+	CPUAllocatableMillis   int64  `json:"cpuAllocatableMillis"`
+	MemoryAllocatableBytes int64  `json:"memoryAllocatableBytes"`
+}
+
+// PodUsage is one pod's usage summed over its containers.
+type PodUsage struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	CPUMillis   int64  `json:"cpuMillis"`
+	MemoryBytes int64  `json:"memoryBytes"`
+	Owner       *Owner `json:"owner,omitempty"`
+}
+
+type metricsUsage struct {
+	CPU    string `json:"cpu"`
+	Memory string `json:"memory"`
+}
+
+type metricsNodeList struct {
+	Items []struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+		Usage    metricsUsage      `json:"usage"`
+	} `json:"items"`
+}
+
+type metricsPodList struct {
+	Items []struct {
+		Metadata   metav1.ObjectMeta `json:"metadata"`
+		Containers []struct {
+			Usage metricsUsage `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+type statsSummary struct {
+	Node struct {
+		CPU    statsCPU    `json:"cpu"`
+		Memory statsMemory `json:"memory"`
+	} `json:"node"`
+	Pods []struct {
+		PodRef struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"podRef"`
+		CPU    statsCPU    `json:"cpu"`
+		Memory statsMemory `json:"memory"`
+	} `json:"pods"`
+}
+
+type statsCPU struct {
+	UsageNanoCores *uint64 `json:"usageNanoCores"`
+}
+
+type statsMemory struct {
+	WorkingSetBytes *uint64 `json:"workingSetBytes"`
+}
+
+// GetUtilization reads node and pod usage from metrics-server. When the metrics API is not
+// served, it falls back to each node's kubelet /stats/summary through the API server's node
+// proxy, which needs get on nodes/proxy but works on clusters without metrics-server.
+func GetUtilization(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*Utilization, error) {
+	nodes, err := listNodes(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	usage, metricsErr := getMetricsServerUsage(ctx, clientset)
+	if metricsErr != nil {
+		names := make([]string, len(nodes))
+		for i, node := range nodes {
+			names[i] = node.Name
+		}
+		usage, err = getKubeletSummaryUsage(ctx, clientset, opts, names)
+		if err != nil {
+			return nil, fmt.Errorf("metrics-server unavailable (%v) and kubelet summary fallback failed: %w", metricsErr, err)
+		}
+		usage.FallbackReason = metricsErr.Error()
+	}
+
+	byName := make(map[string]int, len(usage.Nodes))
+	for i, n := range usage.Nodes {
+		byName[n.Name] = i
+	}
+	for _, node := range nodes {
+		i, ok := byName[node.Name]
+		if !ok {
+			continue
+		}
+		usage.Nodes[i].CPUAllocatableMillis = node.Status.Allocatable.Cpu().MilliValue()
+		usage.Nodes[i].MemoryAllocatableBytes = node.Status.Allocatable.Memory().Value()
+	}
+
+	sort.Slice(usage.Nodes, func(i, j int) bool { return usage.Nodes[i].Name < usage.Nodes[j].Name })
+	return usage, nil
+}
+
+func getMetricsServerUsage(ctx context.Context, clientset kubernetes.Interface) (*Utilization, error) {
+	nodeData, err := getRaw(ctx, clientset, "/apis/metrics.k8s.io/v1beta1/nodes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node metrics: %w", err)
+	}
+	podData, err := getRaw(ctx, clientset, "/apis/metrics.k8s.io/v1beta1/pods")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod metrics: %w", err)
+	}
+	return ParseMetricsServerUsage(nodeData, podData)
+}
+
+// ParseMetricsServerUsage parses NodeMetricsList and PodMetricsList responses from the metrics API.
+func ParseMetricsServerUsage(nodeData, podData []byte) (*Utilization, error) {
+	var nodeList metricsNodeList
+	if err := json.Unmarshal(nodeData, &nodeList); err != nil {
+		return nil, fmt.Errorf("failed to parse node metrics: %w", err)
+	}
+	var podList metricsPodList
+	if err := json.Unmarshal(podData, &podList); err != nil {
+		return nil, fmt.Errorf("failed to parse pod metrics: %w", err)
+	}
+
+	usage := &Utilization{Source: UsageSourceMetricsServer}
+	for _, item := range nodeList.Items {
+		cpu, memory, err := parseMetricsUsage(item.Usage)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", item.Metadata.Name, err)
+		}
+		usage.Nodes = append(usage.Nodes, NodeUsage{Name: item.Metadata.Name, CPUMillis: cpu, MemoryBytes: memory})
+	}
+	for _, item := range podList.Items {
+		pod := PodUsage{Namespace: item.Metadata.Namespace, Name: item.Metadata.Name}
+		for _, c := range item.Containers {
+			cpu, memory, err := parseMetricsUsage(c.Usage)
+			if err != nil {
+				return nil, fmt.Errorf("pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+			pod.CPUMillis += cpu
+			pod.MemoryBytes += memory
+		}
+		usage.Pods = append(usage.Pods, pod)
+	}
+	return usage, nil
+}
+
+func parseMetricsUsage(u metricsUsage) (cpuMillis, memoryBytes int64, err error) {
+	cpu, err := resource.ParseQuantity(u.CPU)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid cpu usage %q: %w", u.CPU, err)
+	}
+	memory, err := resource.ParseQuantity(u.Memory)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid memory usage %q: %w", u.Memory, err)
+	}
+	return cpu.MilliValue(), memory.Value(), nil
+}
+
+func getKubeletSummaryUsage(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions, nodes []string) (*Utilization, error) {
+	client, err := coreRESTClient(clientset)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([][]byte, len(nodes))
+	errs := make([]error, len(nodes))
+	tasks := make([]func(), len(nodes))
+	for i, name := range nodes {
+		tasks[i] = func() {
+			summaries[i], errs[i] = client.Get().
+				Resource("nodes").
+				Name(name).
+				SubResource("proxy", "stats", "summary").
+				DoRaw(ctx)
+		}
+	}
+	runConcurrently(opts.Concurrency, tasks...)
+
+	usage := &Utilization{Source: UsageSourceKubeletSummary}
+	for i, name := range nodes {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("failed to get stats summary for node %s: %w", name, errs[i])
+		}
+		if err := usage.addStatsSummary(name, summaries[i]); err != nil {
+			return nil, err
+		}
+	}
+	return usage, nil
+}
+
+// addStatsSummary adds the node and pod usage from one kubelet /stats/summary response.
+func (u *Utilization) addStatsSummary(node string, data []byte) error {
+	var summary statsSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return fmt.Errorf("failed to parse stats summary for node %s: %w", node, err)
+	}
+
+	u.Nodes = append(u.Nodes, NodeUsage{
+		Name:        node,
+		CPUMillis:   nanoCoresToMillis(summary.Node.CPU.UsageNanoCores),
+		MemoryBytes: int64(derefUint64(summary.Node.Memory.WorkingSetBytes)),
+	})
+	for _, p := range summary.Pods {
+		u.Pods = append(u.Pods, PodUsage{
+			Namespace:   p.PodRef.Namespace,
+			Name:        p.PodRef.Name,
+			CPUMillis:   nanoCoresToMillis(p.CPU.UsageNanoCores),
+			MemoryBytes: int64(derefUint64(p.Memory.WorkingSetBytes)),
+		})
+	}
+	return nil
+}
+
+func nanoCoresToMillis(nanoCores *uint64) int64 {
+	return int64(derefUint64(nanoCores) / 1_000_000)
+}
+
+func derefUint64(v *uint64) uint64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// TopPods returns up to n pods with the highest value of the given measure.
+func (u *Utilization) TopPods(n int, measure func(PodUsage) int64) []PodUsage {
+	pods := append([]PodUsage(nil), u.Pods...)
+	sort.SliceStable(pods, func(i, j int) bool { return measure(pods[i]) > measure(pods[j]) })
+	if len(pods) > n {
+		pods = pods[:n]
+	}
+	return pods
+}
+
+// PrintUtilization writes the utilization section of the text report.
+func PrintUtilization(w io.Writer, u *Utilization) {
+	fmt.Fprintf(w, "Resource utilization (source: %s):\n", u.Source)
+	if u.FallbackReason != "" {
+		fmt.Fprintf(w, "  metrics-server unavailable: %s\n", u.FallbackReason)
+	}
+	for _, n := range u.Nodes {
+		fmt.Fprintf(w, "  - node %s\n", n)
+	}
+	fmt.Fprintln(w, "  Top pods by CPU:")
+	for _, p := range u.TopPods(topPodsShown, func(p PodUsage) int64 { return p.CPUMillis }) {
+		fmt.Fprintf(w, "    - %s: %dm\n", describePod(p.Namespace, p.Name, p.Owner), p.CPUMillis)
+	}
+	fmt.Fprintln(w, "  Top pods by memory:")
+	for _, p := range u.TopPods(topPodsShown, func(p PodUsage) int64 { return p.MemoryBytes }) {
+		fmt.Fprintf(w, "    - %s: %s\n", describePod(p.Namespace, p.Name, p.Owner), formatBytes(p.MemoryBytes))
+	}
+}
+
+// String summarizes the node's usage against its allocatable CPU and memory.
+func (n NodeUsage) String() string {
+	return fmt.Sprintf("%s: CPU %dm / %dm (%s), memory %s / %s (%s)",
+		n.Name, n.CPUMillis, n.CPUAllocatableMillis, percent(n.CPUMillis, n.CPUAllocatableMillis),
+		formatBytes(n.MemoryBytes), formatBytes(n.MemoryAllocatableBytes), percent(n.MemoryBytes, n.MemoryAllocatableBytes))
+}
+
+func percent(used, total int64) string {
+	if total == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.0f%%", 100*float64(used)/float64(total))
+}
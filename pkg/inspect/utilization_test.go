@@ -0,0 +1,63 @@
+package inspect
+
+import "testing"
+
+const (
+	metricsNodeListJSON = `{"kind":"NodeMetricsList","items":[
+		{"metadata":{"name":"n1"},"usage":{"cpu":"250m","memory":"1Gi"}},
+		{"metadata":{"name":"n2"},"usage":{"cpu":"1500000000n","memory":"524288Ki"}}]}`
+	metricsPodListJSON = `{"kind":"PodMetricsList","items":[
+		{"metadata":{"name":"api","namespace":"web"},"containers":[
+			{"name":"app","usage":{"cpu":"100m","memory":"200Mi"}},
+			{"name":"sidecar","usage":{"cpu":"5m","memory":"20Mi"}}]}]}`
+	statsSummaryJSON = `{"node":{"nodeName":"n1","cpu":{"usageNanoCores":350000000},"memory":{"workingSetBytes":2147483648}},
+		"pods":[{"podRef":{"name":"api","namespace":"web"},"cpu":{"usageNanoCores":120000000},"memory":{"workingSetBytes":104857600}},
+		        {"podRef":{"name":"starting","namespace":"web"},"cpu":{},"memory":{}}]}`
+)
+
+func TestParseMetricsServerUsage(t *testing.T) {
+	usage, err := ParseMetricsServerUsage([]byte(metricsNodeListJSON), []byte(metricsPodListJSON))
+	if err != nil {
+		t.Fatalf("ParseMetricsServerUsage() returned error = %v, want nil", err)
+	}
+
+	if len(usage.Nodes) != 2 || usage.Nodes[0].CPUMillis != 250 || usage.Nodes[1].CPUMillis != 1500 {
+		t.Errorf("ParseMetricsServerUsage() nodes = %+v, want n1 at 250m and n2 at 1500m", usage.Nodes)
+	}
+	if usage.Nodes[1].MemoryBytes != 512*1024*1024 {
+		t.Errorf("ParseMetricsServerUsage() n2 memory = %d, want 512Mi", usage.Nodes[1].MemoryBytes)
+	}
+	if len(usage.Pods) != 1 || usage.Pods[0].CPUMillis != 105 || usage.Pods[0].MemoryBytes != 220*1024*1024 {
+		t.Errorf("ParseMetricsServerUsage() pods = %+v, want web/api summed to 105m and 220Mi", usage.Pods)
+	}
+}
+
+func TestParseMetricsServerUsage_InvalidQuantity(t *testing.T) {
+	nodes := `{"items":[{"metadata":{"name":"n1"},"usage":{"cpu":"lots","memory":"1Gi"}}]}`
+	if _, err := ParseMetricsServerUsage([]byte(nodes), []byte(`{"items":[]}`)); err == nil {
+		t.Errorf("ParseMetricsServerUsage() with invalid quantity returned error = nil, want non-nil")
+	}
+}
+
+func TestUtilization_AddStatsSummary(t *testing.T) {
+	usage := &Utilization{Source: UsageSourceKubeletSummary}
+	if err := usage.addStatsSummary("n1", []byte(statsSummaryJSON)); err != nil {
+		t.Fatalf("addStatsSummary() returned error = %v, want nil", err)
+	}
+
+	if len(usage.Nodes) != 1 || usage.Nodes[0].CPUMillis != 350 || usage.Nodes[0].MemoryBytes != 2147483648 {
+		t.Errorf("addStatsSummary() nodes = %+v, want n1 at 350m and 2Gi", usage.Nodes)
+	}
+	if len(usage.Pods) != 2 || usage.Pods[0].CPUMillis != 120 || usage.Pods[1].CPUMillis != 0 {
+		t.Errorf("addStatsSummary() pods = %+v, want api at 120m and starting at 0", usage.Pods)
+	}
+}
+
+func TestUtilization_TopPods(t *testing.T) {
+	usage := &Utilization{Pods: []PodUsage{{Name: "a", CPUMillis: 10}, {Name: "b", CPUMillis: 30}, {Name: "c", CPUMillis: 20}}}
+
+	top := usage.TopPods(2, func(p PodUsage) int64 { return p.CPUMillis })
+	if len(top) != 2 || top[0].Name != "b" || top[1].Name != "c" {
+		t.Errorf("TopPods(2) = %+v, want b then c", top)
+	}
+}
@@ -0,0 +1,55 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// GetKubernetesAPIServerVersion retrieves the server version from the Kubernetes cluster.
+func GetKubernetesAPIServerVersion(clientset kubernetes.Interface) (string, error) {
+	serverVersion, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return "", fmt.Errorf("failed to get server version: %w", err)
+	}
+	return serverVersion.GitVersion, nil
+}
+
+// GetEtcdVersion retrieves the etcd version by inspecting etcd pods in kube-system.
+func GetEtcdVersion(ctx context.Context, clientset kubernetes.Interface) (string, error) {
+	pods, err := clientset.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{
+		LabelSelector: "component=etcd",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list etcd pods: %w", err)
+	}
+
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no etcd pods found in kube-system namespace")
+	}
+
+	// Assume all etcd pods run the same version, take the first one.
+	etcdPod := pods.Items[0]
+	for _, container := range etcdPod.Spec.Containers {
+		// The etcd container might not always be named 'etcd'.
+		// A common convention is that it's the main container or simply named 'etcd'.
+		// We check if the image name contains 'etcd'. This is a heuristic.
+		if strings.Contains(container.Image, "etcd") {
+			imageParts := strings.Split(container.Image, ":")
+			if len(imageParts) > 1 {
+				// The part after the last colon is typically the tag/version.
+				// For images like k8s.gcr.io/etcd:3.5.1-0 or similar.
+				versionPart := imageParts[len(imageParts)-1]
+				// Further stripping might be needed if there are build suffixes, e.g., "3.5.1-0"
+				// For simplicity, we return the full tag here.
+				return versionPart, nil
+			}
+			return "", fmt.Errorf("etcd container image '%s' does not have a discernible version tag", container.Image)
+		}
+	}
+
+	return "", fmt.Errorf("could not find etcd container in pod %s", etcdPod.Name)
+}
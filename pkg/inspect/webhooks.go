@@ -0,0 +1,190 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// webhookTimeoutWarningSeconds is the timeout above which a webhook can noticeably stall API
+// requests; the API server default is 10 seconds and the maximum 30.
+const webhookTimeoutWarningSeconds = 15
+
+// admissionWebhook is the part of a validating or mutating webhook the audit looks at.
+type admissionWebhook struct {
+	configKind        string
+	configName        string
+	config            any
+	name              string
+	failurePolicy     admissionregistrationv1.FailurePolicyType
+	timeoutSeconds    int32
+	namespaceSelector *metav1.LabelSelector
+	objectSelector    *metav1.LabelSelector
+	rules             []admissionregistrationv1.RuleWithOperations
+	service           *admissionregistrationv1.ServiceReference
+}
+
+// GetWebhookFindings flags admission webhooks that can take down the API server's write path.
+func GetWebhookFindings(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) ([]Finding, error) {
+	validating, err := listValidatingWebhookConfigurations(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list validatingwebhookconfigurations: %w", err)
+	}
+	mutating, err := listMutatingWebhookConfigurations(ctx, clientset, opts, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mutatingwebhookconfigurations: %w", err)
+	}
+	webhooks := admissionWebhooks(validating, mutating)
+
+	readyEndpoints := map[string]int{}
+	for _, w := range webhooks {
+		if w.service == nil {
+			continue
+		}
+		key := w.service.Namespace + "/" + w.service.Name
+		if _, ok := readyEndpoints[key]; ok {
+			continue
+		}
+		_, err := clientset.CoreV1().Services(w.service.Namespace).Get(ctx, w.service.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get webhook service %s: %w", key, err)
+		}
+		slices, err := listEndpointSlices(ctx, clientset, opts, w.service.Namespace, metav1.ListOptions{
+			LabelSelector: discoveryv1.LabelServiceName + "=" + w.service.Name,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list endpointslices of webhook service %s: %w", key, err)
+		}
+		readyEndpoints[key] = countReadyEndpoints(slices)
+	}
+
+	return CheckWebhooks(webhooks, readyEndpoints), nil
+}
+
+// admissionWebhooks flattens the webhooks of validating and mutating configurations.
+func admissionWebhooks(validating []admissionregistrationv1.ValidatingWebhookConfiguration, mutating []admissionregistrationv1.MutatingWebhookConfiguration) []admissionWebhook {
+	var webhooks []admissionWebhook
+	for i, c := range validating {
+		for _, w := range c.Webhooks {
+			webhooks = append(webhooks, admissionWebhook{
+				configKind: "ValidatingWebhookConfiguration", configName: c.Name, config: &validating[i], name: w.Name,
+				failurePolicy: failurePolicyOrDefault(w.FailurePolicy), timeoutSeconds: timeoutOrDefault(w.TimeoutSeconds),
+				namespaceSelector: w.NamespaceSelector, objectSelector: w.ObjectSelector, rules: w.Rules, service: w.ClientConfig.Service,
+			})
+		}
+	}
+	for i, c := range mutating {
+		for _, w := range c.Webhooks {
+			webhooks = append(webhooks, admissionWebhook{
+				configKind: "MutatingWebhookConfiguration", configName: c.Name, config: &mutating[i], name: w.Name,
+				failurePolicy: failurePolicyOrDefault(w.FailurePolicy), timeoutSeconds: timeoutOrDefault(w.TimeoutSeconds),
+				namespaceSelector: w.NamespaceSelector, objectSelector: w.ObjectSelector, rules: w.Rules, service: w.ClientConfig.Service,
+			})
+		}
+	}
+	return webhooks
+}
+
+// CheckWebhooks raises findings for fail-closed webhooks that intercept every namespace, webhooks
+// whose backing service is missing or has no ready endpoints, and webhooks with long timeouts.
+// readyEndpoints holds the ready endpoint count of each backing service, keyed by namespace/name;
+// services missing from it do not exist.
+func CheckWebhooks(webhooks []admissionWebhook, readyEndpoints map[string]int) []Finding {
+	var findings []Finding
+	for _, w := range webhooks {
+		failClosed := w.failurePolicy == admissionregistrationv1.Fail
+		newFinding := func(checkID string, severity Severity, message string) Finding {
+			return Finding{
+				CheckID: checkID, Severity: severity, Kind: w.configKind, Name: w.configName,
+				Message: message, object: w.config, keyFields: []string{w.name},
+			}
+		}
+
+		if failClosed && isEmptySelector(w.namespaceSelector) && isEmptySelector(w.objectSelector) {
+			severity, scope := SeverityMedium, "every namespace, including kube-system"
+			if matchesAllResources(w.rules) {
+				severity, scope = SeverityHigh, "every resource in every namespace, including kube-system"
+			}
+			findings = append(findings, newFinding("webhook-fail-closed-broad", severity,
+				fmt.Sprintf("webhook %s in %s %s has failurePolicy Fail and intercepts %s; if it is down, matching API writes fail cluster-wide",
+					w.name, w.configKind, w.configName, scope)))
+		}
+
+		if w.service != nil {
+			key := w.service.Namespace + "/" + w.service.Name
+			ready, exists := readyEndpoints[key]
+			problem := ""
+			switch {
+			case !exists:
+				problem = fmt.Sprintf("points at service %s, which does not exist", key)
+			case ready == 0:
+				problem = fmt.Sprintf("points at service %s, which has no ready endpoints", key)
+			}
+			if problem != "" {
+				severity := SeverityMedium
+				if failClosed {
+					severity = SeverityCritical
+				}
+				findings = append(findings, newFinding("webhook-backend-unavailable", severity,
+					fmt.Sprintf("webhook %s in %s %s %s (failurePolicy %s)", w.name, w.configKind, w.configName, problem, w.failurePolicy)))
+			}
+		}
+
+		if w.timeoutSeconds > webhookTimeoutWarningSeconds {
+			findings = append(findings, newFinding("webhook-long-timeout", SeverityMedium,
+				fmt.Sprintf("webhook %s in %s %s has a %ds timeout; every matching API request can stall that long when it is slow",
+					w.name, w.configKind, w.configName, w.timeoutSeconds)))
+		}
+	}
+	return findings
+}
+
+// matchesAllResources reports whether any rule matches every resource of every API group.
+func matchesAllResources(rules []admissionregistrationv1.RuleWithOperations) bool {
+	for _, r := range rules {
+		if contains(r.APIGroups, "*") && (contains(r.Resources, "*") || contains(r.Resources, "*/*")) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptySelector(s *metav1.LabelSelector) bool {
+	return s == nil || (len(s.MatchLabels) == 0 && len(s.MatchExpressions) == 0)
+}
+
+func countReadyEndpoints(slices []discoveryv1.EndpointSlice) int {
+	var ready int
+	for _, s := range slices {
+		for _, e := range s.Endpoints {
+			if e.Conditions.Ready == nil || *e.Conditions.Ready {
+				ready++
+			}
+		}
+	}
+	return ready
+}
+
+// failurePolicyOrDefault applies the admissionregistration/v1 default of Fail.
+func failurePolicyOrDefault(p *admissionregistrationv1.FailurePolicyType) admissionregistrationv1.FailurePolicyType {
+	if p == nil {
+		return admissionregistrationv1.Fail
+	}
+	return *p
+}
+
+// timeoutOrDefault applies the admissionregistration/v1 default of 10 seconds.
+func timeoutOrDefault(t *int32) int32 {
+	if t == nil {
+		return 10
+	}
+	return *t
+}
@@ -0,0 +1,54 @@
+package inspect
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckWebhooks(t *testing.T) {
+	ignore := admissionregistrationv1.Ignore
+	timeout := int32(30)
+	allResources := []admissionregistrationv1.RuleWithOperations{{Rule: admissionregistrationv1.Rule{APIGroups: []string{"*"}, Resources: []string{"*"}}}}
+	podsOnly := []admissionregistrationv1.RuleWithOperations{{Rule: admissionregistrationv1.Rule{APIGroups: []string{""}, Resources: []string{"pods"}}}}
+	service := func(name string) admissionregistrationv1.WebhookClientConfig {
+		return admissionregistrationv1.WebhookClientConfig{Service: &admissionregistrationv1.ServiceReference{Namespace: "policy", Name: name}}
+	}
+
+	validating := []admissionregistrationv1.ValidatingWebhookConfiguration{{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy-engine"},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{
+			// Fail by default, every resource, every namespace, backend down.
+			{Name: "validate.policy.example.com", Rules: allResources, ClientConfig: service("policy-engine")},
+			// Scoped to labelled namespaces, ignored on failure, slow.
+			{Name: "audit.policy.example.com", Rules: allResources, FailurePolicy: &ignore, TimeoutSeconds: &timeout,
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"policy": "enforced"}}, ClientConfig: service("gone")},
+		},
+	}}
+	mutating := []admissionregistrationv1.MutatingWebhookConfiguration{{
+		ObjectMeta: metav1.ObjectMeta{Name: "sidecar-injector"},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{
+			{Name: "inject.example.com", Rules: podsOnly, ClientConfig: service("injector")},
+		},
+	}}
+	readyEndpoints := map[string]int{"policy/policy-engine": 0, "policy/injector": 2}
+
+	var got []string
+	for _, f := range CheckWebhooks(admissionWebhooks(validating, mutating), readyEndpoints) {
+		got = append(got, strings.Join([]string{f.CheckID, string(f.Severity), f.Name, f.keyFields[0]}, " "))
+	}
+	sort.Strings(got)
+	want := []string{
+		"webhook-backend-unavailable critical policy-engine validate.policy.example.com",
+		"webhook-backend-unavailable medium policy-engine audit.policy.example.com",
+		"webhook-fail-closed-broad high policy-engine validate.policy.example.com",
+		"webhook-fail-closed-broad medium sidecar-injector inject.example.com",
+		"webhook-long-timeout medium policy-engine audit.policy.example.com",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("CheckWebhooks() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
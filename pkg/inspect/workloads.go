@@ -0,0 +1,306 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// terminationTailLines and terminationTailBytes bound how much of a container's last
+// termination message is kept.
+const (
+	terminationTailLines = 5
+	terminationTailBytes = 512
+)
+
+// Waiting reasons that mean a container will not start without intervention.
+var unhealthyWaitingReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"CreateContainerConfigError": true,
+	"InvalidImageName":           true,
+}
+
+// WorkloadHealth is the availability of Deployments, StatefulSets, and DaemonSets and the
+// pods that keep them from becoming ready, grouped by namespace.
+type WorkloadHealth struct {
+	Namespaces []NamespaceWorkloads `json:"namespaces"`
+}
+
+// NamespaceWorkloads is the workload health of a single namespace.
+type NamespaceWorkloads struct {
+	Namespace     string           `json:"namespace"`
+	Workloads     []WorkloadStatus `json:"workloads"`
+	UnhealthyPods []UnhealthyPod   `json:"unhealthyPods,omitempty"`
+}
+
+// WorkloadStatus compares a workload's desired replicas with what is actually running.
+type WorkloadStatus struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Desired   int32  `json:"desired"`
+	Ready     int32  `json:"ready"`
+	Updated   int32  `json:"updated"`
+	// StuckReason is set when a rollout has stopped making progress.
+	StuckReason string `json:"stuckReason,omitempty"`
+}
+
+// Healthy reports whether every desired replica is ready and no rollout is stuck.
+func (w WorkloadStatus) Healthy() bool {
+	return w.Ready >= w.Desired && w.StuckReason == ""
+}
+
+// UnhealthyPod is a container stuck waiting to start.
+type UnhealthyPod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Container string `json:"container"`
+	Reason    string `json:"reason"`
+	Restarts  int32  `json:"restarts"`
+	// LastTermination is the reason, exit code, and tail of the message of the previous run.
+	LastTermination string `json:"lastTermination,omitempty"`
+	Owner           *Owner `json:"owner,omitempty"`
+}
+
+// GetWorkloadHealth collects the workload availability report.
+func GetWorkloadHealth(ctx context.Context, clientset kubernetes.Interface, opts ScanOptions) (*WorkloadHealth, error) {
+	deployments, err := listDeployments(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	statefulSets, err := listStatefulSets(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	daemonSets, err := listDaemonSets(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	pods, err := listPods(ctx, clientset, opts, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return BuildWorkloadHealth(deployments, statefulSets, daemonSets, pods), nil
+}
+
+// BuildWorkloadHealth computes the workload availability report.
+func BuildWorkloadHealth(deployments []appsv1.Deployment, statefulSets []appsv1.StatefulSet, daemonSets []appsv1.DaemonSet, pods []corev1.Pod) *WorkloadHealth {
+	var workloads []WorkloadStatus
+	for _, d := range deployments {
+		workloads = append(workloads, deploymentStatus(d))
+	}
+	for _, s := range statefulSets {
+		workloads = append(workloads, statefulSetStatus(s))
+	}
+	for _, d := range daemonSets {
+		workloads = append(workloads, daemonSetStatus(d))
+	}
+
+	byNamespace := map[string]*NamespaceWorkloads{}
+	group := func(namespace string) *NamespaceWorkloads {
+		if g, ok := byNamespace[namespace]; ok {
+			return g
+		}
+		g := &NamespaceWorkloads{Namespace: namespace}
+		byNamespace[namespace] = g
+		return g
+	}
+	for _, w := range workloads {
+		g := group(w.Namespace)
+		g.Workloads = append(g.Workloads, w)
+	}
+	for _, pod := range pods {
+		for _, u := range unhealthyContainers(pod) {
+			g := group(pod.Namespace)
+			g.UnhealthyPods = append(g.UnhealthyPods, u)
+		}
+	}
+
+	health := &WorkloadHealth{}
+	for _, g := range byNamespace {
+		sort.Slice(g.Workloads, func(i, j int) bool {
+			if g.Workloads[i].Kind != g.Workloads[j].Kind {
+				return g.Workloads[i].Kind < g.Workloads[j].Kind
+			}
+			return g.Workloads[i].Name < g.Workloads[j].Name
+		})
+		health.Namespaces = append(health.Namespaces, *g)
+	}
+	sort.Slice(health.Namespaces, func(i, j int) bool { return health.Namespaces[i].Namespace < health.Namespaces[j].Namespace })
+	return health
+}
+
+func deploymentStatus(d appsv1.Deployment) WorkloadStatus {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	w := WorkloadStatus{
+		Kind: "Deployment", Namespace: d.Namespace, Name: d.Name,
+		Desired: desired, Ready: d.Status.ReadyReplicas, Updated: d.Status.UpdatedReplicas,
+	}
+	for _, c := range d.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Status == corev1.ConditionFalse && c.Reason == "ProgressDeadlineExceeded" {
+			w.StuckReason = c.Message
+		}
+	}
+	return w
+}
+
+func statefulSetStatus(s appsv1.StatefulSet) WorkloadStatus {
+	desired := int32(1)
+	if s.Spec.Replicas != nil {
+		desired = *s.Spec.Replicas
+	}
+	w := WorkloadStatus{
+		Kind: "StatefulSet", Namespace: s.Namespace, Name: s.Name,
+		Desired: desired, Ready: s.Status.ReadyReplicas, Updated: s.Status.UpdatedReplicas,
+	}
+	// StatefulSets have no progress deadline; an ordered rollout that has a pod not ready
+	// halts until that pod recovers.
+	if s.Status.UpdateRevision != "" && s.Status.CurrentRevision != s.Status.UpdateRevision && s.Status.ReadyReplicas < desired {
+		w.StuckReason = fmt.Sprintf("rollout to revision %s halted with %d/%d replicas ready", s.Status.UpdateRevision, s.Status.ReadyReplicas, desired)
+	}
+	return w
+}
+
+func daemonSetStatus(d appsv1.DaemonSet) WorkloadStatus {
+	w := WorkloadStatus{
+		Kind: "DaemonSet", Namespace: d.Namespace, Name: d.Name,
+		Desired: d.Status.DesiredNumberScheduled, Ready: d.Status.NumberReady, Updated: d.Status.UpdatedNumberScheduled,
+	}
+	if d.Status.UpdatedNumberScheduled < d.Status.DesiredNumberScheduled && d.Status.NumberUnavailable > 0 {
+		w.StuckReason = fmt.Sprintf("rollout has %d/%d pods updated with %d unavailable",
+			d.Status.UpdatedNumberScheduled, d.Status.DesiredNumberScheduled, d.Status.NumberUnavailable)
+	}
+	return w
+}
+
+// unhealthyContainers returns the containers of pod waiting in a state that needs intervention.
+func unhealthyContainers(pod corev1.Pod) []UnhealthyPod {
+	var unhealthy []UnhealthyPod
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		if cs.State.Waiting == nil || !unhealthyWaitingReasons[cs.State.Waiting.Reason] {
+			continue
+		}
+		u := UnhealthyPod{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			Container: cs.Name,
+			Reason:    cs.State.Waiting.Reason,
+			Restarts:  cs.RestartCount,
+		}
+		if t := cs.LastTerminationState.Terminated; t != nil {
+			u.LastTermination = fmt.Sprintf("%s (exit code %d)", t.Reason, t.ExitCode)
+			if msg := tail(t.Message, terminationTailLines, terminationTailBytes); msg != "" {
+				u.LastTermination += ": " + msg
+			}
+		} else if msg := cs.State.Waiting.Message; msg != "" {
+			u.LastTermination = tail(msg, terminationTailLines, terminationTailBytes)
+		}
+		unhealthy = append(unhealthy, u)
+	}
+	return unhealthy
+}
+
+// tail returns at most the last maxLines lines and maxBytes bytes of s.
+func tail(s string, maxLines, maxBytes int) string {
+	s = strings.TrimSpace(s)
+	lines := strings.Split(s, "\n")
+	if len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	s = strings.Join(lines, "\n")
+	if len(s) > maxBytes {
+		s = "..." + s[len(s)-maxBytes:]
+	}
+	return s
+}
+
+// CheckWorkloadHealth raises findings for stuck rollouts, workloads short of ready replicas,
+// and containers that can't start.
+func CheckWorkloadHealth(health *WorkloadHealth) []Finding {
+	var findings []Finding
+	for _, g := range health.Namespaces {
+		for _, w := range g.Workloads {
+			switch {
+			case w.StuckReason != "":
+				findings = append(findings, Finding{
+					CheckID: "rollout-stuck", Severity: SeverityHigh,
+					Kind: w.Kind, Namespace: w.Namespace, Name: w.Name,
+					Message: fmt.Sprintf("%s %s/%s rollout is stuck: %s", w.Kind, w.Namespace, w.Name, w.StuckReason),
+				})
+			case w.Ready < w.Desired:
+				findings = append(findings, Finding{
+					CheckID: "workload-unavailable", Severity: SeverityMedium,
+					Kind: w.Kind, Namespace: w.Namespace, Name: w.Name,
+					Message: fmt.Sprintf("%s %s/%s has %d/%d replicas ready", w.Kind, w.Namespace, w.Name, w.Ready, w.Desired),
+				})
+			}
+		}
+		for _, p := range g.UnhealthyPods {
+			severity := SeverityMedium
+			if p.Reason == "CrashLoopBackOff" {
+				severity = SeverityHigh
+			}
+			findings = append(findings, Finding{
+				CheckID: "pod-not-starting", Severity: severity,
+				Kind: "Pod", Namespace: p.Namespace, Name: p.Name,
+				Message:   fmt.Sprintf("container %s in pod %s/%s is in %s (%d restarts)", p.Container, p.Namespace, p.Name, p.Reason, p.Restarts),
+				keyFields: []string{p.Container},
+			})
+		}
+	}
+	return findings
+}
+
+// PrintWorkloadHealth writes the workload section of the text report, listing only
+// namespaces with unhealthy workloads or pods.
+func PrintWorkloadHealth(w io.Writer, health *WorkloadHealth) {
+	fmt.Fprintln(w, "Workload health:")
+	healthy := true
+	for _, g := range health.Namespaces {
+		var lines []string
+		for _, wl := range g.Workloads {
+			if wl.Healthy() {
+				continue
+			}
+			line := fmt.Sprintf("%s %s: %d/%d ready, %d updated", wl.Kind, wl.Name, wl.Ready, wl.Desired, wl.Updated)
+			if wl.StuckReason != "" {
+				line += " - STUCK: " + wl.StuckReason
+			}
+			lines = append(lines, line)
+		}
+		for _, p := range g.UnhealthyPods {
+			line := fmt.Sprintf("Pod %s/%s: %s, %d restart(s)", p.Name, p.Container, p.Reason, p.Restarts)
+			if p.Owner != nil {
+				line = fmt.Sprintf("%s, pod %s/%s: %s, %d restart(s)", p.Owner, p.Name, p.Container, p.Reason, p.Restarts)
+			}
+			if p.LastTermination != "" {
+				line += " - last termination: " + strings.ReplaceAll(p.LastTermination, "\n", " | ")
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		healthy = false
+		fmt.Fprintf(w, "  %s:\n", g.Namespace)
+		for _, line := range lines {
+			fmt.Fprintf(w, "    - %s\n", line)
+		}
+	}
+	if healthy {
+		fmt.Fprintln(w, "  All Deployments, StatefulSets, and DaemonSets are fully ready.")
+	}
+}
@@ -0,0 +1,95 @@
+package inspect
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func int32Ptr(i int32) *int32 { return &i }
+
+func TestBuildWorkloadHealth(t *testing.T) {
+	deployments := []appsv1.Deployment{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "api"},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(3)},
+			Status: appsv1.DeploymentStatus{ReadyReplicas: 1, UpdatedReplicas: 1, Conditions: []appsv1.DeploymentCondition{{
+				Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded",
+				Message: `ReplicaSet "api-7d9" has timed out progressing.`,
+			}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "frontend"},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 2, UpdatedReplicas: 2},
+		},
+	}
+	statefulSets := []appsv1.StatefulSet{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "db", Name: "pg"},
+		Spec:       appsv1.StatefulSetSpec{Replicas: int32Ptr(3)},
+		Status:     appsv1.StatefulSetStatus{ReadyReplicas: 2, UpdatedReplicas: 1, CurrentRevision: "pg-1", UpdateRevision: "pg-2"},
+	}}
+	daemonSets := []appsv1.DaemonSet{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "cni"},
+		Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, NumberReady: 2, UpdatedNumberScheduled: 3},
+	}}
+	pods := []corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "api-7d9-abc"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:         "app",
+			RestartCount: 12,
+			State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				Reason: "Error", ExitCode: 1, Message: "line1\nline2\nline3\nline4\nline5\npanic: missing DATABASE_URL",
+			}},
+		}}},
+	}}
+
+	health := BuildWorkloadHealth(deployments, statefulSets, daemonSets, pods)
+
+	var namespaces []string
+	for _, g := range health.Namespaces {
+		namespaces = append(namespaces, g.Namespace)
+	}
+	if strings.Join(namespaces, ",") != "db,kube-system,web" {
+		t.Fatalf("BuildWorkloadHealth() namespaces = %v, want db, kube-system, web", namespaces)
+	}
+
+	web := health.Namespaces[2]
+	if len(web.Workloads) != 2 || web.Workloads[0].Name != "api" || web.Workloads[0].StuckReason == "" {
+		t.Errorf("BuildWorkloadHealth() web workloads = %+v, want stuck api first", web.Workloads)
+	}
+	if len(web.UnhealthyPods) != 1 {
+		t.Fatalf("BuildWorkloadHealth() web unhealthy pods = %d, want 1", len(web.UnhealthyPods))
+	}
+	pod := web.UnhealthyPods[0]
+	if pod.Restarts != 12 || strings.Contains(pod.LastTermination, "line1") || !strings.Contains(pod.LastTermination, "panic: missing DATABASE_URL") {
+		t.Errorf("BuildWorkloadHealth() unhealthy pod = %+v, want 12 restarts and the tail of the termination message", pod)
+	}
+	if health.Namespaces[0].Workloads[0].StuckReason == "" {
+		t.Errorf("BuildWorkloadHealth() pg = %+v, want halted rollout", health.Namespaces[0].Workloads[0])
+	}
+	if cni := health.Namespaces[1].Workloads[0]; cni.StuckReason != "" || cni.Healthy() {
+		t.Errorf("BuildWorkloadHealth() cni = %+v, want unavailable but not stuck", cni)
+	}
+
+	counts := map[string]int{}
+	for _, f := range CheckWorkloadHealth(health) {
+		counts[f.CheckID]++
+	}
+	if counts["rollout-stuck"] != 2 || counts["workload-unavailable"] != 1 || counts["pod-not-starting"] != 1 {
+		t.Errorf("CheckWorkloadHealth() finding counts = %v, want 2 stuck, 1 unavailable, 1 not starting", counts)
+	}
+}
+
+func TestTail(t *testing.T) {
+	if got := tail("a\nb\nc", 2, 100); got != "b\nc" {
+		t.Errorf("tail() = %q, want %q", got, "b\nc")
+	}
+	if got := tail("abcdef", 5, 3); got != "...def" {
+		t.Errorf("tail() = %q, want %q", got, "...def")
+	}
+}